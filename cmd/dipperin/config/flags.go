@@ -21,6 +21,9 @@ import (
 	"github.com/ethereum/go-ethereum/metrics"
 	"github.com/urfave/cli"
 	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
+	"strings"
+	"time"
 )
 
 // define flag names
@@ -61,6 +64,45 @@ const (
 	AllowHostsFlagName = "allow_hosts"
 
 	MetricsPortFlagName = "m_port"
+
+	BftAdaptiveTimeoutFlagName = "bft_adaptive_timeout"
+	BftMinStepTimeoutFlagName  = "bft_min_step_timeout"
+	BftMaxStepTimeoutFlagName  = "bft_max_step_timeout"
+	BftLatencyWindowFlagName   = "bft_latency_window"
+
+	StratumAddrFlagName = "stratum_addr"
+
+	MineRewardModeFlagName = "mine_reward_mode"
+	MineThreadsFlagName    = "mine_threads"
+
+	PackTxOrderFlagName         = "pack_tx_order"
+	PackPrioritySendersFlagName = "pack_priority_senders"
+	PackPriorityLocalFlagName   = "pack_priority_local"
+	PackMaxTxCountFlagName      = "pack_max_tx_count"
+	PackMaxTxSizeFlagName       = "pack_max_tx_size"
+	PackReservedPercentFlagName = "pack_reserved_percent"
+
+	SyncModeFlagName   = "sync_mode"
+	CheckpointFlagName = "checkpoint"
+
+	GCModeFlagName                  = "gc_mode"
+	StateKeepRecentFlagName         = "state_keep_recent"
+	StateCheckpointIntervalFlagName = "state_checkpoint_interval"
+	AncientThresholdFlagName        = "ancient_threshold"
+	AncientDirFlagName              = "ancient_dir"
+	DBBackendFlagName               = "db_backend"
+	DBCacheFlagName                 = "db_cache"
+	DBHandlesFlagName               = "db_handles"
+	TargetDBBackendFlagName         = "target_db_backend"
+	TargetDataDirFlagName           = "target_data_dir"
+	VerifyFromFlagName              = "from"
+	VerifyToFlagName                = "to"
+	VerifyStateIntervalFlagName     = "state_interval"
+	RepairFlagName                  = "repair"
+	StateNumberFlagName             = "number"
+	StateOutputFlagName             = "output"
+	DiffFromFlagName                = "from"
+	DiffToFlagName                  = "to"
 )
 
 var (
@@ -93,6 +135,29 @@ var (
 		NoDiscoveryFlag,
 		NatFlag,
 		AllowHostsFlag,
+		BftAdaptiveTimeoutFlag,
+		BftMinStepTimeoutFlag,
+		BftMaxStepTimeoutFlag,
+		BftLatencyWindowFlag,
+		StratumAddrFlag,
+		MineRewardModeFlag,
+		MineThreadsFlag,
+		PackTxOrderFlag,
+		PackPrioritySendersFlag,
+		PackPriorityLocalFlag,
+		PackMaxTxCountFlag,
+		PackMaxTxSizeFlag,
+		PackReservedPercentFlag,
+		SyncModeFlag,
+		CheckpointFlag,
+		GCModeFlag,
+		StateKeepRecentFlag,
+		StateCheckpointIntervalFlag,
+		AncientThresholdFlag,
+		AncientDirFlag,
+		DBBackendFlag,
+		DBCacheFlag,
+		DBHandlesFlag,
 	}
 )
 
@@ -228,4 +293,186 @@ var (
 		Value: "",
 		Usage: "nat mode",
 	}
+
+	BftAdaptiveTimeoutFlag = cli.BoolFlag{
+		Name: BftAdaptiveTimeoutFlagName,
+		Usage: "adjust the pbft propose, prevote and precommit timeouts by the observed round latency",
+	}
+
+	BftMinStepTimeoutFlag = cli.DurationFlag{
+		Name: BftMinStepTimeoutFlagName,
+		Value: 2 * time.Second,
+		Usage: "the min pbft step timeout of the adaptive timeout",
+	}
+
+	BftMaxStepTimeoutFlag = cli.DurationFlag{
+		Name: BftMaxStepTimeoutFlagName,
+		Value: 30 * time.Second,
+		Usage: "the max pbft step timeout of the adaptive timeout",
+	}
+
+	BftLatencyWindowFlag = cli.IntFlag{
+		Name: BftLatencyWindowFlagName,
+		Value: 10,
+		Usage: "how many recent rounds the adaptive timeout is calculated by",
+	}
+
+	StratumAddrFlag = cli.StringFlag{
+		Name: StratumAddrFlagName,
+		Value: "",
+		Usage: "the listen address of the stratum server for the external mining rigs, only for mine master",
+	}
+
+	MineRewardModeFlag = cli.StringFlag{
+		Name: MineRewardModeFlagName,
+		Value: "pplns",
+		Usage: "how the mine master divides the block rewards by the worker shares, pplns or proportional",
+	}
+
+	MineThreadsFlag = cli.IntFlag{
+		Name: MineThreadsFlagName,
+		Value: 1,
+		Usage: "the hashing thread count of the local mine worker, only for mine master",
+	}
+
+	PackTxOrderFlag = cli.StringFlag{
+		Name: PackTxOrderFlagName,
+		Value: "fee",
+		Usage: "how the txs are ordered when packing a block, fee or fee_per_byte",
+	}
+
+	PackPrioritySendersFlag = cli.StringSliceFlag{
+		Name: PackPrioritySendersFlagName,
+		Usage: "the sender addresses whose txs are packed before the others",
+	}
+
+	PackPriorityLocalFlag = cli.BoolFlag{
+		Name: PackPriorityLocalFlagName,
+		Usage: "pack the txs of the local accounts before the others",
+	}
+
+	PackMaxTxCountFlag = cli.IntFlag{
+		Name: PackMaxTxCountFlagName,
+		Value: 0,
+		Usage: "the max tx count of a packed block, no limit if =0",
+	}
+
+	PackMaxTxSizeFlag = cli.IntFlag{
+		Name: PackMaxTxSizeFlagName,
+		Value: 0,
+		Usage: "the max total tx bytes of a packed block, no limit if =0",
+	}
+
+	PackReservedPercentFlag = cli.IntFlag{
+		Name: PackReservedPercentFlagName,
+		Value: 0,
+		Usage: "the percent of the block space only for the stake, unstake, cancel and evidence txs",
+	}
+
+	SyncModeFlag = cli.StringFlag{
+		Name: SyncModeFlagName,
+		Value: "full",
+		Usage: "how the chain is synced, full or fast. fast downloads the state of the latest trusted checkpoint instead of processing the history",
+	}
+
+	CheckpointFlag = cli.StringFlag{
+		Name: CheckpointFlagName,
+		Usage: "the trusted checkpoint as number:hash[:verifier set hash], the peers conflicting with it are refused",
+	}
+
+	GCModeFlag = cli.StringFlag{
+		Name: GCModeFlagName,
+		Value: "archive",
+		Usage: "how the states are stored, archive or pruned. pruned only keeps the states of the recent blocks and the checkpoints",
+	}
+
+	StateKeepRecentFlag = cli.Uint64Flag{
+		Name: StateKeepRecentFlagName,
+		Value: 1024,
+		Usage: "the count of the recent block states kept by the pruned node, it isn't less than the blocks of slot_margin+2 slots",
+	}
+
+	StateCheckpointIntervalFlag = cli.Uint64Flag{
+		Name: StateCheckpointIntervalFlagName,
+		Value: 10000,
+		Usage: "the states of the blocks at the multiple of it are written to the disk by the pruned node",
+	}
+
+	AncientThresholdFlag = cli.Uint64Flag{
+		Name: AncientThresholdFlagName,
+		Value: 90000,
+		Usage: "the blocks older than it are moved from the leveldb to the freezer, 0 disables the moving",
+	}
+
+	AncientDirFlag = cli.StringFlag{
+		Name:  AncientDirFlagName,
+		Usage: "the dir of the freezer, default is the ancient dir in the chain data dir",
+	}
+
+	DBBackendFlag = cli.StringFlag{
+		Name:  DBBackendFlagName,
+		Value: chaindb.DefaultBackend,
+		Usage: "the key-value store of the chain data, " + strings.Join(chaindb.Backends(), " or "),
+	}
+
+	DBCacheFlag = cli.IntFlag{
+		Name:  DBCacheFlagName,
+		Usage: "the cache size in MB of the chain database, default is the database cache of the chain config",
+	}
+
+	DBHandlesFlag = cli.IntFlag{
+		Name:  DBHandlesFlagName,
+		Usage: "the max open files of the chain database, default is the database handles of the chain config",
+	}
+
+	TargetDBBackendFlag = cli.StringFlag{
+		Name:  TargetDBBackendFlagName,
+		Value: chaindb.DefaultBackend,
+		Usage: "the key-value store the chain data is converted to",
+	}
+
+	TargetDataDirFlag = cli.StringFlag{
+		Name:  TargetDataDirFlagName,
+		Usage: "the data dir the chain data is converted to",
+	}
+
+	VerifyFromFlag = cli.Uint64Flag{
+		Name:  VerifyFromFlagName,
+		Usage: "the first block verified",
+	}
+
+	VerifyToFlag = cli.Uint64Flag{
+		Name:  VerifyToFlagName,
+		Usage: "the last block verified, default is the current block",
+	}
+
+	VerifyStateIntervalFlag = cli.Uint64Flag{
+		Name:  VerifyStateIntervalFlagName,
+		Usage: "the states of the blocks at the multiple of it are walked, 1 walks all the states and 0 only walks the state of the current block",
+	}
+
+	RepairFlag = cli.BoolFlag{
+		Name:  RepairFlagName,
+		Usage: "rewind the current block to the last consistent block if the chain data is broken",
+	}
+
+	StateNumberFlag = cli.Uint64Flag{
+		Name:  StateNumberFlagName,
+		Usage: "the number of the block whose state is dumped",
+	}
+
+	StateOutputFlag = cli.StringFlag{
+		Name:  StateOutputFlagName,
+		Usage: "the file the json is written to, default is the stdout",
+	}
+
+	DiffFromFlag = cli.Uint64Flag{
+		Name:  DiffFromFlagName,
+		Usage: "the number of the block whose state is compared from",
+	}
+
+	DiffToFlag = cli.Uint64Flag{
+		Name:  DiffToFlagName,
+		Usage: "the number of the block whose state is compared to",
+	}
 )
@@ -116,6 +116,29 @@ func getNodeConf(c *cli.Context) dipperin.NodeConfig {
 	nodeConf.Nat = c.String(config.Nat)
 	nodeConf.AllowHosts = c.StringSlice(config.AllowHostsFlagName)
 	nodeConf.PMetricsPort = c.Int(config.MetricsPortFlagName)
+	nodeConf.BftAdaptiveTimeout = c.Bool(config.BftAdaptiveTimeoutFlagName)
+	nodeConf.BftMinStepTimeout = c.Duration(config.BftMinStepTimeoutFlagName)
+	nodeConf.BftMaxStepTimeout = c.Duration(config.BftMaxStepTimeoutFlagName)
+	nodeConf.BftLatencyWindow = c.Int(config.BftLatencyWindowFlagName)
+	nodeConf.StratumAddr = c.String(config.StratumAddrFlagName)
+	nodeConf.MineRewardMode = c.String(config.MineRewardModeFlagName)
+	nodeConf.MineThreads = c.Int(config.MineThreadsFlagName)
+	nodeConf.PackTxOrder = c.String(config.PackTxOrderFlagName)
+	nodeConf.PackPrioritySenders = c.StringSlice(config.PackPrioritySendersFlagName)
+	nodeConf.PackPriorityLocal = c.Bool(config.PackPriorityLocalFlagName)
+	nodeConf.PackMaxTxCount = c.Int(config.PackMaxTxCountFlagName)
+	nodeConf.PackMaxTxSize = c.Int(config.PackMaxTxSizeFlagName)
+	nodeConf.PackReservedPercent = c.Int(config.PackReservedPercentFlagName)
+	nodeConf.SyncMode = c.String(config.SyncModeFlagName)
+	nodeConf.Checkpoint = c.String(config.CheckpointFlagName)
+	nodeConf.GCMode = c.String(config.GCModeFlagName)
+	nodeConf.StateKeepRecent = c.Uint64(config.StateKeepRecentFlagName)
+	nodeConf.StateCheckpointInterval = c.Uint64(config.StateCheckpointIntervalFlagName)
+	nodeConf.AncientThreshold = c.Uint64(config.AncientThresholdFlagName)
+	nodeConf.AncientDir = c.String(config.AncientDirFlagName)
+	nodeConf.DatabaseBackend = c.String(config.DBBackendFlagName)
+	nodeConf.DatabaseCache = c.Int(config.DBCacheFlagName)
+	nodeConf.DatabaseHandles = c.Int(config.DBHandlesFlagName)
 
 	if c.Int(config.IsStartMine) == 0{
 		nodeConf.IsStartMine =false
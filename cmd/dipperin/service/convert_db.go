@@ -0,0 +1,56 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package service
+
+import (
+	"github.com/dipperin/dipperin-core/cmd/dipperin/config"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/cs-chain/chain-state"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/urfave/cli"
+)
+
+var ConvertDBCommand = cli.Command{
+	Name:  "convert-db",
+	Usage: "copy the chain data of the stopped node to another data dir with the target key-value store",
+	Flags: []cli.Flag{
+		config.DataDirFlag,
+		config.LogLevelFlag,
+		config.DBBackendFlag,
+		config.DBCacheFlag,
+		config.DBHandlesFlag,
+		config.TargetDataDirFlag,
+		config.TargetDBBackendFlag,
+	},
+	Action: ConvertDB,
+}
+
+// ConvertDB converts the chain data from one backend to another, the node is run with the target data dir
+// and db_backend after it
+func ConvertDB(c *cli.Context) error {
+	extraBeforeStart(c, true, false)
+
+	backendConf := chain_state.BackendConfig(chain_config.GetChainConfig(), c.Int(config.DBCacheFlagName), c.Int(config.DBHandlesFlagName))
+	copied, err := chain_state.ConvertChainData(c.String(config.DataDirFlagName), c.String(config.DBBackendFlagName), backendConf,
+		c.String(config.TargetDataDirFlagName), c.String(config.TargetDBBackendFlagName), backendConf)
+	if err != nil {
+		log.Error("convert chain database failed", "err", err)
+		return err
+	}
+	log.Info("convert chain database finished", "copied keys", copied)
+	return nil
+}
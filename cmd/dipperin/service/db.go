@@ -0,0 +1,90 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package service
+
+import (
+	"errors"
+
+	"github.com/dipperin/dipperin-core/cmd/dipperin/config"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/cs-chain/chain-state"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/urfave/cli"
+)
+
+var ErrChainDataBroken = errors.New("the chain data is broken")
+
+var DBCommand = cli.Command{
+	Name:  "db",
+	Usage: "maintain the chain data of the stopped node",
+	Subcommands: []cli.Command{
+		{
+			Name:  "verify",
+			Usage: "check the blocks, the tx lookups, the seen commits and the states of the canonical chain",
+			Flags: []cli.Flag{
+				config.DataDirFlag,
+				config.LogLevelFlag,
+				config.AncientDirFlag,
+				config.DBBackendFlag,
+				config.DBCacheFlag,
+				config.DBHandlesFlag,
+				config.VerifyFromFlag,
+				config.VerifyToFlag,
+				config.VerifyStateIntervalFlag,
+				config.RepairFlag,
+			},
+			Action: VerifyDB,
+		},
+	},
+}
+
+// VerifyDB walks the canonical chain of the chain data, the current block is rewound to the last consistent
+// block in the repair mode
+func VerifyDB(c *cli.Context) error {
+	extraBeforeStart(c, true, false)
+
+	backendConf := chain_state.BackendConfig(chain_config.GetChainConfig(), c.Int(config.DBCacheFlagName), c.Int(config.DBHandlesFlagName))
+	verifyConf := chain_state.ChainVerifyConfig{
+		From:          c.Uint64(config.VerifyFromFlagName),
+		To:            c.Uint64(config.VerifyToFlagName),
+		StateInterval: c.Uint64(config.VerifyStateIntervalFlagName),
+	}
+	repair := c.Bool(config.RepairFlagName)
+	result, err := chain_state.VerifyChainData(c.String(config.DataDirFlagName), c.String(config.AncientDirFlagName),
+		c.String(config.DBBackendFlagName), backendConf, verifyConf, repair)
+	if err != nil {
+		log.Error("verify chain data failed", "err", err)
+		return err
+	}
+
+	for _, issue := range result.Issues {
+		log.Warn("chain data issue", "issue", issue.String())
+	}
+	log.Info("verify chain data finished", "head", result.Head, "checked blocks", result.Checked, "issues", len(result.Issues),
+		"last consistent block", result.LastConsistent)
+	if result.Consistent() {
+		return nil
+	}
+	if result.LastConsistent < result.Head {
+		if repair {
+			log.Info("the current block is rewound", "num", result.LastConsistent, "hash", result.LastConsistentHash.Hex())
+			return nil
+		}
+		log.Warn("run with --repair to rewind the current block", "to", result.LastConsistent)
+	}
+	return ErrChainDataBroken
+}
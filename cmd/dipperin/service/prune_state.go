@@ -0,0 +1,57 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package service
+
+import (
+	"github.com/dipperin/dipperin-core/cmd/dipperin/config"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/cs-chain/chain-state"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/urfave/cli"
+)
+
+var PruneStateCommand = cli.Command{
+	Name:  "prune-state",
+	Usage: "delete the states of the stopped node except the ones of the recent blocks and the checkpoints",
+	Flags: []cli.Flag{
+		config.DataDirFlag,
+		config.LogLevelFlag,
+		config.StateKeepRecentFlag,
+		config.StateCheckpointIntervalFlag,
+		config.AncientDirFlag,
+		config.DBBackendFlag,
+		config.DBCacheFlag,
+		config.DBHandlesFlag,
+	},
+	Action: PruneState,
+}
+
+// PruneState removes the unused states from the chain data, the node can be run in archive or pruned mode after it
+func PruneState(c *cli.Context) error {
+	extraBeforeStart(c, true, false)
+
+	dataDir := c.String(config.DataDirFlagName)
+	ancientDir := c.String(config.AncientDirFlagName)
+	backendConf := chain_state.BackendConfig(chain_config.GetChainConfig(), c.Int(config.DBCacheFlagName), c.Int(config.DBHandlesFlagName))
+	deleted, err := chain_state.PruneState(dataDir, ancientDir, c.String(config.DBBackendFlagName), backendConf, c.Uint64(config.StateKeepRecentFlagName), c.Uint64(config.StateCheckpointIntervalFlagName))
+	if err != nil {
+		log.Error("prune state failed", "err", err)
+		return err
+	}
+	log.Info("prune state finished", "deleted nodes", deleted)
+	return nil
+}
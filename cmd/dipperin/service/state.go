@@ -0,0 +1,121 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package service
+
+import (
+	"encoding/json"
+	"io"
+	"os"
+
+	"github.com/dipperin/dipperin-core/cmd/dipperin/config"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
+	"github.com/dipperin/dipperin-core/core/cs-chain/chain-state"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/urfave/cli"
+)
+
+var stateDBFlags = []cli.Flag{
+	config.DataDirFlag,
+	config.LogLevelFlag,
+	config.AncientDirFlag,
+	config.DBBackendFlag,
+	config.DBCacheFlag,
+	config.DBHandlesFlag,
+	config.StateOutputFlag,
+}
+
+var StateCommand = cli.Command{
+	Name:  "state",
+	Usage: "inspect the account states of the stopped node",
+	Subcommands: []cli.Command{
+		{
+			Name:   "dump",
+			Usage:  "write all the accounts in the state of the block as json",
+			Flags:  append([]cli.Flag{config.StateNumberFlag}, stateDBFlags...),
+			Action: DumpState,
+		},
+		{
+			Name:   "diff",
+			Usage:  "write the accounts changed between the states of two blocks as json",
+			Flags:  append([]cli.Flag{config.DiffFromFlag, config.DiffToFlag}, stateDBFlags...),
+			Action: DiffState,
+		},
+	},
+}
+
+// the json is written to the stdout if no output file is set
+func stateOutput(c *cli.Context) (io.WriteCloser, error) {
+	output := c.String(config.StateOutputFlagName)
+	if output == "" {
+		return os.Stdout, nil
+	}
+	return os.Create(output)
+}
+
+func stateBackendConfig(c *cli.Context) chaindb.BackendConfig {
+	return chain_state.BackendConfig(chain_config.GetChainConfig(), c.Int(config.DBCacheFlagName), c.Int(config.DBHandlesFlagName))
+}
+
+// DumpState writes the accounts in the state of the block one by one
+func DumpState(c *cli.Context) error {
+	// the log is mixed with the json in the stdout
+	extraBeforeStart(c, c.String(config.StateOutputFlagName) != "", false)
+
+	w, err := stateOutput(c)
+	if err != nil {
+		return err
+	}
+	err = chain_state.DumpState(c.String(config.DataDirFlagName), c.String(config.AncientDirFlagName), c.String(config.DBBackendFlagName),
+		stateBackendConfig(c), c.Uint64(config.StateNumberFlagName), w)
+	if w != os.Stdout {
+		if closeErr := w.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	if err != nil {
+		log.Error("dump state failed", "err", err)
+	}
+	return err
+}
+
+// DiffState writes the accounts changed between the states of two blocks
+func DiffState(c *cli.Context) error {
+	extraBeforeStart(c, c.String(config.StateOutputFlagName) != "", false)
+
+	diffs, err := chain_state.DiffState(c.String(config.DataDirFlagName), c.String(config.AncientDirFlagName), c.String(config.DBBackendFlagName),
+		stateBackendConfig(c), c.Uint64(config.DiffFromFlagName), c.Uint64(config.DiffToFlagName))
+	if err != nil {
+		log.Error("diff state failed", "err", err)
+		return err
+	}
+	log.Info("diff state finished", "changed accounts", len(diffs))
+
+	w, err := stateOutput(c)
+	if err != nil {
+		return err
+	}
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	err = encoder.Encode(diffs)
+	if w != os.Stdout {
+		if closeErr := w.Close(); err == nil {
+			err = closeErr
+		}
+	}
+	return err
+}
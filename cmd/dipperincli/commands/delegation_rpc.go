@@ -0,0 +1,217 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/consts"
+	"github.com/dipperin/dipperin-core/core/rpc-interface"
+	"github.com/urfave/cli"
+	"strconv"
+)
+
+// SendDelegateTx delegate money of the default account to a verifier candidate
+func (caller *rpcCaller) SendDelegateTx(c *cli.Context) {
+	if checkSync() {
+		return
+	}
+
+	_, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error", "err", err)
+		return
+	}
+	if len(cParams) != 3 {
+		l.Error("SendDelegateTransaction need：candidate amount transactionFee")
+		return
+	}
+
+	candidate, err := CheckAndChangeHexToAddress(cParams[0])
+	if err != nil {
+		l.Error("the candidate address is invalid", "err", err)
+		return
+	}
+	amount, err := MoneyValueToCSCoin(cParams[1])
+	if err != nil {
+		l.Error("the parameter amount invalid")
+		return
+	}
+	txFee, err := MoneyValueToCSCoin(cParams[2])
+	if err != nil {
+		l.Error("the parameter transactionFee invalid")
+		return
+	}
+
+	var resp common.Hash
+	if err := client.Call(&resp, getDipperinRpcMethodByName("SendDelegateTransaction"), defaultAccount, candidate, amount, txFee, nil); err != nil {
+		l.Error("call send transaction", "err", err)
+		return
+	}
+	l.Info("SendDelegateTransaction result", "txId", resp.Hex())
+}
+
+// SendUnDelegateTx start unbonding money of the default account from a verifier candidate
+func (caller *rpcCaller) SendUnDelegateTx(c *cli.Context) {
+	if checkSync() {
+		return
+	}
+
+	_, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error", "err", err)
+		return
+	}
+	if len(cParams) != 3 {
+		l.Error("SendUnDelegateTransaction need：candidate amount transactionFee")
+		return
+	}
+
+	candidate, err := CheckAndChangeHexToAddress(cParams[0])
+	if err != nil {
+		l.Error("the candidate address is invalid", "err", err)
+		return
+	}
+	amount, err := MoneyValueToCSCoin(cParams[1])
+	if err != nil {
+		l.Error("the parameter amount invalid")
+		return
+	}
+	txFee, err := MoneyValueToCSCoin(cParams[2])
+	if err != nil {
+		l.Error("the parameter transactionFee invalid")
+		return
+	}
+
+	var resp common.Hash
+	if err := client.Call(&resp, getDipperinRpcMethodByName("SendUnDelegateTransaction"), defaultAccount, candidate, amount, txFee, nil); err != nil {
+		l.Error("call send transaction", "err", err)
+		return
+	}
+	l.Info("SendUnDelegateTransaction result", "txId", resp.Hex())
+}
+
+// SendWithdrawDelegationTx return the unbonded money of the default account after the lock period
+func (caller *rpcCaller) SendWithdrawDelegationTx(c *cli.Context) {
+	if checkSync() {
+		return
+	}
+
+	_, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error", "err", err)
+		return
+	}
+	if len(cParams) != 2 {
+		l.Error("SendWithdrawDelegationTransaction need：candidate transactionFee")
+		return
+	}
+
+	candidate, err := CheckAndChangeHexToAddress(cParams[0])
+	if err != nil {
+		l.Error("the candidate address is invalid", "err", err)
+		return
+	}
+	txFee, err := MoneyValueToCSCoin(cParams[1])
+	if err != nil {
+		l.Error("the parameter transactionFee invalid")
+		return
+	}
+
+	var resp common.Hash
+	if err := client.Call(&resp, getDipperinRpcMethodByName("SendWithdrawDelegationTransaction"), defaultAccount, candidate, txFee, nil); err != nil {
+		l.Error("call send transaction", "err", err)
+		return
+	}
+	l.Info("SendWithdrawDelegationTransaction result", "txId", resp.Hex())
+}
+
+// GetDelegation get the delegation to a candidate, the delegator is the default account if it isn't given
+func (caller *rpcCaller) GetDelegation(c *cli.Context) {
+	mName, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+	if len(cParams) != 1 && len(cParams) != 2 {
+		l.Error("GetDelegation need：candidate [delegator]")
+		return
+	}
+
+	candidate, err := CheckAndChangeHexToAddress(cParams[0])
+	if err != nil {
+		l.Error("the candidate address is invalid", "err", err)
+		return
+	}
+	delegator := defaultAccount
+	if len(cParams) == 2 {
+		if delegator, err = CheckAndChangeHexToAddress(cParams[1]); err != nil {
+			l.Error("the delegator address is invalid", "err", err)
+			return
+		}
+	}
+
+	var resp rpc_interface.DelegationResp
+	if err := client.Call(&resp, getDipperinRpcMethodByName(mName), delegator, candidate); err != nil {
+		l.Error("call get delegation", "err", err)
+		return
+	}
+	bonded, err := CSCoinToMoneyValue(resp.Bonded)
+	if err != nil {
+		l.Error("the bonded delegation is invalid", "err", err)
+		return
+	}
+	unbonding, err := CSCoinToMoneyValue(resp.Unbonding)
+	if err != nil {
+		l.Error("the unbonding delegation is invalid", "err", err)
+		return
+	}
+	l.Info("delegation", "bonded", bonded+consts.CoinDIPName, "unbonding", unbonding+consts.CoinDIPName, "unbond height", resp.UnbondHeight)
+}
+
+// GetCandidateDelegation get the delegated stake, the commission and the delegators of a candidate
+func (caller *rpcCaller) GetCandidateDelegation(c *cli.Context) {
+	mName, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+	if len(cParams) != 1 {
+		l.Error("GetCandidateDelegation need：candidate")
+		return
+	}
+
+	candidate, err := CheckAndChangeHexToAddress(cParams[0])
+	if err != nil {
+		l.Error("the candidate address is invalid", "err", err)
+		return
+	}
+
+	var resp rpc_interface.CandidateDelegationResp
+	if err := client.Call(&resp, getDipperinRpcMethodByName(mName), candidate); err != nil {
+		l.Error("call get candidate delegation", "err", err)
+		return
+	}
+	delegated, err := CSCoinToMoneyValue(resp.DelegatedStake)
+	if err != nil {
+		l.Error("the delegated stake is invalid", "err", err)
+		return
+	}
+	l.Info("candidate delegation", "delegated stake", delegated+consts.CoinDIPName, "commission", strconv.FormatUint(resp.Commission, 10)+"‰")
+	for _, delegator := range resp.Delegators {
+		l.Info("delegator", "address", delegator.Hex())
+	}
+}
@@ -0,0 +1,115 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/rpc-interface"
+	"github.com/urfave/cli"
+	"strconv"
+)
+
+// SendGovernanceProposalTx propose a new value of a consensus parameter with the default account
+func (caller *rpcCaller) SendGovernanceProposalTx(c *cli.Context) {
+	if checkSync() {
+		return
+	}
+
+	_, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error", "err", err)
+		return
+	}
+	if len(cParams) != 4 {
+		l.Error("SendGovernanceProposalTransaction need：param value activationSlot transactionFee")
+		return
+	}
+
+	value, err := strconv.ParseUint(cParams[1], 10, 64)
+	if err != nil {
+		l.Error("the parameter value invalid", "err", err)
+		return
+	}
+	activationSlot, err := strconv.ParseUint(cParams[2], 10, 64)
+	if err != nil {
+		l.Error("the parameter activationSlot invalid", "err", err)
+		return
+	}
+	txFee, err := MoneyValueToCSCoin(cParams[3])
+	if err != nil {
+		l.Error("the parameter transactionFee invalid")
+		return
+	}
+
+	var resp common.Hash
+	if err := client.Call(&resp, getDipperinRpcMethodByName("SendGovernanceProposalTransaction"), defaultAccount, cParams[0], value, activationSlot, txFee, nil); err != nil {
+		l.Error("call send transaction", "err", err)
+		return
+	}
+	l.Info("SendGovernanceProposalTransaction result", "proposal id", resp.Hex())
+}
+
+// SendGovernanceVoteTx vote for a governance proposal with the default account
+func (caller *rpcCaller) SendGovernanceVoteTx(c *cli.Context) {
+	if checkSync() {
+		return
+	}
+
+	_, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error", "err", err)
+		return
+	}
+	if len(cParams) != 2 {
+		l.Error("SendGovernanceVoteTransaction need：proposalId transactionFee")
+		return
+	}
+
+	txFee, err := MoneyValueToCSCoin(cParams[1])
+	if err != nil {
+		l.Error("the parameter transactionFee invalid")
+		return
+	}
+
+	var resp common.Hash
+	if err := client.Call(&resp, getDipperinRpcMethodByName("SendGovernanceVoteTransaction"), defaultAccount, common.HexToHash(cParams[0]), txFee, nil); err != nil {
+		l.Error("call send transaction", "err", err)
+		return
+	}
+	l.Info("SendGovernanceVoteTransaction result", "txId", resp.Hex())
+}
+
+// GetGovernanceProposal get a governance proposal and its votes
+func (caller *rpcCaller) GetGovernanceProposal(c *cli.Context) {
+	mName, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+	if len(cParams) != 1 {
+		l.Error("GetGovernanceProposal need：proposalId")
+		return
+	}
+
+	var resp rpc_interface.GovernanceProposalResp
+	if err := client.Call(&resp, getDipperinRpcMethodByName(mName), common.HexToHash(cParams[0])); err != nil {
+		l.Error("call get governance proposal", "err", err)
+		return
+	}
+	l.Info("governance proposal", "param", resp.Param, "value", resp.Value, "activation slot", resp.ActivationSlot,
+		"proposer", resp.Proposer.Hex(), "votes", len(resp.Votes), "passed", resp.Passed)
+}
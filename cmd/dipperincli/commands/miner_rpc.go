@@ -0,0 +1,119 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"github.com/dipperin/dipperin-core/core/rpc-interface"
+	"github.com/urfave/cli"
+	"strings"
+)
+
+// getMinerRpcMethodByName get the rpc method name of the miner namespace
+func getMinerRpcMethodByName(mName string) string {
+	lm := strings.ToLower(string(mName[0])) + mName[1:]
+	return "miner_" + lm
+}
+
+// MinerWorkers list the workers connected to the mine master
+func (caller *rpcCaller) MinerWorkers(c *cli.Context) {
+	var resp []rpc_interface.MinerWorkerResp
+	if err := client.Call(&resp, getMinerRpcMethodByName("Workers")); err != nil {
+		l.Error("call miner workers", "err", err)
+		return
+	}
+
+	l.Info("mine master workers", "count", len(resp))
+	for i := range resp {
+		printMinerWorker(resp[i])
+	}
+}
+
+// MinerWorker get the shares, blocks and rewards of a worker
+func (caller *rpcCaller) MinerWorker(c *cli.Context) {
+	_, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+	if len(cParams) != 1 {
+		l.Error("MinerWorker need：workerId")
+		return
+	}
+
+	var resp rpc_interface.MinerWorkerResp
+	if err := client.Call(&resp, getMinerRpcMethodByName("Worker"), cParams[0]); err != nil {
+		l.Error("call miner worker", "err", err)
+		return
+	}
+	printMinerWorker(resp)
+}
+
+// MinerCurrentWork get the height and the tx count of the block in mining
+func (caller *rpcCaller) MinerCurrentWork(c *cli.Context) {
+	var resp rpc_interface.MinerWorkResp
+	if err := client.Call(&resp, getMinerRpcMethodByName("CurrentWork")); err != nil {
+		l.Error("call miner current work", "err", err)
+		return
+	}
+	l.Info("mine master current work", "height", resp.Height, "tx count", resp.TxCount)
+}
+
+// MinerKickWorker stop and unregister a worker
+func (caller *rpcCaller) MinerKickWorker(c *cli.Context) {
+	_, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+	if len(cParams) != 1 {
+		l.Error("MinerKickWorker need：workerId")
+		return
+	}
+
+	var resp interface{}
+	if err := client.Call(&resp, getMinerRpcMethodByName("KickWorker"), cParams[0]); err != nil {
+		l.Error("call miner kick worker", "err", err)
+		return
+	}
+	l.Info("kick worker success", "worker id", cParams[0])
+}
+
+// MinerPayout pay the workers whose rewards reach the threshold
+func (caller *rpcCaller) MinerPayout(c *cli.Context) {
+	var resp []rpc_interface.MinerPayoutResp
+	if err := client.Call(&resp, getMinerRpcMethodByName("Payout")); err != nil {
+		l.Error("call miner payout", "err", err)
+		return
+	}
+
+	l.Info("mine master payout", "tx count", len(resp))
+	for _, payout := range resp {
+		l.Info("payout", "address", payout.Address.Hex(), "txId", payout.TxHash.Hex())
+	}
+}
+
+func printMinerWorker(worker rpc_interface.MinerWorkerResp) {
+	hashrate, reward := "0", "0"
+	if worker.Hashrate != nil {
+		hashrate = worker.Hashrate.ToInt().String()
+	}
+	if worker.Reward != nil {
+		reward = worker.Reward.ToInt().String()
+	}
+	l.Info("worker", "id", worker.WorkerId, "address", worker.Address.Hex(), "hashrate(H/s)", hashrate,
+		"shares", worker.Shares, "blocks", worker.Blocks, "reward", reward, "last share", worker.LastShare)
+}
@@ -0,0 +1,113 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package commands
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/core/rpc-interface"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli"
+	"math/big"
+	"testing"
+)
+
+func Test_getMinerRpcMethodByName(t *testing.T) {
+	assert.Equal(t, "miner_kickWorker", getMinerRpcMethodByName("KickWorker"))
+}
+
+func Test_rpcCaller_MinerWorkers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := cli.NewApp()
+	app.Flags = []cli.Flag{
+		cli.StringFlag{Name: "m", Usage: "operation"},
+		cli.StringFlag{Name: "p", Usage: "parameters"},
+	}
+
+	app.Action = func(c *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		caller := &rpcCaller{}
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "miner_workers").Return(errors.New("test"))
+		caller.MinerWorkers(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "miner_workers").DoAndReturn(func(result interface{}, method string, args ...interface{}) error {
+			*result.(*[]rpc_interface.MinerWorkerResp) = []rpc_interface.MinerWorkerResp{
+				{WorkerId: "rig1", Address: common.HexToAddress("0x1234"), Hashrate: (*hexutil.Big)(big.NewInt(100))},
+				{WorkerId: "rig2"},
+			}
+			return nil
+		})
+		caller.MinerWorkers(c)
+
+		// the worker id is required
+		caller.MinerWorker(c)
+		c.Set("m", "MinerWorker")
+		c.Set("p", "rig1")
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "miner_worker", "rig1").Return(errors.New("test"))
+		caller.MinerWorker(c)
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "miner_worker", "rig1").Return(nil)
+		caller.MinerWorker(c)
+	}
+
+	app.Run([]string{"xxx"})
+	client = nil
+}
+
+func Test_rpcCaller_MinerControl(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	app := cli.NewApp()
+	app.Flags = []cli.Flag{
+		cli.StringFlag{Name: "m", Usage: "operation"},
+		cli.StringFlag{Name: "p", Usage: "parameters"},
+	}
+
+	app.Action = func(c *cli.Context) {
+		client = NewMockRpcClient(ctrl)
+		caller := &rpcCaller{}
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "miner_currentWork").Return(errors.New("test"))
+		caller.MinerCurrentWork(c)
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "miner_currentWork").Return(nil)
+		caller.MinerCurrentWork(c)
+
+		caller.MinerKickWorker(c)
+		c.Set("m", "MinerKickWorker")
+		c.Set("p", "rig1")
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "miner_kickWorker", "rig1").Return(errors.New("test"))
+		caller.MinerKickWorker(c)
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "miner_kickWorker", "rig1").Return(nil)
+		caller.MinerKickWorker(c)
+
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "miner_payout").Return(errors.New("test"))
+		caller.MinerPayout(c)
+		client.(*MockRpcClient).EXPECT().Call(gomock.Any(), "miner_payout").DoAndReturn(func(result interface{}, method string, args ...interface{}) error {
+			*result.(*[]rpc_interface.MinerPayoutResp) = []rpc_interface.MinerPayoutResp{{Address: common.HexToAddress("0x1234")}}
+			return nil
+		})
+		caller.MinerPayout(c)
+	}
+
+	app.Run([]string{"xxx"})
+	client = nil
+}
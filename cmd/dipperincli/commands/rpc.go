@@ -936,8 +936,8 @@ func (caller *rpcCaller) SendRegisterTx(c *cli.Context) {
 		return
 	}
 
-	if len(cParams) != 2 {
-		l.Error("SendRegisterTransaction need：stake transactionFee")
+	if len(cParams) != 2 && len(cParams) != 3 {
+		l.Error("SendRegisterTransaction need：stake transactionFee [commission]")
 		return
 	}
 
@@ -953,7 +953,18 @@ func (caller *rpcCaller) SendRegisterTx(c *cli.Context) {
 		return
 	}
 	var resp common.Hash
-	if err := client.Call(&resp, getDipperinRpcMethodByName("SendRegisterTransaction"), defaultAccount, stake, txFee, nil); err != nil {
+	if len(cParams) == 3 {
+		// the commission taken from the rewards of the delegators, in per mille
+		commission, err := strconv.ParseUint(cParams[2], 10, 64)
+		if err != nil {
+			l.Error("the parameter commission invalid")
+			return
+		}
+		if err := client.Call(&resp, getDipperinRpcMethodByName("SendRegisterTransactionWithCommission"), defaultAccount, stake, txFee, commission, nil); err != nil {
+			l.Error("call send transaction", "err", err)
+			return
+		}
+	} else if err := client.Call(&resp, getDipperinRpcMethodByName("SendRegisterTransaction"), defaultAccount, stake, txFee, nil); err != nil {
 
 		l.Error("call send transaction", "err", err)
 		return
@@ -1080,6 +1091,145 @@ func (caller *rpcCaller) SendUnStakeTransaction(c *cli.Context) {
 	l.Info("SendUnStakeTransaction result", "txId", resp.Hex())
 }
 
+// SendAddStakeTx top up the stake of the default account
+func (caller *rpcCaller) SendAddStakeTx(c *cli.Context) {
+
+	if checkSync() {
+		return
+	}
+
+	_, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+
+	if len(cParams) != 2 {
+		l.Error("SendAddStakeTransaction need：amount transactionFee")
+		return
+	}
+
+	amount, err := MoneyValueToCSCoin(cParams[0])
+	if err != nil {
+		l.Error("the parameter amount invalid")
+		return
+	}
+	txFee, err := MoneyValueToCSCoin(cParams[1])
+	if err != nil {
+		l.Error("the parameter transactionFee invalid")
+		return
+	}
+
+	var resp common.Hash
+	if err := client.Call(&resp, getDipperinRpcMethodByName("SendAddStakeTransaction"), defaultAccount, amount, txFee, nil); err != nil {
+		l.Error("call send transaction", "err", err)
+		return
+	}
+	l.Info("SendAddStakeTransaction result", "txId", resp.Hex())
+}
+
+// SendPartialUnStakeTx withdraw part of the stake of the default account
+func (caller *rpcCaller) SendPartialUnStakeTx(c *cli.Context) {
+
+	if checkSync() {
+		return
+	}
+
+	_, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+
+	if len(cParams) != 2 {
+		l.Error("SendPartialUnStakeTransaction need：amount transactionFee")
+		return
+	}
+
+	amount, err := MoneyValueToCSCoin(cParams[0])
+	if err != nil {
+		l.Error("the parameter amount invalid")
+		return
+	}
+	txFee, err := MoneyValueToCSCoin(cParams[1])
+	if err != nil {
+		l.Error("the parameter transactionFee invalid")
+		return
+	}
+
+	var resp common.Hash
+	if err := client.Call(&resp, getDipperinRpcMethodByName("SendPartialUnStakeTransaction"), defaultAccount, amount, txFee, nil); err != nil {
+		l.Error("call send transaction", "err", err)
+		return
+	}
+	l.Info("SendPartialUnStakeTransaction result", "txId", resp.Hex())
+}
+
+// SendWithdrawStakeTx return the partially unStaked money of the default account after the lock period
+func (caller *rpcCaller) SendWithdrawStakeTx(c *cli.Context) {
+
+	if checkSync() {
+		return
+	}
+
+	_, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+
+	if len(cParams) != 1 {
+		l.Error("SendWithdrawStakeTransaction need：transactionFee")
+		return
+	}
+
+	txFee, err := MoneyValueToCSCoin(cParams[0])
+	if err != nil {
+		l.Error("the parameter transactionFee invalid")
+		return
+	}
+
+	var resp common.Hash
+	if err := client.Call(&resp, getDipperinRpcMethodByName("SendWithdrawStakeTransaction"), defaultAccount, txFee, nil); err != nil {
+		l.Error("call send transaction", "err", err)
+		return
+	}
+	l.Info("SendWithdrawStakeTransaction result", "txId", resp.Hex())
+}
+
+// GetUnStaking get the partially unStaked money which is locked
+func (caller *rpcCaller) GetUnStaking(c *cli.Context) {
+	mName, cParams, err := getRpcMethodAndParam(c)
+	if err != nil {
+		l.Error("getRpcMethodAndParam error")
+		return
+	}
+	if len(cParams) != 0 && len(cParams) != 1 {
+		l.Error("parameter error")
+		return
+	}
+
+	addr := defaultAccount
+	if len(cParams) == 1 {
+		if addr, err = CheckAndChangeHexToAddress(cParams[0]); err != nil {
+			l.Error("the input address is invalid", "err", err)
+			return
+		}
+	}
+
+	var resp rpc_interface.UnStakingResp
+	if err := client.Call(&resp, getDipperinRpcMethodByName(mName), addr); err != nil {
+		l.Error("call get unStaking", "err", err)
+		return
+	}
+	amount, err := CSCoinToMoneyValue(resp.Amount)
+	if err != nil {
+		l.Error("the unStaking amount is invalid", "err", err)
+		return
+	}
+	l.Info("address unStaking is:", "amount", amount+consts.CoinDIPName, "height", resp.Height)
+}
+
 func (caller *rpcCaller) SendCancelTx(c *cli.Context) {
 
 	if checkSync() {
@@ -112,6 +112,11 @@ var methodFlags = []prompt.Suggest{
 	{Text: "GetVerifiersBySlot", Description: ""},
 	{Text: "ListWallet", Description: ""},
 	{Text: "ListWalletAccount", Description: ""},
+	{Text: "MinerCurrentWork", Description: ""},
+	{Text: "MinerKickWorker", Description: ""},
+	{Text: "MinerPayout", Description: ""},
+	{Text: "MinerWorker", Description: ""},
+	{Text: "MinerWorkers", Description: ""},
 	{Text: "OpenWallet", Description: ""},
 	{Text: "Peers", Description: ""},
 	{Text: "RestoreWallet", Description: ""},
@@ -119,8 +124,20 @@ var methodFlags = []prompt.Suggest{
 	{Text: "SendCancelTx", Description: ""},
 	{Text: "SendUnStakeTransaction", Description: ""},
 	{Text: "SendUnStakeTx", Description: ""},
+	{Text: "SendAddStakeTx", Description: ""},
+	{Text: "SendPartialUnStakeTx", Description: ""},
+	{Text: "SendWithdrawStakeTx", Description: ""},
+	{Text: "GetUnStaking", Description: ""},
 	{Text: "SendRegisterTransaction", Description: ""},
 	{Text: "SendRegisterTx", Description: ""},
+	{Text: "SendDelegateTx", Description: ""},
+	{Text: "SendUnDelegateTx", Description: ""},
+	{Text: "SendWithdrawDelegationTx", Description: ""},
+	{Text: "GetDelegation", Description: ""},
+	{Text: "GetCandidateDelegation", Description: ""},
+	{Text: "SendGovernanceProposalTx", Description: ""},
+	{Text: "SendGovernanceVoteTx", Description: ""},
+	{Text: "GetGovernanceProposal", Description: ""},
 	{Text: "SendTransaction", Description: ""},
 	{Text: "SendTx", Description: ""},
 	{Text: "SetExchangeRate", Description: ""},
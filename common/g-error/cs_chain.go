@@ -58,10 +58,18 @@ var (
 	ErrBlockHeightTooLow = errors.New("block height too low")
 	ErrBlockHeightIsCurrentAndIsNotSpecial = errors.New("block height is the same as current block height and isn't empty block")
 	ErrBlockSizeTooLarge = errors.New("block size too large")
+	ErrBlockTooManyTxs = errors.New("too many txs in block")
 
 
 	ErrBlockNotFound     = errors.New("block not found")
 	ErrCurrentBlockIsNil = errors.New("current block is nil")
+	ErrPivotStateNotFound = errors.New("the state of the fast sync pivot is not found")
+	ErrPivotVerifiersNotFound = errors.New("can't get the verifiers of the fast sync pivot")
+	ErrPivotNotCheckpoint = errors.New("the fast sync pivot is not a trusted checkpoint")
+	ErrCurrentStateNotFound = errors.New("the state of the current block is not found")
+	ErrCheckpointMismatch = errors.New("the block conflicts with the checkpoint")
+	ErrCheckpointVerifiersMismatch = errors.New("the verifiers of the block conflict with the checkpoint")
+	ErrBlockAfterCheckpoint = errors.New("the block is after the checkpoint")
 
 	ErrPreBlockIsNil = errors.New("pre block cannot be null")
 	ErrPreBlockHashNotMatch = errors.New("pre block hash not match")
@@ -78,5 +86,7 @@ var (
 
 	ErrBlockVer		= errors.New("block version not accept")
 	ErrBlockTimeStamp = errors.New("the block time stamp is invalid")
+	ErrBlockTimeBeforeMedian = errors.New("the block time stamp isn't after the median time past")
+	ErrBlockTimeTooFarAway = errors.New("the block time stamp is too far away in the future")
 
 )
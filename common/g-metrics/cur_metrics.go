@@ -49,6 +49,7 @@ const (
 	BftCurStateGauge = "pbft_state"
 	BftCurRoundGauge = "pbft_round"
 	BftTimeoutCount = "pbft_timeout"
+	BftStepTimeoutGauge = "pbft_step_timeout"
 
 	// v halt check
 	CurBlockNumberGauge = "verBootNodeBlockNumber"
@@ -58,6 +59,9 @@ const (
 
 	CurChainHeight = "cur_height"
 	FailedInsertBlockCount = "failed_insert_block_count"
+
+	// local mine worker
+	LocalMinerHashrateGauge = "local_miner_hashrate"
 )
 
 // call this after NewPrometheusMetricsServer
@@ -80,6 +84,7 @@ func InitCSMetrics() {
 	CreateGauge(BftCurStateGauge, "trace pbft state", nil)
 	CreateGauge(BftCurRoundGauge, "trace pbft cur round", nil)
 	CreateCounter(BftTimeoutCount, "trace timeout count", []string{"state_name"})
+	CreateGauge(BftStepTimeoutGauge, "trace pbft step timeout seconds", []string{"state_name"})
 
 	CreateGauge(CurBlockNumberGauge, "trace pbft state", nil)
 
@@ -87,4 +92,6 @@ func InitCSMetrics() {
 	CreateGauge(QueuedTxCountInPool, "trace tx count", nil)
 	CreateGauge(CurChainHeight, "chain height", nil)
 	CreateCounter(FailedInsertBlockCount, "trace failed insert block", nil)
+
+	CreateGauge(LocalMinerHashrateGauge, "hashes per second of the local mine worker", nil)
 }
\ No newline at end of file
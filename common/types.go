@@ -49,6 +49,12 @@ const (
 	AddressTypeCancel   = 0x0003
 	AddressTypeUnStake  = 0x0004
 	AddressTypeEvidence = 0x0005
+	AddressTypeDelegate = 0x0006
+	AddressTypeUnDelegate = 0x0007
+	AddressTypeWithdrawDelegation = 0x0008
+	AddressTypeWithdrawStake = 0x000a
+	AddressTypeGovernanceProposal = 0x000b
+	AddressTypeGovernanceVote = 0x000c
 	AddressTypeERC20    = 0x0010
 	AddressTypeEarlyReward    = 0x0011
 
@@ -68,6 +74,18 @@ func (txType TxType) String() string {
 		return "unstake transaction"
 	case AddressTypeEvidence:
 		return "evidence transaction"
+	case AddressTypeDelegate:
+		return "delegate transaction"
+	case AddressTypeUnDelegate:
+		return "undelegate transaction"
+	case AddressTypeWithdrawDelegation:
+		return "withdraw delegation transaction"
+	case AddressTypeWithdrawStake:
+		return "withdraw stake transaction"
+	case AddressTypeGovernanceProposal:
+		return "governance proposal transaction"
+	case AddressTypeGovernanceVote:
+		return "governance vote transaction"
 	case AddressTypeERC20:
 		return "erc20 transaction"
 	default:
@@ -79,6 +97,9 @@ const (
 	AddressStake   = "0x00020000000000000000000000000000000000000000"
 	AddressCancel  = "0x00030000000000000000000000000000000000000000"
 	AddressUnStake = "0x00040000000000000000000000000000000000000000"
+	AddressWithdrawStake = "0x000A0000000000000000000000000000000000000000"
+	AddressGovernanceProposal = "0x000B0000000000000000000000000000000000000000"
+	AddressGovernanceVote = "0x000C0000000000000000000000000000000000000000"
 )
 
 // Dipperin hash
@@ -251,6 +272,18 @@ func (addr Address) GetAddressTypeStr() string {
 		return "UnStake"
 	case AddressTypeEvidence:
 		return "Evidence"
+	case AddressTypeDelegate:
+		return "Delegate"
+	case AddressTypeUnDelegate:
+		return "UnDelegate"
+	case AddressTypeWithdrawDelegation:
+		return "WithdrawDelegation"
+	case AddressTypeWithdrawStake:
+		return "WithdrawStake"
+	case AddressTypeGovernanceProposal:
+		return "GovernanceProposal"
+	case AddressTypeGovernanceVote:
+		return "GovernanceVote"
 	case AddressTypeEarlyReward:
 		return consts.EarlyTokenTypeName
 	}
@@ -140,10 +140,14 @@ func MakeCsProtocolManager(pmConfig *CsProtocolManagerConfig, txBConf *NewTxBroa
 		Pm:       pm,
 		PbftNode: pmConfig.PbftNode,
 		fetcher:  blockFetcher,
+		FastSync: pmConfig.SyncMode == SyncModeFast,
+
+		Checkpoint: pmConfig.ChainConfig.LatestCheckpoint(),
 	})
 
 	//downloader.SetFetcher(bftOuterFetcher)
 	pm.registerCommunicationService(downloader, downloader)
+	pm.downloader = downloader
 
 	broadcastDelegate := &BroadcastDelegate{
 		newTxBroadcaster: newTxBroadcaster,
@@ -28,9 +28,15 @@ const (
 	TxMsg              = 0x02
 	GetBlocksMsg       = 0x03
 	BlocksMsg          = 0x04
+	GetBlockHeadersMsg = 0x05
+	BlockHeadersMsg    = 0x06
 	NewBlockMsg        = 0x07
 	NewBlockByBloomMsg = 0x08
 
+	// fast sync state entries
+	GetNodeDataMsg = 0x0d
+	NodeDataMsg    = 0x0e
+
 	// finder verifier
 	GetVerifiersConnFromBootNode = 0x60
 	BootNodeVerifiersConn        = 0x61
@@ -70,6 +76,18 @@ var (
 
 const (
 	MaxBlockFetch = 16
+	// the max number of the headers requested in one msg
+	MaxHeaderFetch = 192
+	// the max number of the trie nodes and preimages requested in one msg
+	MaxNodeDataFetch = 384
+)
+
+// the sync modes of the downloader
+const (
+	// download and process all the blocks
+	SyncModeFull = "full"
+	// download the blocks without processing them to a pivot block, then download the state of the pivot
+	SyncModeFast = "fast"
 )
 
 var totalVerifierBootNode int
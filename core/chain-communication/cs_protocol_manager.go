@@ -54,6 +54,8 @@ type CsProtocolManagerConfig struct {
 	VerifiersReader VerifiersReader
 	PbftNode        PbftNode
 	MsgSigner       PbftSigner
+	// SyncModeFull or SyncModeFast
+	SyncMode string
 }
 
 /*
@@ -87,6 +89,8 @@ type CsProtocolManager struct {
 
 	peerSetManager *CsPmPeerSetManager
 
+	downloader *NewPbftDownloader
+
 	stop chan struct{}
 }
 
@@ -111,6 +115,14 @@ func (pm *CsProtocolManager) RemovePeer(id string) {
 	pm.peerSetManager.RemovePeer(id)
 }
 
+// SyncProgress returns the progress of the block sync of the downloader
+func (pm *CsProtocolManager) SyncProgress() SyncProgress {
+	if pm.downloader == nil {
+		return SyncProgress{}
+	}
+	return pm.downloader.SyncProgress()
+}
+
 func (pm *CsProtocolManager) GetPeer(id string) PmAbstractPeer {
 	if p := pm.peerSetManager.basePeers.Peer(id); p != nil {
 		return p
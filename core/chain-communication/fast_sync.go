@@ -0,0 +1,308 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_communication
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/util"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/chain/state-processor"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/dipperin/dipperin-core/third-party/p2p"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// fast sync is given up after failing this number of times, then the blocks are processed from the genesis
+	maxFastSyncFailures = int32(3)
+	nodeDataTimeout     = 10 * time.Second
+)
+
+// the max size of the node data returned at once
+const nodeDataSoftResponseLimit = 2 * 1024 * 1024
+
+var (
+	errNoBlocksForFastSync = errors.New("no blocks returned for fast sync")
+	errNoPeersForFastSync  = errors.New("no peers for fast sync")
+)
+
+type nodeDataPack struct {
+	peerID string
+	data   [][]byte
+}
+
+// The verifiers of a slot are calculated with the state of the last block in the slot
+// which is SlotMargin before, so the states of the blocks in the slots before the pivot
+// are downloaded to verify the pivot and the blocks after it.
+func fastSyncStateWindow(config *chain_config.ChainConfig) uint64 {
+	return config.SlotSize * (config.SlotMargin + 2)
+}
+
+func (fd *NewPbftDownloader) shouldFastSync(bestPeer PmAbstractPeer) bool {
+	if !fd.FastSync || fd.fastSyncChain == nil {
+		return false
+	}
+	if atomic.LoadInt32(&fd.fastSyncFailures) >= maxFastSyncFailures {
+		return false
+	}
+	// only the node without any block skips the history
+	if fd.Chain.CurrentBlock().Number() != 0 {
+		return false
+	}
+	// the seals and votes of the history aren't verified, the pivot is the trusted checkpoint
+	// linked to it and the best peer must have the checkpoint
+	cp := fd.Checkpoint
+	if cp == nil || !fd.checkpointPeers[bestPeer.ID()] {
+		return false
+	}
+	_, height := bestPeer.GetHead()
+	return height >= cp.Number && cp.Number > fastSyncStateWindow(chain_config.GetChainConfig())
+}
+
+func (fd *NewPbftDownloader) runFastSync(bestPeer PmAbstractPeer) {
+	if err := fd.fastSync(bestPeer); err != nil {
+		failures := atomic.AddInt32(&fd.fastSyncFailures, 1)
+		log.Warn("fast sync failed", "err", err, "failures", failures, "remote node", bestPeer.NodeName())
+		if failures >= maxFastSyncFailures {
+			log.Warn("give up fast sync, switch to full sync")
+		}
+	}
+}
+
+// download the blocks to the pivot without processing them, then download the states of
+// the pivot and the blocks in its window, and set the pivot as the current block. The pivot
+// is the trusted checkpoint, so the blocks before it are linked to it by the pre hashes.
+func (fd *NewPbftDownloader) fastSync(bestPeer PmAbstractPeer) error {
+	pivotNumber := fd.Checkpoint.Number
+	log.Info("start fast sync", "pivot", pivotNumber, "remote node", bestPeer.NodeName())
+
+	pivot, commits, err := fd.fetchBlocksWithoutState(bestPeer, pivotNumber)
+	if err != nil {
+		return err
+	}
+
+	// the slot size at the pivot is read from the state before it, the window is never
+	// smaller than the one of the static config in case the slot size was reduced
+	if err := fd.syncBlockStates(pivotNumber-1, pivotNumber); err != nil {
+		return err
+	}
+	window := fastSyncStateWindow(chain_config.GetChainConfig())
+	if w := fastSyncStateWindow(fd.fastSyncChain.GetChainConfigAtHeight(pivotNumber)); w > window {
+		window = w
+	}
+	from := uint64(1)
+	if pivotNumber > window {
+		from = pivotNumber - window
+	}
+	// the tries downloaded already are skipped
+	if err := fd.syncBlockStates(from, pivotNumber); err != nil {
+		return err
+	}
+
+	if err := fd.fastSyncChain.CommitFastSyncPivot(pivot, commits); err != nil {
+		return err
+	}
+	log.Info("fast sync finished, switch to full sync", "pivot", pivotNumber)
+	return nil
+}
+
+// syncBlockStates downloads the states and the register states of the blocks from the number to the pivot
+func (fd *NewPbftDownloader) syncBlockStates(from, pivotNumber uint64) error {
+	var stateRoots, registerRoots []common.Hash
+	for num := from; num <= pivotNumber; num++ {
+		block := fd.Chain.GetBlockByNumber(num)
+		if block == nil {
+			return errNoBlocksForFastSync
+		}
+		stateRoots = append(stateRoots, block.StateRoot())
+		registerRoots = append(registerRoots, block.GetRegisterRoot())
+	}
+
+	sched := state_processor.NewStateSync(fd.fastSyncChain.GetDB(), stateRoots, registerRoots)
+	return fd.syncState(sched, pivotNumber)
+}
+
+func (fd *NewPbftDownloader) fetchBlocksWithoutState(bestPeer PmAbstractPeer, pivotNumber uint64) (pivot model.AbstractBlock, commits []model.AbstractVerification, err error) {
+	nextNumber := fd.Chain.CurrentBlock().Number() + 1
+	request := func() {
+		amount := pivotNumber - nextNumber + 1
+		if amount > MaxBlockFetch {
+			amount = MaxBlockFetch
+		}
+		if err := bestPeer.SendMsg(GetBlocksMsg, &getBlockHeaders{OriginHeight: nextNumber, Amount: amount}); err != nil {
+			log.Warn("send get blocks msg failed", "err", err)
+		}
+	}
+	go request()
+
+	timeoutTimer := time.NewTimer(fetchBlockTimeout)
+	defer timeoutTimer.Stop()
+	for {
+		select {
+		case packet := <-fd.blockC:
+			if packet.peerID != bestPeer.ID() {
+				log.Warn("Received blocks from incorrect peer", "peer", packet.peerID)
+				break
+			}
+			if len(packet.blocks) == 0 {
+				return nil, nil, errNoBlocksForFastSync
+			}
+
+			for _, b := range packet.blocks {
+				if b.Block.Number() != nextNumber {
+					continue
+				}
+				seenCommits := make([]model.AbstractVerification, len(b.SeenCommit))
+				util.InterfaceSliceCopy(seenCommits, b.SeenCommit)
+				if err := fd.fastSyncChain.InsertBlockWithoutState(b.Block, seenCommits); err != nil {
+					return nil, nil, err
+				}
+
+				if nextNumber == pivotNumber {
+					return b.Block, seenCommits, nil
+				}
+				nextNumber++
+			}
+
+			timeoutTimer.Reset(fetchBlockTimeout)
+			go request()
+		case <-timeoutTimer.C:
+			return nil, nil, errors.New("fetch blocks for fast sync timeout")
+		case <-fd.quitCh:
+			return nil, nil, quitErr
+		}
+	}
+}
+
+// the peers which have the state of the pivot
+func (fd *NewPbftDownloader) fastSyncPeers(pivotNumber uint64) []PmAbstractPeer {
+	var peers []PmAbstractPeer
+	for _, p := range fd.Pm.GetPeers() {
+		if _, height := p.GetHead(); height >= pivotNumber {
+			peers = append(peers, p)
+		}
+	}
+	return peers
+}
+
+// request the state entries from the peers round by round, every peer gets a part of the missing entries
+func (fd *NewPbftDownloader) syncState(sched *state_processor.StateSync, pivotNumber uint64) error {
+	atomic.StoreInt32(&fd.stateSyncing, 1)
+	defer atomic.StoreInt32(&fd.stateSyncing, 0)
+
+	for !sched.Done() {
+		peers := fd.fastSyncPeers(pivotNumber)
+		if len(peers) == 0 {
+			return errNoPeersForFastSync
+		}
+
+		waiting := map[string]struct{}{}
+		hashes := sched.Missing(MaxNodeDataFetch * len(peers))
+		for i := 0; len(hashes) > 0; i++ {
+			size := MaxNodeDataFetch
+			if size > len(hashes) {
+				size = len(hashes)
+			}
+			p := peers[i]
+			waiting[p.ID()] = struct{}{}
+			go func(batch []common.Hash) {
+				if err := p.SendMsg(GetNodeDataMsg, batch); err != nil {
+					log.Warn("send get node data msg failed", "err", err)
+				}
+			}(hashes[:size])
+			hashes = hashes[size:]
+		}
+
+		if err := fd.waitNodeData(sched, waiting); err != nil {
+			return err
+		}
+		if err := sched.EndRound(); err != nil {
+			return err
+		}
+		log.Info("fast sync state entries pending", "count", sched.Pending())
+	}
+	return nil
+}
+
+func (fd *NewPbftDownloader) waitNodeData(sched *state_processor.StateSync, waiting map[string]struct{}) error {
+	timeoutTimer := time.NewTimer(nodeDataTimeout)
+	defer timeoutTimer.Stop()
+	for len(waiting) > 0 {
+		select {
+		case pack := <-fd.nodeDataC:
+			// the late data of the last round is accepted too if it's still missing
+			delete(waiting, pack.peerID)
+			if _, err := sched.Process(pack.data); err != nil {
+				return err
+			}
+		case <-timeoutTimer.C:
+			log.Warn("waiting for node data timed out", "peers", len(waiting))
+			return nil
+		case <-fd.quitCh:
+			return quitErr
+		}
+	}
+	return nil
+}
+
+func (fd *NewPbftDownloader) onGetNodeData(msg p2p.Msg, p PmAbstractPeer) error {
+	var hashes []common.Hash
+	if err := msg.Decode(&hashes); err != nil {
+		return errors.New("decode error, invalid message")
+	}
+
+	var (
+		data  [][]byte
+		bytes int
+	)
+	for _, hash := range hashes {
+		if len(data) >= MaxNodeDataFetch || bytes >= nodeDataSoftResponseLimit {
+			break
+		}
+		if entry, err := fd.fastSyncChain.GetStateEntry(hash); err == nil && len(entry) > 0 {
+			data = append(data, entry)
+			bytes += len(entry)
+		}
+	}
+	log.Info("downloader send node data to remote", "remote node", p.NodeName(), "request", len(hashes), "return", len(data))
+	return p.SendMsg(NodeDataMsg, data)
+}
+
+func (fd *NewPbftDownloader) onNodeData(msg p2p.Msg, p PmAbstractPeer) error {
+	var data [][]byte
+	if err := msg.Decode(&data); err != nil {
+		log.Error("downloader decode node data failed", "err", err)
+		return err
+	}
+
+	// drop the data if the state isn't being synced
+	if atomic.LoadInt32(&fd.stateSyncing) == 0 {
+		return nil
+	}
+
+	select {
+	case <-fd.quitCh:
+		return quitErr
+	case fd.nodeDataC <- &nodeDataPack{peerID: p.ID(), data: data}:
+	case <-time.After(nodeDataTimeout):
+		log.Warn("node data is dropped", "remote node", p.NodeName())
+	}
+	return nil
+}
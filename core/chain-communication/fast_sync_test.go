@@ -0,0 +1,185 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_communication
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/tests/factory"
+	"github.com/dipperin/dipperin-core/third-party/p2p"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeFastSyncChain struct {
+	*MockChain
+	entries map[common.Hash][]byte
+}
+
+func (c *fakeFastSyncChain) GetDB() ethdb.Database {
+	return ethdb.NewMemDatabase()
+}
+
+func (c *fakeFastSyncChain) GetChainConfigAtHeight(height uint64) *chain_config.ChainConfig {
+	return chain_config.GetChainConfig()
+}
+
+func (c *fakeFastSyncChain) GetStateEntry(hash common.Hash) ([]byte, error) {
+	if entry, ok := c.entries[hash]; ok {
+		return entry, nil
+	}
+	return nil, errors.New("not found")
+}
+
+func (c *fakeFastSyncChain) InsertBlockWithoutState(block model.AbstractBlock, seenCommits []model.AbstractVerification) error {
+	return nil
+}
+
+func (c *fakeFastSyncChain) CommitFastSyncPivot(block model.AbstractBlock, seenCommits []model.AbstractVerification) error {
+	return nil
+}
+
+func TestNewPbftDownloader_FastSyncMsgHandlers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	handles := MakeNewPbftDownloader(&NewPbftDownloaderConfig{Chain: NewMockChain(ctrl)}).MsgHandlers()
+	assert.Nil(t, handles[GetNodeDataMsg])
+	assert.Nil(t, handles[NodeDataMsg])
+
+	fsChain := &fakeFastSyncChain{MockChain: NewMockChain(ctrl)}
+	handles = MakeNewPbftDownloader(&NewPbftDownloaderConfig{Chain: fsChain}).MsgHandlers()
+	assert.NotNil(t, handles[GetNodeDataMsg])
+	assert.NotNil(t, handles[NodeDataMsg])
+}
+
+func TestNewPbftDownloader_shouldFastSync(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockChain := NewMockChain(ctrl)
+	fsChain := &fakeFastSyncChain{MockChain: mockChain}
+	mockPeer := NewMockPmAbstractPeer(ctrl)
+
+	genesis := factory.CreateBlock2(common.HexToDiff("0x1effffff"), 0)
+	block := factory.CreateBlock2(common.HexToDiff("0x1effffff"), 1)
+	window := fastSyncStateWindow(chain_config.GetChainConfig())
+	assert.Equal(t, chain_config.GetChainConfig().SlotSize*(chain_config.GetChainConfig().SlotMargin+2), window)
+	cp := &chain_config.Checkpoint{Number: window + 1, Hash: common.Hash{0x1}}
+	mockPeer.EXPECT().ID().Return("peer").AnyTimes()
+
+	// full sync mode
+	pbftDownloader := MakeNewPbftDownloader(&NewPbftDownloaderConfig{Chain: fsChain, Checkpoint: cp})
+	assert.False(t, pbftDownloader.shouldFastSync(mockPeer))
+
+	// the chain doesn't support fast sync
+	pbftDownloader = MakeNewPbftDownloader(&NewPbftDownloaderConfig{Chain: mockChain, FastSync: true, Checkpoint: cp})
+	assert.False(t, pbftDownloader.shouldFastSync(mockPeer))
+
+	// no checkpoint to be the pivot
+	pbftDownloader = MakeNewPbftDownloader(&NewPbftDownloaderConfig{Chain: fsChain, FastSync: true})
+	mockChain.EXPECT().CurrentBlock().Return(genesis).Times(1)
+	assert.False(t, pbftDownloader.shouldFastSync(mockPeer))
+
+	pbftDownloader = MakeNewPbftDownloader(&NewPbftDownloaderConfig{Chain: fsChain, FastSync: true, Checkpoint: cp})
+
+	// the chain has blocks
+	mockChain.EXPECT().CurrentBlock().Return(block).Times(1)
+	assert.False(t, pbftDownloader.shouldFastSync(mockPeer))
+
+	// the checkpoint of the peer isn't checked
+	mockChain.EXPECT().CurrentBlock().Return(genesis).AnyTimes()
+	assert.False(t, pbftDownloader.shouldFastSync(mockPeer))
+	pbftDownloader.checkpointPeers["peer"] = true
+
+	// the peer is lower than the checkpoint
+	mockPeer.EXPECT().GetHead().Return(common.Hash{}, cp.Number-1).Times(1)
+	assert.False(t, pbftDownloader.shouldFastSync(mockPeer))
+
+	mockPeer.EXPECT().GetHead().Return(common.Hash{}, cp.Number).Times(1)
+	assert.True(t, pbftDownloader.shouldFastSync(mockPeer))
+
+	// the checkpoint is too low to download the states of its window
+	pbftDownloader.Checkpoint = &chain_config.Checkpoint{Number: window, Hash: common.Hash{0x1}}
+	mockPeer.EXPECT().GetHead().Return(common.Hash{}, cp.Number).Times(1)
+	assert.False(t, pbftDownloader.shouldFastSync(mockPeer))
+	pbftDownloader.Checkpoint = cp
+
+	// give up after too many failures
+	pbftDownloader.fastSyncFailures = maxFastSyncFailures
+	assert.False(t, pbftDownloader.shouldFastSync(mockPeer))
+}
+
+func TestNewPbftDownloader_onGetNodeData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	hash1 := common.HexToHash("0x01")
+	hash2 := common.HexToHash("0x02")
+	fsChain := &fakeFastSyncChain{
+		MockChain: NewMockChain(ctrl),
+		entries:   map[common.Hash][]byte{hash1: {1, 2, 3}},
+	}
+	pbftDownloader := MakeNewPbftDownloader(&NewPbftDownloaderConfig{Chain: fsChain})
+
+	mockPeer := NewMockPmAbstractPeer(ctrl)
+	mockPeer.EXPECT().NodeName().Return("test").AnyTimes()
+
+	err := pbftDownloader.onGetNodeData(p2p.Msg{Payload: bytes.NewReader([]byte{})}, mockPeer)
+	assert.Error(t, err)
+
+	// only the known entries are returned
+	mockPeer.EXPECT().SendMsg(uint64(NodeDataMsg), [][]byte{{1, 2, 3}}).Return(nil).Times(1)
+	payload, _ := rlp.EncodeToBytes([]common.Hash{hash1, hash2})
+	err = pbftDownloader.onGetNodeData(p2p.Msg{Payload: bytes.NewReader(payload)}, mockPeer)
+	assert.NoError(t, err)
+}
+
+func TestNewPbftDownloader_onNodeData(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	fsChain := &fakeFastSyncChain{MockChain: NewMockChain(ctrl)}
+	pbftDownloader := MakeNewPbftDownloader(&NewPbftDownloaderConfig{Chain: fsChain})
+
+	mockPeer := NewMockPmAbstractPeer(ctrl)
+	mockPeer.EXPECT().ID().Return("1").AnyTimes()
+
+	err := pbftDownloader.onNodeData(p2p.Msg{Payload: bytes.NewReader([]byte{})}, mockPeer)
+	assert.Error(t, err)
+
+	payload, _ := rlp.EncodeToBytes([][]byte{{1, 2, 3}})
+
+	// the data is dropped if the state isn't being synced
+	err = pbftDownloader.onNodeData(p2p.Msg{Payload: bytes.NewReader(payload)}, mockPeer)
+	assert.NoError(t, err)
+
+	pbftDownloader.stateSyncing = 1
+	go func() {
+		assert.NoError(t, pbftDownloader.onNodeData(p2p.Msg{Payload: bytes.NewReader(payload)}, mockPeer))
+	}()
+	pack := <-pbftDownloader.nodeDataC
+	assert.Equal(t, "1", pack.peerID)
+	assert.Equal(t, [][]byte{{1, 2, 3}}, pack.data)
+}
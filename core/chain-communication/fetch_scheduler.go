@@ -0,0 +1,218 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_communication
+
+import (
+	"errors"
+	"sort"
+	"time"
+)
+
+// the chunks waiting to be imported are limited to bound the memory of the downloaded blocks
+const maxFetchChunks = 32
+
+var (
+	errUnrequestedChunk = errors.New("blocks aren't requested from the peer")
+	errEmptyChunk       = errors.New("peer returns no blocks")
+	errInvalidChunk     = errors.New("peer returns the blocks which aren't requested")
+)
+
+// fetchChunk is a range of blocks requested from one peer
+type fetchChunk struct {
+	origin uint64
+	amount uint64
+	// the peer which the chunk is assigned to, it's empty if the chunk is waiting for a peer
+	peer     string
+	deadline time.Time
+	blocks   []*catchupRlp
+}
+
+// fetchScheduler splits the missing blocks into chunks and assigns them to the idle peers,
+// a peer has one chunk at most because the blocks msg can't be matched to the request
+type fetchScheduler struct {
+	peers   map[string]PmAbstractPeer
+	heights map[string]uint64
+	busy    map[string]*fetchChunk
+
+	// the chunks which aren't imported, in the order of their heights
+	chunks []*fetchChunk
+	// the origin of the next new chunk
+	next   uint64
+	height uint64
+}
+
+func newFetchScheduler(origin, height uint64) *fetchScheduler {
+	return &fetchScheduler{
+		peers:   map[string]PmAbstractPeer{},
+		heights: map[string]uint64{},
+		busy:    map[string]*fetchChunk{},
+		next:    origin,
+		height:  height,
+	}
+}
+
+func (s *fetchScheduler) addPeer(p PmAbstractPeer, height uint64) {
+	s.peers[p.ID()] = p
+	s.heights[p.ID()] = height
+}
+
+// dropPeer stops downloading from the peer, its chunk is assigned to the other peers
+func (s *fetchScheduler) dropPeer(id string) {
+	if chunk := s.busy[id]; chunk != nil {
+		chunk.peer = ""
+		delete(s.busy, id)
+	}
+	delete(s.peers, id)
+	delete(s.heights, id)
+}
+
+// assign gives the lowest waiting chunks to the idle peers, the lower peers are assigned first
+// because they can't serve the higher chunks
+func (s *fetchScheduler) assign(now time.Time) []*fetchChunk {
+	var idle []string
+	for id := range s.peers {
+		if s.busy[id] == nil {
+			idle = append(idle, id)
+		}
+	}
+	sort.Slice(idle, func(i, j int) bool {
+		if s.heights[idle[i]] != s.heights[idle[j]] {
+			return s.heights[idle[i]] < s.heights[idle[j]]
+		}
+		return idle[i] < idle[j]
+	})
+
+	var assigned []*fetchChunk
+	for _, id := range idle {
+		chunk := s.nextChunk(s.heights[id])
+		if chunk == nil {
+			continue
+		}
+		chunk.peer = id
+		chunk.deadline = now.Add(fetchBlockTimeout)
+		s.busy[id] = chunk
+		assigned = append(assigned, chunk)
+	}
+	return assigned
+}
+
+// nextChunk returns the lowest waiting chunk the peer has, a new chunk is added if there isn't
+func (s *fetchScheduler) nextChunk(peerHeight uint64) *fetchChunk {
+	for _, chunk := range s.chunks {
+		if chunk.peer == "" && chunk.origin <= peerHeight {
+			return chunk
+		}
+	}
+	if len(s.chunks) >= maxFetchChunks || s.next > s.height || s.next > peerHeight {
+		return nil
+	}
+
+	amount := s.height - s.next + 1
+	if amount > MaxBlockFetch {
+		amount = MaxBlockFetch
+	}
+	chunk := &fetchChunk{origin: s.next, amount: amount}
+	s.next += amount
+	s.chunks = append(s.chunks, chunk)
+	return chunk
+}
+
+// deliver fills the chunk of the peer with the blocks, the rest of the chunk is split into a new one
+func (s *fetchScheduler) deliver(id string, blocks []*catchupRlp) error {
+	chunk := s.busy[id]
+	if chunk == nil {
+		return errUnrequestedChunk
+	}
+	delete(s.busy, id)
+
+	if len(blocks) == 0 {
+		chunk.peer = ""
+		return errEmptyChunk
+	}
+	if uint64(len(blocks)) > chunk.amount {
+		chunk.peer = ""
+		return errInvalidChunk
+	}
+	for i, b := range blocks {
+		if b == nil || b.Block == nil || b.Block.Number() != chunk.origin+uint64(i) {
+			chunk.peer = ""
+			return errInvalidChunk
+		}
+	}
+
+	chunk.blocks = blocks
+	if rest := chunk.amount - uint64(len(blocks)); rest > 0 {
+		chunk.amount = uint64(len(blocks))
+		s.insertAfter(chunk, &fetchChunk{origin: chunk.origin + chunk.amount, amount: rest})
+	}
+	return nil
+}
+
+func (s *fetchScheduler) insertAfter(chunk, newChunk *fetchChunk) {
+	for i := range s.chunks {
+		if s.chunks[i] == chunk {
+			s.chunks = append(s.chunks[:i+1], append([]*fetchChunk{newChunk}, s.chunks[i+1:]...)...)
+			return
+		}
+	}
+}
+
+// front returns the lowest chunk if its blocks are downloaded, the chunks are imported in order
+func (s *fetchScheduler) front() *fetchChunk {
+	if len(s.chunks) == 0 || s.chunks[0].blocks == nil {
+		return nil
+	}
+	return s.chunks[0]
+}
+
+func (s *fetchScheduler) pop() {
+	s.chunks = s.chunks[1:]
+}
+
+// retry downloads the lowest chunk again from the origin
+func (s *fetchScheduler) retry(origin uint64) {
+	chunk := s.chunks[0]
+	end := chunk.origin + chunk.amount
+	if origin < end {
+		chunk.origin, chunk.amount = origin, end-origin
+	}
+	chunk.peer = ""
+	chunk.blocks = nil
+}
+
+// expired returns the peers which don't return their chunks in time
+func (s *fetchScheduler) expired(now time.Time) (peers []string) {
+	for id, chunk := range s.busy {
+		if !now.Before(chunk.deadline) {
+			peers = append(peers, id)
+		}
+	}
+	return
+}
+
+func (s *fetchScheduler) nextDeadline() (deadline time.Time) {
+	for _, chunk := range s.busy {
+		if deadline.IsZero() || chunk.deadline.Before(deadline) {
+			deadline = chunk.deadline
+		}
+	}
+	return
+}
+
+func (s *fetchScheduler) done() bool {
+	return len(s.chunks) == 0 && s.next > s.height
+}
@@ -0,0 +1,157 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_communication
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/tests/factory"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func fetchTestBlocks(origin, amount uint64) (blocks []*catchupRlp) {
+	for i := uint64(0); i < amount; i++ {
+		blocks = append(blocks, &catchupRlp{Block: factory.CreateBlock2(common.HexToDiff("0x1effffff"), origin+i)})
+	}
+	return
+}
+
+func fetchTestPeer(ctrl *gomock.Controller, id string) *MockPmAbstractPeer {
+	p := NewMockPmAbstractPeer(ctrl)
+	p.EXPECT().ID().Return(id).AnyTimes()
+	return p
+}
+
+func TestFetchScheduler_assign(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := newFetchScheduler(1, 40)
+	s.addPeer(fetchTestPeer(ctrl, "1"), 40)
+	s.addPeer(fetchTestPeer(ctrl, "2"), 20)
+	s.addPeer(fetchTestPeer(ctrl, "3"), 10)
+
+	now := time.Now()
+	assigned := s.assign(now)
+	assert.Len(t, assigned, 3)
+	assert.Len(t, s.chunks, 3)
+	assert.Equal(t, uint64(1), s.chunks[0].origin)
+	assert.Equal(t, uint64(MaxBlockFetch), s.chunks[0].amount)
+	assert.Equal(t, uint64(33), s.chunks[2].origin)
+	assert.Equal(t, uint64(8), s.chunks[2].amount)
+	assert.True(t, s.next > s.height)
+	for _, chunk := range assigned {
+		assert.True(t, chunk.origin <= s.heights[chunk.peer])
+		assert.Equal(t, now.Add(fetchBlockTimeout), chunk.deadline)
+	}
+
+	// the busy peers aren't assigned again
+	assert.Empty(t, s.assign(now))
+
+	// the chunk of the dropped peer is assigned to the idle peer
+	id := s.chunks[0].peer
+	s.dropPeer(id)
+	assert.Equal(t, "", s.chunks[0].peer)
+	assert.NoError(t, s.deliver(s.chunks[1].peer, fetchTestBlocks(17, 16)))
+	assigned = s.assign(now)
+	if assert.Len(t, assigned, 1) {
+		assert.Equal(t, uint64(1), assigned[0].origin)
+	}
+}
+
+func TestFetchScheduler_deliver(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := newFetchScheduler(1, 20)
+	s.addPeer(fetchTestPeer(ctrl, "1"), 20)
+	assert.Equal(t, errUnrequestedChunk, s.deliver("1", fetchTestBlocks(1, 1)))
+
+	s.assign(time.Now())
+	assert.Equal(t, errEmptyChunk, s.deliver("1", nil))
+	s.assign(time.Now())
+	assert.Equal(t, errInvalidChunk, s.deliver("1", fetchTestBlocks(2, 1)))
+	s.assign(time.Now())
+	assert.Equal(t, errInvalidChunk, s.deliver("1", fetchTestBlocks(1, MaxBlockFetch+1)))
+	assert.Nil(t, s.front())
+
+	// the rest of the chunk is downloaded again
+	s.assign(time.Now())
+	assert.NoError(t, s.deliver("1", fetchTestBlocks(1, 10)))
+	assert.Len(t, s.chunks, 2)
+	assert.Equal(t, uint64(11), s.chunks[1].origin)
+	assert.Equal(t, uint64(6), s.chunks[1].amount)
+
+	chunk := s.front()
+	if assert.NotNil(t, chunk) {
+		assert.Equal(t, uint64(10), chunk.amount)
+		assert.Equal(t, "1", chunk.peer)
+	}
+	s.pop()
+	assert.Nil(t, s.front())
+
+	assigned := s.assign(time.Now())
+	if assert.Len(t, assigned, 1) {
+		assert.Equal(t, uint64(11), assigned[0].origin)
+	}
+	assert.NoError(t, s.deliver("1", fetchTestBlocks(11, 6)))
+	s.pop()
+	assigned = s.assign(time.Now())
+	if assert.Len(t, assigned, 1) {
+		assert.Equal(t, uint64(17), assigned[0].origin)
+		assert.Equal(t, uint64(4), assigned[0].amount)
+	}
+	assert.NoError(t, s.deliver("1", fetchTestBlocks(17, 4)))
+	s.pop()
+	assert.True(t, s.done())
+}
+
+func TestFetchScheduler_retry(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := newFetchScheduler(1, 16)
+	s.addPeer(fetchTestPeer(ctrl, "1"), 16)
+	s.assign(time.Now())
+	assert.NoError(t, s.deliver("1", fetchTestBlocks(1, 16)))
+
+	s.retry(5)
+	assert.Nil(t, s.front())
+	assert.Equal(t, uint64(5), s.chunks[0].origin)
+	assert.Equal(t, uint64(12), s.chunks[0].amount)
+	assert.Equal(t, "", s.chunks[0].peer)
+}
+
+func TestFetchScheduler_expired(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	s := newFetchScheduler(1, 40)
+	s.addPeer(fetchTestPeer(ctrl, "1"), 40)
+	now := time.Now()
+	s.assign(now)
+	s.addPeer(fetchTestPeer(ctrl, "2"), 40)
+	s.assign(now.Add(time.Second))
+
+	assert.Equal(t, now.Add(fetchBlockTimeout), s.nextDeadline())
+	assert.Empty(t, s.expired(now))
+	assert.Equal(t, []string{"1"}, s.expired(now.Add(fetchBlockTimeout)))
+	assert.Len(t, s.expired(now.Add(fetchBlockTimeout+time.Second)), 2)
+}
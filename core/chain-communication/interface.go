@@ -20,12 +20,14 @@ package chain_communication
 import (
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/core/bloom"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/third-party/p2p/enode"
 	"net"
 	"github.com/dipperin/dipperin-core/third-party/p2p"
 	"crypto/ecdsa"
 	"github.com/dipperin/dipperin-core/core/accounts"
+	"github.com/ethereum/go-ethereum/ethdb"
 )
 
 //go:generate mockgen -destination=./peer_mock_test.go -package=chain_communication github.com/caiqingfeng/dipperin-core/core/chain-communication PmAbstractPeer
@@ -149,6 +151,22 @@ type Chain interface {
 	SaveBlock(block model.AbstractBlock, seenCommits []model.AbstractVerification) error
 }
 
+// FastSyncChain is the chain which can be synced by the state of a pivot block instead of processing the history
+type FastSyncChain interface {
+	Chain
+	GetDB() ethdb.Database
+	GetChainConfigAtHeight(height uint64) *chain_config.ChainConfig
+	GetStateEntry(hash common.Hash) ([]byte, error)
+	InsertBlockWithoutState(block model.AbstractBlock, seenCommits []model.AbstractVerification) error
+	CommitFastSyncPivot(block model.AbstractBlock, seenCommits []model.AbstractVerification) error
+}
+
+// CheckpointChain is the chain which saves the blocks until the trusted checkpoint without verifying their votes and seals
+type CheckpointChain interface {
+	Chain
+	SaveBlockBeforeCheckpoint(block model.AbstractBlock, seenCommits []model.AbstractVerification) error
+}
+
 //go:generate mockgen -destination=./pbft_signer_mock_test.go -package=chain_communication github.com/caiqingfeng/dipperin-core/core/chain-communication PbftSigner
 type PbftSigner interface {
 	GetAddress() common.Address
@@ -18,9 +18,11 @@ package chain_communication
 
 import (
 	"errors"
+	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/common/g-timer"
 	"github.com/dipperin/dipperin-core/common/util"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/third-party/log"
 	"github.com/dipperin/dipperin-core/third-party/log/pbft_log"
@@ -33,17 +35,36 @@ import (
 var pollingInterval = 10 * time.Second
 var fetchBlockTimeout = 60 * time.Second
 
+// the checked peers are forgotten when there are too many of them
+const maxCheckpointPeers = 1024
+
 func MakeNewPbftDownloader(config *NewPbftDownloaderConfig) *NewPbftDownloader {
 	service := &NewPbftDownloader{
 		NewPbftDownloaderConfig: config,
 
-		handlers: map[uint64]func(msg p2p.Msg, p PmAbstractPeer) error{},
-		blockC:   make(chan *npbPack),
+		handlers:  map[uint64]func(msg p2p.Msg, p PmAbstractPeer) error{},
+		blockC:    make(chan *npbPack),
+		headerC:   make(chan *headerPack),
+		nodeDataC: make(chan *nodeDataPack),
+
+		checkpointPeers: map[string]bool{},
 
 		quitCh: make(chan struct{}),
 	}
 	service.handlers[GetBlocksMsg] = service.onGetBlocks
 	service.handlers[BlocksMsg] = service.onBlocks
+	service.handlers[GetBlockHeadersMsg] = service.onGetBlockHeaders
+	service.handlers[BlockHeadersMsg] = service.onBlockHeaders
+
+	// the chain which supports fast sync serves the state entries for the other nodes
+	if fsChain, ok := config.Chain.(FastSyncChain); ok {
+		service.fastSyncChain = fsChain
+		service.handlers[GetNodeDataMsg] = service.onGetNodeData
+		service.handlers[NodeDataMsg] = service.onNodeData
+	}
+	if cpChain, ok := config.Chain.(CheckpointChain); ok {
+		service.checkpointChain = cpChain
+	}
 	return service
 }
 
@@ -53,6 +74,10 @@ type NewPbftDownloaderConfig struct {
 	PbftNode PbftNode
 	//fetcher  *EiBlockFetcher
 	fetcher *BlockFetcher
+	// download the state of a pivot block instead of processing the history if the chain is empty
+	FastSync bool
+	// the latest trusted checkpoint, the peers conflicting with it are refused
+	Checkpoint *chain_config.Checkpoint
 }
 
 type NewPbftDownloader struct {
@@ -60,10 +85,24 @@ type NewPbftDownloader struct {
 
 	handlers map[uint64]func(msg p2p.Msg, p PmAbstractPeer) error
 
-	blockC chan *npbPack
+	blockC  chan *npbPack
+	headerC chan *headerPack
 
 	synchronising int32
 
+	fastSyncChain    FastSyncChain
+	nodeDataC        chan *nodeDataPack
+	stateSyncing     int32
+	fastSyncFailures int32
+
+	checkpointChain CheckpointChain
+	// the peers which have the checkpoint block
+	checkpointPeers map[string]bool
+	// the blocks linked to the checkpoint are saved without verifying the votes and seals
+	checkpointLink *checkpointLink
+
+	progress syncTracker
+
 	quitCh chan struct{}
 }
 
@@ -72,6 +111,11 @@ type npbPack struct {
 	blocks []*catchupRlp
 }
 
+type headerPack struct {
+	peerID  string
+	headers []*model.Header
+}
+
 type catchup struct {
 	Block      model.AbstractBlock
 	SeenCommit []model.AbstractVerification
@@ -161,6 +205,45 @@ func (fd *NewPbftDownloader) onBlocks(msg p2p.Msg, p PmAbstractPeer) error {
 	return nil
 }
 
+// onGetBlockHeaders serves the headers without the bodies and the commits
+func (fd *NewPbftDownloader) onGetBlockHeaders(msg p2p.Msg, p PmAbstractPeer) error {
+	var query getBlockHeaders
+	if err := msg.Decode(&query); err != nil {
+		return errors.New("decode error, invalid message")
+	}
+
+	var headers []*model.Header
+	for len(headers) < int(query.Amount) && len(headers) < MaxHeaderFetch {
+		block := fd.Chain.GetBlockByNumber(query.OriginHeight)
+		if block == nil {
+			log.Info("can't get block header for downloader", "height", query.OriginHeight)
+			break
+		}
+
+		headers = append(headers, block.Header().(*model.Header))
+		query.OriginHeight += 1
+	}
+
+	log.Info("downloader send block headers to remote", "remote node", p.NodeName(), "header len", len(headers))
+	return p.SendMsg(BlockHeadersMsg, headers)
+}
+
+func (fd *NewPbftDownloader) onBlockHeaders(msg p2p.Msg, p PmAbstractPeer) error {
+	var headers []*model.Header
+	if err := msg.Decode(&headers); err != nil {
+		log.Error("downloader decode block headers failed", "err", err)
+		return err
+	}
+	log.Info("downloader receive block headers from", "node", p.NodeName(), "header len", len(headers))
+
+	select {
+	case <-fd.quitCh:
+		return quitErr
+	case fd.headerC <- &headerPack{peerID: p.ID(), headers: headers}:
+	}
+	return nil
+}
+
 func (fd *NewPbftDownloader) Start() error {
 	pbft_log.Debug("Start New PBFT Downloader")
 	go fd.loop()
@@ -240,73 +323,136 @@ func (fd *NewPbftDownloader) runSync() {
 		return
 	}
 
+	if err := fd.checkPeerCheckpoint(bestPeer); err != nil {
+		log.Warn("refuse the peer conflicting with the checkpoint", "err", err, "remote node", bestPeer.NodeName())
+		fd.Pm.RemovePeer(bestPeer.ID())
+		return
+	}
+
+	_, height := bestPeer.GetHead()
+	fd.progress.start(fd.Chain.CurrentBlock().Number(), height)
+	defer func() {
+		fd.progress.stop(fd.Chain.CurrentBlock().Number())
+	}()
+
+	if fd.shouldFastSync(bestPeer) {
+		fd.runFastSync(bestPeer)
+		return
+	}
+
+	if err := fd.proveCheckpointLink(bestPeer); err != nil {
+		log.Warn("refuse the peer which can't link the checkpoint to the local chain", "err", err, "remote node", bestPeer.NodeName())
+		fd.Pm.RemovePeer(bestPeer.ID())
+		return
+	}
+
 	fd.fetchBlocks(bestPeer)
 
 }
 
+// fetchBlocks downloads the blocks up to the best peer from all the higher peers at once
 func (fd *NewPbftDownloader) fetchBlocks(bestPeer PmAbstractPeer) {
 	_, height := bestPeer.GetHead()
 
-	//because current block may be reversed by the empty block, the nextNumber should be the current block number
-	nextNumber := fd.Chain.CurrentBlock().Number()
-
-	log.Info("send get blocks msg", "remote peer name", bestPeer.NodeName(), "remote peer height", height)
-	go func() {
-		if err := bestPeer.SendMsg(GetBlocksMsg, &getBlockHeaders{OriginHeight: nextNumber, Amount: MaxBlockFetch}); err != nil {
-			log.Warn("send get blocks msg failed", "err", err)
-		}
-	}()
+	//because current block may be reversed by the empty block, the origin should be the current block number
+	s := newFetchScheduler(fd.Chain.CurrentBlock().Number(), height)
+	s.addPeer(bestPeer, height)
+	fd.addFetchPeers(s, bestPeer)
+	log.Info("downloader fetch blocks", "peers", len(s.peers), "best peer", bestPeer.NodeName(), "remote peer height", height)
 
 	timeoutTimer := time.NewTimer(fetchBlockTimeout)
 	defer timeoutTimer.Stop()
-	for {
-		select {
+	for !s.done() {
+		for _, chunk := range s.assign(time.Now()) {
+			fd.requestChunk(s.peers[chunk.peer], chunk)
+		}
+		fd.progress.setPeers(s.syncPeers())
+		// no peer has the rest blocks
+		if len(s.busy) == 0 {
+			log.Warn("downloader has no peer to fetch blocks", "next", s.next, "remote peer height", height)
+			return
+		}
 
+		if !timeoutTimer.Stop() {
+			select {
+			case <-timeoutTimer.C:
+			default:
+			}
+		}
+		timeoutTimer.Reset(time.Until(s.nextDeadline()))
+
+		select {
 		case packet := <-fd.blockC:
-			if packet.peerID != bestPeer.ID() {
+			switch err := s.deliver(packet.peerID, packet.blocks); err {
+			case nil:
+				fd.importChunks(s)
+			case errUnrequestedChunk:
 				log.Warn("Received skeleton from incorrect peer", "peer", packet.peerID)
-				break
+			case errEmptyChunk:
+				log.Info("peer has no blocks to fetch", "peer", packet.peerID)
+				s.dropPeer(packet.peerID)
+			default:
+				log.Warn("refuse the peer returning invalid blocks", "err", err, "peer", packet.peerID)
+				s.dropPeer(packet.peerID)
+				fd.Pm.RemovePeer(packet.peerID)
 			}
 
-			blocks := packet.blocks
-			size := len(blocks)
-
-			log.Info("fetchBlocks1", "blocks len", size)
-
-			// no block return from remote
-			if size == 0 {
-				return
+		case <-timeoutTimer.C:
+			for _, id := range s.expired(time.Now()) {
+				log.Warn("Waiting for fetchHeaders headers timed out", "node name", s.peers[id].NodeName())
+				s.dropPeer(id)
 			}
 
-			// If the insertion is slow, it will cause a timeout, then the Peer is broken.
-			if size > 0 {
-				if err := fd.importBlockResults(blocks); err != nil {
-					log.Error("downloader save block failed", "err", err, "remote node", bestPeer.NodeName())
-					return
-				}
-				nextNumber += uint64(len(blocks))
-			}
+		case <-fd.quitCh:
+			return
 
-			// stop when equal remote height
-			if height == fd.Chain.CurrentBlock().Number() {
-				return
-			}
+		}
+	}
+}
 
-			// reset timeout timer
-			timeoutTimer.Reset(fetchBlockTimeout)
-			// run sync again
-			go bestPeer.SendMsg(GetBlocksMsg, &getBlockHeaders{OriginHeight: nextNumber, Amount: MaxBlockFetch})
-		case <-timeoutTimer.C:
-			log.Warn("Waiting for fetchHeaders headers timed out", "node name", bestPeer.NodeName())
-			return
+// addFetchPeers adds the other peers higher than the local chain to download the blocks
+func (fd *NewPbftDownloader) addFetchPeers(s *fetchScheduler, bestPeer PmAbstractPeer) {
+	current := fd.Chain.CurrentBlock().Number()
+	for id, p := range fd.Pm.GetPeers() {
+		if id == bestPeer.ID() {
+			continue
+		}
+		if _, height := p.GetHead(); height > current {
+			s.addPeer(p, height)
+		}
+	}
+}
 
-		case <-fd.quitCh:
-			return
+func (fd *NewPbftDownloader) requestChunk(p PmAbstractPeer, chunk *fetchChunk) {
+	query := &getBlockHeaders{OriginHeight: chunk.origin, Amount: chunk.amount}
+	log.Info("send get blocks msg", "remote peer name", p.NodeName(), "origin", query.OriginHeight, "amount", query.Amount)
+	go func() {
+		if err := p.SendMsg(GetBlocksMsg, query); err != nil {
+			log.Warn("send get blocks msg failed", "err", err)
+		}
+	}()
+}
 
+// importChunks imports the downloaded chunks in order, the peer returning the invalid blocks is removed and
+// the chunk is downloaded again from the other peers
+func (fd *NewPbftDownloader) importChunks(s *fetchScheduler) {
+	for chunk := s.front(); chunk != nil; chunk = s.front() {
+		// If the insertion is slow, it will cause a timeout, then the Peer is broken.
+		if err := fd.importBlockResults(chunk.blocks); err != nil {
+			log.Error("downloader save block failed", "err", err, "remote node", chunk.peer)
+			// the blocks saved by the fetcher meanwhile aren't the fault of the peer
+			if err != g_error.ErrAlreadyHaveThisBlock {
+				fd.Pm.RemovePeer(chunk.peer)
+				s.dropPeer(chunk.peer)
+			}
+			s.retry(fd.Chain.CurrentBlock().Number())
+			return
 		}
+		s.pop()
 	}
 }
 
+// the blocks linked to the checkpoint are saved without verifying their votes and seals
 func (fd *NewPbftDownloader) importBlockResults(list []*catchupRlp) error {
 	//log.Info("insert blocks from downloader")
 	for _, b := range list {
@@ -319,7 +465,11 @@ func (fd *NewPbftDownloader) importBlockResults(list []*catchupRlp) error {
 			pbft_log.Warn("commits is empty", "height", b.Block.Number())
 		}
 
-		if err := fd.Chain.SaveBlock(b.Block, commits); err != nil {
+		save := fd.Chain.SaveBlock
+		if fd.checkpointChain != nil && fd.checkpointLink.contains(b.Block) {
+			save = fd.checkpointChain.SaveBlockBeforeCheckpoint
+		}
+		if err := save(b.Block, commits); err != nil {
 			//skip the block if the height is same as current block and it isn't the empty block
 			if err == g_error.ErrBlockHeightIsCurrentAndIsNotSpecial{
 				continue
@@ -331,3 +481,147 @@ func (fd *NewPbftDownloader) importBlockResults(list []*catchupRlp) error {
 
 	return nil
 }
+
+// checkPeerCheckpoint gets the checkpoint block from the peer, an error is returned if the peer conflicts with it
+func (fd *NewPbftDownloader) checkPeerCheckpoint(p PmAbstractPeer) error {
+	cp := fd.Checkpoint
+	if cp == nil || fd.checkpointPeers[p.ID()] {
+		return nil
+	}
+	// the peer can't be checked until it has the checkpoint block
+	if _, height := p.GetHead(); height < cp.Number {
+		return nil
+	}
+
+	blocks, err := fd.requestBlocks(p, cp.Number, 1)
+	if err != nil {
+		return err
+	}
+	if len(blocks) == 0 || blocks[0].Block.Number() != cp.Number {
+		return g_error.ErrBlockNotFound
+	}
+	if !blocks[0].Block.Hash().IsEqual(cp.Hash) {
+		return g_error.ErrCheckpointMismatch
+	}
+
+	if len(fd.checkpointPeers) >= maxCheckpointPeers {
+		fd.checkpointPeers = map[string]bool{}
+	}
+	fd.checkpointPeers[p.ID()] = true
+	log.Info("peer has the checkpoint", "num", cp.Number, "remote node", p.NodeName())
+	return nil
+}
+
+// proveCheckpointLink downloads the headers from the checkpoint back to the current block of the peer having the
+// checkpoint and checks they are linked by the pre hashes. Only the hashes are kept, the blocks in the link are
+// saved without verifying their votes and seals when they are downloaded, no block is processed before the link
+// is proven.
+func (fd *NewPbftDownloader) proveCheckpointLink(p PmAbstractPeer) error {
+	cp := fd.Checkpoint
+	current := fd.Chain.CurrentBlock()
+	if cp == nil || fd.checkpointChain == nil || !fd.checkpointPeers[p.ID()] || current.Number() >= cp.Number {
+		return nil
+	}
+	// the link proven before still covers the blocks after the current block
+	if fd.checkpointLink != nil && fd.checkpointLink.base <= current.Number()+1 {
+		return nil
+	}
+
+	log.Info("prove the link to the checkpoint", "from", cp.Number, "to", current.Number(), "remote node", p.NodeName())
+	hashes := make([]common.Hash, 0, cp.Number-current.Number())
+	expected := cp.Hash
+	for top := cp.Number; top > current.Number(); {
+		origin := current.Number() + 1
+		if top-origin+1 > MaxHeaderFetch {
+			origin = top - MaxHeaderFetch + 1
+		}
+		headers, err := fd.requestHeaders(p, origin, top-origin+1)
+		if err != nil {
+			return err
+		}
+		if uint64(len(headers)) != top-origin+1 {
+			return g_error.ErrBlockNotFound
+		}
+		for i := len(headers) - 1; i >= 0; i-- {
+			header := headers[i]
+			if header.Number != top || !header.Hash().IsEqual(expected) {
+				return g_error.ErrCheckpointMismatch
+			}
+			hashes = append(hashes, expected)
+			expected = header.PreHash
+			top--
+		}
+	}
+	if !expected.IsEqual(current.Hash()) {
+		return g_error.ErrCheckpointMismatch
+	}
+
+	for i, j := 0, len(hashes)-1; i < j; i, j = i+1, j-1 {
+		hashes[i], hashes[j] = hashes[j], hashes[i]
+	}
+	fd.checkpointLink = &checkpointLink{base: current.Number() + 1, hashes: hashes}
+	log.Info("the checkpoint is linked to the local chain", "num", cp.Number, "blocks", len(hashes))
+	return nil
+}
+
+// requestBlocks gets the blocks from the peer and waits for them
+func (fd *NewPbftDownloader) requestBlocks(p PmAbstractPeer, origin, amount uint64) ([]*catchupRlp, error) {
+	go func() {
+		if err := p.SendMsg(GetBlocksMsg, &getBlockHeaders{OriginHeight: origin, Amount: amount}); err != nil {
+			log.Warn("send get blocks msg failed", "err", err)
+		}
+	}()
+
+	timeoutTimer := time.NewTimer(fetchBlockTimeout)
+	defer timeoutTimer.Stop()
+	for {
+		select {
+		case packet := <-fd.blockC:
+			if packet.peerID == p.ID() {
+				return packet.blocks, nil
+			}
+		case <-timeoutTimer.C:
+			return nil, errors.New("get blocks timeout")
+		case <-fd.quitCh:
+			return nil, quitErr
+		}
+	}
+}
+
+// requestHeaders gets the headers from the peer and waits for them
+func (fd *NewPbftDownloader) requestHeaders(p PmAbstractPeer, origin, amount uint64) ([]*model.Header, error) {
+	go func() {
+		if err := p.SendMsg(GetBlockHeadersMsg, &getBlockHeaders{OriginHeight: origin, Amount: amount}); err != nil {
+			log.Warn("send get block headers msg failed", "err", err)
+		}
+	}()
+
+	timeoutTimer := time.NewTimer(fetchBlockTimeout)
+	defer timeoutTimer.Stop()
+	for {
+		select {
+		case packet := <-fd.headerC:
+			if packet.peerID == p.ID() {
+				return packet.headers, nil
+			}
+		case <-timeoutTimer.C:
+			return nil, errors.New("get block headers timeout")
+		case <-fd.quitCh:
+			return nil, quitErr
+		}
+	}
+}
+
+// checkpointLink is the hashes of the blocks from the local chain to the trusted checkpoint
+type checkpointLink struct {
+	// the number of the first block of the hashes
+	base   uint64
+	hashes []common.Hash
+}
+
+func (l *checkpointLink) contains(block model.AbstractBlock) bool {
+	if l == nil || block.Number() < l.base || block.Number()-l.base >= uint64(len(l.hashes)) {
+		return false
+	}
+	return l.hashes[block.Number()-l.base].IsEqual(block.Hash())
+}
@@ -25,6 +25,8 @@ import (
 	"time"
 
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/tests/factory"
 	"github.com/dipperin/dipperin-core/third-party/p2p"
@@ -47,6 +49,8 @@ func TestNewPbftDownloader_MsgHandlers(t *testing.T) {
 
 	assert.NotNil(t, handles[GetBlocksMsg])
 	assert.NotNil(t, handles[BlocksMsg])
+	assert.NotNil(t, handles[GetBlockHeadersMsg])
+	assert.NotNil(t, handles[BlockHeadersMsg])
 }
 
 func TestNewPbftDownloader_onGetBlocks(t *testing.T) {
@@ -178,6 +182,63 @@ func TestNewPbftDownloader_onBlocks(t *testing.T) {
 
 }
 
+func TestNewPbftDownloader_onGetBlockHeaders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockChain := NewMockChain(ctrl)
+	pbftDownloader := MakeNewPbftDownloader(&NewPbftDownloaderConfig{Chain: mockChain})
+	mockPeer := NewMockPmAbstractPeer(ctrl)
+	mockPeer.EXPECT().NodeName().Return("test").AnyTimes()
+
+	err := pbftDownloader.onGetBlockHeaders(p2p.Msg{
+		Payload: bytes.NewReader([]byte{}),
+	}, mockPeer)
+	assert.Error(t, err)
+
+	fakeBlock1 := factory.CreateBlock2(common.HexToDiff("0x1effffff"), 1)
+	mockChain.EXPECT().GetBlockByNumber(uint64(1)).Return(fakeBlock1).Times(1)
+	mockChain.EXPECT().GetBlockByNumber(uint64(2)).Return(nil).Times(1)
+
+	// only the headers are sent, the commits aren't read
+	mockPeer.EXPECT().SendMsg(uint64(BlockHeadersMsg), gomock.Any()).DoAndReturn(func(msgCode uint64, data interface{}) error {
+		headers := data.([]*model.Header)
+		assert.Len(t, headers, 1)
+		assert.Equal(t, fakeBlock1.Hash(), headers[0].Hash())
+		return nil
+	}).Times(1)
+
+	query, _ := rlp.EncodeToBytes(&getBlockHeaders{OriginHeight: 1, Amount: 2})
+	assert.NoError(t, pbftDownloader.onGetBlockHeaders(p2p.Msg{Payload: bytes.NewReader(query)}, mockPeer))
+}
+
+func TestNewPbftDownloader_onBlockHeaders(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	pbftDownloader := MakeNewPbftDownloader(&NewPbftDownloaderConfig{Chain: NewMockChain(ctrl)})
+	mockPeer := NewMockPmAbstractPeer(ctrl)
+	mockPeer.EXPECT().NodeName().Return("test").AnyTimes()
+	mockPeer.EXPECT().ID().Return("1").AnyTimes()
+
+	err := pbftDownloader.onBlockHeaders(p2p.Msg{
+		Payload: bytes.NewReader([]byte{}),
+	}, mockPeer)
+	assert.Error(t, err)
+
+	header := factory.CreateBlock2(common.HexToDiff("0x1effffff"), 1).Header().(*model.Header)
+	data, _ := rlp.EncodeToBytes([]*model.Header{header})
+	go func() {
+		pack := <-pbftDownloader.headerC
+		assert.Equal(t, "1", pack.peerID)
+		assert.Equal(t, header.Hash(), pack.headers[0].Hash())
+	}()
+	assert.NoError(t, pbftDownloader.onBlockHeaders(p2p.Msg{Payload: bytes.NewReader(data)}, mockPeer))
+
+	close(pbftDownloader.quitCh)
+	assert.Equal(t, quitErr, pbftDownloader.onBlockHeaders(p2p.Msg{Payload: bytes.NewReader(data)}, mockPeer))
+}
+
 func TestNewPbftDownloader_Start(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -331,6 +392,7 @@ func TestNewPbftDownloader_fetchBlocks(t *testing.T) {
 
 	pbftDownloader := MakeNewPbftDownloader(pbftDownloaderConfig)
 
+	mockPM.EXPECT().GetPeers().Return(nil).AnyTimes()
 	mockPeer := NewMockPmAbstractPeer(ctrl)
 
 	mockPeer.EXPECT().NodeName().Return("test").AnyTimes()
@@ -369,6 +431,8 @@ func TestNewPbftDownloader_fetchBlocks(t *testing.T) {
 	pbftDownloader.fetchBlocks(mockPeer)
 
 	mockChain.EXPECT().SaveBlock(gomock.Any(), gomock.Any()).Return(errors.New("test")).Times(1)
+	// the peer returning the invalid blocks is removed
+	mockPM.EXPECT().RemovePeer("1").Times(1)
 
 	mockNpbPack3 := &npbPack{
 		peerID: "1",
@@ -424,6 +488,94 @@ func TestNewPbftDownloader_fetchBlocks(t *testing.T) {
 	time.Sleep(10 * time.Millisecond)
 }
 
+func TestNewPbftDownloader_fetchBlocks_MultiPeers(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	timeout := fetchBlockTimeout
+	fetchBlockTimeout = time.Second
+	defer func() { fetchBlockTimeout = timeout }()
+
+	mockPM := NewMockPeerManager(ctrl)
+	mockChain := NewMockChain(ctrl)
+	pbftDownloader := MakeNewPbftDownloader(&NewPbftDownloaderConfig{
+		Chain:   mockChain,
+		Pm:      mockPM,
+		fetcher: getFetcher(),
+	})
+
+	blocks := fetchTestBlocks(1, 40)
+	serve := func(id string, valid bool) func(uint64, interface{}) error {
+		return func(msgCode uint64, msg interface{}) error {
+			query := msg.(*getBlockHeaders)
+			list := blocks[query.OriginHeight-1 : query.OriginHeight-1+query.Amount]
+			if !valid {
+				list = list[1:]
+			}
+			go func() { pbftDownloader.blockC <- &npbPack{peerID: id, blocks: list} }()
+			return nil
+		}
+	}
+
+	peer1 := fetchTestPeer(ctrl, "1")
+	peer1.EXPECT().NodeName().Return("peer1").AnyTimes()
+	peer1.EXPECT().GetHead().Return(common.Hash{}, uint64(40)).AnyTimes()
+	peer1.EXPECT().SendMsg(uint64(GetBlocksMsg), gomock.Any()).DoAndReturn(serve("1", true)).AnyTimes()
+	peer2 := fetchTestPeer(ctrl, "2")
+	peer2.EXPECT().NodeName().Return("peer2").AnyTimes()
+	peer2.EXPECT().GetHead().Return(common.Hash{}, uint64(40)).AnyTimes()
+	peer2.EXPECT().SendMsg(uint64(GetBlocksMsg), gomock.Any()).DoAndReturn(serve("2", false)).AnyTimes()
+	peer3 := fetchTestPeer(ctrl, "3")
+	peer3.EXPECT().GetHead().Return(common.Hash{}, uint64(1)).AnyTimes()
+
+	mockPM.EXPECT().GetPeers().Return(map[string]PmAbstractPeer{"1": peer1, "2": peer2, "3": peer3}).AnyTimes()
+	// the peer returning the invalid blocks is removed, its chunk is downloaded from the other peer
+	mockPM.EXPECT().RemovePeer("2").Times(1)
+	mockChain.EXPECT().CurrentBlock().Return(blocks[0].Block).AnyTimes()
+
+	var saved []uint64
+	mockChain.EXPECT().SaveBlock(gomock.Any(), gomock.Any()).DoAndReturn(func(block model.AbstractBlock, seenCommits []model.AbstractVerification) error {
+		saved = append(saved, block.Number())
+		return nil
+	}).AnyTimes()
+
+	pbftDownloader.fetchBlocks(peer1)
+	if assert.Len(t, saved, 40) {
+		for i, num := range saved {
+			assert.Equal(t, uint64(i+1), num)
+		}
+	}
+}
+
+func TestNewPbftDownloader_importChunks(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPM := NewMockPeerManager(ctrl)
+	mockChain := NewMockChain(ctrl)
+	pbftDownloader := MakeNewPbftDownloader(&NewPbftDownloaderConfig{Chain: mockChain, Pm: mockPM})
+	mockChain.EXPECT().CurrentBlock().Return(factory.CreateBlock2(common.HexToDiff("0x1effffff"), 1)).AnyTimes()
+
+	s := newFetchScheduler(1, 4)
+	s.addPeer(fetchTestPeer(ctrl, "1"), 4)
+	s.assign(time.Now())
+	assert.NoError(t, s.deliver("1", fetchTestBlocks(1, 4)))
+
+	// the block saved by the fetcher meanwhile isn't the fault of the peer
+	mockChain.EXPECT().SaveBlock(gomock.Any(), gomock.Any()).Return(g_error.ErrAlreadyHaveThisBlock).Times(1)
+	pbftDownloader.importChunks(s)
+	assert.NotNil(t, s.peers["1"])
+	assert.Nil(t, s.front())
+
+	s.assign(time.Now())
+	assert.NoError(t, s.deliver("1", fetchTestBlocks(1, 4)))
+	mockChain.EXPECT().SaveBlock(gomock.Any(), gomock.Any()).Return(errors.New("invalid block")).Times(1)
+	mockPM.EXPECT().RemovePeer("1").Times(1)
+	pbftDownloader.importChunks(s)
+	assert.Nil(t, s.peers["1"])
+	assert.Nil(t, s.front())
+}
+
 func TestNewPbftDownloader_importBlockResults(t *testing.T) {
 	ctrl := gomock.NewController(t)
 	defer ctrl.Finish()
@@ -454,6 +606,177 @@ func TestNewPbftDownloader_importBlockResults(t *testing.T) {
 
 }
 
+type fakeCheckpointChain struct {
+	*MockChain
+	savedBeforeCheckpoint []uint64
+}
+
+func (c *fakeCheckpointChain) SaveBlockBeforeCheckpoint(block model.AbstractBlock, seenCommits []model.AbstractVerification) error {
+	c.savedBeforeCheckpoint = append(c.savedBeforeCheckpoint, block.Number())
+	return nil
+}
+
+func TestNewPbftDownloader_checkPeerCheckpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	timeout := fetchBlockTimeout
+	fetchBlockTimeout = time.Second
+	defer func() { fetchBlockTimeout = timeout }()
+
+	block := factory.CreateBlock2(common.HexToDiff("0x1effffff"), 2)
+	mockPeer := NewMockPmAbstractPeer(ctrl)
+	mockPeer.EXPECT().NodeName().Return("test").AnyTimes()
+	mockPeer.EXPECT().ID().Return("1").AnyTimes()
+	mockPeer.EXPECT().SendMsg(uint64(GetBlocksMsg), gomock.Any()).Return(nil).AnyTimes()
+
+	// no checkpoint
+	pbftDownloader := MakeNewPbftDownloader(&NewPbftDownloaderConfig{Chain: NewMockChain(ctrl)})
+	assert.NoError(t, pbftDownloader.checkPeerCheckpoint(mockPeer))
+
+	// the peer hasn't the checkpoint block
+	pbftDownloader = MakeNewPbftDownloader(&NewPbftDownloaderConfig{
+		Chain:      NewMockChain(ctrl),
+		Checkpoint: &chain_config.Checkpoint{Number: 2, Hash: block.Hash()},
+	})
+	mockPeer.EXPECT().GetHead().Return(common.Hash{}, uint64(1)).Times(1)
+	assert.NoError(t, pbftDownloader.checkPeerCheckpoint(mockPeer))
+	assert.False(t, pbftDownloader.checkpointPeers["1"])
+
+	// the peer has the checkpoint block, it isn't checked again
+	mockPeer.EXPECT().GetHead().Return(common.Hash{}, uint64(3)).Times(1)
+	go func() {
+		pbftDownloader.blockC <- &npbPack{peerID: "2", blocks: []*catchupRlp{{Block: block}}}
+		pbftDownloader.blockC <- &npbPack{peerID: "1", blocks: []*catchupRlp{{Block: block}}}
+	}()
+	assert.NoError(t, pbftDownloader.checkPeerCheckpoint(mockPeer))
+	assert.True(t, pbftDownloader.checkpointPeers["1"])
+	assert.NoError(t, pbftDownloader.checkPeerCheckpoint(mockPeer))
+
+	// the peer conflicts with the checkpoint
+	pbftDownloader = MakeNewPbftDownloader(&NewPbftDownloaderConfig{
+		Chain:      NewMockChain(ctrl),
+		Checkpoint: &chain_config.Checkpoint{Number: 2, Hash: common.Hash{0x12}},
+	})
+	mockPeer.EXPECT().GetHead().Return(common.Hash{}, uint64(3)).Times(2)
+	go func() {
+		pbftDownloader.blockC <- &npbPack{peerID: "1", blocks: []*catchupRlp{{Block: block}}}
+	}()
+	assert.Equal(t, g_error.ErrCheckpointMismatch, pbftDownloader.checkPeerCheckpoint(mockPeer))
+	assert.False(t, pbftDownloader.checkpointPeers["1"])
+
+	// the peer doesn't serve the checkpoint block
+	go func() {
+		pbftDownloader.blockC <- &npbPack{peerID: "1", blocks: []*catchupRlp{}}
+	}()
+	assert.Equal(t, g_error.ErrBlockNotFound, pbftDownloader.checkPeerCheckpoint(mockPeer))
+}
+
+func checkpointTestBlocks(count int) []*catchupRlp {
+	list := []*catchupRlp{{Block: factory.CreateBlockByPH(0, common.Hash{})}}
+	for i := 1; i < count; i++ {
+		list = append(list, &catchupRlp{Block: factory.CreateBlockByPH(uint64(i), list[i-1].Block.Hash())})
+	}
+	return list
+}
+
+func checkpointTestHeaders(list []*catchupRlp) []*model.Header {
+	headers := make([]*model.Header, 0, len(list))
+	for _, b := range list {
+		headers = append(headers, b.Block.Header().(*model.Header))
+	}
+	return headers
+}
+
+func TestNewPbftDownloader_proveCheckpointLink(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	timeout := fetchBlockTimeout
+	fetchBlockTimeout = time.Second
+	defer func() { fetchBlockTimeout = timeout }()
+
+	list := checkpointTestBlocks(4)
+	mockChain := NewMockChain(ctrl)
+	mockChain.EXPECT().CurrentBlock().Return(list[0].Block).AnyTimes()
+	mockPeer := NewMockPmAbstractPeer(ctrl)
+	mockPeer.EXPECT().NodeName().Return("test").AnyTimes()
+	mockPeer.EXPECT().ID().Return("1").AnyTimes()
+	// only the headers are requested to prove the link
+	mockPeer.EXPECT().SendMsg(uint64(GetBlockHeadersMsg), gomock.Any()).Return(nil).AnyTimes()
+
+	newDownloader := func(cp *chain_config.Checkpoint) *NewPbftDownloader {
+		fd := MakeNewPbftDownloader(&NewPbftDownloaderConfig{Chain: &fakeCheckpointChain{MockChain: mockChain}, Checkpoint: cp})
+		fd.checkpointPeers["1"] = true
+		return fd
+	}
+
+	// the blocks are linked from the checkpoint to the current block
+	pbftDownloader := newDownloader(&chain_config.Checkpoint{Number: 3, Hash: list[3].Block.Hash()})
+	go func() {
+		pbftDownloader.headerC <- &headerPack{peerID: "1", headers: checkpointTestHeaders(list[1:])}
+	}()
+	assert.NoError(t, pbftDownloader.proveCheckpointLink(mockPeer))
+	for _, b := range list[1:] {
+		assert.True(t, pbftDownloader.checkpointLink.contains(b.Block))
+	}
+	assert.False(t, pbftDownloader.checkpointLink.contains(list[0].Block))
+	assert.False(t, pbftDownloader.checkpointLink.contains(factory.CreateBlockByPH(2, common.Hash{0x1})))
+	// the proven link isn't downloaded again
+	assert.NoError(t, pbftDownloader.proveCheckpointLink(mockPeer))
+
+	// the forged block isn't linked to the checkpoint
+	forged := append([]*catchupRlp{list[1], {Block: factory.CreateBlockByPH(2, list[1].Block.Hash())}}, list[3])
+	pbftDownloader = newDownloader(&chain_config.Checkpoint{Number: 3, Hash: list[3].Block.Hash()})
+	go func() {
+		pbftDownloader.headerC <- &headerPack{peerID: "1", headers: checkpointTestHeaders(forged)}
+	}()
+	assert.Equal(t, g_error.ErrCheckpointMismatch, pbftDownloader.proveCheckpointLink(mockPeer))
+	assert.Nil(t, pbftDownloader.checkpointLink)
+
+	// the checkpoint isn't linked to the current block
+	other := checkpointTestBlocks(4)
+	pbftDownloader = newDownloader(&chain_config.Checkpoint{Number: 3, Hash: other[3].Block.Hash()})
+	go func() {
+		pbftDownloader.headerC <- &headerPack{peerID: "1", headers: checkpointTestHeaders(other[1:])}
+	}()
+	assert.Equal(t, g_error.ErrCheckpointMismatch, pbftDownloader.proveCheckpointLink(mockPeer))
+
+	// the peer doesn't return all the blocks
+	go func() {
+		pbftDownloader.headerC <- &headerPack{peerID: "1", headers: checkpointTestHeaders(other[1:2])}
+	}()
+	assert.Equal(t, g_error.ErrBlockNotFound, pbftDownloader.proveCheckpointLink(mockPeer))
+}
+
+func TestNewPbftDownloader_importBlockResults_Checkpoint(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	list := checkpointTestBlocks(4)[1:]
+	mockChain := NewMockChain(ctrl)
+	cpChain := &fakeCheckpointChain{MockChain: mockChain}
+	pbftDownloader := MakeNewPbftDownloader(&NewPbftDownloaderConfig{
+		Chain:      cpChain,
+		Checkpoint: &chain_config.Checkpoint{Number: 2, Hash: list[1].Block.Hash()},
+	})
+
+	// the blocks are fully verified before the link to the checkpoint is proven
+	mockChain.EXPECT().SaveBlock(gomock.Any(), gomock.Any()).Return(nil).Times(3)
+	assert.NoError(t, pbftDownloader.importBlockResults(list))
+	assert.Empty(t, cpChain.savedBeforeCheckpoint)
+
+	// only the blocks linked to the checkpoint aren't fully verified
+	pbftDownloader.checkpointLink = &checkpointLink{base: 1, hashes: []common.Hash{list[0].Block.Hash(), list[1].Block.Hash()}}
+	mockChain.EXPECT().SaveBlock(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+	forged := &catchupRlp{Block: factory.CreateBlockByPH(2, common.Hash{0x1})}
+	assert.NoError(t, pbftDownloader.importBlockResults(append(list, forged)))
+	assert.Equal(t, []uint64{1, 2}, cpChain.savedBeforeCheckpoint)
+
+	mockChain.EXPECT().SaveBlock(gomock.Any(), gomock.Any()).Return(g_error.ErrCheckpointMismatch).Times(1)
+	assert.Equal(t, g_error.ErrCheckpointMismatch, pbftDownloader.importBlockResults(list[2:]))
+}
+
 func Test_catchup_DecodeRLP(t *testing.T) {
 	c := &catchup{}
 
@@ -0,0 +1,161 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_communication
+
+import (
+	"sort"
+	"sync"
+	"time"
+)
+
+// the blocks per second is measured in one or two windows, so it drops to 0 soon if the sync is stuck
+var syncRateWindow = 30 * time.Second
+
+type SyncPeer struct {
+	ID     string `json:"id"`
+	Name   string `json:"name"`
+	Height uint64 `json:"height"`
+}
+
+// SyncProgress is the progress of the block sync
+type SyncProgress struct {
+	// whether the downloader is fetching the blocks now
+	Syncing bool `json:"syncing"`
+	// the local block when the sync started
+	StartingBlock   uint64  `json:"starting_block"`
+	CurrentBlock    uint64  `json:"current_block"`
+	HighestBlock    uint64  `json:"highest_block"`
+	BlocksPerSecond float64 `json:"blocks_per_second"`
+	// the estimated seconds to reach the highest block, -1 if no block is imported in the last window
+	ETA int64 `json:"eta"`
+	// the peers the blocks are fetched from
+	Peers []SyncPeer `json:"peers"`
+}
+
+type syncSample struct {
+	block uint64
+	time  time.Time
+}
+
+// syncTracker records the sync rounds of the downloader, the starting block is kept until the local chain
+// reaches the highest block
+type syncTracker struct {
+	lock sync.Mutex
+
+	syncing       bool
+	catchingUp    bool
+	startingBlock uint64
+	highestBlock  uint64
+	peers         []SyncPeer
+
+	// the rate is measured from the previous sample, the samples are moved every window
+	prevSample syncSample
+	curSample  syncSample
+}
+
+func (t *syncTracker) start(current, highest uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	if !t.catchingUp {
+		t.catchingUp = true
+		t.startingBlock = current
+		t.prevSample = syncSample{block: current, time: time.Now()}
+		t.curSample = t.prevSample
+	}
+	t.syncing = true
+	if highest > t.highestBlock {
+		t.highestBlock = highest
+	}
+}
+
+func (t *syncTracker) setPeers(peers []SyncPeer) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	sort.Slice(peers, func(i, j int) bool {
+		return peers[i].ID < peers[j].ID
+	})
+	t.peers = peers
+}
+
+// stop finishes the sync round, the sync is finished if the local chain reaches the highest block
+func (t *syncTracker) stop(current uint64) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	t.syncing = false
+	t.peers = nil
+	if current >= t.highestBlock {
+		t.catchingUp = false
+	}
+}
+
+func (t *syncTracker) progress(current uint64) SyncProgress {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	progress := SyncProgress{
+		Syncing:      t.syncing,
+		CurrentBlock: current,
+		HighestBlock: t.highestBlock,
+	}
+	if !t.catchingUp {
+		return progress
+	}
+
+	now := time.Now()
+	if now.Sub(t.curSample.time) >= syncRateWindow {
+		t.prevSample = t.curSample
+		t.curSample = syncSample{block: current, time: now}
+	}
+	if elapsed := now.Sub(t.prevSample.time).Seconds(); elapsed > 0 && current > t.prevSample.block {
+		progress.BlocksPerSecond = float64(current-t.prevSample.block) / elapsed
+	}
+	progress.StartingBlock = t.startingBlock
+	progress.Peers = append([]SyncPeer{}, t.peers...)
+	return progress
+}
+
+// SyncProgress returns the progress of the block sync, the highest block is the best peer's one if it's higher
+func (fd *NewPbftDownloader) SyncProgress() SyncProgress {
+	current := fd.Chain.CurrentBlock().Number()
+	progress := fd.progress.progress(current)
+	if bestPeer := fd.Pm.BestPeer(); bestPeer != nil {
+		if _, height := bestPeer.GetHead(); height > progress.HighestBlock {
+			progress.HighestBlock = height
+		}
+	}
+
+	switch {
+	case current >= progress.HighestBlock:
+		progress.ETA = 0
+	case progress.BlocksPerSecond > 0:
+		progress.ETA = int64(float64(progress.HighestBlock-current) / progress.BlocksPerSecond)
+	default:
+		progress.ETA = -1
+	}
+	return progress
+}
+
+func (s *fetchScheduler) syncPeers() []SyncPeer {
+	peers := make([]SyncPeer, 0, len(s.peers))
+	for id, p := range s.peers {
+		peers = append(peers, SyncPeer{ID: id, Name: p.NodeName(), Height: s.heights[id]})
+	}
+	return peers
+}
@@ -0,0 +1,102 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_communication
+
+import (
+	"testing"
+	"time"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/tests/factory"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSyncTracker(t *testing.T) {
+	tracker := &syncTracker{}
+	assert.Equal(t, SyncProgress{CurrentBlock: 5}, tracker.progress(5))
+
+	tracker.start(5, 100)
+	tracker.setPeers([]SyncPeer{{ID: "2", Height: 90}, {ID: "1", Height: 100}})
+	progress := tracker.progress(5)
+	assert.True(t, progress.Syncing)
+	assert.Equal(t, uint64(5), progress.StartingBlock)
+	assert.Equal(t, uint64(100), progress.HighestBlock)
+	assert.Equal(t, []SyncPeer{{ID: "1", Height: 100}, {ID: "2", Height: 90}}, progress.Peers)
+
+	// the starting block is kept until the highest block is reached
+	tracker.stop(50)
+	tracker.start(50, 120)
+	progress = tracker.progress(50)
+	assert.Equal(t, uint64(5), progress.StartingBlock)
+	assert.Equal(t, uint64(120), progress.HighestBlock)
+	assert.Empty(t, progress.Peers)
+
+	tracker.stop(120)
+	progress = tracker.progress(120)
+	assert.False(t, progress.Syncing)
+	assert.Equal(t, uint64(0), progress.StartingBlock)
+	tracker.start(120, 130)
+	assert.Equal(t, uint64(120), tracker.progress(120).StartingBlock)
+}
+
+func TestSyncTracker_BlocksPerSecond(t *testing.T) {
+	window := syncRateWindow
+	syncRateWindow = 20 * time.Millisecond
+	defer func() { syncRateWindow = window }()
+
+	tracker := &syncTracker{}
+	tracker.start(0, 1000)
+	time.Sleep(10 * time.Millisecond)
+	assert.True(t, tracker.progress(100).BlocksPerSecond > 0)
+
+	// the rate drops to 0 if no block is imported in a window
+	time.Sleep(25 * time.Millisecond)
+	tracker.progress(100)
+	time.Sleep(25 * time.Millisecond)
+	tracker.progress(100)
+	assert.Equal(t, float64(0), tracker.progress(100).BlocksPerSecond)
+}
+
+func TestNewPbftDownloader_SyncProgress(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+
+	mockPM := NewMockPeerManager(ctrl)
+	mockChain := NewMockChain(ctrl)
+	pbftDownloader := MakeNewPbftDownloader(&NewPbftDownloaderConfig{Chain: mockChain, Pm: mockPM})
+
+	mockChain.EXPECT().CurrentBlock().Return(factory.CreateBlock2(common.HexToDiff("0x1effffff"), 10)).AnyTimes()
+	mockPM.EXPECT().BestPeer().Return(nil).Times(1)
+	progress := pbftDownloader.SyncProgress()
+	assert.Equal(t, uint64(10), progress.CurrentBlock)
+	assert.Equal(t, int64(0), progress.ETA)
+
+	// the sync is stuck
+	mockPeer := NewMockPmAbstractPeer(ctrl)
+	mockPeer.EXPECT().GetHead().Return(common.Hash{}, uint64(100)).AnyTimes()
+	mockPM.EXPECT().BestPeer().Return(mockPeer).AnyTimes()
+	pbftDownloader.progress.start(10, 50)
+	progress = pbftDownloader.SyncProgress()
+	assert.Equal(t, uint64(100), progress.HighestBlock)
+	assert.Equal(t, int64(-1), progress.ETA)
+
+	pbftDownloader.progress.prevSample.block = 0
+	progress = pbftDownloader.SyncProgress()
+	assert.True(t, progress.BlocksPerSecond > 0)
+	assert.True(t, progress.ETA >= 0)
+}
@@ -0,0 +1,108 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_config
+
+import (
+	"errors"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/dipperin/dipperin-core/common"
+)
+
+var ErrInvalidCheckpoint = errors.New("invalid checkpoint, it should be number:hash:verifier_hash")
+
+// Checkpoint is a trusted block, the downloader saves the blocks until it without verifying their votes and seals,
+// and refuses the peers whose block at the height is different
+type Checkpoint struct {
+	Number uint64
+	Hash   common.Hash
+	// the hash of the verifiers of the checkpoint slot, it isn't checked if it's empty
+	VerifierHash common.Hash
+}
+
+// VerifierSetHash is the hash of the verifiers of a slot in the checkpoint
+func VerifierSetHash(verifiers []common.Address) common.Hash {
+	return common.RlpHashKeccak256(verifiers)
+}
+
+// ParseCheckpoint parses the checkpoint in the format number:hash:verifier_hash, the verifier hash can be omitted
+func ParseCheckpoint(s string) (Checkpoint, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) < 2 || len(parts) > 3 {
+		return Checkpoint{}, ErrInvalidCheckpoint
+	}
+	number, err := strconv.ParseUint(parts[0], 10, 64)
+	if err != nil || number == 0 {
+		return Checkpoint{}, ErrInvalidCheckpoint
+	}
+	cp := Checkpoint{Number: number}
+	if cp.Hash, err = parseCheckpointHash(parts[1]); err != nil {
+		return Checkpoint{}, err
+	}
+	if len(parts) == 3 {
+		if cp.VerifierHash, err = parseCheckpointHash(parts[2]); err != nil {
+			return Checkpoint{}, err
+		}
+	}
+	return cp, nil
+}
+
+func parseCheckpointHash(s string) (common.Hash, error) {
+	b := common.FromHex(s)
+	if !strings.HasPrefix(s, "0x") || len(b) != common.HashLength {
+		return common.Hash{}, ErrInvalidCheckpoint
+	}
+	return common.BytesToHash(b), nil
+}
+
+// AddCheckpoint adds the checkpoint to the config, the one at the same height is replaced
+func (c *ChainConfig) AddCheckpoint(cp Checkpoint) {
+	checkpoints := make([]Checkpoint, 0, len(c.Checkpoints)+1)
+	for _, old := range c.Checkpoints {
+		if old.Number != cp.Number {
+			checkpoints = append(checkpoints, old)
+		}
+	}
+	checkpoints = append(checkpoints, cp)
+	sort.Slice(checkpoints, func(i, j int) bool {
+		return checkpoints[i].Number < checkpoints[j].Number
+	})
+	c.Checkpoints = checkpoints
+}
+
+// GetCheckpoint returns the checkpoint at the height, nil if there isn't
+func (c *ChainConfig) GetCheckpoint(number uint64) *Checkpoint {
+	for i := range c.Checkpoints {
+		if c.Checkpoints[i].Number == number {
+			return &c.Checkpoints[i]
+		}
+	}
+	return nil
+}
+
+// LatestCheckpoint returns the highest checkpoint, nil if there isn't any checkpoint
+func (c *ChainConfig) LatestCheckpoint() *Checkpoint {
+	var latest *Checkpoint
+	for i := range c.Checkpoints {
+		if latest == nil || c.Checkpoints[i].Number > latest.Number {
+			latest = &c.Checkpoints[i]
+		}
+	}
+	return latest
+}
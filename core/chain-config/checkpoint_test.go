@@ -0,0 +1,68 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_config
+
+import (
+	"testing"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseCheckpoint(t *testing.T) {
+	hash := common.HexToHash("0x1234")
+	verifierHash := common.HexToHash("0x5678")
+
+	cp, err := ParseCheckpoint("100:" + hash.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, Checkpoint{Number: 100, Hash: hash}, cp)
+
+	cp, err = ParseCheckpoint("100:" + hash.Hex() + ":" + verifierHash.Hex())
+	assert.NoError(t, err)
+	assert.Equal(t, Checkpoint{Number: 100, Hash: hash, VerifierHash: verifierHash}, cp)
+
+	for _, s := range []string{
+		"",
+		"100",
+		"0:" + hash.Hex(),
+		"abc:" + hash.Hex(),
+		"100:0x1234",
+		"100:" + hash.Hex()[2:],
+		"100:" + hash.Hex() + ":0x12",
+		"100:" + hash.Hex() + ":" + verifierHash.Hex() + ":1",
+	} {
+		_, err = ParseCheckpoint(s)
+		assert.Equal(t, ErrInvalidCheckpoint, err, s)
+	}
+}
+
+func TestChainConfig_AddCheckpoint(t *testing.T) {
+	c := &ChainConfig{}
+	assert.Nil(t, c.LatestCheckpoint())
+	assert.Nil(t, c.GetCheckpoint(10))
+
+	c.AddCheckpoint(Checkpoint{Number: 20, Hash: common.Hash{0x20}})
+	c.AddCheckpoint(Checkpoint{Number: 10, Hash: common.Hash{0x10}})
+	c.AddCheckpoint(Checkpoint{Number: 20, Hash: common.Hash{0x21}})
+	assert.Len(t, c.Checkpoints, 2)
+	assert.Equal(t, uint64(10), c.Checkpoints[0].Number)
+
+	assert.Equal(t, common.Hash{0x10}, c.GetCheckpoint(10).Hash)
+	assert.Equal(t, common.Hash{0x21}, c.GetCheckpoint(20).Hash)
+	assert.Nil(t, c.GetCheckpoint(15))
+	assert.Equal(t, uint64(20), c.LatestCheckpoint().Number)
+}
@@ -47,9 +47,9 @@ const (
 	TestServer               = "10.200.0.139"
 	TestVerifierBootNodePort = "10000"
 
-	// 20M
-	MaxBlockSize = 20 * 1024 * 1024
-	MaxTxSize    = 512 * 1024
+	// the block limits used before the configured ones are activated, 20M
+	DefaultMaxBlockSize = 20 * 1024 * 1024
+	DefaultMaxTxSize    = 512 * 1024
 )
 
 const (
@@ -71,6 +71,9 @@ func defaultChainConfig() *ChainConfig {
 		SupportHardwareWallet: false,
 		ChainId:               big.NewInt(1),
 		Version:               uint64(0),
+		// db conf, the cache in MB and the open files of the chain database
+		DatabaseCache:   16,
+		DatabaseHandles: 16,
 		// verify segment size
 		SlotSize: uint64(110),
 		// verifier deposit lock period
@@ -91,11 +94,34 @@ func defaultChainConfig() *ChainConfig {
 		BlockGenerate: uint64(13),
 		//the block number in a difficulty adjust cycle
 		BlockCountOfPeriod: uint64(4096),
+		// the difficulty is adjusted every 4096 blocks until a height is set
+		PerBlockDiffHeight: 0,
+		// the normal block number in the per block difficulty window
+		PerBlockDiffWindow: uint64(60),
 
 		//verifier boot node number
 		VerifierBootNodeNumber: 4,
 
 		BlockTimeRestriction: 15*time.Second,
+		// the median time past rule is disabled until a height is set
+		MedianTimeHeight: 0,
+		// the median time past of the recent 11 normal blocks
+		MedianTimeBlocks: uint64(11),
+		MaxFutureBlockTime: 10*time.Second,
+
+		// weighted proposer rotation is disabled until a height is set
+		WeightedProposerHeight: 0,
+		// the register tx declares no commission until a height is set
+		CommissionHeight: 0,
+		// the unStake tx can't withdraw part of the stake until a height is set
+		PartialUnStakeHeight: 0,
+
+		// block limits
+		MaxBlockSize: DefaultMaxBlockSize,
+		MaxTxSize:    DefaultMaxTxSize,
+		MaxTxCount:   0,
+		// the configured block limits are used from the first block
+		BlockLimitsHeight: 0,
 	}
 
 	switch os.Getenv(BootEnvTagName) {
@@ -147,12 +173,87 @@ type ChainConfig struct {
 	BlockGenerate uint64
 	//the block number in a difficulty adjust cycle
 	BlockCountOfPeriod uint64
+	// the height from which the difficulty is adjusted every block by the weighted moving average of the recent blocks, 0 means disabled
+	PerBlockDiffHeight uint64
+	// the number of the recent normal blocks used by the per block difficulty adjustment
+	PerBlockDiffWindow uint64
 
 	//verifier boot node number
 	VerifierBootNodeNumber int
 
 	//timeStamp restriction
 	BlockTimeRestriction time.Duration
+	// the height from which the timestamp of a normal block must be after the median time past, 0 means disabled
+	MedianTimeHeight uint64
+	// the number of the recent normal blocks to calculate the median time past
+	MedianTimeBlocks uint64
+	// the max drift of a block timestamp into the future after the median time past rule is activated
+	MaxFutureBlockTime time.Duration
+
+	// fork conf
+	// the height from which the bft proposer is selected by stake weighted round robin, 0 means disabled
+	WeightedProposerHeight uint64
+	// the height from which the register tx declares the commission of the verifier in its extra data, 0 means disabled
+	CommissionHeight uint64
+	// the height from which the unStake tx withdraws the amount in its extra data from the stake, 0 means disabled
+	PartialUnStakeHeight uint64
+
+	// block limits conf
+	// the max rlp size of a block, the default one is used if it is 0
+	MaxBlockSize int
+	// the max rlp size of a tx, the default one is used if it is 0
+	MaxTxSize int
+	// the max tx count of a block, 0 means no limit
+	MaxTxCount int
+	// the height from which the above limits are used, the default limits are used by the blocks before it
+	BlockLimitsHeight uint64
+
+	// sync conf
+	// the trusted blocks, the history before the latest one is downloaded without verifying the votes and seals
+	Checkpoints []Checkpoint
+}
+
+// whether the difficulty of the block at the height is adjusted by the per block algorithm
+func (c *ChainConfig) IsPerBlockDiff(height uint64) bool {
+	return c.PerBlockDiffHeight != 0 && height >= c.PerBlockDiffHeight
+}
+
+// whether the timestamp of the block at the height is checked by the median time past rule
+func (c *ChainConfig) IsMedianTime(height uint64) bool {
+	return c.MedianTimeHeight != 0 && height >= c.MedianTimeHeight
+}
+
+// whether the extra data of the register tx at the height is decoded as the commission
+func (c *ChainConfig) IsCommission(height uint64) bool {
+	return c.CommissionHeight != 0 && height >= c.CommissionHeight
+}
+
+// whether the extra data of the unStake tx at the height is decoded as the partial unStake amount
+func (c *ChainConfig) IsPartialUnStake(height uint64) bool {
+	return c.PartialUnStakeHeight != 0 && height >= c.PartialUnStakeHeight
+}
+
+// the size and tx count limits of a block, the tx count has no limit if MaxTxCount is 0
+type BlockLimits struct {
+	MaxBlockSize int
+	MaxTxSize    int
+	MaxTxCount   int
+}
+
+// get the block limits used by the block of the height
+func (c *ChainConfig) GetBlockLimits(height uint64) BlockLimits {
+	limits := BlockLimits{MaxBlockSize: DefaultMaxBlockSize, MaxTxSize: DefaultMaxTxSize}
+	if height < c.BlockLimitsHeight {
+		return limits
+	}
+	if c.MaxBlockSize > 0 {
+		limits.MaxBlockSize = c.MaxBlockSize
+	}
+	if c.MaxTxSize > 0 {
+		limits.MaxTxSize = c.MaxTxSize
+	}
+	limits.MaxTxCount = c.MaxTxCount
+	return limits
 }
 
 func GetChainConfig() *ChainConfig {
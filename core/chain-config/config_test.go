@@ -150,3 +150,37 @@ func resetNodes() {
 func Test_initMercuryBoots(t *testing.T) {
 	initMercuryBoots("")
 }
+
+func TestChainConfig_IsPerBlockDiff(t *testing.T) {
+	chainConfig := defaultChainConfig()
+	assert.False(t, chainConfig.IsPerBlockDiff(1))
+
+	chainConfig.PerBlockDiffHeight = 10
+	assert.False(t, chainConfig.IsPerBlockDiff(9))
+	assert.True(t, chainConfig.IsPerBlockDiff(10))
+	assert.True(t, chainConfig.IsPerBlockDiff(11))
+}
+
+func TestChainConfig_IsMedianTime(t *testing.T) {
+	chainConfig := defaultChainConfig()
+	assert.False(t, chainConfig.IsMedianTime(1))
+
+	chainConfig.MedianTimeHeight = 10
+	assert.False(t, chainConfig.IsMedianTime(9))
+	assert.True(t, chainConfig.IsMedianTime(10))
+	assert.True(t, chainConfig.IsMedianTime(11))
+}
+
+func TestChainConfig_GetBlockLimits(t *testing.T) {
+	chainConfig := defaultChainConfig()
+	assert.Equal(t, BlockLimits{MaxBlockSize: DefaultMaxBlockSize, MaxTxSize: DefaultMaxTxSize}, chainConfig.GetBlockLimits(1))
+	assert.Equal(t, BlockLimits{MaxBlockSize: DefaultMaxBlockSize, MaxTxSize: DefaultMaxTxSize}, (&ChainConfig{}).GetBlockLimits(1))
+
+	chainConfig.MaxBlockSize = 1024 * 1024
+	chainConfig.MaxTxSize = 64 * 1024
+	chainConfig.MaxTxCount = 100
+	chainConfig.BlockLimitsHeight = 10
+	assert.Equal(t, BlockLimits{MaxBlockSize: DefaultMaxBlockSize, MaxTxSize: DefaultMaxTxSize}, chainConfig.GetBlockLimits(9))
+	assert.Equal(t, BlockLimits{MaxBlockSize: 1024 * 1024, MaxTxSize: 64 * 1024, MaxTxCount: 100}, chainConfig.GetBlockLimits(10))
+	assert.Equal(t, BlockLimits{MaxBlockSize: 1024 * 1024, MaxTxSize: 64 * 1024, MaxTxCount: 100}, chainConfig.GetBlockLimits(11))
+}
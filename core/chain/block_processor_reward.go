@@ -42,13 +42,8 @@ func (state *BlockProcessor) RewardByzantiumVerifier(Block model.AbstractBlock,
 	for addressType, addresses := range rewardAddress {
 		rewardValue := rewards[addressType]
 		for _, address := range addresses {
-			if empty := state.IsEmptyAccount(address); empty {
-				if err = state.NewAccountState(address); err != nil {
-					return err
-				}
-			}
-
-			if err = state.AddBalance(address, rewardValue); err != nil {
+			// the delegators of the verifier share the reward
+			if err = state.RewardVerifier(address, rewardValue); err != nil {
 				return err
 			}
 		}
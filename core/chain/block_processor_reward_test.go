@@ -21,7 +21,15 @@ import (
 	"github.com/stretchr/testify/assert"
 	"github.com/dipperin/dipperin-core/core/contract"
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/consts"
 	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/chain/state-processor"
+	"github.com/dipperin/dipperin-core/core/economy-model"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"math/big"
 )
 
 func TestBlockProcessor_RewardByzantiumVerifier_Error(t *testing.T) {
@@ -106,4 +114,69 @@ func TestBlockProcessor_RewardCoinBase_Error(t *testing.T) {
 	block = createBlockWithoutCoinBase()
 	err = processor.RewardCoinBase(block, &earlyTokenContract)
 	assert.Equal(t, g_error.InvalidCoinBaseAddressErr, err)
-}
\ No newline at end of file
+}
+// the economy service reads the governed config in the state like the chain state, the slot size is 10
+type governedEconomyService struct {
+	state *state_processor.AccountStateDB
+}
+
+func (s governedEconomyService) GetVerifiers(slotNum uint64) []common.Address {
+	config, _ := s.state.GetGovernedChainConfig(*chain_config.GetChainConfig(), slotNum)
+	return VerifierAddress[:config.VerifierNumber]
+}
+
+func (s governedEconomyService) GetSlot(block model.AbstractBlock) *uint64 {
+	slot := block.Number() / 10
+	return &slot
+}
+
+func (s governedEconomyService) GetChainConfigAtHeight(height uint64) *chain_config.ChainConfig {
+	config, _ := s.state.GetGovernedChainConfig(*chain_config.GetChainConfig(), height/10)
+	return &config
+}
+
+func (s governedEconomyService) GetVerifierWeights(height uint64, verifiers []common.Address) []uint64 {
+	return nil
+}
+
+func TestBlockProcessor_RewardByzantiumVerifier_GovernedVerifierNumber(t *testing.T) {
+	config := chain_config.GetChainConfig()
+	verifierNumber := config.VerifierNumber
+	config.VerifierNumber = 1
+	defer func() { config.VerifierNumber = verifierNumber }()
+
+	storage := state_processor.NewStateStorageWithCache(ethdb.NewMemDatabase())
+	processor, err := NewBlockProcessor(fakeAccountDBChain{}, common.Hash{}, storage)
+	assert.NoError(t, err)
+	assert.NoError(t, processor.NewAccountState(aliceAddr))
+	assert.NoError(t, processor.SetBalance(aliceAddr, big.NewInt(100*consts.DIP)))
+
+	// the only verifier passes the proposal at once, there are 2 verifiers from slot 1
+	key, _ := crypto.HexToECDSA(testPriv1)
+	tx, err := model.NewGovernanceProposalTransaction(0, state_processor.GovernanceParamVerifierNumber, 2, 1, big.NewInt(10)).SignTx(key, model.NewMercurySigner(big.NewInt(1)))
+	assert.NoError(t, err)
+	assert.NoError(t, processor.ProcessTx(tx, 1))
+	processor.economyModel = economy_model.MakeDipperinEconomyModel(governedEconomyService{state: processor.AccountStateDB}, economy_model.DIPProportion)
+
+	checkReward := func(block model.AbstractBlock, governedNumber int) {
+		commitNumber := governedNumber*2/3 + 1
+		totalWeight := economy_model.MainVerifierRewardWeight + commitNumber*economy_model.CommitVerifierRewardWeight + (governedNumber-commitNumber)*economy_model.NoCommitVerifierRewardWeight
+		totalReward, err := processor.economyModel.GetOneBlockTotalDIPReward(block.Number() - 1)
+		assert.NoError(t, err)
+		verifierReward := big.NewInt(0).Div(big.NewInt(0).Mul(totalReward, big.NewInt(economy_model.VerifierRewardProportion)), big.NewInt(100))
+		expect := big.NewInt(0).Div(big.NewInt(0).Mul(verifierReward, big.NewInt(int64(economy_model.CommitVerifierRewardWeight))), big.NewInt(int64(totalWeight)))
+
+		balance, err := processor.GetBalance(aliceAddr)
+		assert.NoError(t, err)
+		var earlyTokenContract contract.EarlyRewardContract
+		earlyTokenContract.Balances = map[string]*big.Int{earlyTokenContract.Owner.Hex(): big.NewInt(100 * consts.DIP)}
+		assert.NoError(t, processor.RewardByzantiumVerifier(block, &earlyTokenContract))
+		rewarded, err := processor.GetBalance(aliceAddr)
+		assert.NoError(t, err)
+		assert.Equal(t, expect, big.NewInt(0).Sub(rewarded, balance))
+	}
+
+	// the pre block is in slot 0 with 1 verifier, then in slot 1 with the 2 governed verifiers
+	checkReward(createBlock(6), 1)
+	checkReward(createBlock(16), 2)
+}
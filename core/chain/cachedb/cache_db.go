@@ -39,14 +39,32 @@ func NewCacheDB(db ethdb.Database) *CacheDB {
 	}
 }
 
+// NewCacheDBWithAncients reads the seen commits of the old blocks from the ancient store
+func NewCacheDBWithAncients(db ethdb.Database, ancients AncientReader) *CacheDB {
+	return &CacheDB{
+		db:       db,
+		ancients: ancients,
+	}
+}
+
+// AncientReader reads the seen commits moved to the ancient store with the old blocks
+type AncientReader interface {
+	HasAncient(number uint64) bool
+	AncientSeenCommits(number uint64) ([]byte, error)
+}
+
 type CacheDB struct {
-	db ethdb.Database
+	db       ethdb.Database
+	ancients AncientReader
 }
 
 // hash must be empty, if only use height for tag
 func (cache *CacheDB) GetSeenCommits(blockHeight uint64, blockHash common.Hash) (result []model.AbstractVerification, err error) {
 	var data []byte
 	data, err = cache.get(seenCommitsKey(blockHeight, blockHash))
+	if err != nil && blockHash.IsEmpty() && cache.ancients != nil && cache.ancients.HasAncient(blockHeight) {
+		data, err = cache.ancients.AncientSeenCommits(blockHeight)
+	}
 	if err != nil {
 		log.Info("get seen commits failed", "height", blockHeight)
 		return
@@ -65,6 +83,16 @@ func (cache *CacheDB) SaveSeenCommits(blockHeight uint64, blockHash common.Hash,
 	return cache.save(seenCommitsKey(blockHeight, blockHash), commits)
 }
 
+// GetSeenCommitsRLP returns the encoded seen commits saved with the height only
+func (cache *CacheDB) GetSeenCommitsRLP(blockHeight uint64) ([]byte, error) {
+	return cache.get(seenCommitsKey(blockHeight, common.Hash{}))
+}
+
+// DeleteSeenCommits deletes the seen commits saved with the height only, they are moved to the ancient store
+func (cache *CacheDB) DeleteSeenCommits(blockHeight uint64) error {
+	return cache.db.Delete(seenCommitsKey(blockHeight, common.Hash{}))
+}
+
 func (cache *CacheDB) save(key []byte, data interface{}) error {
 	dataB, err := rlp.EncodeToBytes(data)
 	if err != nil {
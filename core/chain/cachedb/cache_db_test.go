@@ -120,3 +120,38 @@ func (data fakeDataBase) Close() {
 func (data fakeDataBase) NewBatch() ethdb.Batch {
 	panic("implement me")
 }
+
+type fakeAncients struct {
+	commits map[uint64][]byte
+}
+
+func (a fakeAncients) HasAncient(number uint64) bool {
+	_, ok := a.commits[number]
+	return ok
+}
+
+func (a fakeAncients) AncientSeenCommits(number uint64) ([]byte, error) {
+	return a.commits[number], nil
+}
+
+func TestCacheDB_GetSeenCommitsFromAncients(t *testing.T) {
+	SetCacheDataDecoder(&BFTCacheDataDecoder{})
+
+	db := ethdb.NewMemDatabase()
+	cacheDB := NewCacheDB(db)
+	assert.NoError(t, cacheDB.SaveSeenCommits(2, common.Hash{}, createCommits()))
+
+	data, err := cacheDB.GetSeenCommitsRLP(2)
+	assert.NoError(t, err)
+	assert.NoError(t, cacheDB.DeleteSeenCommits(2))
+	_, err = cacheDB.GetSeenCommits(2, common.Hash{})
+	assert.Error(t, err)
+
+	cacheDB = NewCacheDBWithAncients(db, fakeAncients{commits: map[uint64][]byte{2: data}})
+	vs, err := cacheDB.GetSeenCommits(2, common.Hash{})
+	assert.NoError(t, err)
+	assert.Len(t, vs, 1)
+
+	_, err = cacheDB.GetSeenCommits(3, common.Hash{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,169 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chaindb
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+const (
+	BackendLevelDB = "leveldb"
+	BackendBadger  = "badger"
+	BackendMemory  = "memory"
+
+	DefaultBackend = BackendLevelDB
+)
+
+var (
+	ErrKeyIterationNotSupported = errors.New("the keys of the database can't be iterated")
+	ErrSameDatabase             = errors.New("the source and the target database are the same")
+)
+
+// BackendConfig is the tuning of the key-value store, the backends ignore the items they don't support
+type BackendConfig struct {
+	// the cache size in MB
+	Cache int
+	// the max open files
+	Handles int
+}
+
+// BackendOpener opens the key-value store in the dir
+type BackendOpener func(dir string, conf BackendConfig) (ethdb.Database, error)
+
+// KeyIterator is implemented by the stores whose keys can be iterated, the other backends
+// registered should implement it to be pruned and converted
+type KeyIterator interface {
+	ForEachKey(fn func(key []byte) error) error
+}
+
+var (
+	backendsLock sync.RWMutex
+	backends     = map[string]BackendOpener{
+		BackendLevelDB: func(dir string, conf BackendConfig) (ethdb.Database, error) {
+			return ethdb.NewLDBDatabase(dir, conf.Cache, conf.Handles)
+		},
+		BackendBadger: func(dir string, conf BackendConfig) (ethdb.Database, error) {
+			return NewBadgerDatabase(dir, conf)
+		},
+		BackendMemory: func(dir string, conf BackendConfig) (ethdb.Database, error) {
+			return ethdb.NewMemDatabase(), nil
+		},
+	}
+)
+
+// RegisterBackend adds a key-value store which can be selected by the name, it panics if the name is registered
+func RegisterBackend(name string, opener BackendOpener) {
+	backendsLock.Lock()
+	defer backendsLock.Unlock()
+
+	if _, ok := backends[name]; ok {
+		panic(fmt.Sprintf("chain database backend %v is registered", name))
+	}
+	backends[name] = opener
+}
+
+// Backends returns the sorted names of the registered backends
+func Backends() []string {
+	backendsLock.RLock()
+	defer backendsLock.RUnlock()
+
+	names := make([]string, 0, len(backends))
+	for name := range backends {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// OpenDatabase opens the key-value store in the dir by the backend, the default backend is used if the name is empty
+func OpenDatabase(backend, dir string, conf BackendConfig) (ethdb.Database, error) {
+	if backend == "" {
+		backend = DefaultBackend
+	}
+
+	backendsLock.RLock()
+	opener, ok := backends[backend]
+	backendsLock.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("unknown chain database backend %v, the backends are %v", backend, Backends())
+	}
+	return opener(dir, conf)
+}
+
+// ForEachKey calls the fn with every key of the database
+func ForEachKey(db ethdb.Database, fn func(key []byte) error) error {
+	switch d := db.(type) {
+	case *ethdb.MemDatabase:
+		for _, key := range d.Keys() {
+			if err := fn(key); err != nil {
+				return err
+			}
+		}
+		return nil
+	case *ethdb.LDBDatabase:
+		it := d.NewIterator()
+		defer it.Release()
+		for it.Next() {
+			if err := fn(common.CopyBytes(it.Key())); err != nil {
+				return err
+			}
+		}
+		return it.Error()
+	case KeyIterator:
+		return d.ForEachKey(fn)
+	default:
+		return ErrKeyIterationNotSupported
+	}
+}
+
+// CopyDatabase writes all the data of the src to the dst, it's used to convert the chain data from one
+// backend to another. It returns the count of the copied keys.
+func CopyDatabase(src, dst ethdb.Database) (int, error) {
+	if src == dst {
+		return 0, ErrSameDatabase
+	}
+
+	copied := 0
+	batch := dst.NewBatch()
+	err := ForEachKey(src, func(key []byte) error {
+		value, err := src.Get(key)
+		if err != nil {
+			return err
+		}
+		if err := batch.Put(key, value); err != nil {
+			return err
+		}
+		copied++
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		return nil
+	})
+	if err != nil {
+		return copied, err
+	}
+	return copied, batch.Write()
+}
@@ -0,0 +1,167 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chaindb
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeIterableDataBase struct {
+	*ethdb.MemDatabase
+}
+
+func (db fakeIterableDataBase) ForEachKey(fn func(key []byte) error) error {
+	for _, key := range db.Keys() {
+		if err := fn(key); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func TestRegisterBackend(t *testing.T) {
+	assert.Equal(t, []string{BackendBadger, BackendLevelDB, BackendMemory}, Backends())
+	assert.Panics(t, func() {
+		RegisterBackend(BackendMemory, nil)
+	})
+
+	RegisterBackend("fake", func(dir string, conf BackendConfig) (ethdb.Database, error) {
+		return fakeIterableDataBase{ethdb.NewMemDatabase()}, nil
+	})
+	defer func() {
+		backendsLock.Lock()
+		delete(backends, "fake")
+		backendsLock.Unlock()
+	}()
+	assert.Equal(t, []string{BackendBadger, "fake", BackendLevelDB, BackendMemory}, Backends())
+
+	db, err := OpenDatabase("fake", "", BackendConfig{})
+	assert.NoError(t, err)
+	assert.IsType(t, fakeIterableDataBase{}, db)
+}
+
+func TestOpenDatabase(t *testing.T) {
+	db, err := OpenDatabase(BackendMemory, "", BackendConfig{})
+	assert.NoError(t, err)
+	assert.IsType(t, &ethdb.MemDatabase{}, db)
+
+	dir, err := ioutil.TempDir("", "backend")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	db, err = OpenDatabase("", dir, BackendConfig{Cache: 16, Handles: 16})
+	assert.NoError(t, err)
+	assert.IsType(t, &ethdb.LDBDatabase{}, db)
+	db.Close()
+
+	_, err = OpenDatabase("unknown", dir, BackendConfig{})
+	assert.Error(t, err)
+}
+
+func TestCopyDatabase(t *testing.T) {
+	src := newDb()
+	assert.NoError(t, src.Put([]byte("a"), []byte("1")))
+	assert.NoError(t, src.Put([]byte("b"), []byte("2")))
+
+	dir, err := ioutil.TempDir("", "backend")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+	dst, err := OpenDatabase(BackendLevelDB, dir, BackendConfig{})
+	assert.NoError(t, err)
+	defer dst.Close()
+
+	copied, err := CopyDatabase(src, dst)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, copied)
+	value, err := dst.Get([]byte("b"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("2"), value)
+
+	// copy back from the leveldb
+	back := fakeIterableDataBase{ethdb.NewMemDatabase()}
+	copied, err = CopyDatabase(dst, back)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, copied)
+	assert.Equal(t, 2, back.Len())
+
+	_, err = CopyDatabase(src, src)
+	assert.Equal(t, ErrSameDatabase, err)
+
+	_, err = CopyDatabase(fakeDataBase{}, src)
+	assert.Equal(t, ErrKeyIterationNotSupported, err)
+}
+
+func TestBadgerDatabase(t *testing.T) {
+	dir, err := ioutil.TempDir("", "badger")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	db, err := OpenDatabase(BackendBadger, dir, BackendConfig{Cache: 16})
+	assert.NoError(t, err)
+	assert.IsType(t, &BadgerDatabase{}, db)
+
+	assert.NoError(t, db.Put([]byte("a"), []byte("1")))
+	value, err := db.Get([]byte("a"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("1"), value)
+	has, err := db.Has([]byte("a"))
+	assert.NoError(t, err)
+	assert.True(t, has)
+
+	assert.NoError(t, db.Delete([]byte("a")))
+	has, err = db.Has([]byte("a"))
+	assert.NoError(t, err)
+	assert.False(t, has)
+	_, err = db.Get([]byte("a"))
+	assert.Error(t, err)
+
+	batch := db.NewBatch()
+	assert.NoError(t, batch.Put([]byte("b"), []byte("2")))
+	assert.NoError(t, batch.Put([]byte("c"), []byte("3")))
+	assert.NoError(t, batch.Delete([]byte("c")))
+	assert.Equal(t, 3, batch.ValueSize())
+	has, err = db.Has([]byte("b"))
+	assert.NoError(t, err)
+	assert.False(t, has)
+	assert.NoError(t, batch.Write())
+	batch.Reset()
+	assert.Equal(t, 0, batch.ValueSize())
+
+	var keys []string
+	assert.NoError(t, ForEachKey(db, func(key []byte) error {
+		keys = append(keys, string(key))
+		return nil
+	}))
+	assert.Equal(t, []string{"b"}, keys)
+	db.Close()
+
+	// the data is kept after reopening and can be converted to the leveldb
+	db, err = OpenDatabase(BackendBadger, dir, BackendConfig{})
+	assert.NoError(t, err)
+	defer db.Close()
+	dst := newDb()
+	copied, err := CopyDatabase(db, dst)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, copied)
+	value, err = dst.Get([]byte("b"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("2"), value)
+}
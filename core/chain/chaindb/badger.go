@@ -0,0 +1,193 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chaindb
+
+import (
+	"fmt"
+
+	"github.com/dgraph-io/badger"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// the ratio of the stale data in a value log file to rewrite it when the database is closed
+const badgerGCDiscardRatio = 0.5
+
+// BadgerDatabase is the chain data stored in the badger LSM tree
+type BadgerDatabase struct {
+	dir string
+	db  *badger.DB
+}
+
+// NewBadgerDatabase opens the badger store in the dir. Badger has no block cache,
+// the cache is spent on the memtables, and the handles are ignored.
+func NewBadgerDatabase(dir string, conf BackendConfig) (*BadgerDatabase, error) {
+	opts := badger.DefaultOptions(dir).
+		// keep the durability of the leveldb backend, the writes aren't synced
+		WithSyncWrites(false).
+		WithLogger(badgerLogger{})
+	if conf.Cache > 0 {
+		tableSize := int64(conf.Cache) * 1024 * 1024 / int64(opts.NumMemtables)
+		if tableSize < 1024*1024 {
+			tableSize = 1024 * 1024
+		}
+		opts = opts.WithMaxTableSize(tableSize)
+	}
+
+	db, err := badger.Open(opts)
+	if err != nil {
+		return nil, err
+	}
+	log.Info("open badger database", "dir", dir, "memtable", opts.MaxTableSize)
+	return &BadgerDatabase{dir: dir, db: db}, nil
+}
+
+func (db *BadgerDatabase) Put(key []byte, value []byte) error {
+	return db.db.Update(func(txn *badger.Txn) error {
+		return txn.Set(common.CopyBytes(key), common.CopyBytes(value))
+	})
+}
+
+func (db *BadgerDatabase) Delete(key []byte) error {
+	return db.db.Update(func(txn *badger.Txn) error {
+		return txn.Delete(common.CopyBytes(key))
+	})
+}
+
+func (db *BadgerDatabase) Get(key []byte) (value []byte, err error) {
+	err = db.db.View(func(txn *badger.Txn) error {
+		item, err := txn.Get(key)
+		if err != nil {
+			return err
+		}
+		value, err = item.ValueCopy(nil)
+		return err
+	})
+	return value, err
+}
+
+func (db *BadgerDatabase) Has(key []byte) (bool, error) {
+	err := db.db.View(func(txn *badger.Txn) error {
+		_, err := txn.Get(key)
+		return err
+	})
+	if err == badger.ErrKeyNotFound {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+// Close reclaims the space of the deleted data in the value log before closing the store
+func (db *BadgerDatabase) Close() {
+	for db.db.RunValueLogGC(badgerGCDiscardRatio) == nil {
+	}
+	if err := db.db.Close(); err != nil {
+		log.Error("close badger database failed", "dir", db.dir, "err", err)
+	}
+}
+
+func (db *BadgerDatabase) NewBatch() ethdb.Batch {
+	return &badgerBatch{db: db.db}
+}
+
+// ForEachKey iterates the keys only, the values in the value log aren't read
+func (db *BadgerDatabase) ForEachKey(fn func(key []byte) error) error {
+	return db.db.View(func(txn *badger.Txn) error {
+		opts := badger.DefaultIteratorOptions
+		opts.PrefetchValues = false
+		it := txn.NewIterator(opts)
+		defer it.Close()
+		for it.Rewind(); it.Valid(); it.Next() {
+			if err := fn(it.Item().KeyCopy(nil)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+type badgerWrite struct {
+	key   []byte
+	value []byte
+	del   bool
+}
+
+// badgerBatch buffers the writes in memory, the badger write batch splits them into
+// transactions under the size limit of badger when they are written
+type badgerBatch struct {
+	db     *badger.DB
+	writes []badgerWrite
+	size   int
+}
+
+func (b *badgerBatch) Put(key, value []byte) error {
+	b.writes = append(b.writes, badgerWrite{key: common.CopyBytes(key), value: common.CopyBytes(value)})
+	b.size += len(value)
+	return nil
+}
+
+func (b *badgerBatch) Delete(key []byte) error {
+	b.writes = append(b.writes, badgerWrite{key: common.CopyBytes(key), del: true})
+	b.size += 1
+	return nil
+}
+
+func (b *badgerBatch) ValueSize() int {
+	return b.size
+}
+
+func (b *badgerBatch) Write() error {
+	wb := b.db.NewWriteBatch()
+	for _, w := range b.writes {
+		var err error
+		if w.del {
+			err = wb.Delete(w.key)
+		} else {
+			err = wb.Set(w.key, w.value)
+		}
+		if err != nil {
+			wb.Cancel()
+			return err
+		}
+	}
+	return wb.Flush()
+}
+
+func (b *badgerBatch) Reset() {
+	b.writes = b.writes[:0]
+	b.size = 0
+}
+
+// badgerLogger writes the logs of badger to the node log
+type badgerLogger struct{}
+
+func (badgerLogger) Errorf(format string, args ...interface{}) {
+	log.Error("badger: " + fmt.Sprintf(format, args...))
+}
+
+func (badgerLogger) Warningf(format string, args ...interface{}) {
+	log.Warn("badger: " + fmt.Sprintf(format, args...))
+}
+
+func (badgerLogger) Infof(format string, args ...interface{}) {
+	log.Debug("badger: " + fmt.Sprintf(format, args...))
+}
+
+func (badgerLogger) Debugf(format string, args ...interface{}) {
+	log.Debug("badger: " + fmt.Sprintf(format, args...))
+}
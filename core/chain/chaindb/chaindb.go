@@ -28,6 +28,8 @@ import (
 type ChainDB struct {
 	db      ethdb.Database
 	decoder model.BlockDecoder
+	// the old canonical blocks are moved to it, nil if all the blocks are in db
+	freezer *Freezer
 }
 
 func NewChainDB(db ethdb.Database, decoder model.BlockDecoder) *ChainDB {
@@ -37,6 +39,24 @@ func NewChainDB(db ethdb.Database, decoder model.BlockDecoder) *ChainDB {
 	}
 }
 
+// NewChainDBWithFreezer reads the blocks from the db and then the freezer
+func NewChainDBWithFreezer(db ethdb.Database, decoder model.BlockDecoder, freezer *Freezer) *ChainDB {
+	return &ChainDB{
+		db:      db,
+		decoder: decoder,
+		freezer: freezer,
+	}
+}
+
+func (chainDB *ChainDB) Freezer() *Freezer {
+	return chainDB.freezer
+}
+
+// the frozen block is read by the number, it's the same block if the hash matches
+func (chainDB *ChainDB) isAncient(hash common.Hash, number uint64) bool {
+	return chainDB.freezer != nil && chainDB.freezer.HasAncient(number) && chainDB.freezer.AncientHash(number) == hash
+}
+
 func (chainDB *ChainDB) DB() ethdb.Database {
 	return chainDB.db
 }
@@ -55,6 +75,9 @@ func (chainDB *ChainDB) InsertBlock(block model.AbstractBlock) error {
 func (chainDB *ChainDB) GetBlockHashByNumber(number uint64) common.Hash {
 	data, _ := chainDB.db.Get(headerHashKey(number))
 	if len(data) == 0 {
+		if chainDB.freezer != nil {
+			return chainDB.freezer.AncientHash(number)
+		}
 		return common.Hash{}
 	}
 	return common.BytesToHash(data)
@@ -123,6 +146,9 @@ func (chainDB *ChainDB) SaveHeadBlockHash(hash common.Hash) {
 
 func (chainDB *ChainDB) GetHeaderRLP(hash common.Hash, number uint64) rlp.RawValue {
 	data, _ := chainDB.db.Get(headerKey(number, hash))
+	if len(data) == 0 && chainDB.isAncient(hash, number) {
+		return chainDB.freezer.AncientHeaderRLP(number)
+	}
 	return data
 }
 
@@ -134,7 +160,7 @@ func (chainDB *ChainDB) SaveHeaderRLP(hash common.Hash, number uint64, rlp rlp.R
 
 func (chainDB *ChainDB) HasHeader(hash common.Hash, number uint64) bool {
 	if has, err := chainDB.db.Has(headerKey(number, hash)); !has || err != nil {
-		return false
+		return chainDB.isAncient(hash, number)
 	}
 	return true
 }
@@ -180,6 +206,9 @@ func (chainDB *ChainDB) DeleteHeader(hash common.Hash, number uint64) {
 
 func (chainDB *ChainDB) GetBodyRLP(hash common.Hash, number uint64) rlp.RawValue {
 	data, _ := chainDB.db.Get(blockBodyKey(number, hash))
+	if len(data) == 0 && chainDB.isAncient(hash, number) {
+		return chainDB.freezer.AncientBodyRLP(number)
+	}
 	return data
 }
 
@@ -191,7 +220,7 @@ func (chainDB *ChainDB) SaveBodyRLP(hash common.Hash, number uint64, rlp rlp.Raw
 
 func (chainDB *ChainDB) HasBody(hash common.Hash, number uint64) bool {
 	if has, err := chainDB.db.Has(blockBodyKey(number, hash)); !has || err != nil {
-		return false
+		return chainDB.isAncient(hash, number)
 	}
 	return true
 }
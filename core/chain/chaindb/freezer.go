@@ -0,0 +1,176 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chaindb
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	freezerHashTable    = "hashes"
+	freezerHeaderTable  = "headers"
+	freezerBodyTable    = "bodies"
+	freezerCommitsTable = "commits"
+)
+
+var freezerTables = []string{freezerHashTable, freezerHeaderTable, freezerBodyTable, freezerCommitsTable}
+
+var (
+	ErrAncientNotFound = errors.New("the block isn't in the freezer")
+)
+
+// Freezer is the ancient store of the canonical blocks which won't be changed any more, it saves
+// the hashes, headers, bodies and seen commits of the blocks in append-only flat files, so they
+// are kept out of the key-value store. The blocks are numbered from the genesis and are appended
+// one by one.
+type Freezer struct {
+	// the count of the blocks in all the tables
+	frozen uint64
+
+	lock   sync.Mutex
+	tables map[string]*freezerTable
+}
+
+func NewFreezer(dir string) (*Freezer, error) {
+	freezer := &Freezer{tables: map[string]*freezerTable{}}
+	for _, name := range freezerTables {
+		table, err := newFreezerTable(dir, name)
+		if err != nil {
+			freezer.Close()
+			return nil, err
+		}
+		freezer.tables[name] = table
+	}
+
+	// the tables may have different items if the node is stopped when a block is being appended
+	frozen := freezer.tables[freezerHashTable].Items()
+	for _, table := range freezer.tables {
+		if items := table.Items(); items < frozen {
+			frozen = items
+		}
+	}
+	for _, table := range freezer.tables {
+		if err := table.Truncate(frozen); err != nil {
+			freezer.Close()
+			return nil, err
+		}
+	}
+	freezer.frozen = frozen
+
+	log.Info("open chain freezer", "dir", dir, "blocks", frozen)
+	return freezer, nil
+}
+
+// Ancients returns the count of the blocks in the freezer, they are the blocks from the genesis to Ancients()-1
+func (f *Freezer) Ancients() uint64 {
+	return atomic.LoadUint64(&f.frozen)
+}
+
+// HasAncient reports whether the block of the number is in the freezer
+func (f *Freezer) HasAncient(number uint64) bool {
+	return number < f.Ancients()
+}
+
+func (f *Freezer) ancient(table string, number uint64) ([]byte, error) {
+	if !f.HasAncient(number) {
+		return nil, ErrAncientNotFound
+	}
+	return f.tables[table].Retrieve(number)
+}
+
+func (f *Freezer) AncientHash(number uint64) common.Hash {
+	data, err := f.ancient(freezerHashTable, number)
+	if err != nil {
+		return common.Hash{}
+	}
+	return common.BytesToHash(data)
+}
+
+func (f *Freezer) AncientHeaderRLP(number uint64) []byte {
+	data, _ := f.ancient(freezerHeaderTable, number)
+	return data
+}
+
+func (f *Freezer) AncientBodyRLP(number uint64) []byte {
+	data, _ := f.ancient(freezerBodyTable, number)
+	return data
+}
+
+// AncientSeenCommits returns the encoded seen commits of the block, it's empty if the block has no seen commits
+func (f *Freezer) AncientSeenCommits(number uint64) ([]byte, error) {
+	return f.ancient(freezerCommitsTable, number)
+}
+
+// AppendAncient saves the block as the next block of the freezer, it's read after the freezer is synced
+func (f *Freezer) AppendAncient(number uint64, hash common.Hash, header, body, commits []byte) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	if number != f.tables[freezerHashTable].Items() {
+		return errOutOrderInsertion
+	}
+	blobs := map[string][]byte{
+		freezerHashTable:    hash.Bytes(),
+		freezerHeaderTable:  header,
+		freezerBodyTable:    body,
+		freezerCommitsTable: commits,
+	}
+	for _, name := range freezerTables {
+		if err := f.tables[name].Append(number, blobs[name]); err != nil {
+			// drop the block from the tables which have appended it
+			for _, table := range f.tables {
+				table.Truncate(number)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// Sync writes the appended blocks to the disk and makes them readable
+func (f *Freezer) Sync() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	for _, table := range f.tables {
+		if err := table.Sync(); err != nil {
+			return err
+		}
+	}
+	atomic.StoreUint64(&f.frozen, f.tables[freezerHashTable].Items())
+	return nil
+}
+
+func (f *Freezer) Close() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	var errs []error
+	for _, table := range f.tables {
+		if err := table.Close(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		return errs[0]
+	}
+	return nil
+}
@@ -0,0 +1,181 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chaindb
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"sync"
+	"time"
+)
+
+const (
+	// the interval of checking whether there are blocks to be frozen
+	freezerRecheckInterval = 10 * time.Second
+	// the max blocks moved to the freezer in a round
+	freezerBatchLimit = 30000
+)
+
+var (
+	errCanonicalBlockMissing = errors.New("the canonical block to be frozen is missing")
+)
+
+// SeenCommitsStore saves the seen commits of the blocks by the height, they are moved to the freezer with the blocks
+type SeenCommitsStore interface {
+	GetSeenCommitsRLP(height uint64) ([]byte, error)
+	DeleteSeenCommits(height uint64) error
+}
+
+// FreezerMigrator moves the canonical blocks which are threshold blocks older than the current block from
+// the key-value store to the freezer in the background. The hash to number mappings and the tx lookups
+// are kept in the key-value store.
+type FreezerMigrator struct {
+	chainDB   Database
+	freezer   *Freezer
+	commits   SeenCommitsStore
+	threshold uint64
+
+	quit chan struct{}
+	wg   sync.WaitGroup
+}
+
+func NewFreezerMigrator(chainDB Database, freezer *Freezer, commits SeenCommitsStore, threshold uint64) *FreezerMigrator {
+	return &FreezerMigrator{
+		chainDB:   chainDB,
+		freezer:   freezer,
+		commits:   commits,
+		threshold: threshold,
+		quit:      make(chan struct{}),
+	}
+}
+
+// Start does nothing if the chain has no freezer or the threshold is 0
+func (m *FreezerMigrator) Start() error {
+	if m.freezer == nil || m.threshold == 0 {
+		return nil
+	}
+	m.wg.Add(1)
+	go m.loop()
+	return nil
+}
+
+func (m *FreezerMigrator) Stop() {
+	close(m.quit)
+	m.wg.Wait()
+}
+
+func (m *FreezerMigrator) loop() {
+	defer m.wg.Done()
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		select {
+		case <-m.quit:
+			return
+		case <-timer.C:
+			moved, err := m.migrate()
+			if err != nil {
+				log.Warn("move blocks to the freezer failed", "err", err)
+			}
+			// continue at once if there are more blocks to be frozen
+			if moved == freezerBatchLimit {
+				timer.Reset(0)
+			} else {
+				timer.Reset(freezerRecheckInterval)
+			}
+		}
+	}
+}
+
+func (m *FreezerMigrator) stopped() bool {
+	select {
+	case <-m.quit:
+		return true
+	default:
+		return false
+	}
+}
+
+// migrate appends the blocks to the freezer and deletes them from the key-value store after the
+// freezer is synced, it returns the count of the moved blocks
+func (m *FreezerMigrator) migrate() (int, error) {
+	freezer := m.freezer
+	head := m.chainDB.GetHeaderNumber(m.chainDB.GetHeadBlockHash())
+	if head == nil || *head < m.threshold {
+		return 0, nil
+	}
+
+	first := freezer.Ancients()
+	last := first
+	var err error
+	for number := first; number+m.threshold <= *head && number < first+freezerBatchLimit; number++ {
+		hash := m.chainDB.GetBlockHashByNumber(number)
+		header := m.chainDB.GetHeaderRLP(hash, number)
+		body := m.chainDB.GetBodyRLP(hash, number)
+		if len(header) == 0 || len(body) == 0 {
+			err = errCanonicalBlockMissing
+			break
+		}
+		// the first blocks have no seen commits
+		commits, _ := m.commits.GetSeenCommitsRLP(number)
+		if err = freezer.AppendAncient(number, hash, header, body, commits); err != nil {
+			break
+		}
+		last = number + 1
+
+		if m.stopped() {
+			break
+		}
+	}
+	if last == first {
+		return 0, err
+	}
+	if syncErr := freezer.Sync(); syncErr != nil {
+		return 0, syncErr
+	}
+
+	// the genesis is kept in the key-value store
+	batch := m.chainDB.DB().NewBatch()
+	for number := first; number < last; number++ {
+		if number == 0 {
+			continue
+		}
+		hash := freezer.AncientHash(number)
+		for _, key := range [][]byte{headerKey(number, hash), blockBodyKey(number, hash), headerHashKey(number)} {
+			if delErr := batch.Delete(key); delErr != nil {
+				return 0, delErr
+			}
+		}
+		if delErr := m.commits.DeleteSeenCommits(number); delErr != nil {
+			return 0, delErr
+		}
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if writeErr := batch.Write(); writeErr != nil {
+				return 0, writeErr
+			}
+			batch.Reset()
+		}
+	}
+	if writeErr := batch.Write(); writeErr != nil {
+		return 0, writeErr
+	}
+
+	log.Info("move blocks to the freezer", "from", first, "to", last-1)
+	return int(last - first), err
+}
@@ -0,0 +1,120 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chaindb
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFreezerTable_AppendRetrieve(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer_table")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	table, err := newFreezerTable(dir, "test")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(0), table.Items())
+
+	assert.NoError(t, table.Append(0, []byte{1}))
+	assert.NoError(t, table.Append(1, []byte{}))
+	assert.NoError(t, table.Append(2, []byte{2, 3}))
+	assert.Equal(t, errOutOrderInsertion, table.Append(4, []byte{4}))
+	assert.Equal(t, uint64(3), table.Items())
+
+	blob, err := table.Retrieve(0)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1}, blob)
+	blob, err = table.Retrieve(1)
+	assert.NoError(t, err)
+	assert.Len(t, blob, 0)
+	blob, err = table.Retrieve(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{2, 3}, blob)
+	_, err = table.Retrieve(3)
+	assert.Equal(t, errOutOfBounds, err)
+
+	assert.NoError(t, table.Sync())
+	assert.NoError(t, table.Close())
+	_, err = table.Retrieve(0)
+	assert.Equal(t, errClosed, err)
+	assert.Equal(t, errClosed, table.Append(3, []byte{}))
+}
+
+func TestFreezerTable_Repair(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer_table")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	table, err := newFreezerTable(dir, "test")
+	assert.NoError(t, err)
+	assert.NoError(t, table.Append(0, []byte{1, 2}))
+	assert.NoError(t, table.Append(1, []byte{3, 4}))
+	assert.NoError(t, table.Close())
+
+	// a broken index entry and a half written item
+	idx, err := os.OpenFile(filepath.Join(dir, "test.idx"), os.O_WRONLY|os.O_APPEND, 0644)
+	assert.NoError(t, err)
+	_, err = idx.Write([]byte{0, 0, 0})
+	assert.NoError(t, err)
+	assert.NoError(t, idx.Close())
+	assert.NoError(t, os.Truncate(filepath.Join(dir, "test.dat"), 3))
+
+	table, err = newFreezerTable(dir, "test")
+	assert.NoError(t, err)
+	defer table.Close()
+	assert.Equal(t, uint64(1), table.Items())
+	blob, err := table.Retrieve(0)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1, 2}, blob)
+
+	assert.NoError(t, table.Append(1, []byte{5}))
+	blob, err = table.Retrieve(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{5}, blob)
+}
+
+func TestFreezerTable_Truncate(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer_table")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	table, err := newFreezerTable(dir, "test")
+	assert.NoError(t, err)
+	defer table.Close()
+	for i := uint64(0); i < 5; i++ {
+		assert.NoError(t, table.Append(i, []byte{byte(i)}))
+	}
+
+	assert.NoError(t, table.Truncate(2))
+	assert.Equal(t, uint64(2), table.Items())
+	_, err = table.Retrieve(2)
+	assert.Equal(t, errOutOfBounds, err)
+
+	// truncating to more items does nothing
+	assert.NoError(t, table.Truncate(4))
+	assert.Equal(t, uint64(2), table.Items())
+
+	assert.NoError(t, table.Append(2, []byte{9}))
+	blob, err := table.Retrieve(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{9}, blob)
+}
@@ -0,0 +1,185 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chaindb
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestFreezer(t *testing.T) (*Freezer, func()) {
+	dir, err := ioutil.TempDir("", "freezer")
+	assert.NoError(t, err)
+	freezer, err := NewFreezer(dir)
+	assert.NoError(t, err)
+	return freezer, func() {
+		freezer.Close()
+		os.RemoveAll(dir)
+	}
+}
+
+func TestFreezer_AppendAncient(t *testing.T) {
+	dir, err := ioutil.TempDir("", "freezer")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	freezer, err := NewFreezer(dir)
+	assert.NoError(t, err)
+	hash := common.HexToHash("0x1234")
+	assert.NoError(t, freezer.AppendAncient(0, hash, []byte{1}, []byte{2}, nil))
+	assert.Equal(t, errOutOrderInsertion, freezer.AppendAncient(2, hash, []byte{1}, []byte{2}, nil))
+
+	// the appended block is read after sync
+	assert.False(t, freezer.HasAncient(0))
+	assert.Nil(t, freezer.AncientHeaderRLP(0))
+	assert.NoError(t, freezer.Sync())
+	assert.True(t, freezer.HasAncient(0))
+	assert.Equal(t, uint64(1), freezer.Ancients())
+	assert.Equal(t, hash, freezer.AncientHash(0))
+	assert.Equal(t, []byte{1}, freezer.AncientHeaderRLP(0))
+	assert.Equal(t, []byte{2}, freezer.AncientBodyRLP(0))
+	commits, err := freezer.AncientSeenCommits(0)
+	assert.NoError(t, err)
+	assert.Len(t, commits, 0)
+	_, err = freezer.AncientSeenCommits(1)
+	assert.Equal(t, ErrAncientNotFound, err)
+	assert.Equal(t, common.Hash{}, freezer.AncientHash(1))
+
+	// the block only appended to some tables is dropped when reopened
+	assert.NoError(t, freezer.tables[freezerHashTable].Append(1, hash.Bytes()))
+	assert.NoError(t, freezer.Close())
+	freezer, err = NewFreezer(dir)
+	assert.NoError(t, err)
+	defer freezer.Close()
+	assert.Equal(t, uint64(1), freezer.Ancients())
+	assert.Equal(t, uint64(1), freezer.tables[freezerHashTable].Items())
+	assert.Equal(t, []byte{1}, freezer.AncientHeaderRLP(0))
+}
+
+type fakeSeenCommitsStore struct {
+	commits map[uint64][]byte
+}
+
+func (s *fakeSeenCommitsStore) GetSeenCommitsRLP(height uint64) ([]byte, error) {
+	data, ok := s.commits[height]
+	if !ok {
+		return nil, errors.New("seen commits not found")
+	}
+	return data, nil
+}
+
+func (s *fakeSeenCommitsStore) DeleteSeenCommits(height uint64) error {
+	delete(s.commits, height)
+	return nil
+}
+
+func TestFreezerMigrator_migrate(t *testing.T) {
+	freezer, clean := newTestFreezer(t)
+	defer clean()
+
+	db := NewChainDBWithFreezer(newDb(), newDecoder(), freezer)
+	store := &fakeSeenCommitsStore{commits: map[uint64][]byte{}}
+	var hashes []common.Hash
+	for i := uint64(0); i < 6; i++ {
+		block := createBlock(i)
+		assert.NoError(t, db.InsertBlock(block))
+		db.SaveHeaderNumber(block.Hash(), i)
+		hashes = append(hashes, block.Hash())
+		if i > 1 {
+			store.commits[i] = []byte{byte(i)}
+		}
+	}
+
+	migrator := NewFreezerMigrator(db, freezer, store, 3)
+	moved, err := migrator.migrate()
+	assert.NoError(t, err)
+	assert.Equal(t, 3, moved)
+	assert.Equal(t, uint64(3), freezer.Ancients())
+
+	// the frozen blocks are read from the freezer
+	for i, hash := range hashes {
+		number := uint64(i)
+		assert.Equal(t, hash, db.GetBlockHashByNumber(number))
+		assert.True(t, db.HasHeader(hash, number))
+		assert.True(t, db.HasBody(hash, number))
+		block := db.GetBlock(hash, number)
+		if assert.NotNil(t, block) {
+			assert.Equal(t, hash, block.Hash())
+		}
+	}
+	assert.False(t, db.HasHeader(hashes[1], 2))
+
+	// the genesis is kept in the key-value store
+	has, _ := db.DB().Has(headerKey(0, hashes[0]))
+	assert.True(t, has)
+	has, _ = db.DB().Has(headerKey(1, hashes[1]))
+	assert.False(t, has)
+	has, _ = db.DB().Has(headerKey(3, hashes[3]))
+	assert.True(t, has)
+
+	commits, err := freezer.AncientSeenCommits(2)
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{2}, commits)
+	_, ok := store.commits[2]
+	assert.False(t, ok)
+
+	// nothing to be moved
+	moved, err = migrator.migrate()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, moved)
+
+	// no block is moved if the chain is shorter than the threshold
+	migrator = NewFreezerMigrator(db, freezer, store, 10)
+	moved, err = migrator.migrate()
+	assert.NoError(t, err)
+	assert.Equal(t, 0, moved)
+}
+
+func TestFreezerMigrator_migrateMissingBlock(t *testing.T) {
+	freezer, clean := newTestFreezer(t)
+	defer clean()
+
+	db := NewChainDBWithFreezer(newDb(), newDecoder(), freezer)
+	for i := uint64(0); i < 4; i++ {
+		block := createBlock(i)
+		assert.NoError(t, db.InsertBlock(block))
+		db.SaveHeaderNumber(block.Hash(), i)
+	}
+	db.DeleteBlockHashByNumber(1)
+
+	moved, err := NewFreezerMigrator(db, freezer, &fakeSeenCommitsStore{}, 1).migrate()
+	assert.Equal(t, errCanonicalBlockMissing, err)
+	assert.Equal(t, 1, moved)
+	assert.Equal(t, uint64(1), freezer.Ancients())
+}
+
+func TestFreezerMigrator_StartStop(t *testing.T) {
+	migrator := NewFreezerMigrator(newChainDB(), nil, &fakeSeenCommitsStore{}, 3)
+	assert.NoError(t, migrator.Start())
+	migrator.Stop()
+
+	freezer, clean := newTestFreezer(t)
+	defer clean()
+	migrator = NewFreezerMigrator(newChainDB(), freezer, &fakeSeenCommitsStore{}, 3)
+	assert.NoError(t, migrator.Start())
+	migrator.Stop()
+}
@@ -23,6 +23,7 @@ import (
 	"fmt"
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/common/consts"
+	"github.com/dipperin/dipperin-core/common/hexutil"
 	"github.com/dipperin/dipperin-core/common/util"
 	"github.com/dipperin/dipperin-core/core/bloom"
 	"github.com/dipperin/dipperin-core/core/chain-config"
@@ -58,7 +59,12 @@ func init() {
 	chain_config.VerBootNodeAddress = chain_config.VerifierBootNodeAddress
 }
 
-var errGenesisNoConfig = errors.New("genesis has no chain configuration")
+var (
+	errGenesisNoConfig = errors.New("genesis has no chain configuration")
+
+	ErrGenesisStateExceedIssuance = errors.New("the total DIP of the genesis state exceeds the issuance of the economy model")
+	ErrGenesisVerifierNotEnough   = errors.New("the genesis verifiers are less than the verifier number of the chain")
+)
 // GenesisMismatchError is raised when trying to overwrite an existing
 // genesis block with an incompatible one.
 type GenesisMismatchError struct {
@@ -86,6 +92,13 @@ type Genesis struct {
 	//add verifiers
 	Verifiers []common.Address
 
+	// the accounts and contracts of a state dump to fork a chain from the state of another one, the economy model
+	// allocation and the early token contract are in the state already so they aren't set up again
+	State []*state_processor.DumpAccount `json:"state"`
+	// the block number of the state dump, the total DIP of the state can't exceed the issuance until it. It can be less,
+	// the rewards are rounded down when the blocks are processed
+	StateNumber uint64 `json:"stateNumber"`
+
 	// These fields are used for consensus tests. Please don't use them
 	// in actual genesis blocks.
 	Number uint64 `json:"number"`
@@ -196,7 +209,7 @@ func (g *Genesis) checkEarlyContractExist() {
 			panic("alloc check failed, storageErr: %v" + err.Error())
 		}
 		shouldBe := big.NewInt(0).Add(a, big.NewInt(0))
-		if addr.IsEqual(earlyC.Owner) {
+		if addr.IsEqual(earlyC.Owner) && len(g.State) == 0 {
 			shouldBe = big.NewInt(0).Sub(a, originEarlyC.NeedDIP)
 		}
 		if b.Cmp(shouldBe) != 0 {
@@ -269,11 +282,13 @@ func (g *Genesis) ToBlock() *model.Block {
 		head.Diff = chain_config.GenesisDifficulty
 	}
 
-	//padding economy model balance
-	economyModel := economy_model.MakeDipperinEconomyModel(nil, economy_model.DIPProportion)
-	err := g.paddingEconomyInfo(economyModel)
-	if err != nil {
-		panic("padding economy info error: " + err.Error())
+	//padding economy model balance, the forked state has the balances already
+	if len(g.State) == 0 {
+		economyModel := economy_model.MakeDipperinEconomyModel(nil, economy_model.DIPProportion)
+		err := g.paddingEconomyInfo(economyModel)
+		if err != nil {
+			panic("padding economy info error: " + err.Error())
+		}
 	}
 
 	block := model.NewBlock(head, nil, nil)
@@ -387,11 +402,20 @@ func (g *Genesis) Prepare() (model.AbstractBlock, error) {
 		}
 	}
 
+	// write the forked state, the alloc is written after it to change the balances
+	if len(g.State) > 0 {
+		if err := g.prepareState(); err != nil {
+			return nil, err
+		}
+	}
+
 	// write state，Must be placed after the initial verifier account, otherwise the amount of the verifier in alloc will be overwritten to 0
 	for k, v := range g.Alloc {
 		//log.Debug("add genesis balance", "addr", k.Hex(), "balance", v)
-		if err := g.AccountStateProcessor.NewAccountState(k); err != nil {
-			return nil, err
+		if len(g.State) == 0 || g.AccountStateProcessor.IsEmptyAccount(k) {
+			if err := g.AccountStateProcessor.NewAccountState(k); err != nil {
+				return nil, err
+			}
 		}
 
 		if err := g.AccountStateProcessor.SetBalance(k, v); err != nil {
@@ -399,9 +423,10 @@ func (g *Genesis) Prepare() (model.AbstractBlock, error) {
 		}
 	}
 
-	err := g.SetEarlyTokenContract()
-	if err != nil {
-		return nil, err
+	if len(g.State) == 0 {
+		if err := g.SetEarlyTokenContract(); err != nil {
+			return nil, err
+		}
 	}
 
 	/*	//todo delete after test get contract
@@ -429,6 +454,83 @@ func (g *Genesis) Prepare() (model.AbstractBlock, error) {
 	return block, nil
 }
 
+// the total DIP of the state and the alloc, the alloc replaces the balance of the account in the state.
+// The stake being unStaked, the delegations and the DIP left in the early token contract are counted too.
+func (g *Genesis) stateTotalAmount() (*big.Int, error) {
+	total := big.NewInt(0)
+	for _, account := range g.State {
+		amounts := []*hexutil.Big{account.Balance, account.Stake, account.TimeLock}
+		if account.UnStaking != nil {
+			amounts = append(amounts, account.UnStaking.Amount)
+		}
+		for _, d := range account.Delegations {
+			amounts = append(amounts, d.Bonded, d.Unbonding)
+		}
+		for _, amount := range amounts {
+			if amount != nil {
+				total.Add(total, amount.ToInt())
+			}
+		}
+		if _, ok := g.Alloc[account.Address]; ok && account.Balance != nil {
+			total.Sub(total, account.Balance.ToInt())
+		}
+		if account.Address.IsEqual(contract.EarlyContractAddress) {
+			needDIP, err := earlyContractDIP(account.Contract)
+			if err != nil {
+				return nil, err
+			}
+			total.Add(total, needDIP)
+		}
+	}
+	for _, amount := range g.Alloc {
+		total.Add(total, amount)
+	}
+	return total, nil
+}
+
+// the DIP left in the dumped early token contract, the contract kv has the json fields of the contract
+func earlyContractDIP(kv map[string]string) (*big.Int, error) {
+	enc, ok := kv["need_DIP"]
+	if !ok {
+		return big.NewInt(0), nil
+	}
+	var needDIP hexutil.Big
+	if err := json.Unmarshal([]byte(enc), &needDIP); err != nil {
+		return nil, err
+	}
+	return needDIP.ToInt(), nil
+}
+
+// write the accounts and contracts of the forked state, the DIP of the state can't exceed the issuance of the economy
+// model until the block of the state dump. The active verifiers of the state are registered again.
+func (g *Genesis) prepareState() error {
+	issuance := economy_model.CalcDIPTotalIssuance(g.StateNumber)
+	total, err := g.stateTotalAmount()
+	if err != nil {
+		return err
+	}
+	if total.Cmp(issuance) > 0 {
+		log.Error("genesis state exceeds the issuance", "total", total, "issuance", issuance, "number", g.StateNumber)
+		return ErrGenesisStateExceedIssuance
+	}
+
+	registered := 0
+	for _, account := range g.State {
+		if err := g.AccountStateProcessor.LoadAccount(account); err != nil {
+			return err
+		}
+		if account.Stake == nil || account.Stake.ToInt().Sign() == 0 || account.LastElect != 0 {
+			continue
+		}
+		if err := g.RegisterProcessor.SaveRegisterData(account.Address); err != nil {
+			return err
+		}
+		registered++
+	}
+	log.Info("load genesis state", "accounts", len(g.State), "registered", registered, "number", g.StateNumber)
+	return nil
+}
+
 // MustCommit writes the genesis block and state to db, panicking on error.
 // The block is committed as the canonical head block.
 /*func (g *Genesis) MustCommit() model.AbstractBlock {
@@ -455,6 +557,7 @@ func DefaultGenesisBlock(chainDB chaindb.Database, accountStateProcessor state_p
 
 	//read config file first
 	if mGenesis := GenesisBlockFromFile(chainDB, accountStateProcessor); mGenesis != nil {
+		mGenesis.RegisterProcessor = registerProcessor
 		return mGenesis
 	}
 
@@ -511,24 +614,87 @@ type genesisCfgFile struct {
 	Difficulty string           `json:"difficulty" gencodec:"required"`
 	Verifiers  []string         `json:"verifiers" gencodec:"required"`
 	// todo add a foundation configuration
+
+	// the chain id of the new network, the default one is used if it's 0
+	ChainId uint64 `json:"chainId"`
+	// the state dump file to fork the chain from, the relative path is in the directory of the genesis file
+	State       string `json:"state"`
+	StateNumber uint64 `json:"stateNumber"`
 }
 
-func GenesisBlockFromFile(chainDB chaindb.Database, accountStateProcessor state_processor.AccountStateProcessor) *Genesis {
-	log.Debug("call GenesisBlockFromFile")
+func genesisFilePath() string {
+	return filepath.Join(util.HomeDir(), "softwares", "dipperin_deploy", "genesis.json")
+}
 
-	gFPath := filepath.Join(util.HomeDir(), "softwares", "dipperin_deploy", "genesis.json")
-	ge, e := ioutil.ReadFile(gFPath)
-	if e != nil {
-		return nil
+// read the genesis file, nil is returned if there isn't the file
+func readGenesisFile() (*genesisCfgFile, error) {
+	gFPath := genesisFilePath()
+	ge, err := ioutil.ReadFile(gFPath)
+	if err != nil {
+		return nil, nil
 	}
 	log.Info("load genesis file", "path", gFPath)
 
 	var info genesisCfgFile
-	err := json.Unmarshal(ge, &info)
+	if err = json.Unmarshal(ge, &info); err != nil {
+		return nil, err
+	}
+	return &info, nil
+}
+
+// SetupGenesisConfig uses the chain id and the verifiers of the genesis file for the chain, the default verifiers are
+// replaced by the verifiers of the file. It must be called before the chain config is used.
+func SetupGenesisConfig(conf *chain_config.ChainConfig) error {
+	info, err := readGenesisFile()
+	if err != nil || info == nil {
+		return err
+	}
+	if info.ChainId != 0 {
+		conf.ChainId = big.NewInt(0).SetUint64(info.ChainId)
+	}
+	if len(info.Verifiers) > 0 {
+		if len(info.Verifiers) < conf.VerifierNumber {
+			return ErrGenesisVerifierNotEnough
+		}
+		verifiers := make([]common.Address, 0, len(info.Verifiers))
+		for _, v := range info.Verifiers {
+			verifiers = append(verifiers, common.HexToAddress(v))
+		}
+		VerifierAddress = verifiers
+	}
+	log.Info("setup genesis config", "chain id", conf.ChainId, "verifiers", len(VerifierAddress))
+	return nil
+}
+
+// read the accounts of the state dump file
+func readGenesisState(statePath string) ([]*state_processor.DumpAccount, error) {
+	if !filepath.IsAbs(statePath) {
+		statePath = filepath.Join(filepath.Dir(genesisFilePath()), statePath)
+	}
+	f, err := os.Open(statePath)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var dump state_processor.StateDump
+	if err = json.NewDecoder(f).Decode(&dump); err != nil {
+		return nil, err
+	}
+	return dump.Accounts, nil
+}
+
+func GenesisBlockFromFile(chainDB chaindb.Database, accountStateProcessor state_processor.AccountStateProcessor) *Genesis {
+	log.Debug("call GenesisBlockFromFile")
+
+	info, err := readGenesisFile()
 	if err != nil {
 		log.Error("unmarshal genesisCfgFile failed", "storageErr", err)
 		return nil
 	}
+	if info == nil {
+		return nil
+	}
 
 	var gTime time.Time
 	if gTime, err = time.Parse("2006-01-02 15:04:05", info.Timestamp); err != nil {
@@ -548,6 +714,14 @@ func GenesisBlockFromFile(chainDB chaindb.Database, accountStateProcessor state_
 		verifiers = append(verifiers, common.HexToAddress(v))
 	}
 
+	// the genesis of the fork can't fall back to the default one
+	var state []*state_processor.DumpAccount
+	if info.State != "" {
+		if state, err = readGenesisState(info.State); err != nil {
+			panic(fmt.Sprintf("read genesis state failed, %v: %v", info.State, err))
+		}
+	}
+
 	return &Genesis{
 		ChainDB: chainDB,
 
@@ -557,7 +731,9 @@ func GenesisBlockFromFile(chainDB chaindb.Database, accountStateProcessor state_
 		Timestamp:             big.NewInt(gTime.UnixNano()),
 		//ExtraData:             []byte(info.Note),
 		Difficulty: common.HexToDiff(info.Difficulty),
-		Alloc:      alloc,
-		Verifiers:  verifiers,
+		Alloc:       alloc,
+		Verifiers:   verifiers,
+		State:       state,
+		StateNumber: info.StateNumber,
 	}
 }
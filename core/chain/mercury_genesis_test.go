@@ -32,6 +32,14 @@ import (
 	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/third-party/log"
 	"encoding/json"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/contract"
+	"github.com/dipperin/dipperin-core/core/economy-model"
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/crypto"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"reflect"
 )
 
 func TestSetupGenesisBlock(t *testing.T) {
@@ -248,4 +256,250 @@ func TestGenesis_SetEarlyTokenContract_Error(t *testing.T) {
 
 	err = defaultGenesis.SetEarlyTokenContract()
 	assert.Equal(t, TrieError, err)
-}
\ No newline at end of file
+}
+type testGenesisContract struct {
+	Owner common.Address `json:"owner"`
+	Name  string         `json:"name"`
+}
+
+// the state dump of the verifier alice, bob delegating to alice and a contract without the account,
+// the total DIP of the state is the issuance of the genesis
+func createGenesisState(t *testing.T) (*state_processor.StateDump, common.Hash) {
+	storage := state_processor.NewStateStorageWithCache(ethdb.NewMemDatabase())
+	processor, err := state_processor.NewAccountStateDB(common.Hash{}, storage)
+	assert.NoError(t, err)
+	assert.NoError(t, processor.NewAccountState(aliceAddr))
+	assert.NoError(t, processor.SetBalance(aliceAddr, big.NewInt(10*consts.DIP)))
+	assert.NoError(t, processor.SetNonce(aliceAddr, 5))
+	assert.NoError(t, processor.SetStake(aliceAddr, big.NewInt(2*consts.DIP)))
+	assert.NoError(t, processor.SetCommission(aliceAddr, 100))
+	assert.NoError(t, processor.NewAccountState(bobAddr))
+	assert.NoError(t, processor.SetBalance(bobAddr, big.NewInt(0).Sub(economy_model.CalcDIPTotalIssuance(0), big.NewInt(12*consts.DIP))))
+	assert.NoError(t, processor.Delegate(bobAddr, aliceAddr, big.NewInt(100*consts.DIP)))
+	assert.NoError(t, processor.PutContract(contract.EarlyContractAddress, reflect.ValueOf(&testGenesisContract{Owner: aliceAddr, Name: "test"})))
+	root, err := processor.Commit()
+	assert.NoError(t, err)
+
+	dump, err := processor.DumpState(nil, 100)
+	assert.NoError(t, err)
+	return dump, root
+}
+
+func createStateGenesis(state []*state_processor.DumpAccount) *Genesis {
+	db := ethdb.NewMemDatabase()
+	storage := state_processor.NewStateStorageWithCache(db)
+	stateProcessor, _ := state_processor.MakeGenesisAccountStateProcessor(storage)
+	registerProcessor, _ := registerdb.MakeGenesisRegisterProcessor(storage)
+	return &Genesis{
+		ChainDB:               chaindb.NewChainDB(db, model.MakeDefaultBlockDecoder()),
+		AccountStateProcessor: stateProcessor,
+		RegisterProcessor:     registerProcessor,
+		Config:                chain_config.GetChainConfig(),
+		Timestamp:             big.NewInt(0),
+		Alloc:                 map[common.Address]*big.Int{},
+		State:                 state,
+	}
+}
+
+func TestGenesis_PrepareState(t *testing.T) {
+	dump, root := createGenesisState(t)
+	assert.Equal(t, 3, len(dump.Accounts))
+
+	// the fork of the state is the same as the source
+	fork := createStateGenesis(dump.Accounts)
+	block, err := fork.Prepare()
+	assert.NoError(t, err)
+	assert.Equal(t, root, block.StateRoot())
+	assert.Equal(t, 0, len(fork.Alloc))
+	assert.Equal(t, []common.Address{aliceAddr}, fork.RegisterProcessor.(*registerdb.RegisterDB).GetRegisterData())
+	assert.NoError(t, fork.Commit(block))
+
+	forkState, err := state_processor.NewAccountStateDB(root, state_processor.NewStateStorageWithCache(fork.ChainDB.DB()))
+	assert.NoError(t, err)
+	forkDump, err := forkState.DumpState(nil, 100)
+	assert.NoError(t, err)
+	assert.Equal(t, dump, forkDump)
+
+	delegation, err := forkState.GetDelegation(bobAddr, aliceAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(100*consts.DIP), delegation.Bonded)
+
+	// the alloc moves the balances of the state
+	bobBalance := big.NewInt(0).Sub(economy_model.CalcDIPTotalIssuance(0), big.NewInt(112*consts.DIP))
+	fork = createStateGenesis(dump.Accounts)
+	fork.Alloc[aliceAddr] = big.NewInt(consts.DIP)
+	fork.Alloc[bobAddr] = big.NewInt(0).Add(bobBalance, big.NewInt(9*consts.DIP))
+	block, err = fork.Prepare()
+	assert.NoError(t, err)
+	assert.NotEqual(t, root, block.StateRoot())
+	balance, err := fork.AccountStateProcessor.GetBalance(aliceAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(consts.DIP), balance)
+	nonce, err := fork.AccountStateProcessor.(*state_processor.AccountStateDB).GetNonce(aliceAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(5), nonce)
+	total, err := fork.stateTotalAmount()
+	assert.NoError(t, err)
+	assert.Equal(t, economy_model.CalcDIPTotalIssuance(0), total)
+
+	// the state can't exceed the issuance of the state number
+	fork = createStateGenesis(dump.Accounts)
+	fork.Alloc[aliceAddr] = big.NewInt(11 * consts.DIP)
+	_, err = fork.Prepare()
+	assert.Equal(t, ErrGenesisStateExceedIssuance, err)
+
+	fork = createStateGenesis(dump.Accounts)
+	fork.Alloc[aliceAddr] = big.NewInt(consts.DIP)
+	_, err = fork.Prepare()
+	assert.NoError(t, err)
+
+	fork = createStateGenesis(dump.Accounts)
+	fork.StateNumber = 100
+	_, err = fork.Prepare()
+	assert.NoError(t, err)
+
+	fork = createStateGenesis(dump.Accounts)
+	fork.Alloc[bobAddr] = big.NewInt(0).Sub(economy_model.CalcDIPTotalIssuance(100), big.NewInt(112*consts.DIP))
+	fork.StateNumber = 100
+	_, err = fork.Prepare()
+	assert.NoError(t, err)
+}
+
+// the state dump of a chain which processed the rewards and fees of the blocks until the state number,
+// the rewards are rounded down so the total DIP is less than the issuance
+func TestGenesis_PrepareProcessedState(t *testing.T) {
+	dump, _ := createGenesisState(t)
+	source := createStateGenesis(dump.Accounts)
+	block, err := source.Prepare()
+	assert.NoError(t, err)
+	assert.NoError(t, source.Commit(block))
+
+	storage := state_processor.NewStateStorageWithCache(source.ChainDB.DB())
+	processor, err := NewBlockProcessor(fakeAccountDBChain{}, block.StateRoot(), storage)
+	assert.NoError(t, err)
+	processor.economyModel = economy_model.MakeDipperinEconomyModel(&earlyContractFakeChainService{}, economy_model.DIPProportion)
+	var earlyTokenContract contract.EarlyRewardContract
+	earlyTokenContract.Balances = map[string]*big.Int{earlyTokenContract.Owner.Hex(): big.NewInt(1000000 * consts.DIP)}
+
+	stateNumber := uint64(8)
+	key, _ := crypto.HexToECDSA(testPriv1)
+	for number := uint64(2); number <= stateNumber; number++ {
+		// alice mines the blocks and commits the pre blocks, the fee of her transfer goes back to her
+		tx, err := model.NewTransaction(number+3, bobAddr, big.NewInt(consts.DIP), big.NewInt(10000), nil).SignTx(key, model.NewMercurySigner(big.NewInt(1)))
+		assert.NoError(t, err)
+		assert.NoError(t, processor.ProcessTx(tx, number))
+
+		header := model.NewHeader(1, number, common.Hash{}, common.HexToHash("123456"), common.HexToDiff("1fffffff"), big.NewInt(0), aliceAddr, common.BlockNonce{})
+		b := model.NewBlock(header, []*model.Transaction{tx}, nil)
+		b.SetVerifications([]model.AbstractVerification{createSignedVote2(number-1, common.Hash{}, model.VoteMessage, testPriv1, aliceAddr)})
+		assert.NoError(t, processor.RewardCoinBase(b, &earlyTokenContract))
+		if number > 2 {
+			assert.NoError(t, processor.RewardByzantiumVerifier(b, &earlyTokenContract))
+		}
+	}
+	root, err := processor.Commit()
+	assert.NoError(t, err)
+	processed, err := state_processor.NewAccountStateDB(root, storage)
+	assert.NoError(t, err)
+	dump, err = processed.DumpState(nil, 100)
+	assert.NoError(t, err)
+
+	fork := createStateGenesis(dump.Accounts)
+	fork.StateNumber = stateNumber
+	total, err := fork.stateTotalAmount()
+	assert.NoError(t, err)
+	assert.True(t, total.Cmp(economy_model.CalcDIPTotalIssuance(stateNumber)) < 0)
+	block, err = fork.Prepare()
+	assert.NoError(t, err)
+	assert.Equal(t, root, block.StateRoot())
+
+	// the state of the processed chain can't be forked as an earlier block
+	fork = createStateGenesis(dump.Accounts)
+	fork.StateNumber = 2
+	_, err = fork.Prepare()
+	assert.Equal(t, ErrGenesisStateExceedIssuance, err)
+}
+
+func Test_earlyContractDIP(t *testing.T) {
+	needDIP, err := earlyContractDIP(map[string]string{"name": "\"test\""})
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(0), needDIP)
+
+	// the need_DIP field of the early token contract is a hex string
+	needDIP, err = earlyContractDIP(map[string]string{"need_DIP": "\"0xb2d05e00\""})
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(3*consts.DIP), needDIP)
+
+	_, err = earlyContractDIP(map[string]string{"need_DIP": "1"})
+	assert.Error(t, err)
+}
+
+func TestSetupGenesisConfig(t *testing.T) {
+	gFPath := filepath.Join(util.HomeDir(), "softwares", "dipperin_deploy")
+	assert.True(t, pathIsExist(gFPath))
+	gFPath = filepath.Join(gFPath, "genesis.json")
+	os.Remove(gFPath)
+
+	defaultVerifiers := VerifierAddress
+	defer func() { VerifierAddress = defaultVerifiers }()
+
+	// no genesis file
+	conf := *chain_config.GetChainConfig()
+	conf.VerifierNumber = 2
+	assert.NoError(t, SetupGenesisConfig(&conf))
+	assert.Equal(t, chain_config.GetChainConfig().ChainId, conf.ChainId)
+
+	// the verifiers are less than the verifier number
+	cfg := genesisCfgFile{ChainId: 1600, Verifiers: []string{aliceAddr.String()}}
+	bytes, err := json.Marshal(cfg)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(gFPath, bytes, 0666))
+	defer os.Remove(gFPath)
+	assert.Equal(t, ErrGenesisVerifierNotEnough, SetupGenesisConfig(&conf))
+
+	cfg.Verifiers = append(cfg.Verifiers, bobAddr.String())
+	bytes, err = json.Marshal(cfg)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(gFPath, bytes, 0666))
+	assert.NoError(t, SetupGenesisConfig(&conf))
+	assert.Equal(t, big.NewInt(1600), conf.ChainId)
+	assert.Equal(t, []common.Address{aliceAddr, bobAddr}, VerifierAddress)
+}
+
+func TestGenesisBlockFromFile_State(t *testing.T) {
+	gFPath := filepath.Join(util.HomeDir(), "softwares", "dipperin_deploy")
+	assert.True(t, pathIsExist(gFPath))
+	statePath := filepath.Join(gFPath, "genesis_state_test.json")
+	gFPath = filepath.Join(gFPath, "genesis.json")
+	os.Remove(gFPath)
+
+	dump, root := createGenesisState(t)
+	bytes, err := json.Marshal(dump)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(statePath, bytes, 0666))
+	defer os.Remove(statePath)
+
+	// the relative path is in the directory of the genesis file
+	cfg := genesisCfgFile{State: "genesis_state_test.json"}
+	bytes, err = json.Marshal(cfg)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(gFPath, bytes, 0666))
+	defer os.Remove(gFPath)
+
+	fork := createGenesis()
+	assert.NotNil(t, fork.RegisterProcessor)
+	assert.Equal(t, uint64(0), fork.StateNumber)
+	assert.Equal(t, len(dump.Accounts), len(fork.State))
+	block, err := fork.Prepare()
+	assert.NoError(t, err)
+	assert.Equal(t, root, block.StateRoot())
+
+	// the fork can't fall back to the default genesis
+	cfg.State = "not_exist.json"
+	bytes, err = json.Marshal(cfg)
+	assert.NoError(t, err)
+	assert.NoError(t, ioutil.WriteFile(gFPath, bytes, 0666))
+	assert.Panics(t, func() {
+		createGenesis()
+	})
+}
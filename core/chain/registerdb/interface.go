@@ -18,12 +18,14 @@ package registerdb
 
 import (
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
 )
 
 // only genesis use
 type RegisterProcessor interface {
 	PrepareRegisterDB() error
+	SaveRegisterData(addr common.Address) error
 	Finalise() common.Hash
 	Commit() (root common.Hash, err error)
 }
@@ -31,3 +33,9 @@ type RegisterProcessor interface {
 type ChainReader interface {
 	GetBlockByNumber(number uint64) model.AbstractBlock
 }
+
+// the chain reader which supports the governed parameters, the slot size of the chain config is used if the chain reader
+// doesn't implement it
+type GovernedChainReader interface {
+	GetGovernedChainConfig(num uint64, slot uint64) *chain_config.ChainConfig
+}
@@ -208,7 +208,7 @@ func (register RegisterDB) IsChangePoint(block model.AbstractBlock, isProcessPac
 		diff += 1
 	}
 
-	slotSize := chain_config.GetChainConfig().SlotSize
+	slotSize := register.getSlotSize(block)
 
 	//the mineMaster packaged Block diff and nonce is 0
 	if isProcessPackageBlock {
@@ -224,6 +224,19 @@ func (register RegisterDB) IsChangePoint(block model.AbstractBlock, isProcessPac
 	return false
 }
 
+// the register db is built on the register root of the block, so the slot of it is the slot of the block
+func (register RegisterDB) getSlotSize(block model.AbstractBlock) uint64 {
+	reader, ok := register.chainReader.(GovernedChainReader)
+	if !ok {
+		return chain_config.GetChainConfig().SlotSize
+	}
+	slot, err := register.GetSlot()
+	if err != nil {
+		return chain_config.GetChainConfig().SlotSize
+	}
+	return reader.GetGovernedChainConfig(block.Number(), slot).SlotSize
+}
+
 func (register RegisterDB) Finalise() common.Hash {
 	return register.trie.Hash()
 }
@@ -232,10 +245,15 @@ func (register RegisterDB) Commit() (root common.Hash, err error) {
 	if root, err = register.trie.Commit(nil); err != nil {
 		return root, err
 	}
-	err = register.storage.TrieDB().Commit(root, false)
+	err = state_processor.CommitTrie(register.storage, root)
 	return root, err
 }
 
+// SaveRegisterData register the verifier of the forked genesis state
+func (register RegisterDB) SaveRegisterData(addr common.Address) error {
+	return register.saveRegisterData(addr)
+}
+
 func (register RegisterDB) saveRegisterData(addr common.Address) error {
 	return register.trie.TryUpdate(addr.Bytes(), []byte{0})
 }
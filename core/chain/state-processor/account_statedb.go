@@ -17,6 +17,7 @@
 package state_processor
 
 import (
+	"bytes"
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/common/util/json-kv"
 	"github.com/dipperin/dipperin-core/core/contract"
@@ -929,6 +930,28 @@ func (state *AccountStateDB) setLastElect(addr common.Address, blockID uint64) e
 	return nil
 }
 
+// The optional values are not created with the account, they are only written to the trie when they are used,
+// so the existing state roots don't change. An empty value deletes the key.
+//setOptionalValue do not change the changelist, usually called by the revert operation.
+func (state *AccountStateDB) setOptionalValue(key []byte, value []byte) error {
+	if len(value) == 0 {
+		return state.blockStateTrie.TryDelete(key)
+	}
+	return state.blockStateTrie.TryUpdate(key, value)
+}
+
+func (state *AccountStateDB) putOptionalValue(owner common.Address, key []byte, value []byte) error {
+	old, err := state.blockStateTrie.TryGet(key)
+	if err != nil {
+		return err
+	}
+	if err = state.setOptionalValue(key, value); err != nil {
+		return err
+	}
+	state.stateChangeList.append(optionalValueChange{Account: &owner, Key: key, Prev: old, Current: value, ChangeType: OptionalValueChange})
+	return nil
+}
+
 func (state *AccountStateDB) NewAccountState(addr common.Address) error {
 	_, err := state.newAccountState(addr)
 	if err != nil {
@@ -1111,7 +1134,8 @@ func (state *AccountStateDB) GetAccountState(addr common.Address) (*account, err
 	return account, nil
 }
 
-// commit contract data
+// commit contract data, the contract tries have their own trie database and are always written to the disk,
+// the unused ones are deleted by the offline state pruning
 func (state *AccountStateDB) commitContractData() error {
 	for addr, root := range state.finalisedContractRoot {
 		mpt_log.Debug("commit contract", "addr", addr.Hex(), "root", root.Hex(), "pre state", state.preStateRoot.Hex())
@@ -1166,6 +1190,12 @@ func (state *AccountStateDB) Commit() (common.Hash, error) {
 		return common.Hash{}, err
 	}
 
+	// the governance trie is not referenced by the nodes of the state trie
+	if err := state.commitGovernanceTrie(); err != nil {
+		log.Warn("commit governance trie failed", "err", err)
+		return common.Hash{}, err
+	}
+
 	//it's difficult to do reference in here,because we don't know if the data of the leaf callback is contract data,maybe
 	//balance or other data
 	//if root, err := state.blockStateTrie.Commit(nil); err != nil {
@@ -1177,7 +1207,7 @@ func (state *AccountStateDB) Commit() (common.Hash, error) {
 	//        return common.Hash{}, errors.New("finalised state root not match commit state root")
 	//    }
 	//have committed in the finalise
-	err = state.storage.TrieDB().Commit(fStateRoot, false)
+	err = CommitTrie(state.storage, fStateRoot)
 	return fStateRoot, err
 	//}
 }
@@ -1233,11 +1263,26 @@ func (state *AccountStateDB) Finalise() (result common.Hash, err error) {
 
 	state.alreadyFinalised = true
 
-	result, err = state.blockStateTrie.Commit(nil)
+	result, err = state.blockStateTrie.Commit(state.referenceGovernanceTrie())
 	mpt_log.Debug("Finalise", "cur root", result.Hex(), "pre state", state.preStateRoot.Hex())
 	return
 }
 
+// the governance trie is saved as a value of the state trie, its root is referenced by the node
+// containing the value, so the pruned storage keeps it in memory as long as the state
+func (state *AccountStateDB) referenceGovernanceTrie() trie.LeafCallback {
+	root, err := state.blockStateTrie.TryGet(GetGovernanceRootKey())
+	if err != nil || len(root) != common.HashLength {
+		return nil
+	}
+	return func(leaf []byte, parent common.Hash) error {
+		if bytes.Equal(leaf, root) {
+			state.storage.TrieDB().Reference(common.BytesToHash(leaf), parent)
+		}
+		return nil
+	}
+}
+
 //todo these processes are removed afterwards。
 // todo Write a unit test for each transaction to cover all situations
 func (state *AccountStateDB) ProcessTx(tx model.AbstractTransaction, height uint64) (err error) {
@@ -1256,13 +1301,25 @@ func (state *AccountStateDB) ProcessTx(tx model.AbstractTransaction, height uint
 		err = state.processERC20Tx(tx, height)
 		// Verifier relate transaction processor
 	case common.AddressTypeStake:
-		err = state.processStakeTx(tx)
+		err = state.processStakeTx(tx, height)
 	case common.AddressTypeCancel:
 		err = state.processCancelTx(tx, height)
 	case common.AddressTypeUnStake:
-		err = state.processUnStakeTx(tx)
+		err = state.processUnStakeTx(tx, height)
 	case common.AddressTypeEvidence:
 		err = state.processEvidenceTx(tx)
+	case common.AddressTypeDelegate:
+		err = state.processDelegateTx(tx)
+	case common.AddressTypeUnDelegate:
+		err = state.processUnDelegateTx(tx, height)
+	case common.AddressTypeWithdrawDelegation:
+		err = state.processWithdrawDelegationTx(tx)
+	case common.AddressTypeWithdrawStake:
+		err = state.processWithdrawStakeTx(tx)
+	case common.AddressTypeGovernanceProposal:
+		err = state.processGovernanceProposalTx(tx)
+	case common.AddressTypeGovernanceVote:
+		err = state.processGovernanceVoteTx(tx)
 	case common.AddressTypeEarlyReward:
 		err = state.processEarlyTokenTx(tx, height)
 	default:
@@ -47,11 +47,37 @@ func NewStateStorageWithCache(db ethdb.Database) StateStorage {
 	}
 }
 
+// NewPrunedStateStorageWithCache keeps the committed tries in the memory database, they are flushed to the disk
+// or dereferenced by the TrieGC
+func NewPrunedStateStorageWithCache(db ethdb.Database) StateStorage {
+	csc, _ := lru.New(codeSizeCacheSize)
+	return &cachingDB{
+		db:            trie.NewDatabase(db),
+		codeSizeCache: csc,
+		pruned:        true,
+	}
+}
+
+// CommitTrie writes the trie of the root to the disk if the storage keeps all the states
+func CommitTrie(storage StateStorage, root common.Hash) error {
+	if IsPrunedStorage(storage) {
+		return nil
+	}
+	return storage.TrieDB().Commit(root, false)
+}
+
+// IsPrunedStorage reports whether the committed tries of the storage are kept in memory until the TrieGC handles them
+func IsPrunedStorage(storage StateStorage) bool {
+	db, ok := storage.(*cachingDB)
+	return ok && db.pruned
+}
+
 type cachingDB struct {
 	db            *trie.Database
 	mu            sync.Mutex
 	pastTries     []*trie.SecureTrie
 	codeSizeCache *lru.Cache
+	pruned        bool
 }
 
 // OpenTrie opens the main account trie.
@@ -0,0 +1,509 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_processor
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/economy-model"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/dipperin/dipperin-core/third-party/log/pbft_log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"math/big"
+)
+
+var (
+	NotEnoughDelegationErr   = errors.New("not enough bonded delegation")
+	NoUnbondingDelegationErr = errors.New("no unbonding delegation to withdraw")
+	InvalidCommissionErr     = errors.New("invalid verifier commission")
+	CandidateNotActiveErr    = errors.New("the delegated target is not an active verifier candidate")
+	TooLowDelegationErr      = errors.New("the bonded delegation is lower than MiniDelegationValue")
+	TooManyDelegatorsErr     = errors.New("the verifier candidate has too many delegators")
+)
+
+// the delegators of a candidate are rewarded one by one in each block, so their number is limited
+var maxDelegators = 1000
+
+// The delegation keys are optional values, the state root of the accounts which never delegate
+// or are never delegated keeps the same.
+const (
+	delegationKeySuffix     = "_delegation"
+	delegatorsKeySuffix     = "_delegators"
+	delegatedStakeKeySuffix = "_delegated_stake"
+	commissionKeySuffix     = "_commission"
+)
+
+// Delegation is the stake a delegator bonds to a verifier candidate
+type Delegation struct {
+	Bonded *big.Int
+	// the amount waiting for the end of the lock period, it is returned by the withdraw tx
+	Unbonding *big.Int
+	// the block number of the last undelegate tx, the lock period restarts with each undelegate tx
+	UnbondHeight uint64
+}
+
+func GetDelegationKey(delegator common.Address, candidate common.Address) []byte {
+	return append(append(delegator[:], candidate[:]...), []byte(delegationKeySuffix)...)
+}
+
+func GetDelegatorsKey(candidate common.Address) []byte {
+	return append(candidate[:], []byte(delegatorsKeySuffix)...)
+}
+
+func GetDelegatedStakeKey(candidate common.Address) []byte {
+	return append(candidate[:], []byte(delegatedStakeKeySuffix)...)
+}
+
+func GetCommissionKey(candidate common.Address) []byte {
+	return append(candidate[:], []byte(commissionKeySuffix)...)
+}
+
+// GetDelegation return an empty delegation if the delegator never delegates to the candidate
+func (state *AccountStateDB) GetDelegation(delegator common.Address, candidate common.Address) (*Delegation, error) {
+	res := &Delegation{Bonded: big.NewInt(0), Unbonding: big.NewInt(0)}
+	enc, err := state.blockStateTrie.TryGet(GetDelegationKey(delegator, candidate))
+	if err != nil {
+		return nil, err
+	}
+	if len(enc) == 0 {
+		return res, nil
+	}
+	if err = rlp.DecodeBytes(enc, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (state *AccountStateDB) setDelegation(delegator common.Address, candidate common.Address, d *Delegation) error {
+	var enc []byte
+	if d.Bonded.Sign() > 0 || d.Unbonding.Sign() > 0 {
+		var err error
+		if enc, err = rlp.EncodeToBytes(d); err != nil {
+			return err
+		}
+	}
+	return state.putOptionalValue(delegator, GetDelegationKey(delegator, candidate), enc)
+}
+
+// GetDelegators return the delegators of the candidate in the order of their first delegation
+func (state *AccountStateDB) GetDelegators(candidate common.Address) ([]common.Address, error) {
+	var res []common.Address
+	enc, err := state.blockStateTrie.TryGet(GetDelegatorsKey(candidate))
+	if err != nil {
+		return nil, err
+	}
+	if len(enc) == 0 {
+		return res, nil
+	}
+	if err = rlp.DecodeBytes(enc, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (state *AccountStateDB) setDelegators(candidate common.Address, delegators []common.Address) error {
+	var enc []byte
+	if len(delegators) > 0 {
+		var err error
+		if enc, err = rlp.EncodeToBytes(delegators); err != nil {
+			return err
+		}
+	}
+	return state.putOptionalValue(candidate, GetDelegatorsKey(candidate), enc)
+}
+
+// GetDelegatedStake return the total bonded delegation of the candidate
+func (state *AccountStateDB) GetDelegatedStake(candidate common.Address) (*big.Int, error) {
+	res := big.NewInt(0)
+	enc, err := state.blockStateTrie.TryGet(GetDelegatedStakeKey(candidate))
+	if err != nil {
+		return nil, err
+	}
+	if len(enc) == 0 {
+		return res, nil
+	}
+	if err = rlp.DecodeBytes(enc, &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (state *AccountStateDB) setDelegatedStake(candidate common.Address, amount *big.Int) error {
+	var enc []byte
+	if amount.Sign() > 0 {
+		var err error
+		if enc, err = rlp.EncodeToBytes(amount); err != nil {
+			return err
+		}
+	}
+	return state.putOptionalValue(candidate, GetDelegatedStakeKey(candidate), enc)
+}
+
+// GetVotingStake return the own stake of the candidate plus the stake delegated to it,
+// it is the stake used to elect verifiers. Like GetStake, 0 is returned with the error.
+func (state *AccountStateDB) GetVotingStake(candidate common.Address) (*big.Int, error) {
+	stake, err := state.GetStake(candidate)
+	if err != nil {
+		return big.NewInt(0), err
+	}
+	delegated, err := state.GetDelegatedStake(candidate)
+	if err != nil {
+		return big.NewInt(0), err
+	}
+	return big.NewInt(0).Add(stake, delegated), nil
+}
+
+// GetCommission return the per mille commission the candidate takes from the rewards of its delegators
+func (state *AccountStateDB) GetCommission(candidate common.Address) (uint64, error) {
+	var res uint64
+	enc, err := state.blockStateTrie.TryGet(GetCommissionKey(candidate))
+	if err != nil {
+		return res, err
+	}
+	if len(enc) == 0 {
+		return res, nil
+	}
+	err = rlp.DecodeBytes(enc, &res)
+	return res, err
+}
+
+func (state *AccountStateDB) SetCommission(candidate common.Address, commission uint64) error {
+	if commission > model.MaxCommission {
+		return InvalidCommissionErr
+	}
+	var enc []byte
+	if commission > 0 {
+		enc, _ = rlp.EncodeToBytes(commission)
+	}
+	return state.putOptionalValue(candidate, GetCommissionKey(candidate), enc)
+}
+
+// DecodeCommission decode the commission in the extra data of the register tx,
+// no extra data means the verifier keeps the former commission.
+func DecodeCommission(extraData []byte) (commission uint64, declared bool, err error) {
+	if len(extraData) == 0 {
+		return 0, false, nil
+	}
+	if err = rlp.DecodeBytes(extraData, &commission); err != nil {
+		return 0, false, err
+	}
+	if commission > model.MaxCommission {
+		return 0, false, InvalidCommissionErr
+	}
+	return commission, true, nil
+}
+
+// DecodeUnDelegateAmount decode the amount in the extra data of the undelegate tx
+func DecodeUnDelegateAmount(extraData []byte) (*big.Int, error) {
+	amount := big.NewInt(0)
+	if err := rlp.DecodeBytes(extraData, &amount); err != nil {
+		return nil, err
+	}
+	if amount.Sign() <= 0 {
+		return nil, NotEnoughDelegationErr
+	}
+	return amount, nil
+}
+
+// CheckDelegation check the bonded delegation after the delegate tx isn't lower than MiniDelegationValue,
+// and a new delegator doesn't exceed the delegator number limit of the candidate
+func (state *AccountStateDB) CheckDelegation(delegator common.Address, candidate common.Address, amount *big.Int) error {
+	d, err := state.GetDelegation(delegator, candidate)
+	if err != nil {
+		return err
+	}
+	if big.NewInt(0).Add(d.Bonded, amount).Cmp(economy_model.MiniDelegationValue) < 0 {
+		return TooLowDelegationErr
+	}
+	if d.Bonded.Sign() > 0 || d.Unbonding.Sign() > 0 {
+		return nil
+	}
+	delegators, err := state.GetDelegators(candidate)
+	if err != nil {
+		return err
+	}
+	if len(delegators) >= maxDelegators {
+		return TooManyDelegatorsErr
+	}
+	return nil
+}
+
+// CheckUnDelegation check the rest bonded delegation is either withdrawn entirely or not lower than MiniDelegationValue
+func CheckUnDelegation(d *Delegation, amount *big.Int) error {
+	if d.Bonded.Cmp(amount) < 0 {
+		return NotEnoughDelegationErr
+	}
+	rest := big.NewInt(0).Sub(d.Bonded, amount)
+	if rest.Sign() > 0 && rest.Cmp(economy_model.MiniDelegationValue) < 0 {
+		return TooLowDelegationErr
+	}
+	return nil
+}
+
+/*
+Bond money from the balance of the delegator to the candidate
+*/
+func (state *AccountStateDB) Delegate(delegator common.Address, candidate common.Address, amount *big.Int) error {
+	balance, err := state.GetBalance(delegator)
+	if err != nil || balance.Cmp(amount) < 0 {
+		return NotEnoughBalanceError
+	}
+	if err = state.CheckDelegation(delegator, candidate, amount); err != nil {
+		return err
+	}
+	d, err := state.GetDelegation(delegator, candidate)
+	if err != nil {
+		return err
+	}
+	if d.Bonded.Sign() == 0 && d.Unbonding.Sign() == 0 {
+		delegators, err := state.GetDelegators(candidate)
+		if err != nil {
+			return err
+		}
+		if err = state.setDelegators(candidate, append(delegators, delegator)); err != nil {
+			return err
+		}
+	}
+	if err = state.SubBalance(delegator, amount); err != nil {
+		return err
+	}
+	d.Bonded.Add(d.Bonded, amount)
+	if err = state.setDelegation(delegator, candidate, d); err != nil {
+		return err
+	}
+	delegated, err := state.GetDelegatedStake(candidate)
+	if err != nil {
+		return err
+	}
+	return state.setDelegatedStake(candidate, delegated.Add(delegated, amount))
+}
+
+/*
+Move amount from the bonded delegation to the unbonding delegation, num is processing block num
+*/
+func (state *AccountStateDB) UnDelegate(delegator common.Address, candidate common.Address, amount *big.Int, num uint64) error {
+	d, err := state.GetDelegation(delegator, candidate)
+	if err != nil {
+		return err
+	}
+	if err = CheckUnDelegation(d, amount); err != nil {
+		return err
+	}
+	d.Bonded.Sub(d.Bonded, amount)
+	d.Unbonding.Add(d.Unbonding, amount)
+	d.UnbondHeight = num
+	if err = state.setDelegation(delegator, candidate, d); err != nil {
+		return err
+	}
+	delegated, err := state.GetDelegatedStake(candidate)
+	if err != nil {
+		return err
+	}
+	return state.setDelegatedStake(candidate, delegated.Sub(delegated, amount))
+}
+
+/*
+Return the unbonding delegation to the balance of the delegator
+*/
+func (state *AccountStateDB) WithdrawDelegation(delegator common.Address, candidate common.Address) error {
+	d, err := state.GetDelegation(delegator, candidate)
+	if err != nil {
+		return err
+	}
+	if d.Unbonding.Sign() == 0 {
+		return NoUnbondingDelegationErr
+	}
+	if err = state.AddBalance(delegator, d.Unbonding); err != nil {
+		return err
+	}
+	d.Unbonding = big.NewInt(0)
+	d.UnbondHeight = 0
+	if err = state.setDelegation(delegator, candidate, d); err != nil {
+		return err
+	}
+	if d.Bonded.Sign() > 0 {
+		return nil
+	}
+
+	// the delegation is removed, remove the delegator from the candidate
+	delegators, err := state.GetDelegators(candidate)
+	if err != nil {
+		return err
+	}
+	var remain []common.Address
+	for _, addr := range delegators {
+		if !addr.IsEqual(delegator) {
+			remain = append(remain, addr)
+		}
+	}
+	return state.setDelegators(candidate, remain)
+}
+
+/*
+Reward a verifier and its delegators.
+The reward is split by the own stake of the verifier and the delegated stake, the verifier takes its commission
+from the part of the delegators, and the rest is shared by the delegators according to their bonded delegation.
+The remainder of the integer division goes to the verifier.
+*/
+func (state *AccountStateDB) RewardVerifier(candidate common.Address, amount *big.Int) error {
+	if empty := state.IsEmptyAccount(candidate); empty {
+		if err := state.NewAccountState(candidate); err != nil {
+			return err
+		}
+	}
+	stake, err := state.GetStake(candidate)
+	if err != nil {
+		return err
+	}
+	delegated, err := state.GetDelegatedStake(candidate)
+	if err != nil {
+		return err
+	}
+	total := big.NewInt(0).Add(stake, delegated)
+	if delegated.Sign() == 0 || amount.Sign() == 0 {
+		return state.AddBalance(candidate, amount)
+	}
+
+	commission, err := state.GetCommission(candidate)
+	if err != nil {
+		return err
+	}
+	delegatorsReward := big.NewInt(0).Mul(amount, delegated)
+	delegatorsReward.Div(delegatorsReward, total)
+	commissionReward := big.NewInt(0).Mul(delegatorsReward, big.NewInt(int64(commission)))
+	commissionReward.Div(commissionReward, big.NewInt(int64(model.MaxCommission)))
+	delegatorsReward.Sub(delegatorsReward, commissionReward)
+
+	delegators, err := state.GetDelegators(candidate)
+	if err != nil {
+		return err
+	}
+	rest := new(big.Int).Set(amount)
+	for _, delegator := range delegators {
+		d, err := state.GetDelegation(delegator, candidate)
+		if err != nil {
+			return err
+		}
+		if d.Bonded.Sign() == 0 {
+			continue
+		}
+		reward := big.NewInt(0).Mul(delegatorsReward, d.Bonded)
+		reward.Div(reward, delegated)
+		if reward.Sign() == 0 {
+			continue
+		}
+		if empty := state.IsEmptyAccount(delegator); empty {
+			if err = state.NewAccountState(delegator); err != nil {
+				return err
+			}
+		}
+		if err = state.AddBalance(delegator, reward); err != nil {
+			return err
+		}
+		rest.Sub(rest, reward)
+	}
+	return state.AddBalance(candidate, rest)
+}
+
+/*
+Process delegate tx
+Bond the amount of the tx to the candidate
+*/
+func (state *AccountStateDB) processDelegateTx(tx model.AbstractTransaction) (err error) {
+	sender, _ := tx.Sender(nil)
+	receiver := *(tx.To())
+	if receiver.GetAddressType() != common.AddressTypeDelegate {
+		return TransactionTypeError
+	}
+	candidate := cs_crypto.GetNormalAddressFromDelegation(receiver)
+	if err = state.checkDelegateCandidate(candidate); err != nil {
+		return
+	}
+	if tx.Amount().Sign() <= 0 {
+		return NotEnoughBalanceError
+	}
+
+	err = state.Delegate(sender, candidate, tx.Amount())
+	if err != nil {
+		return
+	}
+	pbft_log.Info("success process a delegate transaction", "tx hash", tx.CalTxId().Hex())
+	return
+}
+
+// the candidate must have registered and not cancelled
+func (state *AccountStateDB) checkDelegateCandidate(candidate common.Address) error {
+	if empty := state.IsEmptyAccount(candidate); empty {
+		return CandidateNotActiveErr
+	}
+	stake, err := state.GetStake(candidate)
+	if err != nil {
+		return err
+	}
+	if stake.Sign() == 0 {
+		return CandidateNotActiveErr
+	}
+	lastBlock, err := state.GetLastElect(candidate)
+	if err != nil {
+		return err
+	}
+	if lastBlock != 0 {
+		return CandidateNotActiveErr
+	}
+	return nil
+}
+
+/*
+Process undelegate tx, num is processing block num
+*/
+func (state *AccountStateDB) processUnDelegateTx(tx model.AbstractTransaction, num uint64) (err error) {
+	sender, _ := tx.Sender(nil)
+	receiver := *(tx.To())
+	if receiver.GetAddressType() != common.AddressTypeUnDelegate {
+		return TransactionTypeError
+	}
+	amount, err := DecodeUnDelegateAmount(tx.ExtraData())
+	if err != nil {
+		return
+	}
+
+	err = state.UnDelegate(sender, cs_crypto.GetNormalAddressFromDelegation(receiver), amount, num)
+	if err != nil {
+		return
+	}
+	pbft_log.Info("success process a undelegate transaction", "tx hash", tx.CalTxId().Hex())
+	return
+}
+
+/*
+Process withdraw delegation tx, the lock period is checked by the tx validator like the unStake tx
+*/
+func (state *AccountStateDB) processWithdrawDelegationTx(tx model.AbstractTransaction) (err error) {
+	sender, _ := tx.Sender(nil)
+	receiver := *(tx.To())
+	if receiver.GetAddressType() != common.AddressTypeWithdrawDelegation {
+		return TransactionTypeError
+	}
+
+	err = state.WithdrawDelegation(sender, cs_crypto.GetNormalAddressFromDelegation(receiver))
+	if err != nil {
+		return
+	}
+	pbft_log.Info("success process a withdraw delegation transaction", "tx hash", tx.CalTxId().Hex())
+	return
+}
@@ -0,0 +1,208 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+
+package state_processor
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/economy-model"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func getTestDelegationTx(t *testing.T, tx *model.Transaction) *model.Transaction {
+	_, key2 := createKey()
+	signedTx, err := tx.SignTx(key2, model.NewMercurySigner(big.NewInt(1)))
+	assert.NoError(t, err)
+	return signedTx
+}
+
+// the tests bond wei amounts
+func setTestMiniDelegationValue() func() {
+	mini := economy_model.MiniDelegationValue
+	economy_model.MiniDelegationValue = big.NewInt(50)
+	return func() { economy_model.MiniDelegationValue = mini }
+}
+
+func TestAccountStateDB_Delegation(t *testing.T) {
+	defer setTestMiniDelegationValue()()
+	db, root := createTestStateDB()
+	processor, err := NewAccountStateDB(root, NewStateStorageWithCache(db))
+	assert.NoError(t, err)
+	assert.NoError(t, processor.Stake(aliceAddr, big.NewInt(1000)))
+	assert.NoError(t, processor.SetCommission(aliceAddr, 100))
+	assert.Equal(t, InvalidCommissionErr, processor.SetCommission(aliceAddr, model.MaxCommission+1))
+
+	// bob delegates to alice
+	tx := getTestDelegationTx(t, model.NewDelegateTransaction(0, aliceAddr, big.NewInt(100), big.NewInt(10)))
+	assert.NoError(t, processor.ProcessTx(tx, 1))
+	bobBalance, _ := processor.GetBalance(bobAddr)
+	assert.EqualValues(t, big.NewInt(90), bobBalance)
+	delegated, _ := processor.GetDelegatedStake(aliceAddr)
+	assert.EqualValues(t, big.NewInt(100), delegated)
+	votingStake, _ := processor.GetVotingStake(aliceAddr)
+	assert.EqualValues(t, big.NewInt(1100), votingStake)
+	votingStake, err = processor.GetVotingStake(common.HexToAddress("0x1234"))
+	assert.Equal(t, g_error.AccountNotExist, err)
+	assert.EqualValues(t, big.NewInt(0), votingStake)
+	delegators, _ := processor.GetDelegators(aliceAddr)
+	assert.Equal(t, []common.Address{bobAddr}, delegators)
+
+	// 1/11 of the reward belongs to bob, alice takes 10% of it
+	assert.NoError(t, processor.RewardVerifier(aliceAddr, big.NewInt(1100)))
+	bobBalance, _ = processor.GetBalance(bobAddr)
+	assert.EqualValues(t, big.NewInt(180), bobBalance)
+	aliceBalance, _ := processor.GetBalance(aliceAddr)
+	assert.EqualValues(t, big.NewInt(4800), aliceBalance)
+
+	// unbond part of the delegation
+	tx = getTestDelegationTx(t, model.NewUnDelegateTransaction(1, aliceAddr, big.NewInt(40), big.NewInt(10)))
+	assert.NoError(t, processor.ProcessTx(tx, 5))
+	delegation, _ := processor.GetDelegation(bobAddr, aliceAddr)
+	assert.EqualValues(t, big.NewInt(60), delegation.Bonded)
+	assert.EqualValues(t, big.NewInt(40), delegation.Unbonding)
+	assert.EqualValues(t, 5, delegation.UnbondHeight)
+	delegated, _ = processor.GetDelegatedStake(aliceAddr)
+	assert.EqualValues(t, big.NewInt(60), delegated)
+
+	tx = getTestDelegationTx(t, model.NewUnDelegateTransaction(2, aliceAddr, big.NewInt(100), big.NewInt(10)))
+	assert.Equal(t, NotEnoughDelegationErr, processor.ProcessTx(tx, 6))
+
+	tx = getTestDelegationTx(t, model.NewWithdrawDelegationTransaction(3, aliceAddr, big.NewInt(10)))
+	assert.NoError(t, processor.ProcessTx(tx, 7))
+	bobBalance, _ = processor.GetBalance(bobAddr)
+	assert.EqualValues(t, big.NewInt(180-30+40), bobBalance)
+	delegators, _ = processor.GetDelegators(aliceAddr)
+	assert.Equal(t, []common.Address{bobAddr}, delegators)
+
+	// remove the whole delegation
+	assert.NoError(t, processor.UnDelegate(bobAddr, aliceAddr, big.NewInt(60), 8))
+	assert.NoError(t, processor.WithdrawDelegation(bobAddr, aliceAddr))
+	assert.Equal(t, NoUnbondingDelegationErr, processor.WithdrawDelegation(bobAddr, aliceAddr))
+	delegators, _ = processor.GetDelegators(aliceAddr)
+	assert.Len(t, delegators, 0)
+	delegated, _ = processor.GetDelegatedStake(aliceAddr)
+	assert.EqualValues(t, big.NewInt(0), delegated)
+}
+
+func TestAccountStateDB_DelegateInactiveCandidate(t *testing.T) {
+	db, root := createTestStateDB()
+	processor, err := NewAccountStateDB(root, NewStateStorageWithCache(db))
+	assert.NoError(t, err)
+
+	tx := getTestDelegationTx(t, model.NewDelegateTransaction(0, aliceAddr, big.NewInt(100), big.NewInt(10)))
+	assert.Equal(t, CandidateNotActiveErr, processor.ProcessTx(tx, 1))
+
+	assert.NoError(t, processor.Stake(aliceAddr, big.NewInt(1000)))
+	assert.NoError(t, processor.SetLastElect(aliceAddr, 3))
+	tx = getTestDelegationTx(t, model.NewDelegateTransaction(1, aliceAddr, big.NewInt(100), big.NewInt(10)))
+	assert.Equal(t, CandidateNotActiveErr, processor.ProcessTx(tx, 4))
+}
+
+func TestAccountStateDB_RevertDelegation(t *testing.T) {
+	defer setTestMiniDelegationValue()()
+	db, root := createTestStateDB()
+	processor, err := NewAccountStateDB(root, NewStateStorageWithCache(db))
+	assert.NoError(t, err)
+	assert.NoError(t, processor.Stake(aliceAddr, big.NewInt(1000)))
+	before := processor.blockStateTrie.Hash()
+
+	snapshot := processor.Snapshot()
+	assert.NoError(t, processor.SetCommission(aliceAddr, 50))
+	assert.NoError(t, processor.Delegate(bobAddr, aliceAddr, big.NewInt(100)))
+	assert.NotEqual(t, before, processor.blockStateTrie.Hash())
+
+	processor.RevertToSnapshot(snapshot)
+	assert.Equal(t, before, processor.blockStateTrie.Hash())
+	delegation, _ := processor.GetDelegation(bobAddr, aliceAddr)
+	assert.EqualValues(t, big.NewInt(0), delegation.Bonded)
+	commission, _ := processor.GetCommission(aliceAddr)
+	assert.EqualValues(t, 0, commission)
+}
+
+func TestDecodeCommission(t *testing.T) {
+	tx := model.NewRegisterTransactionWithCommission(0, big.NewInt(100), big.NewInt(10), 200)
+	commission, declared, err := DecodeCommission(tx.ExtraData())
+	assert.NoError(t, err)
+	assert.True(t, declared)
+	assert.EqualValues(t, 200, commission)
+
+	_, declared, err = DecodeCommission(nil)
+	assert.NoError(t, err)
+	assert.False(t, declared)
+
+	tx = model.NewRegisterTransactionWithCommission(0, big.NewInt(100), big.NewInt(10), model.MaxCommission+1)
+	_, _, err = DecodeCommission(tx.ExtraData())
+	assert.Equal(t, InvalidCommissionErr, err)
+}
+
+func TestAccountStateDB_DelegationLimits(t *testing.T) {
+	defer setTestMiniDelegationValue()()
+	defer func(max int) { maxDelegators = max }(maxDelegators)
+	maxDelegators = 1
+
+	db, root := createTestStateDB()
+	processor, err := NewAccountStateDB(root, NewStateStorageWithCache(db))
+	assert.NoError(t, err)
+	assert.NoError(t, processor.Stake(aliceAddr, big.NewInt(1000)))
+
+	assert.Equal(t, TooLowDelegationErr, processor.Delegate(bobAddr, aliceAddr, big.NewInt(40)))
+	assert.NoError(t, processor.Delegate(bobAddr, aliceAddr, big.NewInt(60)))
+	// the top up can be lower than the minimum
+	assert.NoError(t, processor.Delegate(bobAddr, aliceAddr, big.NewInt(10)))
+
+	// the rest must be zero or not lower than the minimum
+	assert.Equal(t, TooLowDelegationErr, processor.UnDelegate(bobAddr, aliceAddr, big.NewInt(30), 1))
+	assert.NoError(t, processor.UnDelegate(bobAddr, aliceAddr, big.NewInt(20), 1))
+
+	charlie := common.HexToAddress("0x0000000000000000000000000000000000000000aaaa")
+	assert.NoError(t, processor.NewAccountState(charlie))
+	assert.NoError(t, processor.AddBalance(charlie, big.NewInt(100)))
+	assert.Equal(t, TooManyDelegatorsErr, processor.Delegate(charlie, aliceAddr, big.NewInt(100)))
+
+	// the delegator is removed after the whole delegation is withdrawn
+	assert.NoError(t, processor.UnDelegate(bobAddr, aliceAddr, big.NewInt(50), 2))
+	assert.NoError(t, processor.WithdrawDelegation(bobAddr, aliceAddr))
+	assert.NoError(t, processor.Delegate(charlie, aliceAddr, big.NewInt(100)))
+}
+
+func TestAccountStateDB_CommissionHeight(t *testing.T) {
+	config := chain_config.GetChainConfig()
+	defer func(height uint64) { config.CommissionHeight = height }(config.CommissionHeight)
+	config.CommissionHeight = 5
+
+	db, root := createTestStateDB()
+	processor, err := NewAccountStateDB(root, NewStateStorageWithCache(db))
+	assert.NoError(t, err)
+
+	// the extra data isn't decoded before the commission height
+	tx := getTestDelegationTx(t, model.NewRegisterTransactionWithCommission(0, big.NewInt(100), big.NewInt(10), model.MaxCommission+1))
+	assert.NoError(t, processor.processStakeTx(tx, 4))
+	commission, _ := processor.GetCommission(bobAddr)
+	assert.EqualValues(t, 0, commission)
+
+	tx = getTestDelegationTx(t, model.NewRegisterTransactionWithCommission(1, big.NewInt(10), big.NewInt(10), model.MaxCommission+1))
+	assert.Equal(t, InvalidCommissionErr, processor.processStakeTx(tx, 5))
+	tx = getTestDelegationTx(t, model.NewRegisterTransactionWithCommission(1, big.NewInt(10), big.NewInt(10), 200))
+	assert.NoError(t, processor.processStakeTx(tx, 5))
+	commission, _ = processor.GetCommission(bobAddr)
+	assert.EqualValues(t, 200, commission)
+}
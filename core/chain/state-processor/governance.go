@@ -0,0 +1,356 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_processor
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/log/pbft_log"
+	"github.com/ethereum/go-ethereum/rlp"
+	"time"
+)
+
+var (
+	UnknownGovernanceParamErr     = errors.New("unknown governance parameter")
+	InvalidGovernanceValueErr     = errors.New("invalid governance parameter value")
+	GovernanceProposalExistErr    = errors.New("the governance proposal already exists")
+	GovernanceProposalNotFoundErr = errors.New("the governance proposal is not found")
+	GovernanceProposalPassedErr   = errors.New("the governance proposal has been passed")
+	GovernanceAlreadyVotedErr     = errors.New("the verifier has voted for the governance proposal")
+)
+
+// the consensus parameters which can be changed by governance
+const (
+	GovernanceParamVerifierNumber = "VerifierNumber"
+	GovernanceParamSlotSize       = "SlotSize"
+	GovernanceParamStakeLockSlot  = "StakeLockSlot"
+	// the value is in seconds
+	GovernanceParamBlockTimeRestriction = "BlockTimeRestriction"
+)
+
+// The proposals and the passed parameter changes are saved in a dedicated trie, only its root is saved in the
+// state trie as an optional value, so the state root keeps the same before the first proposal.
+const (
+	governanceRootKeySuffix = "_governance_root"
+	proposalKeyPrefix       = "proposal_"
+	paramKeyPrefix          = "param_"
+)
+
+// GovernanceProposalState is the proposal saved in the governance trie
+type GovernanceProposalState struct {
+	Param          string
+	Value          uint64
+	ActivationSlot uint64
+	Proposer       common.Address
+	Votes          []common.Address
+	Passed         bool
+}
+
+// ParamChange is a passed proposal of a parameter, the value is used from the activation slot
+type ParamChange struct {
+	Value          uint64
+	ActivationSlot uint64
+}
+
+func governanceAddress() common.Address {
+	return common.HexToAddress(common.AddressGovernanceProposal)
+}
+
+func GetGovernanceRootKey() []byte {
+	addr := governanceAddress()
+	return append(addr[:], []byte(governanceRootKeySuffix)...)
+}
+
+func getProposalKey(id common.Hash) []byte {
+	return append([]byte(proposalKeyPrefix), id[:]...)
+}
+
+func getParamKey(param string) []byte {
+	return []byte(paramKeyPrefix + param)
+}
+
+// CheckGovernanceParam check whether the param can be governed and the value is valid for it
+func CheckGovernanceParam(param string, value uint64) error {
+	switch param {
+	case GovernanceParamVerifierNumber, GovernanceParamSlotSize, GovernanceParamBlockTimeRestriction:
+		if value == 0 {
+			return InvalidGovernanceValueErr
+		}
+	case GovernanceParamStakeLockSlot:
+	default:
+		return UnknownGovernanceParamErr
+	}
+	return nil
+}
+
+// DecodeGovernanceProposal decode the proposal in the extra data of the governance proposal tx
+func DecodeGovernanceProposal(extraData []byte) (*model.GovernanceProposal, error) {
+	var proposal model.GovernanceProposal
+	if err := rlp.DecodeBytes(extraData, &proposal); err != nil {
+		return nil, err
+	}
+	if err := CheckGovernanceParam(proposal.Param, proposal.Value); err != nil {
+		return nil, err
+	}
+	return &proposal, nil
+}
+
+// DecodeGovernanceVote decode the proposal id in the extra data of the governance vote tx
+func DecodeGovernanceVote(extraData []byte) (common.Hash, error) {
+	if len(extraData) != common.HashLength {
+		return common.Hash{}, GovernanceProposalNotFoundErr
+	}
+	return common.BytesToHash(extraData), nil
+}
+
+func (state *AccountStateDB) getGovernanceTrie() (StateTrie, error) {
+	root, err := state.blockStateTrie.TryGet(GetGovernanceRootKey())
+	if err != nil {
+		return nil, err
+	}
+	return state.storage.OpenTrie(common.BytesToHash(root))
+}
+
+func (state *AccountStateDB) getGovernanceValue(key []byte, res interface{}) (bool, error) {
+	t, err := state.getGovernanceTrie()
+	if err != nil {
+		return false, err
+	}
+	enc, err := t.TryGet(key)
+	if err != nil {
+		return false, err
+	}
+	if len(enc) == 0 {
+		return false, nil
+	}
+	return true, rlp.DecodeBytes(enc, res)
+}
+
+// the governance trie is committed to the trie db at once, so the root saved in the state trie can be reverted
+// like the other optional values
+func (state *AccountStateDB) putGovernanceValue(key []byte, value interface{}) error {
+	t, err := state.getGovernanceTrie()
+	if err != nil {
+		return err
+	}
+	enc, err := rlp.EncodeToBytes(value)
+	if err != nil {
+		return err
+	}
+	if err = t.TryUpdate(key, enc); err != nil {
+		return err
+	}
+	root, err := t.Commit(nil)
+	if err != nil {
+		return err
+	}
+	return state.putOptionalValue(governanceAddress(), GetGovernanceRootKey(), root.Bytes())
+}
+
+func (state *AccountStateDB) commitGovernanceTrie() error {
+	root, err := state.blockStateTrie.TryGet(GetGovernanceRootKey())
+	if err != nil || len(root) == 0 {
+		return err
+	}
+	return CommitTrie(state.storage, common.BytesToHash(root))
+}
+
+// GetGovernanceProposal return GovernanceProposalNotFoundErr if there is no proposal with the id
+func (state *AccountStateDB) GetGovernanceProposal(id common.Hash) (*GovernanceProposalState, error) {
+	var res GovernanceProposalState
+	found, err := state.getGovernanceValue(getProposalKey(id), &res)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, GovernanceProposalNotFoundErr
+	}
+	return &res, nil
+}
+
+// GetParamChanges return the passed changes of the param in the order of the activation slot
+func (state *AccountStateDB) GetParamChanges(param string) ([]ParamChange, error) {
+	var res []ParamChange
+	if _, err := state.getGovernanceValue(getParamKey(param), &res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+// GetGovernedChainConfig return the base config with the governed parameters which are active at the slot
+func (state *AccountStateDB) GetGovernedChainConfig(base chain_config.ChainConfig, slot uint64) (chain_config.ChainConfig, error) {
+	for _, param := range []string{GovernanceParamVerifierNumber, GovernanceParamSlotSize, GovernanceParamStakeLockSlot, GovernanceParamBlockTimeRestriction} {
+		changes, err := state.GetParamChanges(param)
+		if err != nil {
+			return base, err
+		}
+
+		found := false
+		var value uint64
+		for _, c := range changes {
+			if c.ActivationSlot > slot {
+				break
+			}
+			found = true
+			value = c.Value
+		}
+		if !found {
+			continue
+		}
+
+		switch param {
+		case GovernanceParamVerifierNumber:
+			base.VerifierNumber = int(value)
+		case GovernanceParamSlotSize:
+			base.SlotSize = value
+		case GovernanceParamStakeLockSlot:
+			base.StakeLockSlot = value
+		case GovernanceParamBlockTimeRestriction:
+			base.BlockTimeRestriction = time.Duration(value) * time.Second
+		}
+	}
+	return base, nil
+}
+
+// The votes are counted against the verifier number in force before the proposal takes effect,
+// the voters are checked to be the current verifiers by the tx validator.
+func (state *AccountStateDB) governanceThreshold(activationSlot uint64) (int, error) {
+	config := chain_config.GetChainConfig()
+	if activationSlot == 0 {
+		return config.VerifierNumber*2/3 + 1, nil
+	}
+	governed, err := state.GetGovernedChainConfig(*config, activationSlot-1)
+	if err != nil {
+		return 0, err
+	}
+	return governed.VerifierNumber*2/3 + 1, nil
+}
+
+func (state *AccountStateDB) addGovernanceVote(id common.Hash, proposal *GovernanceProposalState, voter common.Address) error {
+	for _, v := range proposal.Votes {
+		if v.IsEqual(voter) {
+			return GovernanceAlreadyVotedErr
+		}
+	}
+	proposal.Votes = append(proposal.Votes, voter)
+
+	threshold, err := state.governanceThreshold(proposal.ActivationSlot)
+	if err != nil {
+		return err
+	}
+	if len(proposal.Votes) >= threshold {
+		proposal.Passed = true
+		if err = state.addParamChange(proposal.Param, ParamChange{Value: proposal.Value, ActivationSlot: proposal.ActivationSlot}); err != nil {
+			return err
+		}
+		pbft_log.Info("governance proposal passed", "param", proposal.Param, "value", proposal.Value, "activation slot", proposal.ActivationSlot)
+	}
+	return state.putGovernanceValue(getProposalKey(id), proposal)
+}
+
+// keep the changes ordered by the activation slot, the later passed one wins if the activation slots are the same
+func (state *AccountStateDB) addParamChange(param string, change ParamChange) error {
+	changes, err := state.GetParamChanges(param)
+	if err != nil {
+		return err
+	}
+	index := len(changes)
+	for index > 0 && changes[index-1].ActivationSlot > change.ActivationSlot {
+		index--
+	}
+	changes = append(changes[:index], append([]ParamChange{change}, changes[index:]...)...)
+	return state.putGovernanceValue(getParamKey(param), changes)
+}
+
+/*
+Save a new proposal with the id, the proposer votes for it at once
+*/
+func (state *AccountStateDB) ProposeGovernance(proposer common.Address, id common.Hash, proposal *model.GovernanceProposal) error {
+	if err := CheckGovernanceParam(proposal.Param, proposal.Value); err != nil {
+		return err
+	}
+	if _, err := state.GetGovernanceProposal(id); err == nil {
+		return GovernanceProposalExistErr
+	} else if err != GovernanceProposalNotFoundErr {
+		return err
+	}
+	p := &GovernanceProposalState{
+		Param:          proposal.Param,
+		Value:          proposal.Value,
+		ActivationSlot: proposal.ActivationSlot,
+		Proposer:       proposer,
+	}
+	return state.addGovernanceVote(id, p, proposer)
+}
+
+/*
+Vote for the proposal, the proposal is passed once it gets more than 2/3 votes of the verifiers
+*/
+func (state *AccountStateDB) VoteGovernance(voter common.Address, id common.Hash) error {
+	proposal, err := state.GetGovernanceProposal(id)
+	if err != nil {
+		return err
+	}
+	if proposal.Passed {
+		return GovernanceProposalPassedErr
+	}
+	return state.addGovernanceVote(id, proposal, voter)
+}
+
+/*
+Process governance proposal tx, the proposal id is the tx id
+*/
+func (state *AccountStateDB) processGovernanceProposalTx(tx model.AbstractTransaction) (err error) {
+	sender, _ := tx.Sender(nil)
+	if tx.To().GetAddressType() != common.AddressTypeGovernanceProposal {
+		return TransactionTypeError
+	}
+	proposal, err := DecodeGovernanceProposal(tx.ExtraData())
+	if err != nil {
+		return
+	}
+
+	err = state.ProposeGovernance(sender, tx.CalTxId(), proposal)
+	if err != nil {
+		return
+	}
+	pbft_log.Info("success process a governance proposal transaction", "tx hash", tx.CalTxId().Hex())
+	return
+}
+
+/*
+Process governance vote tx
+*/
+func (state *AccountStateDB) processGovernanceVoteTx(tx model.AbstractTransaction) (err error) {
+	sender, _ := tx.Sender(nil)
+	if tx.To().GetAddressType() != common.AddressTypeGovernanceVote {
+		return TransactionTypeError
+	}
+	id, err := DecodeGovernanceVote(tx.ExtraData())
+	if err != nil {
+		return
+	}
+
+	err = state.VoteGovernance(sender, id)
+	if err != nil {
+		return
+	}
+	pbft_log.Info("success process a governance vote transaction", "tx hash", tx.CalTxId().Hex())
+	return
+}
@@ -0,0 +1,145 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_processor
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestAccountStateDB_Governance(t *testing.T) {
+	config := chain_config.GetChainConfig()
+	verifierNumber := config.VerifierNumber
+	config.VerifierNumber = 4
+	defer func() { config.VerifierNumber = verifierNumber }()
+
+	db, root := createTestStateDB()
+	processor, err := NewAccountStateDB(root, NewStateStorageWithCache(db))
+	assert.NoError(t, err)
+	preRoot, _ := processor.Finalise()
+	processor, _ = NewAccountStateDB(root, NewStateStorageWithCache(db))
+
+	verifiers := []common.Address{aliceAddr, bobAddr, common.HexToAddress("0x1234"), common.HexToAddress("0x5678")}
+	id := common.HexToHash("0x01")
+	proposal := &model.GovernanceProposal{Param: GovernanceParamSlotSize, Value: 50, ActivationSlot: 10}
+	assert.Equal(t, GovernanceProposalNotFoundErr, processor.VoteGovernance(bobAddr, id))
+	assert.Equal(t, UnknownGovernanceParamErr, processor.ProposeGovernance(aliceAddr, id, &model.GovernanceProposal{Param: "SlotMargin", Value: 1}))
+	assert.Equal(t, InvalidGovernanceValueErr, processor.ProposeGovernance(aliceAddr, id, &model.GovernanceProposal{Param: GovernanceParamSlotSize}))
+
+	// revert the proposal
+	snapshot := processor.Snapshot()
+	assert.NoError(t, processor.ProposeGovernance(aliceAddr, id, proposal))
+	processor.RevertToSnapshot(snapshot)
+	_, err = processor.GetGovernanceProposal(id)
+	assert.Equal(t, GovernanceProposalNotFoundErr, err)
+	curRoot, _ := processor.Finalise()
+	assert.Equal(t, preRoot, curRoot)
+
+	// 3 of 4 verifiers must vote for the proposal
+	processor, _ = NewAccountStateDB(root, NewStateStorageWithCache(db))
+	assert.NoError(t, processor.ProposeGovernance(aliceAddr, id, proposal))
+	assert.Equal(t, GovernanceProposalExistErr, processor.ProposeGovernance(aliceAddr, id, proposal))
+	assert.NoError(t, processor.VoteGovernance(verifiers[1], id))
+	assert.Equal(t, GovernanceAlreadyVotedErr, processor.VoteGovernance(verifiers[1], id))
+	p, err := processor.GetGovernanceProposal(id)
+	assert.NoError(t, err)
+	assert.False(t, p.Passed)
+	assert.Equal(t, aliceAddr, p.Proposer)
+
+	assert.NoError(t, processor.VoteGovernance(verifiers[2], id))
+	assert.Equal(t, GovernanceProposalPassedErr, processor.VoteGovernance(verifiers[3], id))
+	p, err = processor.GetGovernanceProposal(id)
+	assert.NoError(t, err)
+	assert.True(t, p.Passed)
+	assert.Equal(t, verifiers[:3], p.Votes)
+
+	// the proposal is saved in the governance trie after commit
+	root, err = processor.Commit()
+	assert.NoError(t, err)
+	processor, err = NewAccountStateDB(root, NewStateStorageWithCache(db))
+	assert.NoError(t, err)
+
+	governed, err := processor.GetGovernedChainConfig(*config, 9)
+	assert.NoError(t, err)
+	assert.Equal(t, config.SlotSize, governed.SlotSize)
+	governed, err = processor.GetGovernedChainConfig(*config, 10)
+	assert.NoError(t, err)
+	assert.EqualValues(t, 50, governed.SlotSize)
+	assert.Equal(t, config.VerifierNumber, governed.VerifierNumber)
+}
+
+func TestAccountStateDB_GovernanceParamChanges(t *testing.T) {
+	config := chain_config.GetChainConfig()
+	verifierNumber := config.VerifierNumber
+	config.VerifierNumber = 1
+	defer func() { config.VerifierNumber = verifierNumber }()
+
+	db, root := createTestStateDB()
+	processor, err := NewAccountStateDB(root, NewStateStorageWithCache(db))
+	assert.NoError(t, err)
+
+	// one verifier passes the proposals at once
+	assert.NoError(t, processor.ProposeGovernance(aliceAddr, common.HexToHash("0x01"), &model.GovernanceProposal{Param: GovernanceParamBlockTimeRestriction, Value: 30, ActivationSlot: 20}))
+	assert.NoError(t, processor.ProposeGovernance(aliceAddr, common.HexToHash("0x02"), &model.GovernanceProposal{Param: GovernanceParamBlockTimeRestriction, Value: 20, ActivationSlot: 10}))
+	assert.NoError(t, processor.ProposeGovernance(aliceAddr, common.HexToHash("0x03"), &model.GovernanceProposal{Param: GovernanceParamStakeLockSlot, Value: 0, ActivationSlot: 10}))
+	changes, err := processor.GetParamChanges(GovernanceParamBlockTimeRestriction)
+	assert.NoError(t, err)
+	assert.Equal(t, []ParamChange{{Value: 20, ActivationSlot: 10}, {Value: 30, ActivationSlot: 20}}, changes)
+
+	governed, err := processor.GetGovernedChainConfig(*config, 15)
+	assert.NoError(t, err)
+	assert.Equal(t, 20*time.Second, governed.BlockTimeRestriction)
+	assert.EqualValues(t, 0, governed.StakeLockSlot)
+	governed, err = processor.GetGovernedChainConfig(*config, 20)
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, governed.BlockTimeRestriction)
+}
+
+func TestAccountStateDB_ProcessGovernanceTx(t *testing.T) {
+	config := chain_config.GetChainConfig()
+	verifierNumber := config.VerifierNumber
+	config.VerifierNumber = 2
+	defer func() { config.VerifierNumber = verifierNumber }()
+
+	db, root := createTestStateDB()
+	processor, err := NewAccountStateDB(root, NewStateStorageWithCache(db))
+	assert.NoError(t, err)
+
+	key1, _ := createKey()
+	tx := model.NewGovernanceProposalTransaction(1, GovernanceParamVerifierNumber, 3, 10, big.NewInt(10))
+	tx, err = tx.SignTx(key1, model.NewMercurySigner(big.NewInt(1)))
+	assert.NoError(t, err)
+	assert.NoError(t, processor.ProcessTx(tx, 1))
+
+	vote := getTestDelegationTx(t, model.NewGovernanceVoteTransaction(0, tx.CalTxId(), big.NewInt(10)))
+	assert.NoError(t, processor.ProcessTx(vote, 2))
+	p, err := processor.GetGovernanceProposal(tx.CalTxId())
+	assert.NoError(t, err)
+	assert.True(t, p.Passed)
+
+	governed, err := processor.GetGovernedChainConfig(*config, 10)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, governed.VerifierNumber)
+
+	tx = getTestDelegationTx(t, model.NewGovernanceProposalTransaction(1, "SlotMargin", 3, 10, big.NewInt(10)))
+	assert.Equal(t, UnknownGovernanceParamErr, processor.ProcessTx(tx, 3))
+}
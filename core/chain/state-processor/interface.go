@@ -78,4 +78,7 @@ type AccountStateProcessor interface {
 	PutContract(addr common.Address, v reflect.Value) error
 	GetContract(addr common.Address, vType reflect.Type) (v reflect.Value, err error)
 	ContractExist(addr common.Address) bool
+
+	IsEmptyAccount(addr common.Address) bool
+	LoadAccount(account *DumpAccount) error
 }
@@ -18,6 +18,7 @@
 package state_processor
 
 import (
+	"bytes"
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/ethereum/go-ethereum/rlp"
 	"math/big"
@@ -121,6 +122,10 @@ func (scl *StateChangeList) DecodeRLP(s *rlp.Stream) (err error) {
 			var change deleteAccountChange
 			rlp.DecodeBytes(state.StateChange, &change)
 			scl.append(change)
+		case OptionalValueChange:
+			var change optionalValueChange
+			rlp.DecodeBytes(state.StateChange, &change)
+			scl.append(change)
 		default:
 			panic("no type")
 		}
@@ -263,6 +268,9 @@ const (
 	LastElectChange
 
 	DeleteAccountChange
+
+	// optional values are not account fields, they are kept after the account change types
+	OptionalValueChange
 )
 
 type (
@@ -341,6 +349,14 @@ type (
 		Current    uint64
 		ChangeType uint64
 	}
+	// the raw trie value of an optional key, an empty value means the key doesn't exist
+	optionalValueChange struct {
+		Account    *common.Address
+		Key        []byte
+		Prev       []byte
+		Current    []byte
+		ChangeType uint64
+	}
 )
 
 func (sc deleteAccountChange) revert(s *AccountStateDB) {
@@ -647,3 +663,29 @@ func (sc nonceChange) digest(change StateChange) StateChange {
 	}
 	return nil
 }
+
+func (sc optionalValueChange) revert(s *AccountStateDB) {
+	s.setOptionalValue(sc.Key, sc.Prev)
+}
+
+func (sc optionalValueChange) recover(s *AccountStateDB) {
+	s.setOptionalValue(sc.Key, sc.Current)
+}
+
+func (sc optionalValueChange) dirtied() *common.Address {
+	return sc.Account
+}
+
+func (sc optionalValueChange) getType() int {
+	return int(sc.ChangeType)
+}
+func (sc optionalValueChange) digest(change StateChange) StateChange {
+	if change.getType() == OptionalValueChange {
+		c := change.(optionalValueChange)
+		if !bytes.Equal(c.Key, sc.Key) {
+			panic("digest optional value changes of different keys")
+		}
+		return optionalValueChange{Account: sc.Account, Key: sc.Key, Prev: c.Prev, Current: sc.Current, ChangeType: OptionalValueChange}
+	}
+	return nil
+}
@@ -0,0 +1,536 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"reflect"
+	"sort"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/third-party/trie"
+	"github.com/ethereum/go-ethereum/rlp"
+)
+
+// DumpAccount is all the state of an account, the contract is the kv of the contract data if the account is a contract
+type DumpAccount struct {
+	Address     common.Address `json:"address"`
+	Nonce       uint64         `json:"nonce"`
+	Balance     *hexutil.Big   `json:"balance"`
+	Stake       *hexutil.Big   `json:"stake"`
+	Performance uint64         `json:"performance"`
+	CommitNum   uint64         `json:"commit_num"`
+	VerifyNum   uint64         `json:"verify_num"`
+	LastElect   uint64         `json:"last_elect"`
+	HashLock    common.Hash    `json:"hash_lock"`
+	TimeLock    *hexutil.Big   `json:"time_lock"`
+	DataRoot    common.Hash    `json:"data_root"`
+
+	// the optional values of a verifier candidate, the delegations to it are dumped in the order of the delegators
+	UnStaking      *DumpUnStaking    `json:"unstaking,omitempty"`
+	Commission     uint64            `json:"commission,omitempty"`
+	DelegatedStake *hexutil.Big      `json:"delegated_stake,omitempty"`
+	Delegations    []*DumpDelegation `json:"delegations,omitempty"`
+
+	ContractRoot common.Hash       `json:"contract_root"`
+	Contract     map[string]string `json:"contract,omitempty"`
+	Governance   *DumpGovernance   `json:"governance,omitempty"`
+}
+
+// DumpUnStaking is the partially unStaked money of a verifier
+type DumpUnStaking struct {
+	Amount *hexutil.Big `json:"amount"`
+	Height uint64       `json:"height"`
+}
+
+// DumpDelegation is the delegation of a delegator to the dumped candidate
+type DumpDelegation struct {
+	Delegator    common.Address `json:"delegator"`
+	Bonded       *hexutil.Big   `json:"bonded"`
+	Unbonding    *hexutil.Big   `json:"unbonding"`
+	UnbondHeight uint64         `json:"unbond_height"`
+}
+
+// DumpGovernance is the governance trie, it's dumped with the governance address
+type DumpGovernance struct {
+	Proposals []*DumpProposal          `json:"proposals"`
+	Params    map[string][]ParamChange `json:"params"`
+}
+
+// DumpProposal is a proposal in the governance trie with its id
+type DumpProposal struct {
+	Id       common.Hash              `json:"id"`
+	Proposal *GovernanceProposalState `json:"proposal"`
+}
+
+// StateDump is a page of the accounts ordered by the trie, Next is the start of the next page and it's empty at the end
+type StateDump struct {
+	Root     common.Hash    `json:"root"`
+	Accounts []*DumpAccount `json:"accounts"`
+	Next     hexutil.Bytes  `json:"next,omitempty"`
+}
+
+// AccountDiff is an account changed between two states, From is nil if the account is created and To is nil if
+// it's deleted. The contract data isn't dumped, its change is shown by the contract root.
+type AccountDiff struct {
+	Address common.Address `json:"address"`
+	From    *DumpAccount   `json:"from"`
+	To      *DumpAccount   `json:"to"`
+}
+
+// StateDiff is a page of the changed accounts ordered by the address, Next is the first address of the next page
+// and it's nil at the end
+type StateDiff struct {
+	Accounts []*AccountDiff  `json:"accounts"`
+	Next     *common.Address `json:"next,omitempty"`
+}
+
+// DumpAccount returns all the state of the account, only the contract or the governance is dumped if there is
+// no account at the address
+func (state *AccountStateDB) DumpAccount(addr common.Address) (*DumpAccount, error) {
+	return state.dumpAccount(addr, true)
+}
+
+func (state *AccountStateDB) dumpAccount(addr common.Address, withContract bool) (*DumpAccount, error) {
+	account := &DumpAccount{Address: addr}
+	if !state.IsEmptyAccount(addr) {
+		if err := state.dumpAccountState(account); err != nil {
+			return nil, err
+		}
+	} else if !state.hasContract(addr) && !state.hasGovernance(addr) {
+		return nil, g_error.AccountNotExist
+	}
+
+	if state.hasContract(addr) {
+		contractRoot, err := state.blockStateTrie.TryGet(GetContractRootKey(addr))
+		if err != nil {
+			return nil, err
+		}
+		account.ContractRoot = common.BytesToHash(contractRoot)
+		if withContract {
+			if account.Contract, err = state.getContractKV(addr); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if state.hasGovernance(addr) {
+		var err error
+		if account.Governance, err = state.dumpGovernance(); err != nil {
+			return nil, err
+		}
+	}
+	return account, nil
+}
+
+func (state *AccountStateDB) dumpAccountState(account *DumpAccount) error {
+	addr := account.Address
+	var err error
+	if account.Nonce, err = state.GetNonce(addr); err != nil {
+		return err
+	}
+	balance, err := state.GetBalance(addr)
+	if err != nil {
+		return err
+	}
+	stake, err := state.GetStake(addr)
+	if err != nil {
+		return err
+	}
+	timeLock, err := state.GetTimeLock(addr)
+	if err != nil {
+		return err
+	}
+	account.Balance, account.Stake, account.TimeLock = (*hexutil.Big)(balance), (*hexutil.Big)(stake), (*hexutil.Big)(timeLock)
+	if account.Performance, err = state.GetPerformance(addr); err != nil {
+		return err
+	}
+	if account.CommitNum, err = state.GetCommitNum(addr); err != nil {
+		return err
+	}
+	if account.VerifyNum, err = state.GetVerifyNum(addr); err != nil {
+		return err
+	}
+	if account.LastElect, err = state.GetLastElect(addr); err != nil {
+		return err
+	}
+	if account.HashLock, err = state.GetHashLock(addr); err != nil {
+		return err
+	}
+	if account.DataRoot, err = state.GetDataRoot(addr); err != nil {
+		return err
+	}
+
+	unStaking, err := state.GetUnStaking(addr)
+	if err != nil {
+		return err
+	}
+	if unStaking.Amount.Sign() > 0 {
+		account.UnStaking = &DumpUnStaking{Amount: (*hexutil.Big)(unStaking.Amount), Height: unStaking.Height}
+	}
+	if account.Commission, err = state.GetCommission(addr); err != nil {
+		return err
+	}
+	delegated, err := state.GetDelegatedStake(addr)
+	if err != nil {
+		return err
+	}
+	if delegated.Sign() > 0 {
+		account.DelegatedStake = (*hexutil.Big)(delegated)
+	}
+	delegators, err := state.GetDelegators(addr)
+	if err != nil {
+		return err
+	}
+	for _, delegator := range delegators {
+		d, err := state.GetDelegation(delegator, addr)
+		if err != nil {
+			return err
+		}
+		account.Delegations = append(account.Delegations, &DumpDelegation{
+			Delegator:    delegator,
+			Bonded:       (*hexutil.Big)(d.Bonded),
+			Unbonding:    (*hexutil.Big)(d.Unbonding),
+			UnbondHeight: d.UnbondHeight,
+		})
+	}
+	return nil
+}
+
+func (state *AccountStateDB) hasContract(addr common.Address) bool {
+	contractRoot, err := state.blockStateTrie.TryGet(GetContractRootKey(addr))
+	return err == nil && len(contractRoot) > 0
+}
+
+func (state *AccountStateDB) hasGovernance(addr common.Address) bool {
+	if !addr.IsEqual(governanceAddress()) {
+		return false
+	}
+	root, err := state.blockStateTrie.TryGet(GetGovernanceRootKey())
+	return err == nil && len(root) > 0
+}
+
+// the proposals and the param changes in the governance trie
+func (state *AccountStateDB) dumpGovernance() (*DumpGovernance, error) {
+	t, err := state.getGovernanceTrie()
+	if err != nil {
+		return nil, err
+	}
+	res := &DumpGovernance{Proposals: []*DumpProposal{}, Params: map[string][]ParamChange{}}
+	it := trie.NewIterator(t.NodeIterator(nil))
+	for it.Next() {
+		key := t.GetKey(it.Key)
+		switch {
+		case bytes.HasPrefix(key, []byte(proposalKeyPrefix)):
+			var proposal GovernanceProposalState
+			if err = rlp.DecodeBytes(it.Value, &proposal); err != nil {
+				return nil, err
+			}
+			res.Proposals = append(res.Proposals, &DumpProposal{Id: common.BytesToHash(key[len(proposalKeyPrefix):]), Proposal: &proposal})
+		case bytes.HasPrefix(key, []byte(paramKeyPrefix)):
+			var changes []ParamChange
+			if err = rlp.DecodeBytes(it.Value, &changes); err != nil {
+				return nil, err
+			}
+			res.Params[string(key[len(paramKeyPrefix):])] = changes
+		}
+	}
+	return res, it.Err
+}
+
+// the address of the key if it's the nonce key of an account or the root key of a contract
+func addressOfKey(key []byte, suffix string) (common.Address, bool) {
+	if len(key) != common.AddressLength+len(suffix) || !bytes.HasSuffix(key, []byte(suffix)) {
+		return common.Address{}, false
+	}
+	return common.BytesToAddress(key[:common.AddressLength]), true
+}
+
+// iterate the accounts from the hashed key, fn returns false to stop at the account and the hashed key of it is returned.
+// The contracts without the account like the early token contract and the governance are iterated too.
+func (state *AccountStateDB) forEachAccount(start []byte, fn func(addr common.Address) (bool, error)) ([]byte, error) {
+	it := trie.NewIterator(state.blockStateTrie.NodeIterator(start))
+	for it.Next() {
+		key := state.blockStateTrie.GetKey(it.Key)
+		addr, ok := addressOfKey(key, nonceKeySuffix)
+		if !ok {
+			if addr, ok = addressOfKey(key, contractRootSuffix); !ok {
+				addr, ok = addressOfKey(key, governanceRootKeySuffix)
+			}
+			if !ok || !state.IsEmptyAccount(addr) {
+				continue
+			}
+		}
+		next, err := fn(addr)
+		if err != nil {
+			return nil, err
+		}
+		if !next {
+			return common.CopyBytes(it.Key), nil
+		}
+	}
+	return nil, it.Err
+}
+
+// DumpState returns at most max accounts from the hashed key start
+func (state *AccountStateDB) DumpState(start []byte, max int) (*StateDump, error) {
+	dump := &StateDump{Root: state.blockStateTrie.Hash(), Accounts: []*DumpAccount{}}
+	next, err := state.forEachAccount(start, func(addr common.Address) (bool, error) {
+		if len(dump.Accounts) >= max {
+			return false, nil
+		}
+		account, err := state.DumpAccount(addr)
+		if err != nil {
+			return false, err
+		}
+		dump.Accounts = append(dump.Accounts, account)
+		return true, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	dump.Next = next
+	return dump, nil
+}
+
+// Dump writes all the accounts to the writer as a json object, the accounts are written one by one
+func (state *AccountStateDB) Dump(w io.Writer) error {
+	root, err := json.Marshal(state.blockStateTrie.Hash())
+	if err != nil {
+		return err
+	}
+	if _, err := io.WriteString(w, `{"root":`+string(root)+`,"accounts":[`); err != nil {
+		return err
+	}
+
+	first := true
+	_, err = state.forEachAccount(nil, func(addr common.Address) (bool, error) {
+		account, err := state.DumpAccount(addr)
+		if err != nil {
+			return false, err
+		}
+		data, err := json.Marshal(account)
+		if err != nil {
+			return false, err
+		}
+		if !first {
+			data = append([]byte{','}, data...)
+		}
+		first = false
+		_, err = w.Write(append(data, '\n'))
+		return true, err
+	})
+	if err != nil {
+		return err
+	}
+	_, err = io.WriteString(w, "]}\n")
+	return err
+}
+
+// the addresses of the keys in b not in a, the key of the trie begins with the address.
+// The delegation is dumped with the candidate, so the candidate in the delegation key is changed too.
+func changedAddresses(a, b StateTrie, changed map[common.Address]struct{}) error {
+	diff, _ := trie.NewDifferenceIterator(a.NodeIterator(nil), b.NodeIterator(nil))
+	it := trie.NewIterator(diff)
+	for it.Next() {
+		key := b.GetKey(it.Key)
+		if len(key) > common.AddressLength {
+			changed[common.BytesToAddress(key[:common.AddressLength])] = struct{}{}
+		}
+		if len(key) == 2*common.AddressLength+len(delegationKeySuffix) && bytes.HasSuffix(key, []byte(delegationKeySuffix)) {
+			changed[common.BytesToAddress(key[common.AddressLength:2*common.AddressLength])] = struct{}{}
+		}
+	}
+	return it.Err
+}
+
+// DiffStates returns at most max accounts changed from the state from to the state to, the accounts are ordered by
+// the address from start. There is no limit if max is 0.
+func DiffStates(from, to *AccountStateDB, start common.Address, max int) (*StateDiff, error) {
+	changed := map[common.Address]struct{}{}
+	if err := changedAddresses(from.blockStateTrie, to.blockStateTrie, changed); err != nil {
+		return nil, err
+	}
+	if err := changedAddresses(to.blockStateTrie, from.blockStateTrie, changed); err != nil {
+		return nil, err
+	}
+	addresses := make([]common.Address, 0, len(changed))
+	for addr := range changed {
+		if bytes.Compare(addr[:], start[:]) >= 0 {
+			addresses = append(addresses, addr)
+		}
+	}
+	sort.Slice(addresses, func(i, j int) bool {
+		return bytes.Compare(addresses[i][:], addresses[j][:]) < 0
+	})
+
+	res := &StateDiff{Accounts: []*AccountDiff{}}
+	for i := range addresses {
+		addr := addresses[i]
+		if max > 0 && len(res.Accounts) >= max {
+			res.Next = &addr
+			break
+		}
+		diff := &AccountDiff{Address: addr}
+		var err error
+		if diff.From, err = from.dumpChangedAccount(addr); err != nil {
+			return nil, err
+		}
+		if diff.To, err = to.dumpChangedAccount(addr); err != nil {
+			return nil, err
+		}
+		// only the delegations of the address as a delegator may be changed, they are dumped with the candidate
+		if reflect.DeepEqual(diff.From, diff.To) {
+			continue
+		}
+		res.Accounts = append(res.Accounts, diff)
+	}
+	return res, nil
+}
+
+// the account without the contract data, it's nil if there is nothing at the address
+func (state *AccountStateDB) dumpChangedAccount(addr common.Address) (*DumpAccount, error) {
+	if state.IsEmptyAccount(addr) && !state.hasContract(addr) && !state.hasGovernance(addr) {
+		return nil, nil
+	}
+	return state.dumpAccount(addr, false)
+}
+
+// LoadAccount writes the dumped account to the state, the account isn't created if only the contract or the governance
+// is dumped
+func (state *AccountStateDB) LoadAccount(account *DumpAccount) error {
+	addr := account.Address
+	if account.Balance != nil {
+		if err := state.NewAccountState(addr); err != nil {
+			return err
+		}
+		if err := state.SetNonce(addr, account.Nonce); err != nil {
+			return err
+		}
+		if err := state.SetBalance(addr, account.Balance.ToInt()); err != nil {
+			return err
+		}
+		if account.Stake != nil {
+			if err := state.SetStake(addr, account.Stake.ToInt()); err != nil {
+				return err
+			}
+		}
+		if account.TimeLock != nil {
+			if err := state.SetTimeLock(addr, account.TimeLock.ToInt()); err != nil {
+				return err
+			}
+		}
+		if err := state.SetPerformance(addr, account.Performance); err != nil {
+			return err
+		}
+		if err := state.SetCommitNum(addr, account.CommitNum); err != nil {
+			return err
+		}
+		if err := state.SetVerifyNum(addr, account.VerifyNum); err != nil {
+			return err
+		}
+		if err := state.SetLastElect(addr, account.LastElect); err != nil {
+			return err
+		}
+		if err := state.SetHashLock(addr, account.HashLock); err != nil {
+			return err
+		}
+		if err := state.SetDataRoot(addr, account.DataRoot); err != nil {
+			return err
+		}
+		if err := state.loadVerifierData(account); err != nil {
+			return err
+		}
+	}
+	if account.Governance != nil {
+		if err := state.loadGovernance(account.Governance); err != nil {
+			return err
+		}
+	}
+	if len(account.Contract) == 0 {
+		return nil
+	}
+	return state.loadContractKV(addr, account.Contract)
+}
+
+// the unStaking, the commission and the delegations to the candidate
+func (state *AccountStateDB) loadVerifierData(account *DumpAccount) error {
+	addr := account.Address
+	if account.UnStaking != nil {
+		if err := state.setUnStaking(addr, &UnStaking{Amount: account.UnStaking.Amount.ToInt(), Height: account.UnStaking.Height}); err != nil {
+			return err
+		}
+	}
+	if err := state.SetCommission(addr, account.Commission); err != nil {
+		return err
+	}
+	if account.DelegatedStake != nil {
+		if err := state.setDelegatedStake(addr, account.DelegatedStake.ToInt()); err != nil {
+			return err
+		}
+	}
+	delegators := make([]common.Address, 0, len(account.Delegations))
+	for _, d := range account.Delegations {
+		delegation := &Delegation{Bonded: d.Bonded.ToInt(), Unbonding: d.Unbonding.ToInt(), UnbondHeight: d.UnbondHeight}
+		if err := state.setDelegation(d.Delegator, addr, delegation); err != nil {
+			return err
+		}
+		delegators = append(delegators, d.Delegator)
+	}
+	return state.setDelegators(addr, delegators)
+}
+
+func (state *AccountStateDB) loadGovernance(governance *DumpGovernance) error {
+	for _, p := range governance.Proposals {
+		if err := state.putGovernanceValue(getProposalKey(p.Id), p.Proposal); err != nil {
+			return err
+		}
+	}
+	for param, changes := range governance.Params {
+		if err := state.putGovernanceValue(getParamKey(param), changes); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// the contract type is unknown, so the kv is written to a new contract trie directly instead of the contract data,
+// the trie is committed to the disk with the other contracts in the commit
+func (state *AccountStateDB) loadContractKV(addr common.Address, kv map[string]string) error {
+	ct, err := state.contractTrieCache.OpenTrie(common.Hash{})
+	if err != nil {
+		return err
+	}
+	for k, v := range kv {
+		if err := ct.TryUpdate(GetContractFieldKey(addr, k), []byte(v)); err != nil {
+			return err
+		}
+	}
+	root, err := ct.Commit(nil)
+	if err != nil {
+		return err
+	}
+	if err := state.blockStateTrie.TryUpdate(GetContractRootKey(addr), root.Bytes()); err != nil {
+		return err
+	}
+	delete(state.contractData, addr)
+	state.finalisedContractRoot[addr] = root
+	return nil
+}
@@ -0,0 +1,264 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_processor
+
+import (
+	"bytes"
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAccountStateDB_DumpState(t *testing.T) {
+	storage := NewStateStorageWithCache(ethdb.NewMemDatabase())
+	processor, err := NewAccountStateDB(common.Hash{}, storage)
+	assert.NoError(t, err)
+
+	cAddr := common.HexToAddress("0x3213123af")
+	c := erc20{Owners: []string{"123"}, Name: "jk", Dis: 10002}
+	assert.NoError(t, processor.NewAccountState(aliceAddr))
+	assert.NoError(t, processor.AddBalance(aliceAddr, big.NewInt(5000)))
+	assert.NoError(t, processor.AddStake(aliceAddr, big.NewInt(30)))
+	assert.NoError(t, processor.NewAccountState(bobAddr))
+	assert.NoError(t, processor.NewAccountState(cAddr))
+	assert.NoError(t, processor.PutContract(cAddr, reflect.ValueOf(&c)))
+	root, err := processor.Commit()
+	assert.NoError(t, err)
+
+	state, err := NewAccountStateDB(root, storage)
+	assert.NoError(t, err)
+	account, err := state.DumpAccount(aliceAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(5000), account.Balance.ToInt())
+	assert.Equal(t, big.NewInt(30), account.Stake.ToInt())
+	assert.Equal(t, performanceInitial, account.Performance)
+	assert.Nil(t, account.Contract)
+	account, err = state.DumpAccount(cAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, "\"jk\"", account.Contract["name"])
+	_, err = state.DumpAccount(common.HexToAddress("0x123"))
+	assert.Error(t, err)
+
+	// page the accounts
+	var addresses []common.Address
+	dump, err := state.DumpState(nil, 2)
+	assert.NoError(t, err)
+	assert.Equal(t, root, dump.Root)
+	assert.Len(t, dump.Accounts, 2)
+	assert.NotEmpty(t, dump.Next)
+	for _, account := range dump.Accounts {
+		addresses = append(addresses, account.Address)
+	}
+	dump, err = state.DumpState(dump.Next, 2)
+	assert.NoError(t, err)
+	assert.Len(t, dump.Accounts, 1)
+	assert.Empty(t, dump.Next)
+	addresses = append(addresses, dump.Accounts[0].Address)
+	assert.ElementsMatch(t, []common.Address{aliceAddr, bobAddr, cAddr}, addresses)
+
+	buf := new(bytes.Buffer)
+	assert.NoError(t, state.Dump(buf))
+	var full StateDump
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &full))
+	assert.Equal(t, root, full.Root)
+	assert.Len(t, full.Accounts, 3)
+}
+
+func TestDiffStates(t *testing.T) {
+	storage := NewStateStorageWithCache(ethdb.NewMemDatabase())
+	processor, err := NewAccountStateDB(common.Hash{}, storage)
+	assert.NoError(t, err)
+	assert.NoError(t, processor.NewAccountState(aliceAddr))
+	assert.NoError(t, processor.NewAccountState(bobAddr))
+	root1, err := processor.Commit()
+	assert.NoError(t, err)
+
+	processor, err = NewAccountStateDB(root1, storage)
+	assert.NoError(t, err)
+	assert.NoError(t, processor.AddBalance(aliceAddr, big.NewInt(1)))
+	assert.NoError(t, processor.DeleteAccountState(bobAddr))
+	charlie := common.HexToAddress("0x1234")
+	assert.NoError(t, processor.NewAccountState(charlie))
+	root2, err := processor.Commit()
+	assert.NoError(t, err)
+
+	from, err := NewAccountStateDB(root1, storage)
+	assert.NoError(t, err)
+	to, err := NewAccountStateDB(root2, storage)
+	assert.NoError(t, err)
+	diff, err := DiffStates(from, to, common.Address{}, 0)
+	assert.NoError(t, err)
+	assert.Len(t, diff.Accounts, 3)
+	assert.Nil(t, diff.Next)
+	for _, diff := range diff.Accounts {
+		switch diff.Address {
+		case aliceAddr:
+			assert.Equal(t, big.NewInt(0), diff.From.Balance.ToInt())
+			assert.Equal(t, big.NewInt(1), diff.To.Balance.ToInt())
+		case bobAddr:
+			assert.NotNil(t, diff.From)
+			assert.Nil(t, diff.To)
+		case charlie:
+			assert.Nil(t, diff.From)
+			assert.NotNil(t, diff.To)
+		default:
+			t.Fatalf("unexpected diff %v", diff.Address.Hex())
+		}
+	}
+
+	// page the diff
+	page, err := DiffStates(from, to, common.Address{}, 2)
+	assert.NoError(t, err)
+	assert.Len(t, page.Accounts, 2)
+	assert.NotNil(t, page.Next)
+	page, err = DiffStates(from, to, *page.Next, 2)
+	assert.NoError(t, err)
+	assert.Len(t, page.Accounts, 1)
+	assert.Nil(t, page.Next)
+	assert.Equal(t, diff.Accounts[2], page.Accounts[0])
+
+	diff, err = DiffStates(to, to, common.Address{}, 0)
+	assert.NoError(t, err)
+	assert.Len(t, diff.Accounts, 0)
+}
+
+func TestDiffStates_VerifierData(t *testing.T) {
+	defer setTestMiniDelegationValue()()
+	storage := NewStateStorageWithCache(ethdb.NewMemDatabase())
+	processor, err := NewAccountStateDB(common.Hash{}, storage)
+	assert.NoError(t, err)
+	assert.NoError(t, processor.NewAccountState(aliceAddr))
+	assert.NoError(t, processor.AddBalance(aliceAddr, big.NewInt(5000)))
+	assert.NoError(t, processor.NewAccountState(bobAddr))
+	assert.NoError(t, processor.AddBalance(bobAddr, big.NewInt(5000)))
+	assert.NoError(t, processor.Stake(aliceAddr, big.NewInt(1000)))
+	assert.NoError(t, processor.Delegate(bobAddr, aliceAddr, big.NewInt(100)))
+	assert.NoError(t, processor.UnDelegate(bobAddr, aliceAddr, big.NewInt(100), 1))
+	root1, err := processor.Commit()
+	assert.NoError(t, err)
+
+	// only the unbonding delegation of bob, the commission, the unStaking and the governance are changed
+	processor, err = NewAccountStateDB(root1, storage)
+	assert.NoError(t, err)
+	assert.NoError(t, processor.WithdrawDelegation(bobAddr, aliceAddr))
+	assert.NoError(t, processor.SubBalance(bobAddr, big.NewInt(100)))
+	assert.NoError(t, processor.SetCommission(aliceAddr, 100))
+	assert.NoError(t, processor.setUnStaking(aliceAddr, &UnStaking{Amount: big.NewInt(10), Height: 2}))
+	assert.NoError(t, processor.addParamChange(GovernanceParamSlotSize, ParamChange{Value: 200, ActivationSlot: 3}))
+	root2, err := processor.Commit()
+	assert.NoError(t, err)
+
+	from, err := NewAccountStateDB(root1, storage)
+	assert.NoError(t, err)
+	to, err := NewAccountStateDB(root2, storage)
+	assert.NoError(t, err)
+	diff, err := DiffStates(from, to, common.Address{}, 0)
+	assert.NoError(t, err)
+	assert.Len(t, diff.Accounts, 2)
+	for _, diff := range diff.Accounts {
+		switch diff.Address {
+		case aliceAddr:
+			assert.Len(t, diff.From.Delegations, 1)
+			assert.Equal(t, big.NewInt(100), diff.From.Delegations[0].Unbonding.ToInt())
+			assert.Len(t, diff.To.Delegations, 0)
+			assert.EqualValues(t, 100, diff.To.Commission)
+			assert.Nil(t, diff.From.UnStaking)
+			assert.Equal(t, big.NewInt(10), diff.To.UnStaking.Amount.ToInt())
+		case governanceAddress():
+			assert.Nil(t, diff.From)
+			assert.Equal(t, []ParamChange{{Value: 200, ActivationSlot: 3}}, diff.To.Governance.Params[GovernanceParamSlotSize])
+		default:
+			t.Fatalf("unexpected diff %v", diff.Address.Hex())
+		}
+	}
+}
+
+func TestAccountStateDB_LoadAccount(t *testing.T) {
+	defer setTestMiniDelegationValue()()
+	storage := NewStateStorageWithCache(ethdb.NewMemDatabase())
+	processor, err := NewAccountStateDB(common.Hash{}, storage)
+	assert.NoError(t, err)
+
+	// the contract without the account is dumped too
+	cAddr := common.HexToAddress("0x3213123af")
+	c := erc20{Owners: []string{"123"}, Name: "jk", Dis: 10002}
+	assert.NoError(t, processor.NewAccountState(aliceAddr))
+	assert.NoError(t, processor.AddBalance(aliceAddr, big.NewInt(5000)))
+	assert.NoError(t, processor.SetNonce(aliceAddr, 3))
+	assert.NoError(t, processor.PutContract(cAddr, reflect.ValueOf(&c)))
+	// the verifier data, the delegations and the governance are dumped too
+	assert.NoError(t, processor.Stake(aliceAddr, big.NewInt(1000)))
+	assert.NoError(t, processor.SetCommission(aliceAddr, 100))
+	assert.NoError(t, processor.setUnStaking(aliceAddr, &UnStaking{Amount: big.NewInt(10), Height: 2}))
+	assert.NoError(t, processor.NewAccountState(bobAddr))
+	assert.NoError(t, processor.AddBalance(bobAddr, big.NewInt(500)))
+	assert.NoError(t, processor.Delegate(bobAddr, aliceAddr, big.NewInt(300)))
+	assert.NoError(t, processor.UnDelegate(bobAddr, aliceAddr, big.NewInt(100), 3))
+	assert.NoError(t, processor.addParamChange(GovernanceParamSlotSize, ParamChange{Value: 200, ActivationSlot: 3}))
+	assert.NoError(t, processor.putGovernanceValue(getProposalKey(common.HexToHash("0x12")), &GovernanceProposalState{Param: GovernanceParamVerifierNumber, Value: 4, ActivationSlot: 5, Proposer: aliceAddr, Votes: []common.Address{aliceAddr}}))
+	root, err := processor.Commit()
+	assert.NoError(t, err)
+
+	state, err := NewAccountStateDB(root, storage)
+	assert.NoError(t, err)
+	dump, err := state.DumpState(nil, 10)
+	assert.NoError(t, err)
+	assert.Len(t, dump.Accounts, 4)
+
+	// the dump is loaded from the json
+	data, err := json.Marshal(dump)
+	assert.NoError(t, err)
+	dump = &StateDump{}
+	assert.NoError(t, json.Unmarshal(data, dump))
+	contractAccount, err := state.DumpAccount(cAddr)
+	assert.NoError(t, err)
+	assert.Nil(t, contractAccount.Balance)
+	assert.Equal(t, "\"jk\"", contractAccount.Contract["name"])
+
+	// load the dump into another database
+	loadStorage := NewStateStorageWithCache(ethdb.NewMemDatabase())
+	loader, err := NewAccountStateDB(common.Hash{}, loadStorage)
+	assert.NoError(t, err)
+	for _, account := range dump.Accounts {
+		assert.NoError(t, loader.LoadAccount(account))
+	}
+	loadRoot, err := loader.Commit()
+	assert.NoError(t, err)
+	assert.Equal(t, root, loadRoot)
+
+	loaded, err := NewAccountStateDB(loadRoot, loadStorage)
+	assert.NoError(t, err)
+	nonce, err := loaded.GetNonce(aliceAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), nonce)
+	assert.True(t, loaded.IsEmptyAccount(cAddr))
+	cv, err := loaded.GetContract(cAddr, reflect.TypeOf(erc20{}))
+	assert.NoError(t, err)
+	assert.Equal(t, "jk", cv.Interface().(*erc20).Name)
+	delegation, err := loaded.GetDelegation(bobAddr, aliceAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(200), delegation.Bonded)
+	assert.Equal(t, big.NewInt(100), delegation.Unbonding)
+	proposal, err := loaded.GetGovernanceProposal(common.HexToHash("0x12"))
+	assert.NoError(t, err)
+	assert.Equal(t, []common.Address{aliceAddr}, proposal.Votes)
+}
@@ -0,0 +1,152 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_processor
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/dipperin/dipperin-core/third-party/trie"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+var (
+	KeyIterationNotSupportedErr = chaindb.ErrKeyIterationNotSupported
+)
+
+// PruneState deletes the trie nodes which can't be reached from the state roots and the register roots,
+// the contract tries and the governance tries of the states are kept too. The nodes are marked before
+// anything is deleted, so nothing is changed if a trie of the roots is incomplete. It returns the count
+// of the deleted nodes.
+func PruneState(db ethdb.Database, stateRoots, registerRoots []common.Hash) (int, error) {
+	live, err := markStateNodes(db, stateRoots, registerRoots)
+	if err != nil {
+		return 0, err
+	}
+	log.Info("mark state nodes finished", "live nodes", len(live))
+
+	deleted := 0
+	batch := db.NewBatch()
+	err = chaindb.ForEachKey(db, func(key []byte) error {
+		// the other data of the chain is saved with the prefixed keys
+		if len(key) != common.HashLength {
+			return nil
+		}
+		if _, ok := live[common.BytesToHash(key)]; ok {
+			return nil
+		}
+		if err := batch.Delete(key); err != nil {
+			return err
+		}
+		deleted++
+		if batch.ValueSize() >= ethdb.IdealBatchSize {
+			if err := batch.Write(); err != nil {
+				return err
+			}
+			batch.Reset()
+		}
+		return nil
+	})
+	if err != nil {
+		return deleted, err
+	}
+	return deleted, batch.Write()
+}
+
+// HasTrie reports whether the root node of the trie is in the database, the empty trie has no node
+func HasTrie(db ethdb.Database, root common.Hash) bool {
+	if root == emptyTrieRoot || root == (common.Hash{}) {
+		return true
+	}
+	ok, _ := db.Has(root.Bytes())
+	return ok
+}
+
+// VerifyTries walks all the nodes of the tries, it returns the error of the first missing node. The sub tries
+// of the state are not walked.
+func VerifyTries(db ethdb.Database, roots ...common.Hash) error {
+	trieDB := trie.NewDatabase(db)
+	live := map[common.Hash]struct{}{}
+	for _, root := range roots {
+		if err := markTrieNodes(trieDB, root, live, nil); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// find all the nodes of the tries, the sub tries are found with the preimages of the state trie leaves
+func markStateNodes(db ethdb.Database, stateRoots, registerRoots []common.Hash) (map[common.Hash]struct{}, error) {
+	trieDB := trie.NewDatabase(db)
+	live := map[common.Hash]struct{}{}
+
+	var subRoots []common.Hash
+	for _, root := range stateRoots {
+		err := markTrieNodes(trieDB, root, live, func(keyHash common.Hash, value []byte) error {
+			if len(value) != common.HashLength {
+				return nil
+			}
+			key, err := trieDB.Preimage(keyHash)
+			if err != nil {
+				return err
+			}
+			if IsSubTrieRootKey(key) {
+				subRoots = append(subRoots, common.BytesToHash(value))
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	for _, root := range append(registerRoots, subRoots...) {
+		if err := markTrieNodes(trieDB, root, live, nil); err != nil {
+			return nil, err
+		}
+	}
+	return live, nil
+}
+
+// the nodes already marked are skipped with their children, the leaves under them have been handled too
+func markTrieNodes(trieDB *trie.Database, root common.Hash, live map[common.Hash]struct{}, onLeaf func(keyHash common.Hash, value []byte) error) error {
+	if root == emptyTrieRoot || root == (common.Hash{}) {
+		return nil
+	}
+	t, err := trie.New(root, trieDB)
+	if err != nil {
+		return err
+	}
+
+	it := t.NodeIterator(nil)
+	for descend := true; it.Next(descend); {
+		descend = true
+		if hash := it.Hash(); hash != (common.Hash{}) {
+			if _, ok := live[hash]; ok {
+				descend = false
+				continue
+			}
+			live[hash] = struct{}{}
+		}
+		if it.Leaf() && onLeaf != nil {
+			if err := onLeaf(common.BytesToHash(it.LeafKey()), it.LeafBlob()); err != nil {
+				return err
+			}
+		}
+	}
+	return it.Error()
+}
@@ -0,0 +1,122 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_processor
+
+import (
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPruneState(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	storage := NewStateStorageWithCache(db)
+	processor, err := NewAccountStateDB(common.Hash{}, storage)
+	assert.NoError(t, err)
+
+	cAddr := common.HexToAddress("0x3213123af")
+	c := erc20{Owners: []string{"123"}, Name: "jk", Dis: 10002}
+	assert.NoError(t, processor.NewAccountState(aliceAddr))
+	assert.NoError(t, processor.AddBalance(aliceAddr, big.NewInt(5000)))
+	assert.NoError(t, processor.PutContract(cAddr, reflect.ValueOf(&c)))
+	proposalId := common.HexToHash("0x12")
+	assert.NoError(t, processor.putGovernanceValue(getProposalKey(proposalId), &GovernanceProposalState{Param: GovernanceParamSlotSize, Value: 20}))
+	root1, err := processor.Commit()
+	assert.NoError(t, err)
+
+	processor, err = NewAccountStateDB(root1, storage)
+	assert.NoError(t, err)
+	assert.NoError(t, processor.AddBalance(aliceAddr, big.NewInt(1)))
+	assert.NoError(t, processor.NewAccountState(bobAddr))
+	root2, err := processor.Commit()
+	assert.NoError(t, err)
+
+	registerTrie, err := storage.OpenTrie(common.Hash{})
+	assert.NoError(t, err)
+	assert.NoError(t, registerTrie.TryUpdate(aliceAddr[:], []byte{1}))
+	registerRoot, err := registerTrie.Commit(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, storage.TrieDB().Commit(registerRoot, false))
+
+	// nothing is deleted if a trie is incomplete
+	size := db.Len()
+	_, err = PruneState(db, []common.Hash{root2, common.HexToHash("0x1234")}, []common.Hash{registerRoot})
+	assert.Error(t, err)
+	assert.Equal(t, size, db.Len())
+
+	deleted, err := PruneState(db, []common.Hash{root2}, []common.Hash{registerRoot})
+	assert.NoError(t, err)
+	assert.True(t, deleted > 0)
+	assert.Equal(t, size-deleted, db.Len())
+	assert.False(t, HasTrie(db, root1))
+
+	state, err := NewAccountStateDB(root2, NewStateStorageWithCache(db))
+	assert.NoError(t, err)
+	balance, err := state.GetBalance(aliceAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(5001), balance)
+	v, err := state.GetContract(cAddr, reflect.TypeOf(c))
+	assert.NoError(t, err)
+	assert.Equal(t, c.Name, v.Interface().(*erc20).Name)
+	proposal, err := state.GetGovernanceProposal(proposalId)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(20), proposal.Value)
+
+	registerTrie, err = NewStateStorageWithCache(db).OpenTrie(registerRoot)
+	assert.NoError(t, err)
+	value, err := registerTrie.TryGet(aliceAddr[:])
+	assert.NoError(t, err)
+	assert.Equal(t, []byte{1}, value)
+
+	// pruning again deletes nothing
+	deleted, err = PruneState(db, []common.Hash{root2}, []common.Hash{registerRoot})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, deleted)
+}
+
+func TestHasTrie(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	assert.True(t, HasTrie(db, common.Hash{}))
+	assert.True(t, HasTrie(db, emptyTrieRoot))
+	assert.False(t, HasTrie(db, common.HexToHash("0x12")))
+}
+
+func TestVerifyTries(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	processor, err := NewAccountStateDB(common.Hash{}, NewStateStorageWithCache(db))
+	assert.NoError(t, err)
+	assert.NoError(t, processor.NewAccountState(aliceAddr))
+	assert.NoError(t, processor.NewAccountState(bobAddr))
+	root, err := processor.Commit()
+	assert.NoError(t, err)
+
+	assert.NoError(t, VerifyTries(db, root, common.Hash{}, emptyTrieRoot))
+	assert.Error(t, VerifyTries(db, common.HexToHash("0x12")))
+
+	// the root is kept but the other nodes are lost
+	for _, key := range db.Keys() {
+		if len(key) == common.HashLength && common.BytesToHash(key) != root {
+			assert.NoError(t, db.Delete(key))
+		}
+	}
+	assert.True(t, HasTrie(db, root))
+	assert.Error(t, VerifyTries(db, root))
+}
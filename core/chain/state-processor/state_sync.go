@@ -0,0 +1,334 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_processor
+
+import (
+	"bytes"
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/dipperin/dipperin-core/third-party/trie"
+	"github.com/ethereum/go-ethereum/ethdb"
+)
+
+// the times a trie node or preimage can be requested without being delivered before the sync fails
+const maxStateSyncRetries = 8
+
+var (
+	StateSyncStalledErr = errors.New("state sync stalled, the state entries can't be retrieved from peers")
+)
+
+// the root of the trie without any node
+var emptyTrieRoot = common.HexToHash("56e81f171bcc55a6ff8345e692c0f86e5b48e01b996cadc001622fb5e363b421")
+
+type stateSyncPhase int
+
+const (
+	// download the nodes of the tries
+	stateSyncNodes stateSyncPhase = iota
+	// download the preimages of the leaf keys, the secure tries need them to iterate keys
+	stateSyncPreimages
+	stateSyncDone
+)
+
+// IsSubTrieRootKey reports whether the value of the key in the state trie is the root of
+// another trie, they are the contract data tries and the governance trie
+func IsSubTrieRootKey(key []byte) bool {
+	if len(key) == common.AddressLength+len(contractRootSuffix) && bytes.HasSuffix(key, []byte(contractRootSuffix)) {
+		return true
+	}
+	return bytes.Equal(key, GetGovernanceRootKey())
+}
+
+// StateSync schedules the retrieval of the state tries and register tries of the blocks
+// in the fast sync. Every trie is downloaded node by node, then the preimages of its leaf
+// keys are downloaded. The contract tries and the governance trie are found with the
+// keys of the state trie leaves, so they are downloaded after the state tries.
+//
+// The nodes and preimages are both requested by their keccak hash, the caller sends the
+// hashes returned by Missing to the peers, passes the answers to Process and calls
+// EndRound after every request round.
+type StateSync struct {
+	db     ethdb.Database
+	trieDB *trie.Database
+
+	phase stateSyncPhase
+	sched *trie.Sync
+	// the roots of the tries in the current sync level
+	roots []common.Hash
+	// the state tries may contain the roots of sub tries, they are checked after the preimages are downloaded
+	stateRoots []common.Hash
+	// the candidate leaves of the state tries, hashed key -> value
+	subTrieLeaves map[common.Hash][]byte
+
+	preimages     []common.Hash
+	preimageBatch ethdb.Batch
+
+	// the hashes requested in the current round
+	inFlight map[common.Hash]struct{}
+	// the hashes not delivered in the last rounds and the times they have been requested
+	retry    []common.Hash
+	attempts map[common.Hash]int
+}
+
+// NewStateSync creates a state sync for the state roots and register roots, the tries found
+// in the database are skipped
+func NewStateSync(db ethdb.Database, stateRoots []common.Hash, registerRoots []common.Hash) *StateSync {
+	s := &StateSync{
+		db:            db,
+		trieDB:        trie.NewDatabase(db),
+		stateRoots:    uniqueRoots(stateRoots, nil),
+		subTrieLeaves: map[common.Hash][]byte{},
+		preimageBatch: db.NewBatch(),
+		inFlight:      map[common.Hash]struct{}{},
+		attempts:      map[common.Hash]int{},
+	}
+	s.startNodes(uniqueRoots(append(append([]common.Hash{}, stateRoots...), registerRoots...), nil))
+	return s
+}
+
+func uniqueRoots(roots []common.Hash, known map[common.Hash]struct{}) (result []common.Hash) {
+	if known == nil {
+		known = map[common.Hash]struct{}{}
+	}
+	for _, root := range roots {
+		if _, ok := known[root]; ok {
+			continue
+		}
+		known[root] = struct{}{}
+		result = append(result, root)
+	}
+	return
+}
+
+func (s *StateSync) startNodes(roots []common.Hash) {
+	s.phase = stateSyncNodes
+	s.roots = roots
+	if len(roots) == 0 {
+		s.sched = trie.NewSync(emptyTrieRoot, s.db, nil)
+		return
+	}
+	s.sched = trie.NewSync(roots[0], s.db, nil)
+	for _, root := range roots[1:] {
+		s.sched.AddSubTrie(root, 0, common.Hash{}, nil)
+	}
+}
+
+// Done reports whether all the tries and preimages are downloaded
+func (s *StateSync) Done() bool {
+	return s.phase == stateSyncDone
+}
+
+// Pending returns the number of the entries waiting for retrieval in the current phase
+func (s *StateSync) Pending() int {
+	switch s.phase {
+	case stateSyncNodes:
+		return s.sched.Pending()
+	case stateSyncPreimages:
+		return len(s.preimages) + len(s.retry) + len(s.inFlight)
+	}
+	return 0
+}
+
+// Missing returns at most max hashes to request, the undelivered hashes of the last round come first
+func (s *StateSync) Missing(max int) []common.Hash {
+	var hashes []common.Hash
+	for len(s.retry) > 0 && len(hashes) < max {
+		hashes = append(hashes, s.retry[0])
+		s.retry = s.retry[1:]
+	}
+
+	switch s.phase {
+	case stateSyncNodes:
+		if len(hashes) < max {
+			hashes = append(hashes, s.sched.Missing(max-len(hashes))...)
+		}
+	case stateSyncPreimages:
+		for len(s.preimages) > 0 && len(hashes) < max {
+			hashes = append(hashes, s.preimages[0])
+			s.preimages = s.preimages[1:]
+		}
+	}
+
+	for _, hash := range hashes {
+		s.inFlight[hash] = struct{}{}
+		s.attempts[hash]++
+	}
+	return hashes
+}
+
+// Process injects the data returned by peers, the data which hash is not requested is ignored.
+// It returns the number of the accepted entries.
+func (s *StateSync) Process(data [][]byte) (int, error) {
+	accepted := 0
+	for _, blob := range data {
+		hash := cs_crypto.Keccak256Hash(blob)
+		if _, ok := s.inFlight[hash]; !ok {
+			continue
+		}
+		delete(s.inFlight, hash)
+		delete(s.attempts, hash)
+
+		switch s.phase {
+		case stateSyncNodes:
+			if _, _, err := s.sched.Process([]trie.SyncResult{{Hash: hash, Data: blob}}); err != nil {
+				return accepted, err
+			}
+		case stateSyncPreimages:
+			if err := s.preimageBatch.Put(trie.PreimageKey(hash), blob); err != nil {
+				return accepted, err
+			}
+		}
+		accepted++
+	}
+	return accepted, nil
+}
+
+// EndRound requeues the undelivered hashes, writes the downloaded entries to the database and
+// moves to the next phase if the current one is finished
+func (s *StateSync) EndRound() error {
+	for hash := range s.inFlight {
+		if s.attempts[hash] >= maxStateSyncRetries {
+			log.Warn("state entry can't be retrieved", "hash", hash.Hex(), "attempts", s.attempts[hash])
+			return StateSyncStalledErr
+		}
+		s.retry = append(s.retry, hash)
+		delete(s.inFlight, hash)
+	}
+
+	if err := s.commit(); err != nil {
+		return err
+	}
+
+	for s.phase != stateSyncDone && s.Pending() == 0 {
+		if err := s.nextPhase(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (s *StateSync) commit() error {
+	if s.phase == stateSyncNodes {
+		batch := s.db.NewBatch()
+		if _, err := s.sched.Commit(batch); err != nil {
+			return err
+		}
+		if err := batch.Write(); err != nil {
+			return err
+		}
+	}
+
+	if s.preimageBatch.ValueSize() > 0 {
+		if err := s.preimageBatch.Write(); err != nil {
+			return err
+		}
+		s.preimageBatch.Reset()
+	}
+	return nil
+}
+
+func (s *StateSync) nextPhase() error {
+	switch s.phase {
+	case stateSyncNodes:
+		// the iteration of the downloaded tries also verifies that no node is missing
+		if err := s.collectPreimages(); err != nil {
+			return err
+		}
+		s.phase = stateSyncPreimages
+	case stateSyncPreimages:
+		subRoots, err := s.subTrieRoots()
+		if err != nil {
+			return err
+		}
+		if len(subRoots) == 0 {
+			s.phase = stateSyncDone
+			return nil
+		}
+		log.Info("state sync download sub tries", "count", len(subRoots))
+		s.startNodes(subRoots)
+	}
+	return nil
+}
+
+// walk all the distinct leaves of the current tries
+func (s *StateSync) collectPreimages() error {
+	stateRoots := map[common.Hash]struct{}{}
+	for _, root := range s.stateRoots {
+		stateRoots[root] = struct{}{}
+	}
+
+	known := map[common.Hash]struct{}{}
+	visited := map[common.Hash]struct{}{}
+	for _, root := range s.roots {
+		_, isState := stateRoots[root]
+		t, err := trie.New(root, s.trieDB)
+		if err != nil {
+			return err
+		}
+
+		it := t.NodeIterator(nil)
+		for descend := true; it.Next(descend); {
+			descend = true
+			if hash := it.Hash(); hash != (common.Hash{}) {
+				if _, ok := visited[hash]; ok {
+					descend = false
+					continue
+				}
+				visited[hash] = struct{}{}
+			}
+			if !it.Leaf() {
+				continue
+			}
+
+			keyHash := common.BytesToHash(it.LeafKey())
+			if isState && len(it.LeafBlob()) == common.HashLength {
+				s.subTrieLeaves[keyHash] = common.CopyBytes(it.LeafBlob())
+			}
+			if _, ok := known[keyHash]; ok {
+				continue
+			}
+			known[keyHash] = struct{}{}
+			if ok, _ := s.db.Has(trie.PreimageKey(keyHash)); !ok {
+				s.preimages = append(s.preimages, keyHash)
+			}
+		}
+		if it.Error() != nil {
+			return it.Error()
+		}
+	}
+	return nil
+}
+
+// find the roots of the sub tries with the preimages of the state trie leaves
+func (s *StateSync) subTrieRoots() ([]common.Hash, error) {
+	var roots []common.Hash
+	for keyHash, value := range s.subTrieLeaves {
+		key, err := s.db.Get(trie.PreimageKey(keyHash))
+		if err != nil {
+			return nil, err
+		}
+		if IsSubTrieRootKey(key) {
+			roots = append(roots, common.BytesToHash(value))
+		}
+	}
+	s.subTrieLeaves = map[common.Hash][]byte{}
+	// the sub tries don't contain any other sub tries
+	s.stateRoots = nil
+	return uniqueRoots(roots, nil), nil
+}
@@ -0,0 +1,141 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_processor
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/third-party/trie"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"reflect"
+	"testing"
+)
+
+// serve the requested nodes and preimages from the source trie database
+func runStateSync(t *testing.T, s *StateSync, src *trie.Database) {
+	for !s.Done() {
+		var data [][]byte
+		for _, hash := range s.Missing(3) {
+			if blob, err := src.Node(hash); err == nil {
+				data = append(data, blob)
+			} else if blob, err := src.Preimage(hash); err == nil {
+				data = append(data, blob)
+			}
+		}
+		_, err := s.Process(data)
+		assert.NoError(t, err)
+		if err := s.EndRound(); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIsSubTrieRootKey(t *testing.T) {
+	assert.True(t, IsSubTrieRootKey(GetContractRootKey(aliceAddr)))
+	assert.True(t, IsSubTrieRootKey(GetGovernanceRootKey()))
+	assert.False(t, IsSubTrieRootKey(GetDataRootKey(aliceAddr)))
+	assert.False(t, IsSubTrieRootKey(GetBalanceKey(aliceAddr)))
+	assert.False(t, IsSubTrieRootKey(append(GetContractFieldKey(aliceAddr, "x"), []byte(contractRootSuffix)...)))
+}
+
+func TestStateSync(t *testing.T) {
+	srcDB := ethdb.NewMemDatabase()
+	storage := NewStateStorageWithCache(srcDB)
+	processor, err := NewAccountStateDB(common.Hash{}, storage)
+	assert.NoError(t, err)
+
+	cAddr := common.HexToAddress("0x3213123af")
+	c := erc20{Owners: []string{"123"}, Name: "jk", Dis: 10002}
+	assert.NoError(t, processor.NewAccountState(aliceAddr))
+	assert.NoError(t, processor.AddBalance(aliceAddr, big.NewInt(5000)))
+	assert.NoError(t, processor.PutContract(cAddr, reflect.ValueOf(&c)))
+	proposalId := common.HexToHash("0x12")
+	assert.NoError(t, processor.putGovernanceValue(getProposalKey(proposalId), &GovernanceProposalState{Param: GovernanceParamSlotSize, Value: 20}))
+	root1, err := processor.Commit()
+	assert.NoError(t, err)
+
+	// the second state shares most nodes with the first one
+	processor, err = NewAccountStateDB(root1, storage)
+	assert.NoError(t, err)
+	assert.NoError(t, processor.NewAccountState(bobAddr))
+	assert.NoError(t, processor.AddBalance(bobAddr, big.NewInt(300)))
+	root2, err := processor.Commit()
+	assert.NoError(t, err)
+
+	registerTrie, err := storage.OpenTrie(common.Hash{})
+	assert.NoError(t, err)
+	assert.NoError(t, registerTrie.TryUpdate(aliceAddr[:], []byte{1}))
+	registerRoot, err := registerTrie.Commit(nil)
+	assert.NoError(t, err)
+	assert.NoError(t, storage.TrieDB().Commit(registerRoot, false))
+
+	dstDB := ethdb.NewMemDatabase()
+	s := NewStateSync(dstDB, []common.Hash{root1, root2, root2}, []common.Hash{registerRoot})
+	assert.False(t, s.Done())
+	runStateSync(t, s, storage.TrieDB())
+
+	dstStorage := NewStateStorageWithCache(dstDB)
+	state, err := NewAccountStateDB(root2, dstStorage)
+	assert.NoError(t, err)
+	balance, err := state.GetBalance(aliceAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(5000), balance)
+	balance, err = state.GetBalance(bobAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(300), balance)
+
+	v, err := state.GetContract(cAddr, reflect.TypeOf(c))
+	assert.NoError(t, err)
+	assert.Equal(t, c.Name, v.Interface().(*erc20).Name)
+
+	proposal, err := state.GetGovernanceProposal(proposalId)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(20), proposal.Value)
+
+	// the keys of the secure tries can be iterated with the preimages
+	registerTrie, err = dstStorage.OpenTrie(registerRoot)
+	assert.NoError(t, err)
+	it := trie.NewIterator(registerTrie.NodeIterator(nil))
+	assert.True(t, it.Next())
+	assert.Equal(t, aliceAddr[:], registerTrie.GetKey(it.Key))
+
+	// nothing is downloaded again
+	s = NewStateSync(dstDB, []common.Hash{root1, root2}, []common.Hash{registerRoot})
+	assert.Len(t, s.Missing(10), 0)
+	assert.NoError(t, s.EndRound())
+	assert.True(t, s.Done())
+}
+
+func TestStateSync_Stalled(t *testing.T) {
+	srcDB, root := createTestStateDB()
+	s := NewStateSync(ethdb.NewMemDatabase(), []common.Hash{root}, nil)
+
+	// the data which is not requested is ignored
+	blob, err := srcDB.Get(root[:])
+	assert.NoError(t, err)
+	accepted, err := s.Process([][]byte{blob, []byte("123")})
+	assert.NoError(t, err)
+	assert.Equal(t, 0, accepted)
+
+	for i := 0; i < maxStateSyncRetries-1; i++ {
+		assert.Equal(t, []common.Hash{root}, s.Missing(10))
+		assert.NoError(t, s.EndRound())
+	}
+	assert.Len(t, s.Missing(10), 1)
+	assert.Equal(t, StateSyncStalledErr, s.EndRound())
+}
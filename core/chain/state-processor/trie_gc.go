@@ -0,0 +1,127 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_processor
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"sync"
+)
+
+const (
+	// all the states are written to the disk
+	GCModeArchive = "archive"
+	// only the states of the recent blocks and the checkpoints are kept
+	GCModePruned = "pruned"
+)
+
+// the memory used by the trie nodes of the recent states, the oldest nodes are flushed to the disk if it's exceeded
+var TrieGCMemoryLimit = common.StorageSize(256 * 1024 * 1024)
+
+type gcRoots struct {
+	number uint64
+	roots  []common.Hash
+}
+
+// TrieGC manages the tries of the pruned storage. The tries of the recent blocks are referenced in the
+// memory database and dereferenced once they are older than keepRecent blocks, so the nodes which are
+// not used by the recent states are released without being written to the disk. The states of the
+// genesis and the checkpoints are flushed to the disk every checkpointInterval blocks.
+type TrieGC struct {
+	storage            StateStorage
+	keepRecent         uint64
+	checkpointInterval uint64
+
+	lock  sync.Mutex
+	queue []gcRoots
+}
+
+func NewTrieGC(storage StateStorage, keepRecent, checkpointInterval uint64) *TrieGC {
+	// the state of the current block is always kept
+	if keepRecent == 0 {
+		keepRecent = 1
+	}
+	return &TrieGC{
+		storage:            storage,
+		keepRecent:         keepRecent,
+		checkpointInterval: checkpointInterval,
+	}
+}
+
+// Commit is called after the state trie and the register trie of the block are committed to the storage,
+// it does nothing if the storage writes all the states to the disk
+func (gc *TrieGC) Commit(number uint64, roots ...common.Hash) error {
+	if gc == nil || !IsPrunedStorage(gc.storage) {
+		return nil
+	}
+
+	gc.lock.Lock()
+	defer gc.lock.Unlock()
+
+	trieDB := gc.storage.TrieDB()
+	for _, root := range roots {
+		trieDB.Reference(root, common.Hash{})
+	}
+	gc.queue = append(gc.queue, gcRoots{number: number, roots: roots})
+
+	if number == 0 || (gc.checkpointInterval > 0 && number%gc.checkpointInterval == 0) {
+		for _, root := range roots {
+			if err := trieDB.Commit(root, false); err != nil {
+				return err
+			}
+		}
+		log.Info("flush state checkpoint", "num", number)
+	}
+
+	if nodes, _ := trieDB.Size(); nodes > TrieGCMemoryLimit {
+		if err := trieDB.Cap(TrieGCMemoryLimit - ethdb.IdealBatchSize); err != nil {
+			return err
+		}
+	}
+
+	for len(gc.queue) > 0 && gc.queue[0].number+gc.keepRecent <= number {
+		for _, root := range gc.queue[0].roots {
+			trieDB.Dereference(root)
+		}
+		gc.queue = gc.queue[1:]
+	}
+	return nil
+}
+
+// Flush writes the states of the recent blocks to the disk, it's called when the node stops, so the
+// verifiers can be calculated with them after the node restarts
+func (gc *TrieGC) Flush() error {
+	if gc == nil || !IsPrunedStorage(gc.storage) {
+		return nil
+	}
+
+	gc.lock.Lock()
+	defer gc.lock.Unlock()
+
+	trieDB := gc.storage.TrieDB()
+	for _, r := range gc.queue {
+		for _, root := range r.roots {
+			if err := trieDB.Commit(root, false); err != nil {
+				return err
+			}
+		}
+	}
+	log.Info("flush recent states", "blocks", len(gc.queue))
+	gc.queue = nil
+	return nil
+}
@@ -0,0 +1,119 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package state_processor
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTrieGC(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	storage := NewPrunedStateStorageWithCache(db)
+	assert.True(t, IsPrunedStorage(storage))
+	gc := NewTrieGC(storage, 2, 4)
+
+	processor, err := NewAccountStateDB(common.Hash{}, storage)
+	assert.NoError(t, err)
+	assert.NoError(t, processor.NewAccountState(aliceAddr))
+	roots := []common.Hash{}
+	root, err := processor.Commit()
+	assert.NoError(t, err)
+	roots = append(roots, root)
+
+	// the committed state is kept in memory until the gc flushes it
+	has, _ := db.Has(root.Bytes())
+	assert.False(t, has)
+	assert.NoError(t, gc.Commit(0, root))
+	assert.True(t, HasTrie(db, root))
+
+	proposalId := common.HexToHash("0x12")
+	var oldGovernanceRoot []byte
+	for num := uint64(1); num <= 5; num++ {
+		processor, err = NewAccountStateDB(roots[num-1], storage)
+		assert.NoError(t, err)
+		assert.NoError(t, processor.AddBalance(aliceAddr, big.NewInt(1)))
+		if num <= 2 {
+			assert.NoError(t, processor.putGovernanceValue(getProposalKey(proposalId), &GovernanceProposalState{Param: GovernanceParamSlotSize, Value: num}))
+		}
+		root, err = processor.Commit()
+		assert.NoError(t, err)
+		if num == 1 {
+			oldGovernanceRoot, err = processor.blockStateTrie.TryGet(GetGovernanceRootKey())
+			assert.NoError(t, err)
+		}
+		roots = append(roots, root)
+		assert.NoError(t, gc.Commit(num, root))
+	}
+
+	trieDB := storage.TrieDB()
+	for _, num := range []int{1, 2, 3} {
+		_, err = trieDB.Node(roots[num])
+		assert.Error(t, err)
+	}
+	// the governance trie is released with the state trie which references it
+	_, err = trieDB.Node(common.BytesToHash(oldGovernanceRoot))
+	assert.Error(t, err)
+
+	// the checkpoint is on the disk
+	assert.True(t, HasTrie(db, roots[4]))
+	assert.False(t, HasTrie(db, roots[5]))
+
+	state, err := NewAccountStateDB(roots[5], storage)
+	assert.NoError(t, err)
+	balance, err := state.GetBalance(aliceAddr)
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(5), balance)
+	proposal, err := state.GetGovernanceProposal(proposalId)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), proposal.Value)
+
+	// the recent states are written to the disk when the node stops
+	assert.NoError(t, gc.Flush())
+	assert.True(t, HasTrie(db, roots[5]))
+	state, err = NewAccountStateDB(roots[5], NewStateStorageWithCache(db))
+	assert.NoError(t, err)
+	proposal, err = state.GetGovernanceProposal(proposalId)
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), proposal.Value)
+}
+
+func TestTrieGC_Archive(t *testing.T) {
+	var nilGC *TrieGC
+	assert.NoError(t, nilGC.Commit(1, common.HexToHash("0x12")))
+	assert.NoError(t, nilGC.Flush())
+
+	db := ethdb.NewMemDatabase()
+	storage := NewStateStorageWithCache(db)
+	assert.False(t, IsPrunedStorage(storage))
+	gc := NewTrieGC(storage, 0, 0)
+	assert.Equal(t, uint64(1), gc.keepRecent)
+
+	processor, err := NewAccountStateDB(common.Hash{}, storage)
+	assert.NoError(t, err)
+	assert.NoError(t, processor.NewAccountState(aliceAddr))
+	root, err := processor.Commit()
+	assert.NoError(t, err)
+	assert.True(t, HasTrie(db, root))
+
+	assert.NoError(t, gc.Commit(1, root))
+	assert.Len(t, gc.queue, 0)
+}
@@ -18,12 +18,15 @@ package state_processor
 
 import (
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/economy-model"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/third-party/log"
 	"github.com/dipperin/dipperin-core/third-party/log/pbft_log"
 	"math/big"
 	"errors"
 	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
 var (
@@ -34,8 +37,62 @@ var (
 	ReceiverNotExistErr   = errors.New("receiver account does not exist")
 	SendRegisterTxFirst   = errors.New("target need to send register transaction first")
 	SendCancelTxFirst     = errors.New("target need to send cancel transaction first")
+	NoUnStakingErr        = errors.New("no partially unStaked money to withdraw")
 )
 
+// the partially unStaked money is an optional value of the verifier
+const unStakingKeySuffix = "_unstaking"
+
+// UnStaking is the money withdrawn from the stake of a registered verifier, it is locked like the stake after the cancel tx
+type UnStaking struct {
+	Amount *big.Int
+	// the block number of the last partial unStake tx, the lock period restarts with each partial unStake tx
+	Height uint64
+}
+
+func GetUnStakingKey(address common.Address) []byte {
+	return append(address[:], []byte(unStakingKeySuffix)...)
+}
+
+// GetUnStaking return an empty UnStaking if the verifier has no partially unStaked money
+func (state *AccountStateDB) GetUnStaking(addr common.Address) (*UnStaking, error) {
+	res := &UnStaking{Amount: big.NewInt(0)}
+	enc, err := state.blockStateTrie.TryGet(GetUnStakingKey(addr))
+	if err != nil {
+		return nil, err
+	}
+	if len(enc) == 0 {
+		return res, nil
+	}
+	if err = rlp.DecodeBytes(enc, res); err != nil {
+		return nil, err
+	}
+	return res, nil
+}
+
+func (state *AccountStateDB) setUnStaking(addr common.Address, unStaking *UnStaking) error {
+	var enc []byte
+	if unStaking.Amount.Sign() > 0 {
+		var err error
+		if enc, err = rlp.EncodeToBytes(unStaking); err != nil {
+			return err
+		}
+	}
+	return state.putOptionalValue(addr, GetUnStakingKey(addr), enc)
+}
+
+// DecodePartialUnStakeAmount decode the amount in the extra data of the partial unStake tx
+func DecodePartialUnStakeAmount(extraData []byte) (*big.Int, error) {
+	amount := big.NewInt(0)
+	if err := rlp.DecodeBytes(extraData, &amount); err != nil {
+		return nil, err
+	}
+	if amount.Sign() <= 0 {
+		return nil, NotEnoughStakeErr
+	}
+	return amount, nil
+}
+
 /*
 Basic operations
 Stake money from balance
@@ -87,6 +144,54 @@ func (state *AccountStateDB) UnStake(addr common.Address) error {
 	return nil
 }
 
+/*
+Withdraw part of the stake, the rest of the stake must not be lower than MiniPledgeValue which the tx validator checks too.
+The amount is locked until the withdraw stake tx, num is processing block num
+*/
+func (state *AccountStateDB) PartialUnStake(addr common.Address, amount *big.Int, num uint64) error {
+	stake, err := state.GetStake(addr)
+	if err != nil {
+		return err
+	}
+	rest := big.NewInt(0).Sub(stake, amount)
+	if rest.Cmp(economy_model.MiniPledgeValue) < 0 {
+		log.Debug("partial unStake failed", "address", addr.Hex(), "stake", stake, "amount", amount)
+		return NotEnoughStakeErr
+	}
+	unStaking, err := state.GetUnStaking(addr)
+	if err != nil {
+		return err
+	}
+	if err = state.SubStake(addr, amount); err != nil {
+		return err
+	}
+	unStaking.Amount.Add(unStaking.Amount, amount)
+	unStaking.Height = num
+	if err = state.setUnStaking(addr, unStaking); err != nil {
+		return err
+	}
+	pbft_log.Info("partial unStake", "address", addr.Hex(), "amount", amount)
+	return nil
+}
+
+/*
+Return the partially unStaked money to the balance
+*/
+func (state *AccountStateDB) WithdrawStake(addr common.Address) error {
+	unStaking, err := state.GetUnStaking(addr)
+	if err != nil {
+		return err
+	}
+	if unStaking.Amount.Sign() == 0 {
+		return NoUnStakingErr
+	}
+	if err = state.AddBalance(addr, unStaking.Amount); err != nil {
+		return err
+	}
+	pbft_log.Info("withdraw stake", "address", addr.Hex(), "amount", unStaking.Amount)
+	return state.setUnStaking(addr, &UnStaking{Amount: big.NewInt(0)})
+}
+
 /*Move stake to some address*/
 func (state *AccountStateDB) MoveStakeToAddress(fromAdd common.Address, toAdd common.Address) error {
 	amount, err := state.GetStake(fromAdd)
@@ -122,10 +227,10 @@ func (state *AccountStateDB) MoveStakeToAddress(fromAdd common.Address, toAdd co
 * Process verifier related transactions
 * Include AddPeerSet(Stake), Evidence, Cancel, UnStake
 
-* Process register tx
+* Process register tx, num is processing block num
 * Stake some money
   */
-func (state *AccountStateDB) processStakeTx(tx model.AbstractTransaction) (err error) {
+func (state *AccountStateDB) processStakeTx(tx model.AbstractTransaction, num uint64) (err error) {
 
 	//Check
 	sender, _ := tx.Sender(nil)
@@ -144,12 +249,25 @@ func (state *AccountStateDB) processStakeTx(tx model.AbstractTransaction) (err e
 		log.Debug("process register transaction failed", "err", NotEnoughStakeErr)
 		return NotEnoughStakeErr
 	}
+	// the extra data of the register tx before the commission height isn't checked
+	var commission uint64
+	var declared bool
+	if chain_config.GetChainConfig().IsCommission(num) {
+		if commission, declared, err = DecodeCommission(tx.ExtraData()); err != nil {
+			return
+		}
+	}
 
 	//Process
 	err = state.Stake(sender, tx.Amount())
 	if err != nil {
 		return
 	}
+	if declared {
+		if err = state.SetCommission(sender, commission); err != nil {
+			return
+		}
+	}
 	pbft_log.Info("success process a register transaction", "tx hash", tx.CalTxId().Hex())
 
 	//TODO add receipt?
@@ -197,10 +315,10 @@ func (state *AccountStateDB) processCancelTx(tx model.AbstractTransaction, num u
 }
 
 /*
-Process UnStake tx
-Un stake money
+Process UnStake tx, num is processing block num
+Un stake money, the tx with an amount in the extra data only un stakes the amount from the partial unStake height
  */
-func (state *AccountStateDB) processUnStakeTx(tx model.AbstractTransaction) (err error) {
+func (state *AccountStateDB) processUnStakeTx(tx model.AbstractTransaction, num uint64) (err error) {
 
 	//Check
 	sender, _ := tx.Sender(nil)
@@ -223,6 +341,24 @@ func (state *AccountStateDB) processUnStakeTx(tx model.AbstractTransaction) (err
 	if err != nil {
 		return
 	}
+
+	//the registered verifier withdraws part of its stake, the extra data before the partial unStake height isn't decoded
+	if len(tx.ExtraData()) > 0 && chain_config.GetChainConfig().IsPartialUnStake(num) {
+		if lastBlock != 0 {
+			return SendRegisterTxFirst
+		}
+		amount, decodeErr := DecodePartialUnStakeAmount(tx.ExtraData())
+		if decodeErr != nil {
+			return decodeErr
+		}
+		err = state.PartialUnStake(sender, amount, num)
+		if err != nil {
+			return
+		}
+		pbft_log.Info("success process a partial unStake transaction", "tx hash", tx.CalTxId().Hex())
+		return
+	}
+
 	if lastBlock == 0 {
 		return SendCancelTxFirst
 	}
@@ -232,6 +368,13 @@ func (state *AccountStateDB) processUnStakeTx(tx model.AbstractTransaction) (err
 	if err != nil {
 		return
 	}
+
+	//the partially unStaked money is locked before the cancel tx, so it can be returned with the stake
+	err = state.WithdrawStake(sender)
+	if err != nil && err != NoUnStakingErr {
+		return
+	}
+	err = nil
 	pbft_log.Info("success process a unStake transaction", "tx hash", tx.CalTxId().Hex())
 
 	//TODO add receipt return?
@@ -266,6 +409,39 @@ func (state *AccountStateDB) processEvidenceTx(tx model.AbstractTransaction) (er
 		return err
 	}
 
+	//the locked partially unStaked money is punished too
+	unStaking, err := state.GetUnStaking(originalReceiver)
+	if err != nil {
+		return err
+	}
+	if unStaking.Amount.Sign() > 0 {
+		if err = state.AddBalance(sender, unStaking.Amount); err != nil {
+			return err
+		}
+		if err = state.setUnStaking(originalReceiver, &UnStaking{Amount: big.NewInt(0)}); err != nil {
+			return err
+		}
+	}
+
 	//TODO add receipt return
 	return nil
 }
+
+/*
+Process withdraw stake tx
+Return the partially unStaked money, the lock period is checked by the tx validator like the unStake tx
+*/
+func (state *AccountStateDB) processWithdrawStakeTx(tx model.AbstractTransaction) (err error) {
+	sender, _ := tx.Sender(nil)
+	receiver := *(tx.To())
+	if receiver.GetAddressType() != common.AddressTypeWithdrawStake {
+		return TransactionTypeError
+	}
+
+	err = state.WithdrawStake(sender)
+	if err != nil {
+		return
+	}
+	pbft_log.Info("success process a withdraw stake transaction", "tx hash", tx.CalTxId().Hex())
+	return
+}
@@ -25,6 +25,8 @@ import (
 	"github.com/dipperin/dipperin-core/third-party/crypto"
 	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/ethereum/go-ethereum/rlp"
+	"github.com/dipperin/dipperin-core/core/economy-model"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
 )
 
@@ -143,20 +145,20 @@ func TestAccountStateDB_processStakeTx_Error(t *testing.T) {
 	assert.NoError(t, err)
 
 	tx := getTestCancelTransaction(0, key1)
-	err = processor.processStakeTx(tx)
+	err = processor.processStakeTx(tx, 1)
 	assert.Equal(t, TransactionTypeError, err)
 
 	tx = getTestRegisterTransaction(0, key1, big.NewInt(10))
-	err = processor.processStakeTx(tx)
+	err = processor.processStakeTx(tx, 1)
 	assert.Equal(t, g_error.AccountNotExist, err)
 
 	key1, _ = createKey()
 	tx = getTestRegisterTransaction(0, key1, big.NewInt(10))
-	err = processor.processStakeTx(tx)
+	err = processor.processStakeTx(tx, 1)
 	assert.Equal(t, NotEnoughStakeErr, err)
 
 	tx = getTestRegisterTransaction(0, key1, big.NewInt(1e4))
-	err = processor.processStakeTx(tx)
+	err = processor.processStakeTx(tx, 1)
 	assert.Equal(t, NotEnoughBalanceError, err)
 }
 
@@ -208,16 +210,16 @@ func TestAccountStateDB_processUnStakeTx_Error(t *testing.T) {
 	assert.NoError(t, err)
 
 	tx := getTestRegisterTransaction(0, key1, big.NewInt(10))
-	err = processor.processUnStakeTx(tx)
+	err = processor.processUnStakeTx(tx, 1)
 	assert.Equal(t, TransactionTypeError, err)
 
 	tx = getTestUnStakeTransaction(0, key1)
-	err = processor.processUnStakeTx(tx)
+	err = processor.processUnStakeTx(tx, 1)
 	assert.Equal(t, g_error.AccountNotExist, err)
 
 	key1, _ = createKey()
 	tx = getTestUnStakeTransaction(0, key1)
-	err = processor.processUnStakeTx(tx)
+	err = processor.processUnStakeTx(tx, 1)
 	assert.Equal(t, SendRegisterTxFirst, err)
 
 	// add alice stake set last elect num
@@ -227,7 +229,7 @@ func TestAccountStateDB_processUnStakeTx_Error(t *testing.T) {
 	// delete alice last elect num from tree
 	err = processor.blockStateTrie.TryDelete(GetLastElectKey(aliceAddr))
 	assert.NoError(t, err)
-	err = processor.processUnStakeTx(tx)
+	err = processor.processUnStakeTx(tx, 1)
 	assert.Error(t, err)
 
 	// set alice last elect num
@@ -236,7 +238,7 @@ func TestAccountStateDB_processUnStakeTx_Error(t *testing.T) {
 	err = processor.blockStateTrie.TryUpdate(GetLastElectKey(aliceAddr), num)
 	assert.NoError(t, err)
 
-	err = processor.processUnStakeTx(tx)
+	err = processor.processUnStakeTx(tx, 1)
 	assert.Equal(t, SendCancelTxFirst, err)
 }
 
@@ -254,4 +256,100 @@ func TestAccountStateDB_processEvidenceTx_Error(t *testing.T) {
 	tx = getTestEvidenceTransaction(0, key1, common.HexToAddress("123"), &model.VoteMsg{}, &model.VoteMsg{})
 	err = processor.processEvidenceTx(tx)
 	assert.Equal(t, ReceiverNotExistErr, err)
-}
\ No newline at end of file
+}
+func TestAccountStateDB_PartialUnStake(t *testing.T) {
+	minStake := economy_model.MiniPledgeValue
+	economy_model.MiniPledgeValue = big.NewInt(100)
+	defer func() { economy_model.MiniPledgeValue = minStake }()
+	config := chain_config.GetChainConfig()
+	defer func(height uint64) { config.PartialUnStakeHeight = height }(config.PartialUnStakeHeight)
+	config.PartialUnStakeHeight = 1
+
+	db, root := createTestStateDB()
+	processor, _ := NewAccountStateDB(root, NewStateStorageWithCache(db))
+	key1, _ := createKey()
+	fs := model.NewMercurySigner(big.NewInt(1))
+
+	// register and top up the stake
+	assert.NoError(t, processor.ProcessTx(getTestRegisterTransaction(1, key1, big.NewInt(1000)), 1))
+	assert.NoError(t, processor.ProcessTx(getTestRegisterTransaction(2, key1, big.NewInt(500)), 2))
+	stake, _ := processor.GetStake(aliceAddr)
+	assert.EqualValues(t, big.NewInt(1500), stake)
+
+	// the rest of the stake can't be lower than the minimum stake
+	tx, _ := model.NewPartialUnStakeTransaction(3, big.NewInt(1450), big.NewInt(40)).SignTx(key1, fs)
+	assert.Equal(t, NotEnoughStakeErr, processor.ProcessTx(tx, 3))
+
+	tx, _ = model.NewPartialUnStakeTransaction(4, big.NewInt(600), big.NewInt(40)).SignTx(key1, fs)
+	assert.NoError(t, processor.ProcessTx(tx, 4))
+	stake, _ = processor.GetStake(aliceAddr)
+	assert.EqualValues(t, big.NewInt(900), stake)
+	unStaking, _ := processor.GetUnStaking(aliceAddr)
+	assert.EqualValues(t, big.NewInt(600), unStaking.Amount)
+	assert.EqualValues(t, 4, unStaking.Height)
+	lastElect, _ := processor.GetLastElect(aliceAddr)
+	assert.EqualValues(t, 0, lastElect)
+
+	balance, _ := processor.GetBalance(aliceAddr)
+	tx, _ = model.NewWithdrawStakeTransaction(5, big.NewInt(40)).SignTx(key1, fs)
+	assert.NoError(t, processor.ProcessTx(tx, 5))
+	newBalance, _ := processor.GetBalance(aliceAddr)
+	assert.EqualValues(t, big.NewInt(0).Add(balance, big.NewInt(600-40)), newBalance)
+
+	tx, _ = model.NewWithdrawStakeTransaction(6, big.NewInt(40)).SignTx(key1, fs)
+	assert.Equal(t, NoUnStakingErr, processor.ProcessTx(tx, 6))
+}
+
+func TestAccountStateDB_UnStakeWithPartialUnStake(t *testing.T) {
+	minStake := economy_model.MiniPledgeValue
+	economy_model.MiniPledgeValue = big.NewInt(100)
+	defer func() { economy_model.MiniPledgeValue = minStake }()
+	config := chain_config.GetChainConfig()
+	defer func(height uint64) { config.PartialUnStakeHeight = height }(config.PartialUnStakeHeight)
+	config.PartialUnStakeHeight = 1
+
+	db, root := createTestStateDB()
+	processor, _ := NewAccountStateDB(root, NewStateStorageWithCache(db))
+	key1, _ := createKey()
+	fs := model.NewMercurySigner(big.NewInt(1))
+
+	assert.NoError(t, processor.ProcessTx(getTestRegisterTransaction(1, key1, big.NewInt(1000)), 1))
+	tx, _ := model.NewPartialUnStakeTransaction(2, big.NewInt(300), big.NewInt(40)).SignTx(key1, fs)
+	assert.NoError(t, processor.ProcessTx(tx, 2))
+	assert.NoError(t, processor.ProcessTx(getTestCancelTransaction(3, key1), 3))
+
+	// the cancelled verifier can't partially unStake
+	tx, _ = model.NewPartialUnStakeTransaction(4, big.NewInt(100), big.NewInt(40)).SignTx(key1, fs)
+	assert.Equal(t, SendRegisterTxFirst, processor.ProcessTx(tx, 4))
+
+	// the unStake tx returns the stake and the partially unStaked money
+	assert.NoError(t, processor.ProcessTx(getTestUnStakeTransaction(5, key1), 5))
+	balance, _ := processor.GetBalance(aliceAddr)
+	assert.EqualValues(t, big.NewInt(4790-40*5), balance)
+	unStaking, _ := processor.GetUnStaking(aliceAddr)
+	assert.EqualValues(t, big.NewInt(0), unStaking.Amount)
+}
+
+func TestAccountStateDB_PartialUnStakeHeight(t *testing.T) {
+	minStake := economy_model.MiniPledgeValue
+	economy_model.MiniPledgeValue = big.NewInt(100)
+	defer func() { economy_model.MiniPledgeValue = minStake }()
+	config := chain_config.GetChainConfig()
+	defer func(height uint64) { config.PartialUnStakeHeight = height }(config.PartialUnStakeHeight)
+	config.PartialUnStakeHeight = 5
+
+	db, root := createTestStateDB()
+	processor, _ := NewAccountStateDB(root, NewStateStorageWithCache(db))
+	key1, _ := createKey()
+	fs := model.NewMercurySigner(big.NewInt(1))
+	assert.NoError(t, processor.ProcessTx(getTestRegisterTransaction(1, key1, big.NewInt(1000)), 1))
+
+	// the extra data isn't decoded before the partial unStake height, the tx un stakes all of the stake
+	tx, _ := model.NewPartialUnStakeTransaction(2, big.NewInt(300), big.NewInt(40)).SignTx(key1, fs)
+	assert.Equal(t, SendCancelTxFirst, processor.ProcessTx(tx, 4))
+
+	tx, _ = model.NewPartialUnStakeTransaction(3, big.NewInt(300), big.NewInt(40)).SignTx(key1, fs)
+	assert.NoError(t, processor.ProcessTx(tx, 5))
+	stake, _ := processor.GetStake(aliceAddr)
+	assert.EqualValues(t, big.NewInt(700), stake)
+}
@@ -378,6 +378,14 @@ func (s *earlyContractFakeChainService) GetSlot(block model.AbstractBlock) *uint
 	return &slot
 }
 
+func (s *earlyContractFakeChainService) GetChainConfigAtHeight(height uint64) *chain_config.ChainConfig {
+	return chain_config.GetChainConfig()
+}
+
+func (s *earlyContractFakeChainService) GetVerifierWeights(height uint64, verifiers []common.Address) []uint64 {
+	return nil
+}
+
 type fakeChain struct {
 	block model.AbstractBlock
 }
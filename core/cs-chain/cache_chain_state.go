@@ -108,6 +108,20 @@ func (chain *CacheChainState) SaveBftBlock(block model.AbstractBlock, seenCommit
 	return nil
 }
 
+// SaveBftBlockBeforeCheckpoint saves the block until the checkpoint without verifying its votes and seal
+func (chain *CacheChainState) SaveBftBlockBeforeCheckpoint(block model.AbstractBlock, seenCommits []model.AbstractVerification) error {
+	err := chain.WriterFactory.NewWriter(middleware.NewBftBlockContextBeforeCheckpoint(block, seenCommits, chain)).SaveBlock()
+
+	if err != nil {
+		return err
+	}
+
+	chain.currentBlock.Store(block)
+	chain.currentHeader.Store(block.Header())
+
+	return nil
+}
+
 // Deprecated: use SaveBftBlock
 //func (chain *CacheChainState) SaveBlock(block model.AbstractBlock) error {
 //	panic("cache chain state no allow use chain state save block")
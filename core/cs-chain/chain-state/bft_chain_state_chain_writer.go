@@ -24,4 +24,8 @@ import (
 
 func (cs *ChainState) SaveBftBlock(block model.AbstractBlock, seenCommits []model.AbstractVerification) error {
 	return cs.WriterFactory.NewWriter(middleware.NewBftBlockContext(block, seenCommits, cs)).SaveBlock()
-}
\ No newline at end of file
+}
+// SaveBftBlockWithoutState saves the block of the fast sync without processing it
+func (cs *ChainState) SaveBftBlockWithoutState(block model.AbstractBlock, seenCommits []model.AbstractVerification) error {
+	return cs.WriterFactory.NewWriter(middleware.NewBftBlockContextWithoutState(block, seenCommits, cs)).SaveBlock()
+}
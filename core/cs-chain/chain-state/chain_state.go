@@ -38,6 +38,19 @@ type ChainStateConfig struct {
 	ChainConfig   *chain_config.ChainConfig
 	DataDir string
 	WriterFactory chain_writer.AbstractChainWriterFactory
+	// archive or pruned, the states are all kept if it's empty
+	GCMode string
+	// the count of the recent block states kept by the pruned node
+	StateKeepRecent uint64
+	// the states of the blocks at the multiple of it are flushed to the disk by the pruned node
+	StateCheckpointInterval uint64
+	// the dir of the freezer, it's in the chain data dir if it's empty
+	AncientDir string
+	// the key-value store of the chain data, the default backend is used if it's empty
+	DatabaseBackend string
+	// the tuning of the key-value store, the values of the chain config are used if they are 0
+	DatabaseCache   int
+	DatabaseHandles int
 }
 
 // the struct of ChainState
@@ -46,7 +59,10 @@ type ChainState struct {
 
 	ethDB ethdb.Database
 	ChainDB       chaindb.Database
+	// the old canonical blocks are moved to it, nil if the chain data is in memory
+	Freezer       *chaindb.Freezer
 	StateStorage  state_processor.StateStorage
+	TrieGC        *state_processor.TrieGC
 	EconomyModel  economy_model.EconomyModel
 }
 
@@ -68,7 +84,7 @@ func (cs *ChainState) BlockProcessorByNumber(num uint64) (*chain.BlockProcessor,
 func NewChainState(conf *ChainStateConfig) *ChainState {
 	g_event.Add(g_event.NewBlockInsertEvent)
 	cs := &ChainState{ ChainStateConfig: conf }
-	cs.initConfigAndDB(conf.DataDir)
+	cs.initConfigAndDB(conf.DataDir, conf.AncientDir)
 	cs.WriterFactory = conf.WriterFactory
 	cs.WriterFactory.SetChain(cs)
 	return cs
@@ -79,28 +95,64 @@ func (cs *ChainState) GetDB() ethdb.Database {
 	return cs.ethDB
 }
 
-func (cs *ChainState) initConfigAndDB(dataDir string) {
+func (cs *ChainState) initConfigAndDB(dataDir, ancientDir string) {
+	// init chain config
+	cs.ChainConfig = chain_config.GetChainConfig()
+
 	// init ethdb
-	ethDB := initEthDB(dataDir)
+	ethDB := initEthDB(dataDir, cs.DatabaseBackend, cs.backendConfig())
 	cs.ethDB = ethDB
+	cs.Freezer = initFreezer(dataDir, ancientDir)
 
 	// init block decoder
 	blockDecoder := model.MakeDefaultBlockDecoder()
 
-	// init chain config
-	cs.ChainConfig = chain_config.GetChainConfig()
-
 	// init chainDB
-	cs.ChainDB = chaindb.NewChainDB(ethDB, blockDecoder)
+	cs.ChainDB = chaindb.NewChainDBWithFreezer(ethDB, blockDecoder, cs.Freezer)
 
-	cs.StateStorage = state_processor.NewStateStorageWithCache(ethDB)
+	if cs.GCMode == state_processor.GCModePruned {
+		cs.StateStorage = state_processor.NewPrunedStateStorageWithCache(ethDB)
+	} else {
+		cs.StateStorage = state_processor.NewStateStorageWithCache(ethDB)
+	}
+	cs.TrieGC = state_processor.NewTrieGC(cs.StateStorage, StateKeepRecent(cs.StateKeepRecent), cs.StateCheckpointInterval)
 
 	// init economy model
 	cs.EconomyModel = economy_model.MakeDipperinEconomyModel(cs, economy_model.DIPProportion)
 }
 
+// StateKeepRecent returns the count of the recent block states kept by the pruned node, the verifiers are
+// calculated with the register states SlotMargin slots before, so the states of these slots are always kept
+func StateKeepRecent(keepRecent uint64) uint64 {
+	config := chain_config.GetChainConfig()
+	if min := config.SlotSize * (config.SlotMargin + 2); keepRecent < min {
+		return min
+	}
+	return keepRecent
+}
+
+func (cs *ChainState) backendConfig() chaindb.BackendConfig {
+	return BackendConfig(cs.ChainConfig, cs.DatabaseCache, cs.DatabaseHandles)
+}
+
+// BackendConfig returns the tuning of the chain database, the values of the chain config are used if the cache or the handles is 0
+func BackendConfig(chainConfig *chain_config.ChainConfig, cache, handles int) chaindb.BackendConfig {
+	if cache == 0 {
+		cache = chainConfig.DatabaseCache
+	}
+	if handles == 0 {
+		handles = chainConfig.DatabaseHandles
+	}
+	return chaindb.BackendConfig{Cache: cache, Handles: handles}
+}
+
+// ChainDataDir returns the dir of the key-value store of the chain data in the data dir
+func ChainDataDir(dataDir string) string {
+	return filepath.Join(dataDir, "full_chain_data")
+}
+
 // init database
-func initEthDB(dataDir string) ethdb.Database {
+func initEthDB(dataDir, backend string, conf chaindb.BackendConfig) ethdb.Database {
 	var db ethdb.Database
 
 	switch dataDir {
@@ -108,9 +160,9 @@ func initEthDB(dataDir string) ethdb.Database {
 		db = ethdb.NewMemDatabase()
 
 	default:
-		dataDir = filepath.Join(dataDir, "full_chain_data")
-		log.Info("open chain data", "dir", dataDir)
-		tmpDB, err := ethdb.NewLDBDatabase(dataDir, 0, 0)
+		dataDir = ChainDataDir(dataDir)
+		log.Info("open chain data", "dir", dataDir, "backend", backend, "cache", conf.Cache, "handles", conf.Handles)
+		tmpDB, err := chaindb.OpenDatabase(backend, dataDir, conf)
 
 		if err != nil {
 			panic(err)
@@ -121,3 +173,33 @@ func initEthDB(dataDir string) ethdb.Database {
 
 	return db
 }
+
+// open the chain data of the stopped node, the returned func closes it
+func openChainDB(dataDir, ancientDir, backend string, backendConf chaindb.BackendConfig) (*chaindb.ChainDB, func()) {
+	db := initEthDB(dataDir, backend, backendConf)
+	freezer := initFreezer(dataDir, ancientDir)
+	closeDB := func() {
+		if freezer != nil {
+			freezer.Close()
+		}
+		db.Close()
+	}
+	return chaindb.NewChainDBWithFreezer(db, model.MakeDefaultBlockDecoder(), freezer), closeDB
+}
+
+// init the freezer of the chain data on the disk
+func initFreezer(dataDir, ancientDir string) *chaindb.Freezer {
+	switch dataDir {
+	case "mem", "test", "":
+		return nil
+	}
+
+	if ancientDir == "" {
+		ancientDir = filepath.Join(ChainDataDir(dataDir), "ancient")
+	}
+	freezer, err := chaindb.NewFreezer(ancientDir)
+	if err != nil {
+		panic(err)
+	}
+	return freezer
+}
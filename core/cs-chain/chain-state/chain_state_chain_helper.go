@@ -21,6 +21,7 @@ import (
 	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/chain/chaindb"
 	"github.com/dipperin/dipperin-core/core/economy-model"
+	"github.com/dipperin/dipperin-core/third-party/log"
 )
 
 // get the configuration of the chain
@@ -28,6 +29,34 @@ func (cs *ChainState) GetChainConfig() *chain_config.ChainConfig {
 	return cs.ChainConfig
 }
 
+// get the configuration with the governed parameters which are active at the slot,
+// the passed governance proposals are read from the state of the block num
+func (cs *ChainState) GetGovernedChainConfig(num uint64, slot uint64) *chain_config.ChainConfig {
+	state, err := cs.StateAtByBlockNumber(num)
+	if err != nil {
+		log.Warn("can't get state for governed chain config", "num", num, "err", err)
+		return cs.ChainConfig
+	}
+	config, err := state.GetGovernedChainConfig(*cs.ChainConfig, slot)
+	if err != nil {
+		log.Warn("get governed chain config failed", "num", num, "slot", slot, "err", err)
+		return cs.ChainConfig
+	}
+	return &config
+}
+
+// get the configuration used by the block of the height, every node reads the same parameters for a height
+func (cs *ChainState) GetChainConfigAtHeight(height uint64) *chain_config.ChainConfig {
+	if height < 1 {
+		return cs.ChainConfig
+	}
+	slot := cs.GetSlotByNum(height)
+	if slot == nil {
+		return cs.ChainConfig
+	}
+	return cs.GetGovernedChainConfig(height-1, *slot)
+}
+
 // get economy model
 func (cs *ChainState) GetEconomyModel() economy_model.EconomyModel {
 	return cs.EconomyModel
@@ -18,9 +18,11 @@
 package chain_state
 
 import (
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
 	"github.com/dipperin/dipperin-core/core/cs-chain/chain-writer"
 	"github.com/stretchr/testify/assert"
 	"os"
+	"path/filepath"
 	"testing"
 	"github.com/dipperin/dipperin-core/core/chain-config"
 )
@@ -53,7 +55,7 @@ func TestChainState_initConfigAndDB(t *testing.T) {
 	assert.Nil(t, cs.StateStorage)
 	assert.Nil(t, cs.EconomyModel)
 
-	cs.initConfigAndDB("test")
+	cs.initConfigAndDB("test", "")
 
 	assert.NotNil(t, cs.ChainConfig)
 	assert.NotNil(t, cs.ChainDB)
@@ -62,19 +64,40 @@ func TestChainState_initConfigAndDB(t *testing.T) {
 }
 
 func Test_initEthDB(t *testing.T) {
-	testMemDB := initEthDB("mem")
+	testMemDB := initEthDB("mem", "", chaindb.BackendConfig{})
 	assert.NotNil(t, testMemDB)
 
-	testMemDB2 := initEthDB("test")
+	testMemDB2 := initEthDB("test", "", chaindb.BackendConfig{})
 	assert.NotNil(t, testMemDB2)
 
-	testMemDB3 := initEthDB("")
+	testMemDB3 := initEthDB("", "", chaindb.BackendConfig{})
 	assert.NotNil(t, testMemDB3)
 
 	dbPath := "/tmp/chainState/test_init_db"
 	defer os.RemoveAll(dbPath)
-	assert.NotNil(t, initEthDB(dbPath))
+	assert.NotNil(t, initEthDB(dbPath, "", chaindb.BackendConfig{}))
 	assert.Panics(t, func() {
-		initEthDB(dbPath)
+		initEthDB(dbPath, "", chaindb.BackendConfig{})
 	})
 }
+
+func Test_initFreezer(t *testing.T) {
+	assert.Nil(t, initFreezer("mem", ""))
+	assert.Nil(t, initFreezer("test", ""))
+	assert.Nil(t, initFreezer("", ""))
+
+	dataDir := "/tmp/chainState/test_init_freezer"
+	defer os.RemoveAll(dataDir)
+	freezer := initFreezer(dataDir, "")
+	assert.NotNil(t, freezer)
+	freezer.Close()
+	_, err := os.Stat(filepath.Join(dataDir, "full_chain_data", "ancient"))
+	assert.NoError(t, err)
+
+	ancientDir := filepath.Join(dataDir, "ancient_custom")
+	freezer = initFreezer(dataDir, ancientDir)
+	assert.NotNil(t, freezer)
+	freezer.Close()
+	_, err = os.Stat(ancientDir)
+	assert.NoError(t, err)
+}
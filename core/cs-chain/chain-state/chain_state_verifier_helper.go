@@ -60,7 +60,7 @@ func (cs *ChainState) IsChangePoint(block model.AbstractBlock, isProcessPackageB
 	if point == 0 {
 		diff += 1
 	}
-	slotSize := cs.ChainConfig.SlotSize
+	slotSize := cs.GetChainConfigAtHeight(block.Number()).SlotSize
 
 	// the mineMaster packaged Block diff and nonce is 0
 	if isProcessPackageBlock {
@@ -149,11 +149,10 @@ func (cs *ChainState) GetCurrVerifiers() []common.Address {
 func (cs *ChainState) GetVerifiers(slot uint64) []common.Address {
 	// check round
 	config := cs.GetChainConfig()
-	defaultVerifiers := chain.VerifierAddress[:config.VerifierNumber]
-
 	if slot < config.SlotMargin {
-		// replace by configured verifiers
-		return defaultVerifiers
+		// replace by configured verifiers, the number is governed like the calculated verifiers
+		verifierNumber := capVerifierNumber(cs.GetGovernedChainConfig(0, slot).VerifierNumber)
+		return chain.VerifierAddress[:verifierNumber]
 	}
 
 	num := cs.NumBeforeLastBySlot(slot)
@@ -238,6 +237,9 @@ func (cs *ChainState) CalVerifiers(block model.AbstractBlock) []common.Address {
 	//pbft_log.Debug("GetRegisterData", "register data", list, "root", root)
 	//log.Info("GetRegisterData", "register data", list, "root", root)
 
+	// the verifier number may be changed by governance for the slot the verifiers are calculated for
+	verifierNumber := cs.getVerifierNumber(block)
+
 	// get top verifiers
 	var topAddress []common.Address
 	var topPriority []uint64
@@ -246,16 +248,16 @@ func (cs *ChainState) CalVerifiers(block model.AbstractBlock) []common.Address {
 		if err != nil {
 			pbft_log.Info("calPriority", "err", err)
 		}
-		topAddress, topPriority = cs.getTopVerifiers(list[i], priority, topAddress, topPriority)
+		topAddress, topPriority = cs.getTopVerifiers(list[i], priority, topAddress, topPriority, verifierNumber)
 	}
 	//pbft_log.Info("getTopVerifiers", "topAddress", len(topAddress), "topPriority", topPriority)
 
 	// angel nodes take the place
 	config := cs.GetChainConfig()
-	defaultVerifiers := chain.VerifierAddress[:config.VerifierNumber]
+	defaultVerifiers := chain.VerifierAddress[:verifierNumber]
 	for add := range defaultVerifiers {
-		if len(topAddress) < config.VerifierNumber {
-			topAddress, topPriority = cs.getTopVerifiers(defaultVerifiers[add], config.SystemVerifierPriority, topAddress, topPriority)
+		if len(topAddress) < verifierNumber {
+			topAddress, topPriority = cs.getTopVerifiers(defaultVerifiers[add], config.SystemVerifierPriority, topAddress, topPriority, verifierNumber)
 		}
 	}
 
@@ -263,9 +265,8 @@ func (cs *ChainState) CalVerifiers(block model.AbstractBlock) []common.Address {
 	return topAddress
 }
 
-func (cs *ChainState) getTopVerifiers(address common.Address, priority uint64, topAddress []common.Address, topPriority []uint64) ([]common.Address, []uint64) {
-	config := cs.GetChainConfig()
-	if len(topAddress) < config.VerifierNumber {
+func (cs *ChainState) getTopVerifiers(address common.Address, priority uint64, topAddress []common.Address, topPriority []uint64, verifierNumber int) ([]common.Address, []uint64) {
+	if len(topAddress) < verifierNumber {
 		tmpIndex := 0
 		for i := 0; i < len(topAddress); i++ {
 			if topAddress[i].IsEqual(address) {
@@ -285,8 +286,7 @@ func (cs *ChainState) getTopVerifiers(address common.Address, priority uint64, t
 
 	} else {
 		insertPosition := 0
-		config := cs.GetChainConfig()
-		if priority <= topPriority[config.VerifierNumber-1] {
+		if priority <= topPriority[verifierNumber-1] {
 			return topAddress, topPriority
 		}
 
@@ -303,15 +303,33 @@ func (cs *ChainState) getTopVerifiers(address common.Address, priority uint64, t
 		}
 
 		//insert the priority and delete the smallest priority
-		recordAddress := append([]common.Address{}, topAddress[insertPosition:config.VerifierNumber-1]...)
+		recordAddress := append([]common.Address{}, topAddress[insertPosition:verifierNumber-1]...)
 		topAddress = append(append(topAddress[:insertPosition], address), recordAddress...)
 
-		recordPriority := append([]uint64{}, topPriority[insertPosition:config.VerifierNumber-1]...)
+		recordPriority := append([]uint64{}, topPriority[insertPosition:verifierNumber-1]...)
 		topPriority = append(append(topPriority[:insertPosition], priority), recordPriority...)
 	}
 	return topAddress, topPriority
 }
 
+// the verifiers calculated with the block are used in the slot after the slot margin,
+// the number can't be larger than the default verifiers which take the empty places
+func (cs *ChainState) getVerifierNumber(block model.AbstractBlock) int {
+	config := cs.GetChainConfig()
+	slot := cs.GetSlot(block)
+	if slot == nil {
+		return config.VerifierNumber
+	}
+	return capVerifierNumber(cs.GetGovernedChainConfig(block.Number(), *slot+config.SlotMargin).VerifierNumber)
+}
+
+func capVerifierNumber(verifierNumber int) int {
+	if verifierNumber > len(chain.VerifierAddress) {
+		return len(chain.VerifierAddress)
+	}
+	return verifierNumber
+}
+
 func (cs *ChainState) calPriority(addr common.Address, blockNum uint64) (uint64, error) {
 	luck := cs.getLuck(addr, blockNum)
 	state, err := cs.StateAtByBlockNumber(blockNum)
@@ -320,7 +338,8 @@ func (cs *ChainState) calPriority(addr common.Address, blockNum uint64) (uint64,
 	}
 
 	accountNonce, err := state.GetNonce(addr)
-	stake, err := state.GetStake(addr)
+	// the stake delegated to the candidate counts for the election
+	stake, err := state.GetVotingStake(addr)
 	performance, err := state.GetPerformance(addr)
 
 	// todo take this shit to Ox Star Star
@@ -331,6 +350,46 @@ func (cs *ChainState) calPriority(addr common.Address, blockNum uint64) (uint64,
 	return priority, nil
 }
 
+// Calculate the proposer weights of the verifiers at the height, they are the reputations calculated with the voting stake,
+// nonce and performance in the state of the previous block, so every node gets the same weights.
+// Return nil before the weighted proposer is activated.
+func (cs *ChainState) GetVerifierWeights(height uint64, verifiers []common.Address) []uint64 {
+	activeHeight := cs.GetChainConfig().WeightedProposerHeight
+	if activeHeight == 0 || height < activeHeight || height < 1 {
+		return nil
+	}
+
+	state, err := cs.StateAtByBlockNumber(height - 1)
+	if err != nil {
+		pbft_log.Warn("can't get state for verifier weights", "height", height, "err", err)
+		return nil
+	}
+
+	weights := make([]uint64, len(verifiers))
+	for i, v := range verifiers {
+		accountNonce, err := state.GetNonce(v)
+		if err != nil {
+			continue
+		}
+		stake, err := state.GetVotingStake(v)
+		if err != nil {
+			continue
+		}
+		performance, err := state.GetPerformance(v)
+		if err != nil {
+			continue
+		}
+
+		// the verifiers which have not enough stake keep weight 0, they still propose with the lowest frequency
+		reputation, err := model.CalReputation(accountNonce, stake, performance)
+		if err != nil {
+			continue
+		}
+		weights[i] = reputation
+	}
+	return weights
+}
+
 func (cs *ChainState) getLuck(addr common.Address, blockNum uint64) common.Hash {
 	seed := cs.GetBlockByNumber(blockNum).Seed()
 	list := append(seed.Bytes(), addr.Bytes()...)
@@ -223,15 +223,15 @@ func (suite *chainWriterSuite) TestChainState_getTopVerifiers(t *check.C) {
 	}
 
 	// add small priority
-	resultAddress, _ := suite.chainState.getTopVerifiers(aliceAddr, 1, topAddress, topPriority)
+	resultAddress, _ := suite.chainState.getTopVerifiers(aliceAddr, 1, topAddress, topPriority, config.VerifierNumber)
 	assert.False(t, aliceAddr.InSlice(resultAddress))
 
 	// add same address
-	resultAddress, _ = suite.chainState.getTopVerifiers(ver[1].Address(), uint64(2*config.VerifierNumber-1), topAddress, topPriority)
+	resultAddress, _ = suite.chainState.getTopVerifiers(ver[1].Address(), uint64(2*config.VerifierNumber-1), topAddress, topPriority, config.VerifierNumber)
 	assert.True(t, ver[1].Address().InSlice(resultAddress))
 
 	// add alice address
-	resultAddress, _ = suite.chainState.getTopVerifiers(aliceAddr, uint64(2*config.VerifierNumber-1), topAddress, topPriority)
+	resultAddress, _ = suite.chainState.getTopVerifiers(aliceAddr, uint64(2*config.VerifierNumber-1), topAddress, topPriority, config.VerifierNumber)
 	assert.Equal(t, aliceAddr, resultAddress[1])
 }
 
@@ -0,0 +1,108 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_state
+
+import (
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
+	"github.com/dipperin/dipperin-core/third-party/log"
+)
+
+var (
+	ErrConvertSameDataDir = errors.New("the chain data can't be converted in the same data dir")
+	ErrConvertMemDataDir  = errors.New("the chain data in memory can't be converted")
+)
+
+// ConvertChainData copies the chain data of the stopped node to the target data dir with the target backend,
+// the freezer files in the default ancient dir are copied too. It returns the count of the copied keys.
+func ConvertChainData(dataDir, backend string, conf chaindb.BackendConfig, targetDataDir, targetBackend string, targetConf chaindb.BackendConfig) (int, error) {
+	for _, dir := range []string{dataDir, targetDataDir} {
+		switch dir {
+		case "mem", "test", "":
+			return 0, ErrConvertMemDataDir
+		}
+	}
+	if filepath.Clean(dataDir) == filepath.Clean(targetDataDir) {
+		return 0, ErrConvertSameDataDir
+	}
+
+	src, err := chaindb.OpenDatabase(backend, ChainDataDir(dataDir), conf)
+	if err != nil {
+		return 0, err
+	}
+	defer src.Close()
+	dst, err := chaindb.OpenDatabase(targetBackend, ChainDataDir(targetDataDir), targetConf)
+	if err != nil {
+		return 0, err
+	}
+	defer dst.Close()
+
+	copied, err := chaindb.CopyDatabase(src, dst)
+	if err != nil {
+		return copied, err
+	}
+	log.Info("copy chain data finished", "keys", copied)
+
+	ancientDir := filepath.Join(ChainDataDir(dataDir), "ancient")
+	if _, err := os.Stat(ancientDir); os.IsNotExist(err) {
+		return copied, nil
+	}
+	return copied, copyFiles(ancientDir, filepath.Join(ChainDataDir(targetDataDir), "ancient"))
+}
+
+// copy the regular files in the src dir to the dst dir
+func copyFiles(src, dst string) error {
+	if err := os.MkdirAll(dst, 0755); err != nil {
+		return err
+	}
+	infos, err := ioutil.ReadDir(src)
+	if err != nil {
+		return err
+	}
+	for _, info := range infos {
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		if err := copyFile(filepath.Join(src, info.Name()), filepath.Join(dst, info.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(out, in); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,61 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_state
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertChainData(t *testing.T) {
+	dir, err := ioutil.TempDir("", "convert_chain_data")
+	assert.NoError(t, err)
+	defer os.RemoveAll(dir)
+
+	dataDir := filepath.Join(dir, "src")
+	targetDataDir := filepath.Join(dir, "dst")
+	_, err = ConvertChainData(dataDir, "", chaindb.BackendConfig{}, "mem", "", chaindb.BackendConfig{})
+	assert.Equal(t, ErrConvertMemDataDir, err)
+	_, err = ConvertChainData(dataDir, "", chaindb.BackendConfig{}, dataDir+"/", "", chaindb.BackendConfig{})
+	assert.Equal(t, ErrConvertSameDataDir, err)
+
+	db := initEthDB(dataDir, chaindb.BackendLevelDB, chaindb.BackendConfig{})
+	assert.NoError(t, db.Put([]byte("key"), []byte("value")))
+	db.Close()
+	freezer := initFreezer(dataDir, "")
+	freezer.Close()
+
+	copied, err := ConvertChainData(dataDir, chaindb.BackendLevelDB, chaindb.BackendConfig{}, targetDataDir, chaindb.BackendLevelDB, chaindb.BackendConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, 1, copied)
+
+	db = initEthDB(targetDataDir, chaindb.BackendLevelDB, chaindb.BackendConfig{})
+	value, err := db.Get([]byte("key"))
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("value"), value)
+	db.Close()
+	_, err = os.Stat(filepath.Join(ChainDataDir(targetDataDir), "ancient", "hashes.idx"))
+	assert.NoError(t, err)
+
+	_, err = ConvertChainData(dataDir, "unknown", chaindb.BackendConfig{}, targetDataDir, "", chaindb.BackendConfig{})
+	assert.Error(t, err)
+}
@@ -0,0 +1,225 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_state
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain/cachedb"
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
+	"github.com/dipperin/dipperin-core/core/chain/state-processor"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/log"
+)
+
+var (
+	ErrCanonicalHashMissing = errors.New("the canonical hash is missing")
+	ErrHeaderMissing        = errors.New("the header is missing")
+	ErrBodyMissing          = errors.New("the body is missing")
+	ErrBlockUndecodable     = errors.New("the block can't be decoded")
+	ErrHeaderNumberMismatch = errors.New("the number of the block hash doesn't match")
+	ErrTxLookupMismatch     = errors.New("the tx lookup entry doesn't match")
+	ErrSeenCommitsMissing   = errors.New("the seen commits are missing")
+	ErrNoConsistentBlock    = errors.New("no consistent block to rewind to")
+)
+
+// ChainVerifyConfig is the range of the canonical chain to be verified
+type ChainVerifyConfig struct {
+	// the first block checked, 0 is the genesis
+	From uint64
+	// the last block checked, the current block is used if it's 0 or higher than the current block
+	To uint64
+	// the states of the blocks at the multiple of it are walked besides the state of the last consistent block,
+	// 1 walks the states of the full range and 0 only walks the last consistent one
+	StateInterval uint64
+}
+
+// ChainIssue is a broken part of the chain data
+type ChainIssue struct {
+	Number uint64
+	Hash   common.Hash
+	Err    error
+}
+
+func (issue ChainIssue) String() string {
+	return fmt.Sprintf("block %v %v: %v", issue.Number, issue.Hash.Hex(), issue.Err)
+}
+
+// ChainVerifyResult is the result of the verification of the chain data
+type ChainVerifyResult struct {
+	Head    uint64
+	Checked uint64
+	Issues  []ChainIssue
+	// the latest block before the first broken block whose state is complete, the head is rewound to it by the repair
+	LastConsistent     uint64
+	LastConsistentHash common.Hash
+}
+
+// Consistent reports whether nothing is broken in the verified range
+func (result *ChainVerifyResult) Consistent() bool {
+	return len(result.Issues) == 0
+}
+
+type seenCommitsReader interface {
+	GetSeenCommits(blockHeight uint64, blockHash common.Hash) ([]model.AbstractVerification, error)
+}
+
+// VerifyChainData checks the chain data of the stopped node, the head is rewound to the last consistent block
+// if repair is true and something is broken.
+func VerifyChainData(dataDir, ancientDir, backend string, backendConf chaindb.BackendConfig, conf ChainVerifyConfig, repair bool) (*ChainVerifyResult, error) {
+	chainDB, closeDB := openChainDB(dataDir, ancientDir, backend, backendConf)
+	defer closeDB()
+	var commits *cachedb.CacheDB
+	if freezer := chainDB.Freezer(); freezer != nil {
+		commits = cachedb.NewCacheDBWithAncients(chainDB.DB(), freezer)
+	} else {
+		commits = cachedb.NewCacheDB(chainDB.DB())
+	}
+	cachedb.SetCacheDataDecoder(&cachedb.BFTCacheDataDecoder{})
+
+	result, err := verifyChain(chainDB, commits, conf)
+	if err != nil || !repair {
+		return result, err
+	}
+	rewindHead(chainDB, result)
+	return result, nil
+}
+
+func verifyChain(chainDB chaindb.Database, commits seenCommitsReader, conf ChainVerifyConfig) (*ChainVerifyResult, error) {
+	headNumber := chainDB.GetHeaderNumber(chainDB.GetHeadBlockHash())
+	if headNumber == nil {
+		return nil, g_error.ErrCurrentBlockIsNil
+	}
+
+	result := &ChainVerifyResult{Head: *headNumber}
+	to := conf.To
+	if to == 0 || to > *headNumber {
+		to = *headNumber
+	}
+
+	// the first broken block, the blocks before From are assumed to be consistent
+	broken := to + 1
+	preHash := common.Hash{}
+	if conf.From > 0 {
+		preHash = chainDB.GetBlockHashByNumber(conf.From - 1)
+	}
+	for num := conf.From; num <= to; num++ {
+		hash, err := verifyBlock(chainDB, commits, num, preHash)
+		if err == nil && conf.StateInterval > 0 && num%conf.StateInterval == 0 {
+			if block := chainDB.GetBlock(hash, num); block != nil {
+				err = state_processor.VerifyTries(chainDB.DB(), block.StateRoot(), block.GetRegisterRoot())
+			}
+		}
+		result.Checked++
+		if err != nil {
+			log.Warn("the chain data is broken", "num", num, "hash", hash.Hex(), "err", err)
+			result.Issues = append(result.Issues, ChainIssue{Number: num, Hash: hash, Err: err})
+			if num < broken && isStructureIssue(err) {
+				broken = num
+			}
+		}
+		preHash = hash
+	}
+
+	// the head must have the complete state
+	for num := broken; num > 0; num-- {
+		hash := chainDB.GetBlockHashByNumber(num - 1)
+		block := chainDB.GetBlock(hash, num-1)
+		if block == nil {
+			continue
+		}
+		if err := state_processor.VerifyTries(chainDB.DB(), block.StateRoot(), block.GetRegisterRoot()); err != nil {
+			sampled := conf.StateInterval > 0 && to%conf.StateInterval == 0
+			if num-1 == to && !sampled {
+				result.Issues = append(result.Issues, ChainIssue{Number: num - 1, Hash: hash, Err: err})
+			}
+			continue
+		}
+		result.LastConsistent, result.LastConsistentHash = num-1, hash
+		return result, nil
+	}
+	return result, ErrNoConsistentBlock
+}
+
+// the blocks after the broken structure can't be used, but the missing states of the old blocks may be pruned
+// and the tx lookups are only used by the rpc
+func isStructureIssue(err error) bool {
+	switch err {
+	case ErrCanonicalHashMissing, ErrHeaderMissing, ErrBodyMissing, ErrBlockUndecodable, ErrHeaderNumberMismatch,
+		g_error.ErrPreBlockHashNotMatch, ErrSeenCommitsMissing:
+		return true
+	}
+	return false
+}
+
+func verifyBlock(chainDB chaindb.Database, commits seenCommitsReader, num uint64, preHash common.Hash) (common.Hash, error) {
+	hash := chainDB.GetBlockHashByNumber(num)
+	if hash.IsEmpty() {
+		return hash, ErrCanonicalHashMissing
+	}
+	if !chainDB.HasHeader(hash, num) {
+		return hash, ErrHeaderMissing
+	}
+	if !chainDB.HasBody(hash, num) {
+		return hash, ErrBodyMissing
+	}
+	if number := chainDB.GetHeaderNumber(hash); number == nil || *number != num {
+		return hash, ErrHeaderNumberMismatch
+	}
+	block := chainDB.GetBlock(hash, num)
+	if block == nil || !block.Hash().IsEqual(hash) {
+		return hash, ErrBlockUndecodable
+	}
+	if num > 0 && !block.PreHash().IsEqual(preHash) {
+		return hash, g_error.ErrPreBlockHashNotMatch
+	}
+
+	if err := block.TxIterator(func(i int, tx model.AbstractTransaction) error {
+		blockHash, blockIndex, index := chainDB.GetTxLookupEntry(tx.CalTxId())
+		if !blockHash.IsEqual(hash) || blockIndex != num || index != uint64(i) {
+			return ErrTxLookupMismatch
+		}
+		return nil
+	}); err != nil {
+		return hash, err
+	}
+
+	if num > 0 {
+		if _, err := commits.GetSeenCommits(num, common.Hash{}); err != nil {
+			return hash, ErrSeenCommitsMissing
+		}
+	}
+	return hash, nil
+}
+
+// set the last consistent block as the head and remove the canonical hashes of the blocks after it,
+// the blocks after it are synced again
+func rewindHead(chainDB chaindb.Database, result *ChainVerifyResult) {
+	if result.LastConsistent == result.Head {
+		return
+	}
+
+	log.Warn("rewind the chain to the last consistent block", "from", result.Head, "to", result.LastConsistent)
+	chainDB.SaveHeadBlockHash(result.LastConsistentHash)
+	chainDB.SaveHeadHeaderHash(result.LastConsistentHash)
+	for num := result.LastConsistent + 1; num <= result.Head; num++ {
+		chainDB.DeleteBlockHashByNumber(num)
+	}
+}
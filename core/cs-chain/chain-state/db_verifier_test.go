@@ -0,0 +1,130 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_state
+
+import (
+	"math/big"
+	"testing"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain/cachedb"
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func createVerifyChain(t *testing.T, count uint64, stateRoots map[uint64]common.Hash) (*chaindb.ChainDB, *cachedb.CacheDB, []model.AbstractBlock) {
+	return createVerifyChainWithDB(t, chaindb.NewChainDB(ethdb.NewMemDatabase(), model.MakeDefaultBlockDecoder()), count, stateRoots)
+}
+
+func createVerifyChainWithDB(t *testing.T, chainDB *chaindb.ChainDB, count uint64, stateRoots map[uint64]common.Hash) (*chaindb.ChainDB, *cachedb.CacheDB, []model.AbstractBlock) {
+	cachedb.SetCacheDataDecoder(&cachedb.BFTCacheDataDecoder{})
+	cacheDB := cachedb.NewCacheDB(chainDB.DB())
+
+	var blocks []model.AbstractBlock
+	preHash := common.Hash{}
+	for num := uint64(0); num < count; num++ {
+		var block *model.Block
+		if num == 1 {
+			block = model.CreateBlock(num, preHash, 2)
+		} else {
+			header := model.NewHeader(1, num, preHash, common.HexToHash("123456"), common.HexToDiff("1fffffff"), big.NewInt(int64(num)), common.HexToAddress("0x123"), common.BlockNonce{})
+			header.StateRoot = stateRoots[num]
+			block = model.NewBlock(header, nil, nil)
+		}
+		assert.NoError(t, chainDB.InsertBlock(block))
+		chainDB.SaveHeaderNumber(block.Hash(), num)
+		if num > 0 {
+			assert.NoError(t, cacheDB.SaveSeenCommits(num, common.Hash{}, []model.AbstractVerification{}))
+		}
+		blocks = append(blocks, block)
+		preHash = block.Hash()
+	}
+	return chainDB, cacheDB, blocks
+}
+
+func TestVerifyChain(t *testing.T) {
+	chainDB, cacheDB, blocks := createVerifyChain(t, 6, nil)
+	result, err := verifyChain(chainDB, cacheDB, ChainVerifyConfig{StateInterval: 1})
+	assert.NoError(t, err)
+	assert.True(t, result.Consistent())
+	assert.Equal(t, uint64(5), result.Head)
+	assert.Equal(t, uint64(6), result.Checked)
+	assert.Equal(t, uint64(5), result.LastConsistent)
+	assert.Equal(t, blocks[5].Hash(), result.LastConsistentHash)
+
+	result, err = verifyChain(chainDB, cacheDB, ChainVerifyConfig{From: 2, To: 3})
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), result.Checked)
+	assert.Equal(t, uint64(3), result.LastConsistent)
+
+	// the tx lookups don't affect the last consistent block
+	chainDB.DeleteTxLookupEntry(blocks[1])
+	result, err = verifyChain(chainDB, cacheDB, ChainVerifyConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, []ChainIssue{{Number: 1, Hash: blocks[1].Hash(), Err: ErrTxLookupMismatch}}, result.Issues)
+	assert.Equal(t, uint64(5), result.LastConsistent)
+
+	_, err = verifyChain(chaindb.NewChainDB(ethdb.NewMemDatabase(), model.MakeDefaultBlockDecoder()), cacheDB, ChainVerifyConfig{})
+	assert.Equal(t, g_error.ErrCurrentBlockIsNil, err)
+}
+
+func TestVerifyChain_Broken(t *testing.T) {
+	chainDB, cacheDB, blocks := createVerifyChain(t, 6, nil)
+	chainDB.DeleteBody(blocks[3].Hash(), 3)
+	assert.NoError(t, cacheDB.DeleteSeenCommits(4))
+
+	result, err := verifyChain(chainDB, cacheDB, ChainVerifyConfig{})
+	assert.NoError(t, err)
+	assert.Equal(t, []ChainIssue{
+		{Number: 3, Hash: blocks[3].Hash(), Err: ErrBodyMissing},
+		{Number: 4, Hash: blocks[4].Hash(), Err: ErrSeenCommitsMissing},
+	}, result.Issues)
+	assert.Equal(t, uint64(2), result.LastConsistent)
+
+	rewindHead(chainDB, result)
+	assert.Equal(t, blocks[2].Hash(), chainDB.GetHeadBlockHash())
+	assert.Equal(t, blocks[2].Hash(), chainDB.GetHeadHeaderHash())
+	assert.True(t, chainDB.GetBlockHashByNumber(3).IsEmpty())
+	assert.True(t, chainDB.GetBlockHashByNumber(5).IsEmpty())
+
+	result, err = verifyChain(chainDB, cacheDB, ChainVerifyConfig{})
+	assert.NoError(t, err)
+	assert.True(t, result.Consistent())
+	assert.Equal(t, uint64(2), result.Head)
+}
+
+func TestVerifyChain_StateMissing(t *testing.T) {
+	missing := common.HexToHash("0x1234")
+	chainDB, cacheDB, blocks := createVerifyChain(t, 6, map[uint64]common.Hash{2: missing, 5: missing})
+
+	// the missing state of the old block may be pruned
+	result, err := verifyChain(chainDB, cacheDB, ChainVerifyConfig{})
+	assert.NoError(t, err)
+	assert.Len(t, result.Issues, 1)
+	assert.Equal(t, uint64(5), result.Issues[0].Number)
+	assert.Equal(t, uint64(4), result.LastConsistent)
+	assert.Equal(t, blocks[4].Hash(), result.LastConsistentHash)
+
+	result, err = verifyChain(chainDB, cacheDB, ChainVerifyConfig{StateInterval: 1})
+	assert.NoError(t, err)
+	assert.Len(t, result.Issues, 2)
+	assert.Equal(t, uint64(2), result.Issues[0].Number)
+	assert.Equal(t, uint64(4), result.LastConsistent)
+}
@@ -0,0 +1,67 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_state
+
+import (
+	"io"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
+	"github.com/dipperin/dipperin-core/core/chain/state-processor"
+)
+
+// DumpState writes all the accounts in the state of the block to the writer as json, it's run when the node is stopped
+func DumpState(dataDir, ancientDir, backend string, backendConf chaindb.BackendConfig, number uint64, w io.Writer) error {
+	chainDB, closeDB := openChainDB(dataDir, ancientDir, backend, backendConf)
+	defer closeDB()
+
+	state, err := stateAtNumber(chainDB, state_processor.NewStateStorageWithCache(chainDB.DB()), number)
+	if err != nil {
+		return err
+	}
+	return state.Dump(w)
+}
+
+// DiffState returns the accounts changed between the states of two blocks, it's run when the node is stopped
+func DiffState(dataDir, ancientDir, backend string, backendConf chaindb.BackendConfig, from, to uint64) ([]*state_processor.AccountDiff, error) {
+	chainDB, closeDB := openChainDB(dataDir, ancientDir, backend, backendConf)
+	defer closeDB()
+
+	storage := state_processor.NewStateStorageWithCache(chainDB.DB())
+	fromState, err := stateAtNumber(chainDB, storage, from)
+	if err != nil {
+		return nil, err
+	}
+	toState, err := stateAtNumber(chainDB, storage, to)
+	if err != nil {
+		return nil, err
+	}
+	diff, err := state_processor.DiffStates(fromState, toState, common.Address{}, 0)
+	if err != nil {
+		return nil, err
+	}
+	return diff.Accounts, nil
+}
+
+func stateAtNumber(chainDB chaindb.Database, storage state_processor.StateStorage, number uint64) (*state_processor.AccountStateDB, error) {
+	block := chainDB.GetBlock(chainDB.GetBlockHashByNumber(number), number)
+	if block == nil {
+		return nil, g_error.ErrBlockNotFound
+	}
+	return state_processor.NewAccountStateDB(block.StateRoot(), storage)
+}
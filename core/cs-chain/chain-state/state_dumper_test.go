@@ -0,0 +1,68 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_state
+
+import (
+	"bytes"
+	"math/big"
+	"testing"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
+	"github.com/dipperin/dipperin-core/core/chain/state-processor"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDumpState(t *testing.T) {
+	// there is no block in the data dir
+	assert.Equal(t, g_error.ErrBlockNotFound, DumpState("", "", "", chaindb.BackendConfig{}, 0, new(bytes.Buffer)))
+	_, err := DiffState("", "", "", chaindb.BackendConfig{}, 0, 1)
+	assert.Equal(t, g_error.ErrBlockNotFound, err)
+}
+
+func Test_stateAtNumber(t *testing.T) {
+	chainDB := chaindb.NewChainDB(ethdb.NewMemDatabase(), model.MakeDefaultBlockDecoder())
+	storage := state_processor.NewStateStorageWithCache(chainDB.DB())
+	state, err := state_processor.NewAccountStateDB(common.Hash{}, storage)
+	assert.NoError(t, err)
+	addr := common.HexToAddress("0x1234")
+	assert.NoError(t, state.NewAccountState(addr))
+	assert.NoError(t, state.AddBalance(addr, big.NewInt(10)))
+	root, err := state.Commit()
+	assert.NoError(t, err)
+
+	createVerifyChainWithDB(t, chainDB, 3, map[uint64]common.Hash{2: root})
+	state, err = stateAtNumber(chainDB, storage, 2)
+	assert.NoError(t, err)
+	dump, err := state.DumpState(nil, 10)
+	assert.NoError(t, err)
+	assert.Len(t, dump.Accounts, 1)
+	assert.Equal(t, big.NewInt(10), dump.Accounts[0].Balance.ToInt())
+
+	from, err := stateAtNumber(chainDB, storage, 1)
+	assert.NoError(t, err)
+	diff, err := state_processor.DiffStates(from, state, common.Address{}, 0)
+	assert.NoError(t, err)
+	assert.Len(t, diff.Accounts, 1)
+	assert.Equal(t, addr, diff.Accounts[0].Address)
+
+	_, err = stateAtNumber(chainDB, storage, 3)
+	assert.Equal(t, g_error.ErrBlockNotFound, err)
+}
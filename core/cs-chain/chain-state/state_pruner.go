@@ -0,0 +1,84 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_state
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
+	"github.com/dipperin/dipperin-core/core/chain/state-processor"
+	"github.com/dipperin/dipperin-core/third-party/log"
+)
+
+// PruneState deletes the states of the chain in the data dir except the states of the recent blocks and
+// the checkpoints, it's run when the node is stopped. It returns the count of the deleted trie nodes.
+func PruneState(dataDir, ancientDir, backend string, backendConf chaindb.BackendConfig, keepRecent, checkpointInterval uint64) (int, error) {
+	chainDB, closeDB := openChainDB(dataDir, ancientDir, backend, backendConf)
+	defer closeDB()
+	return pruneState(chainDB, keepRecent, checkpointInterval)
+}
+
+func pruneState(chainDB chaindb.Database, keepRecent, checkpointInterval uint64) (int, error) {
+	headHash := chainDB.GetHeadBlockHash()
+	headNumber := chainDB.GetHeaderNumber(headHash)
+	if headNumber == nil {
+		return 0, g_error.ErrCurrentBlockIsNil
+	}
+
+	var stateRoots, registerRoots []common.Hash
+	// the states of the checkpoints may be missing if they were pruned with another interval
+	keepState := func(num uint64, required bool) error {
+		block := chainDB.GetBlock(chainDB.GetBlockHashByNumber(num), num)
+		if block == nil {
+			return g_error.ErrBlockNotFound
+		}
+		for _, root := range []common.Hash{block.StateRoot(), block.GetRegisterRoot()} {
+			if !state_processor.HasTrie(chainDB.DB(), root) {
+				if required {
+					return g_error.ErrCurrentStateNotFound
+				}
+				log.Warn("the state of the block is missing", "num", num, "root", root.Hex())
+				return nil
+			}
+		}
+		stateRoots = append(stateRoots, block.StateRoot())
+		registerRoots = append(registerRoots, block.GetRegisterRoot())
+		return nil
+	}
+
+	keepRecent = StateKeepRecent(keepRecent)
+	from := uint64(0)
+	if *headNumber >= keepRecent {
+		from = *headNumber - keepRecent + 1
+	}
+	for num := from; num <= *headNumber; num++ {
+		if err := keepState(num, num == *headNumber); err != nil {
+			return 0, err
+		}
+	}
+	if err := keepState(0, false); err != nil {
+		return 0, err
+	}
+	for num := checkpointInterval; checkpointInterval > 0 && num < from; num += checkpointInterval {
+		if err := keepState(num, false); err != nil {
+			return 0, err
+		}
+	}
+
+	log.Info("prune state", "current block", *headNumber, "keep recent", keepRecent, "checkpoint interval", checkpointInterval)
+	return state_processor.PruneState(chainDB.DB(), stateRoots, registerRoots)
+}
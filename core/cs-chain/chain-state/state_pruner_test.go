@@ -0,0 +1,58 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package chain_state
+
+import (
+	"testing"
+
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
+	"github.com/dipperin/dipperin-core/core/chain/state-processor"
+	"github.com/dipperin/dipperin-core/core/cs-chain/chain-writer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStateKeepRecent(t *testing.T) {
+	config := chain_config.GetChainConfig()
+	min := config.SlotSize * (config.SlotMargin + 2)
+	assert.Equal(t, min, StateKeepRecent(0))
+	assert.Equal(t, min, StateKeepRecent(min-1))
+	assert.Equal(t, min+1, StateKeepRecent(min+1))
+}
+
+func TestNewChainState_Pruned(t *testing.T) {
+	cs := NewChainState(&ChainStateConfig{
+		DataDir:       "",
+		WriterFactory: chain_writer.NewChainWriterFactory(),
+		GCMode:        state_processor.GCModePruned,
+	})
+	assert.True(t, state_processor.IsPrunedStorage(cs.StateStorage))
+	assert.NotNil(t, cs.TrieGC)
+
+	cs = NewChainState(&ChainStateConfig{
+		DataDir:       "",
+		WriterFactory: chain_writer.NewChainWriterFactory(),
+	})
+	assert.False(t, state_processor.IsPrunedStorage(cs.StateStorage))
+}
+
+func TestPruneState(t *testing.T) {
+	// there is no block in the data dir
+	_, err := PruneState("", "", "", chaindb.BackendConfig{}, 0, 0)
+	assert.Equal(t, g_error.ErrCurrentBlockIsNil, err)
+}
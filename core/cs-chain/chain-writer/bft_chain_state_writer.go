@@ -47,6 +47,8 @@ func (cw *BftChainWriter) SaveBlock() error {
 	c.Use(middleware.ValidateBlockCoinBase(&c.BlockContext))
 	c.Use(middleware.ValidateSeed(&c.BlockContext))
 	c.Use(middleware.ValidateBlockTime(&c.BlockContext))
+	c.Use(middleware.ValidateCheckpoint(&c.BlockContext))
+	c.Use(middleware.ValidateCheckpointVerifiers(&c.BlockContext))
 
 	c.Use(middleware.ValidateBlockTxs(&c.BlockContext))
 	c.Use(middleware.ValidateVotes(c))
@@ -91,6 +93,7 @@ func (cw *BftChainWriterWithoutVotes) SaveBlock() error {
 	c.Use(middleware.ValidateBlockHash(&c.BlockContext))
 	c.Use(middleware.ValidateBlockCoinBase(&c.BlockContext))
 	c.Use(middleware.ValidateSeed(&c.BlockContext))
+	c.Use(middleware.ValidateCheckpoint(&c.BlockContext))
 
 	c.Use(middleware.ValidateBlockTxs(&c.BlockContext))
 
@@ -110,3 +113,81 @@ func (cw *BftChainWriterWithoutVotes) SaveBlock() error {
 
 	return err
 }
+
+type BftChainWriterWithoutState struct {
+	context *middleware.BftBlockContextWithoutState
+	chain   middleware.ChainInterface
+}
+
+func NewBftChainWriterWithoutState(context *middleware.BftBlockContextWithoutState, chain middleware.ChainInterface) *BftChainWriterWithoutState {
+	return &BftChainWriterWithoutState{context: context, chain: chain}
+}
+
+// SaveBlock saves the block of the fast sync, the state of the block isn't available,
+// so the txs are not processed and the votes are saved without verified. Only the blocks
+// until the trusted checkpoint are saved, they are linked to it by the pre hashes.
+func (cw *BftChainWriterWithoutState) SaveBlock() error {
+	c := cw.context
+
+	c.Use(middleware.ValidateBeforeCheckpoint(&c.BlockContext))
+	c.Use(middleware.ValidatePreBlockWithoutState(&c.BlockContext))
+	c.Use(middleware.ValidateBlockVersion(&c.BlockContext))
+	c.Use(middleware.ValidateBlockHash(&c.BlockContext))
+	c.Use(middleware.ValidateBlockPow(&c.BlockContext))
+	c.Use(middleware.ValidateBlockCoinBase(&c.BlockContext))
+	c.Use(middleware.ValidateSeed(&c.BlockContext))
+	c.Use(middleware.ValidateBlockTxRoot(&c.BlockContext))
+	c.Use(middleware.ValidateVerificationRoot(&c.BlockContext))
+	c.Use(middleware.ValidateCheckpoint(&c.BlockContext))
+
+	c.Use(middleware.InsertBlockWithoutState(&c.BlockContext))
+
+	err := c.Process()
+	if err != nil {
+		log.Error("bft save block without state failed", "err", err)
+	}
+
+	return err
+}
+
+type BftChainWriterBeforeCheckpoint struct {
+	context *middleware.BftBlockContextBeforeCheckpoint
+	chain   middleware.ChainInterface
+}
+
+func NewBftChainWriterBeforeCheckpoint(context *middleware.BftBlockContextBeforeCheckpoint, chain middleware.ChainInterface) *BftChainWriterBeforeCheckpoint {
+	return &BftChainWriterBeforeCheckpoint{context: context, chain: chain}
+}
+
+// SaveBlock saves the block until the trusted checkpoint, the checkpoint is linked to it by the pre hashes so
+// the votes and the pow seal are not verified, the txs are still processed to build the state
+func (cw *BftChainWriterBeforeCheckpoint) SaveBlock() error {
+	c := cw.context
+
+	c.Use(middleware.ValidateBeforeCheckpoint(&c.BlockContext))
+	c.Use(middleware.ValidateBlockNumber(&c.BlockContext))
+	c.Use(middleware.ValidateBlockVersion(&c.BlockContext))
+	c.Use(middleware.ValidateBlockSize(&c.BlockContext))
+	c.Use(middleware.ValidateBlockHash(&c.BlockContext))
+	c.Use(middleware.ValidateBlockCoinBase(&c.BlockContext))
+	c.Use(middleware.ValidateVerificationRoot(&c.BlockContext))
+	c.Use(middleware.ValidateCheckpoint(&c.BlockContext))
+	c.Use(middleware.ValidateCheckpointVerifiers(&c.BlockContext))
+
+	c.Use(middleware.ValidateBlockTxs(&c.BlockContext))
+
+	c.Use(middleware.UpdateStateRoot(&c.BlockContext))
+
+	c.Use(middleware.UpdateBlockVerifier(&c.BlockContext))
+	c.Use(middleware.InsertBlock(&c.BlockContext))
+
+	// after insert block, update verifier
+	c.Use(middleware.NextRoundVerifier(&c.BlockContext))
+
+	err := c.Process()
+	if err != nil {
+		log.Error("bft save block before checkpoint failed", "err", err)
+	}
+
+	return err
+}
@@ -18,6 +18,7 @@
 package chain_writer
 
 import (
+	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/cs-chain/chain-writer/middleware"
 	"github.com/golang/mock/gomock"
@@ -52,3 +53,15 @@ func TestBftChainWriter_SaveBlock(t *testing.T) {
 		BlockContext: middleware.BlockContext{ Block: mb, Chain: mc },
 	}, mc).SaveBlock())
 }
+
+func TestBftChainWriterBeforeCheckpoint_SaveBlock(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	mc := NewMockChainInterface(controller)
+	mb := NewMockAbstractBlock(controller)
+	mb.EXPECT().Number().Return(uint64(1)).AnyTimes()
+	mc.EXPECT().GetChainConfig().Return(chain_config.GetChainConfig()).AnyTimes()
+
+	// there isn't any checkpoint
+	assert.Equal(t, g_error.ErrBlockAfterCheckpoint, NewBftChainWriterBeforeCheckpoint(middleware.NewBftBlockContextBeforeCheckpoint(mb, nil, mc), mc).SaveBlock())
+}
@@ -287,6 +287,20 @@ func (mr *MockChainInterfaceMockRecorder) GetChainConfig() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChainConfig", reflect.TypeOf((*MockChainInterface)(nil).GetChainConfig))
 }
 
+// GetChainConfigAtHeight mocks base method
+func (m *MockChainInterface) GetChainConfigAtHeight(arg0 uint64) *chain_config.ChainConfig {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetChainConfigAtHeight", arg0)
+	ret0, _ := ret[0].(*chain_config.ChainConfig)
+	return ret0
+}
+
+// GetChainConfigAtHeight indicates an expected call of GetChainConfigAtHeight
+func (mr *MockChainInterfaceMockRecorder) GetChainConfigAtHeight(arg0 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetChainConfigAtHeight", reflect.TypeOf((*MockChainInterface)(nil).GetChainConfigAtHeight), arg0)
+}
+
 // GetChainDB mocks base method
 func (m *MockChainInterface) GetChainDB() chaindb.Database {
 	m.ctrl.T.Helper()
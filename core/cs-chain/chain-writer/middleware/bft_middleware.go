@@ -50,11 +50,39 @@ func NewBftBlockContextWithoutVotes(b model.AbstractBlock, chain ChainInterface)
 	return bc
 }
 
+// the block downloaded by the fast sync, its state isn't available when it's saved
+type BftBlockContextWithoutState struct {
+	BlockContext
+
+	Votes []model.AbstractVerification
+}
+
+func NewBftBlockContextWithoutState(b model.AbstractBlock, votes []model.AbstractVerification, chain ChainInterface) *BftBlockContextWithoutState {
+	bc := &BftBlockContextWithoutState{}
+	bc.index = -1
 
+	bc.Block = b
+	bc.Votes = votes
+	bc.Chain = chain
+	return bc
+}
 
+// the block downloaded until the trusted checkpoint, its votes and seal aren't verified
+type BftBlockContextBeforeCheckpoint struct {
+	BlockContext
 
+	Votes []model.AbstractVerification
+}
 
+func NewBftBlockContextBeforeCheckpoint(b model.AbstractBlock, votes []model.AbstractVerification, chain ChainInterface) *BftBlockContextBeforeCheckpoint {
+	bc := &BftBlockContextBeforeCheckpoint{}
+	bc.index = -1
 
+	bc.Block = b
+	bc.Votes = votes
+	bc.Chain = chain
+	return bc
+}
 
 func NewBftBlockValidator(chain ChainInterface) *BftBlockValidator {
 	return &BftBlockValidator{ Chain: chain }
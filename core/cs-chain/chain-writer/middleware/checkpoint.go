@@ -0,0 +1,64 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package middleware
+
+import (
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/dipperin/dipperin-core/third-party/log"
+)
+
+// ValidateCheckpoint refuses the block which conflicts with the checkpoint at its height
+func ValidateCheckpoint(c *BlockContext) Middleware {
+	return func() error {
+		if cp := c.Chain.GetChainConfig().GetCheckpoint(c.Block.Number()); cp != nil && !cp.Hash.IsEqual(c.Block.Hash()) {
+			log.Error("block conflicts with the checkpoint", "num", c.Block.Number(), "hash", c.Block.Hash().Hex(), "checkpoint", cp.Hash.Hex())
+			return g_error.ErrCheckpointMismatch
+		}
+		return c.Next()
+	}
+}
+
+// ValidateCheckpointVerifiers checks the verifiers of the checkpoint slot, the votes of the blocks after the
+// checkpoint are verified by them
+func ValidateCheckpointVerifiers(c *BlockContext) Middleware {
+	return func() error {
+		cp := c.Chain.GetChainConfig().GetCheckpoint(c.Block.Number())
+		if cp == nil || cp.VerifierHash.IsEmpty() {
+			return c.Next()
+		}
+		slot := c.Chain.GetSlot(c.Block)
+		if slot == nil {
+			return g_error.ErrCheckpointVerifiersMismatch
+		}
+		if hash := chain_config.VerifierSetHash(c.Chain.GetVerifiers(*slot)); !hash.IsEqual(cp.VerifierHash) {
+			log.Error("verifiers conflict with the checkpoint", "num", c.Block.Number(), "hash", hash.Hex(), "checkpoint", cp.VerifierHash.Hex())
+			return g_error.ErrCheckpointVerifiersMismatch
+		}
+		return c.Next()
+	}
+}
+
+// ValidateBeforeCheckpoint refuses the block after the latest checkpoint, its votes and seal must be verified
+func ValidateBeforeCheckpoint(c *BlockContext) Middleware {
+	return func() error {
+		if cp := c.Chain.GetChainConfig().LatestCheckpoint(); cp == nil || c.Block.Number() > cp.Number {
+			return g_error.ErrBlockAfterCheckpoint
+		}
+		return c.Next()
+	}
+}
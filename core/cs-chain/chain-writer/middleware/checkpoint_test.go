@@ -0,0 +1,66 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package middleware
+
+import (
+	"testing"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/stretchr/testify/assert"
+)
+
+func checkpointTestChain(cp chain_config.Checkpoint, verifiers []common.Address) *fakeChainInterface {
+	cf := *chain_config.GetChainConfig()
+	cf.Checkpoints = nil
+	cf.AddCheckpoint(cp)
+	return &fakeChainInterface{cf: &cf, verifiers: verifiers}
+}
+
+func TestValidateCheckpoint(t *testing.T) {
+	chain := checkpointTestChain(chain_config.Checkpoint{Number: 10, Hash: common.Hash{0x10}}, nil)
+
+	assert.NoError(t, ValidateCheckpoint(&BlockContext{Block: &fakeBlock{num: 10, hash: common.Hash{0x10}}, Chain: chain})())
+	assert.NoError(t, ValidateCheckpoint(&BlockContext{Block: &fakeBlock{num: 9, hash: common.Hash{0x11}}, Chain: chain})())
+	assert.Equal(t, g_error.ErrCheckpointMismatch, ValidateCheckpoint(&BlockContext{Block: &fakeBlock{num: 10, hash: common.Hash{0x11}}, Chain: chain})())
+}
+
+func TestValidateCheckpointVerifiers(t *testing.T) {
+	verifiers := []common.Address{{0x1}, {0x2}}
+	block := &fakeBlock{num: 10, hash: common.Hash{0x10}}
+
+	// the verifier hash isn't checked if it's empty
+	chain := checkpointTestChain(chain_config.Checkpoint{Number: 10, Hash: common.Hash{0x10}}, nil)
+	assert.NoError(t, ValidateCheckpointVerifiers(&BlockContext{Block: block, Chain: chain})())
+
+	cp := chain_config.Checkpoint{Number: 10, Hash: common.Hash{0x10}, VerifierHash: chain_config.VerifierSetHash(verifiers)}
+	chain = checkpointTestChain(cp, verifiers)
+	assert.NoError(t, ValidateCheckpointVerifiers(&BlockContext{Block: block, Chain: chain})())
+
+	chain = checkpointTestChain(cp, verifiers[:1])
+	assert.Equal(t, g_error.ErrCheckpointVerifiersMismatch, ValidateCheckpointVerifiers(&BlockContext{Block: block, Chain: chain})())
+	assert.NoError(t, ValidateCheckpointVerifiers(&BlockContext{Block: &fakeBlock{num: 11}, Chain: chain})())
+}
+
+func TestValidateBeforeCheckpoint(t *testing.T) {
+	assert.Equal(t, g_error.ErrBlockAfterCheckpoint, ValidateBeforeCheckpoint(&BlockContext{Block: &fakeBlock{num: 1}, Chain: &fakeChainInterface{}})())
+
+	chain := checkpointTestChain(chain_config.Checkpoint{Number: 10, Hash: common.Hash{0x10}}, nil)
+	assert.NoError(t, ValidateBeforeCheckpoint(&BlockContext{Block: &fakeBlock{num: 10}, Chain: chain})())
+	assert.Equal(t, g_error.ErrBlockAfterCheckpoint, ValidateBeforeCheckpoint(&BlockContext{Block: &fakeBlock{num: 11}, Chain: chain})())
+}
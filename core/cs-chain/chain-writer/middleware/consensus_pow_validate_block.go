@@ -20,8 +20,8 @@ package middleware
 import (
 	"errors"
 	"fmt"
+	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/common/g-error"
-	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/third-party/crypto"
 	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
@@ -101,9 +101,13 @@ func ValidateBlockSize(c *BlockContext) Middleware {
 		if err != nil {
 			return err
 		}
-		if len(bb) > chain_config.MaxBlockSize {
+		limits := c.ChainConfig().GetBlockLimits(c.Block.Number())
+		if len(bb) > limits.MaxBlockSize {
 			return g_error.ErrBlockSizeTooLarge
 		}
+		if limits.MaxTxCount > 0 && c.Block.TxCount() > limits.MaxTxCount {
+			return g_error.ErrBlockTooManyTxs
+		}
 		return c.Next()
 	}
 }
@@ -115,20 +119,26 @@ func ValidateBlockDifficulty(c *BlockContext) Middleware {
 
 		}
 
-		preBlockHeight := c.Block.Number() - 1
-		preSpanH := model.LastPeriodBlockNum(preBlockHeight)
-		if preSpanH == 0 {
-			preSpanH = 1
-		}
-
-		//get the first block in the preBlock period
-		preSpanBlock := c.Chain.GetBlockByNumber(preSpanH)
-		//lastBlock := c.Chain.GetBlockByNumber(preBlockHeight)
-
 		//find the neighbor normal block
 		findBlock := c.Chain.GetLatestNormalBlock()
 
-		targetDiff := model.NewCalNewWorkDiff(preSpanBlock, findBlock, c.Block.Number()-1)
+		var targetDiff common.Difficulty
+		if config := c.ChainConfig(); config.IsPerBlockDiff(c.Block.Number()) {
+			recentBlocks := model.GetRecentNormalBlocks(c.Chain, findBlock, config.PerBlockDiffWindow+1)
+			targetDiff = model.CalWeightedWorkDiff(recentBlocks)
+		} else {
+			preBlockHeight := c.Block.Number() - 1
+			preSpanH := model.LastPeriodBlockNum(preBlockHeight)
+			if preSpanH == 0 {
+				preSpanH = 1
+			}
+
+			//get the first block in the preBlock period
+			preSpanBlock := c.Chain.GetBlockByNumber(preSpanH)
+			//lastBlock := c.Chain.GetBlockByNumber(preBlockHeight)
+
+			targetDiff = model.NewCalNewWorkDiff(preSpanBlock, findBlock, c.Block.Number()-1)
+		}
 		//targetDiff := model.CalNewWorkDiff(preSpanBlock, lastBlock)
 		//log.Info("the two Diff is:", "calc", targetDiff.Hex(), "block", c.Block.Difficulty().Hex())
 		if !targetDiff.Equal(c.Block.Difficulty()) {
@@ -196,10 +206,23 @@ func ValidateBlockVersion(c *BlockContext) Middleware {
 
 func ValidateBlockTime(c *BlockContext) Middleware {
 	return func() error {
+		config := c.ChainConfig()
 		blockTime := c.Block.Timestamp().Int64()
-		if time.Now().Add(c.Chain.GetChainConfig().BlockTimeRestriction).UnixNano() < blockTime {
+		if time.Now().Add(config.BlockTimeRestriction).UnixNano() < blockTime {
 			return g_error.ErrBlockTimeStamp
 		}
+
+		// the special blocks have no difficulty, so their timestamps can't skew the difficulty
+		if !config.IsMedianTime(c.Block.Number()) || c.Block.IsSpecial() {
+			return c.Next()
+		}
+		if time.Now().Add(config.MaxFutureBlockTime).UnixNano() < blockTime {
+			return g_error.ErrBlockTimeTooFarAway
+		}
+		recentBlocks := model.GetRecentNormalBlocks(c.Chain, c.Chain.GetLatestNormalBlock(), config.MedianTimeBlocks)
+		if c.Block.Timestamp().Cmp(model.CalMedianTimePast(recentBlocks)) <= 0 {
+			return g_error.ErrBlockTimeBeforeMedian
+		}
 		return c.Next()
 	}
 }
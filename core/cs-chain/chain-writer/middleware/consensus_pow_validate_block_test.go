@@ -18,6 +18,7 @@ package middleware
 
 import (
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
 	"github.com/dipperin/dipperin-core/core/bloom"
 	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
@@ -66,7 +67,7 @@ func TestValidBlockSize(t *testing.T)  {
 	})())
 
 	assert.Error(t, ValidateBlockSize(&BlockContext{
-		Block: &fakeBlock{ ExtraData: make([]byte, chain_config.MaxBlockSize + 1) },
+		Block: &fakeBlock{ ExtraData: make([]byte, chain_config.DefaultMaxBlockSize + 1) },
 		Chain: &fakeChainInterface{},
 	})())
 
@@ -74,6 +75,33 @@ func TestValidBlockSize(t *testing.T)  {
 		Block: &fakeBlock{},
 		Chain: &fakeChainInterface{},
 	})())
+
+	// the configured limits after the activation height
+	cf := *chain_config.GetChainConfig()
+	cf.MaxBlockSize = 1024
+	cf.MaxTxCount = 1
+	cf.BlockLimitsHeight = 2
+	limitedChain := &fakeChainInterface{cf: &cf}
+
+	assert.NoError(t, ValidateBlockSize(&BlockContext{
+		Block: &fakeBlock{num: 1, ExtraData: make([]byte, 1025)},
+		Chain: limitedChain,
+	})())
+
+	assert.Equal(t, g_error.ErrBlockSizeTooLarge, ValidateBlockSize(&BlockContext{
+		Block: &fakeBlock{num: 2, ExtraData: make([]byte, 1025)},
+		Chain: limitedChain,
+	})())
+
+	assert.Equal(t, g_error.ErrBlockTooManyTxs, ValidateBlockSize(&BlockContext{
+		Block: &fakeBlock{num: 2, txs: []model.AbstractTransaction{&fakeTx{}, &fakeTx{}}},
+		Chain: limitedChain,
+	})())
+
+	assert.NoError(t, ValidateBlockSize(&BlockContext{
+		Block: &fakeBlock{num: 2, txs: []model.AbstractTransaction{&fakeTx{}}},
+		Chain: limitedChain,
+	})())
 }
 
 func TestValidateBlockDifficulty(t *testing.T) {
@@ -99,6 +127,34 @@ func TestValidateBlockDifficulty(t *testing.T) {
 	})())
 }
 
+func TestValidateBlockDifficulty_PerBlock(t *testing.T) {
+	nt := time.Now()
+	cf := *chain_config.GetChainConfig()
+	cf.PerBlockDiffHeight = 2
+	cf.PerBlockDiffWindow = 10
+	preBlock := &fakeBlock{ts: big.NewInt(nt.Add(-time.Second).UnixNano()), diff: common.HexToDiff("0x1f3fffff"), num: 1}
+	chain := &fakeChainInterface{block: preBlock, cf: &cf}
+
+	diff := model.CalWeightedWorkDiff(model.GetRecentNormalBlocks(chain, preBlock, cf.PerBlockDiffWindow+1))
+	assert.False(t, diff.Equal(preBlock.diff))
+	assert.NoError(t, ValidateBlockDifficulty(&BlockContext{
+		Block: &fakeBlock{ts: big.NewInt(nt.UnixNano()), diff: diff, num: 2},
+		Chain: chain,
+	})())
+
+	assert.Equal(t, g_error.ErrInvalidDiff, ValidateBlockDifficulty(&BlockContext{
+		Block: &fakeBlock{ts: big.NewInt(nt.UnixNano()), diff: preBlock.diff, num: 2},
+		Chain: chain,
+	})())
+
+	// the difficulty of the period algorithm is used before the activation height
+	cf.PerBlockDiffHeight = 3
+	assert.NoError(t, ValidateBlockDifficulty(&BlockContext{
+		Block: &fakeBlock{ts: big.NewInt(nt.UnixNano()), diff: preBlock.diff, num: 2},
+		Chain: chain,
+	})())
+}
+
 func TestValidateBlockCoinBase(t *testing.T) {
 	assert.NoError(t, ValidateBlockCoinBase(&BlockContext{
 		Block: &fakeBlock{},
@@ -162,6 +218,45 @@ func TestValidateBlockVersion(t *testing.T) {
 	})())
 }
 
+func TestValidateBlockTime_MedianTime(t *testing.T) {
+	tn := time.Now()
+	cf := *chain_config.GetChainConfig()
+	cf.MedianTimeHeight = 2
+	chain := &fakeChainInterface{block: &fakeBlock{num: 1, ts: big.NewInt(tn.Add(-time.Minute).UnixNano())}, cf: &cf}
+
+	assert.NoError(t, ValidateBlockTime(&BlockContext{
+		Block: &fakeBlock{num: 2, ts: big.NewInt(tn.UnixNano())},
+		Chain: chain,
+	})())
+
+	// not after the median time past
+	assert.Equal(t, g_error.ErrBlockTimeBeforeMedian, ValidateBlockTime(&BlockContext{
+		Block: &fakeBlock{num: 2, ts: big.NewInt(tn.Add(-time.Minute).UnixNano())},
+		Chain: chain,
+	})())
+	assert.Equal(t, g_error.ErrBlockTimeBeforeMedian, ValidateBlockTime(&BlockContext{
+		Block: &fakeBlock{num: 2, ts: big.NewInt(tn.Add(-2 * time.Minute).UnixNano())},
+		Chain: chain,
+	})())
+
+	// in the block time restriction but too far away in the future
+	assert.Equal(t, g_error.ErrBlockTimeTooFarAway, ValidateBlockTime(&BlockContext{
+		Block: &fakeBlock{num: 2, ts: big.NewInt(tn.Add(cf.MaxFutureBlockTime + 2*time.Second).UnixNano())},
+		Chain: chain,
+	})())
+
+	// the special blocks and the blocks before the activation height are not checked
+	assert.NoError(t, ValidateBlockTime(&BlockContext{
+		Block: &fakeBlock{num: 2, ts: big.NewInt(tn.Add(-2 * time.Minute).UnixNano()), isSpecial: true},
+		Chain: chain,
+	})())
+	cf.MedianTimeHeight = 3
+	assert.NoError(t, ValidateBlockTime(&BlockContext{
+		Block: &fakeBlock{num: 2, ts: big.NewInt(tn.Add(-2 * time.Minute).UnixNano())},
+		Chain: chain,
+	})())
+}
+
 type fakeWrongBlock struct {
 	X int
 }
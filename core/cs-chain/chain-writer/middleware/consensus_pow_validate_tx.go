@@ -20,6 +20,7 @@ import (
 	"errors"
 	"fmt"
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/chain"
 	"github.com/dipperin/dipperin-core/core/chain/state-processor"
 	"github.com/dipperin/dipperin-core/core/contract"
 	"github.com/dipperin/dipperin-core/core/economy-model"
@@ -45,6 +46,12 @@ var txValidators = map[common.TxType]func(tx model.AbstractTransaction, chain Ch
 	common.TxType(common.AddressTypeEvidence):    validEvidenceTx,
 	common.TxType(common.AddressTypeERC20):       validContractTx,
 	common.TxType(common.AddressTypeEarlyReward): validEarlyTokenTx,
+	common.TxType(common.AddressTypeDelegate):           validDelegateTx,
+	common.TxType(common.AddressTypeUnDelegate):         validUnDelegateTx,
+	common.TxType(common.AddressTypeWithdrawDelegation): validWithdrawDelegationTx,
+	common.TxType(common.AddressTypeWithdrawStake):      validWithdrawStakeTx,
+	common.TxType(common.AddressTypeGovernanceProposal): validGovernanceProposalTx,
+	common.TxType(common.AddressTypeGovernanceVote):     validGovernanceVoteTx,
 }
 
 //type TxContext struct {
@@ -86,9 +93,11 @@ func ValidateBlockTxs(c *BlockContext) Middleware {
 			}
 		}
 
+		// the governed config of the block is read once for all the txs
+		chain := &blockConfigChain{ChainInterface: c.Chain, height: c.Block.Number(), config: c.ChainConfig()}
 		// start:=time.Now()
 		for _, tx := range txs {
-			if err := validTx(tx, c.Chain, c.Block.Number()); err != nil {
+			if err := validTx(tx, chain, c.Block.Number()); err != nil {
 				return err
 			}
 		}
@@ -97,6 +106,20 @@ func ValidateBlockTxs(c *BlockContext) Middleware {
 	}
 }
 
+// blockConfigChain returns the config resolved for the block instead of reopening the state and governance trie
+type blockConfigChain struct {
+	ChainInterface
+	height uint64
+	config *chain_config.ChainConfig
+}
+
+func (c *blockConfigChain) GetChainConfigAtHeight(height uint64) *chain_config.ChainConfig {
+	if height == c.height {
+		return c.config
+	}
+	return c.ChainInterface.GetChainConfigAtHeight(height)
+}
+
 // TODO The size of transaction will influence the transaction fee?
 //func ValidTxSizeM(c *TxContext) Middleware {
 //	return func() error {
@@ -125,8 +148,9 @@ func ValidateBlockTxs(c *BlockContext) Middleware {
 //	}
 //}
 
-func ValidTxSize(tx model.AbstractTransaction) error {
-	if tx.Size() > chain_config.MaxTxSize {
+// valid the tx size with the max tx size of the block limits
+func ValidTxSize(tx model.AbstractTransaction, maxTxSize int) error {
+	if tx.Size() > common.StorageSize(maxTxSize) {
 		return g_error.ErrTxOverSize
 	}
 	return nil
@@ -199,7 +223,7 @@ func validTx(tx model.AbstractTransaction, chain ChainInterface, blockHeight uin
 		return err
 	}
 
-	if err := ValidTxSize(tx); err != nil {
+	if err := ValidTxSize(tx, getBlockLimitsForHeight(blockHeight, chain).MaxTxSize); err != nil {
 		return err
 	}
 
@@ -221,15 +245,37 @@ func validRegisterTx(tx model.AbstractTransaction, chain ChainInterface, blockHe
 		return errors.New("the tx or chain is nil")
 	}
 	if tx.Amount().Cmp(economy_model.MiniPledgeValue) == -1{
-		return errors.New("the register tx delegate is lower than MiniPledgeValue")
+		// the registered verifier can top up its stake with any amount
+		registered, err := isRegisteredVerifier(tx, chain, blockHeight)
+		if err != nil {
+			return err
+		}
+		if !registered || tx.Amount().Sign() <= 0 {
+			return errors.New("the register tx delegate is lower than MiniPledgeValue")
+		}
 	}
-	return nil
+	if blockHeight == 0 {
+		blockHeight = chain.CurrentBlock().Number() + 1
+	}
+	if !getConfigForHeight(blockHeight, chain).IsCommission(blockHeight) {
+		return nil
+	}
+	_, _, err := state_processor.DecodeCommission(tx.ExtraData())
+	return err
 }
 
 func validUnStakeTx(tx model.AbstractTransaction, chain ChainInterface, blockHeight uint64) error {
 	if err := haveStack(tx, chain, blockHeight); err != nil {
 		return err
 	}
+	// the extra data before the partial unStake height isn't decoded, the next block is used if blockHeight == 0
+	height := blockHeight
+	if height == 0 {
+		height = chain.CurrentBlock().Number() + 1
+	}
+	if len(tx.ExtraData()) > 0 && getConfigForHeight(height, chain).IsPartialUnStake(height) {
+		return validPartialUnStake(tx, chain, blockHeight)
+	}
 	if err := validUnStakeTime(tx, chain, blockHeight); err != nil {
 		return err
 	}
@@ -303,10 +349,24 @@ func getPreStateForHeight(height uint64, reader ChainInterface) (s *state_proces
 	return
 }
 
+// the chain config used by the block of the height, the next block is used if height == 0
+func getConfigForHeight(height uint64, reader ChainInterface) *chain_config.ChainConfig {
+	if height == 0 {
+		height = reader.CurrentBlock().Number() + 1
+	}
+	return reader.GetChainConfigAtHeight(height)
+}
+
+// the block limits used by the block of the height, the next block is used if height == 0
+func getBlockLimitsForHeight(height uint64, reader ChainInterface) chain_config.BlockLimits {
+	if height == 0 {
+		height = reader.CurrentBlock().Number() + 1
+	}
+	return reader.GetChainConfigAtHeight(height).GetBlockLimits(height)
+}
+
 func validEvidenceTime(tx model.AbstractTransaction, chain ChainInterface, blockHeight uint64) error {
 	chainReader := chain
-	//config := nodeContext.ChainConfig()
-	config := chain_config.GetChainConfig()
 
 	target := tx.To()
 	state, err := getPreStateForHeight(blockHeight, chainReader)
@@ -321,6 +381,7 @@ func validEvidenceTime(tx model.AbstractTransaction, chain ChainInterface, block
 
 	if lastBlock != 0 {
 		// lastBlock < (current +1) < (lastBlock/SlotSize + StakeLockSlot)*SlotSize
+		config := getConfigForHeight(blockHeight, chainReader)
 		current := chainReader.CurrentBlock().Number()
 		slotSpace := (current+1)/config.SlotSize - lastBlock/config.SlotSize
 		if slotSpace > config.StakeLockSlot {
@@ -372,8 +433,6 @@ func validUnStakeTime(tx model.AbstractTransaction, chain ChainInterface, blockH
 		return err
 	}
 	chainReader := chain
-	//config := nodeContext.ChainConfig()
-	config := chain_config.GetChainConfig()
 	state, err := getPreStateForHeight(blockHeight, chainReader)
 	if err != nil {
 		return err
@@ -398,6 +457,7 @@ func validUnStakeTime(tx model.AbstractTransaction, chain ChainInterface, blockH
 	}
 
 	// whether in lockup period
+	config := getConfigForHeight(blockHeight, chainReader)
 	current := chainReader.CurrentBlock().Number()
 	slotSpace := (current+1)/config.SlotSize - lastBlock/config.SlotSize
 	if slotSpace < config.StakeLockSlot {
@@ -449,3 +509,235 @@ func validTargetStake(tx model.AbstractTransaction, chain ChainInterface, blockH
 	}
 	return nil
 }
+
+func validDelegateTx(tx model.AbstractTransaction, chain ChainInterface, blockHeight uint64) error {
+	sender, err := tx.Sender(tx.GetSigner())
+	if err != nil {
+		return err
+	}
+	if tx.Amount().Sign() <= 0 {
+		return errors.New("the delegate amount must be positive")
+	}
+	candidate := cs_crypto.GetNormalAddressFromDelegation(*tx.To())
+	if candidate.IsEqual(sender) {
+		return errors.New("can't delegate to self, send register tx instead")
+	}
+	state, err := getPreStateForHeight(blockHeight, chain)
+	if err != nil {
+		return err
+	}
+
+	// the candidate must have registered and not cancelled
+	stake, err := state.GetStake(candidate)
+	if err != nil {
+		return state_processor.CandidateNotActiveErr
+	}
+	if stake.Sign() == 0 {
+		return state_processor.CandidateNotActiveErr
+	}
+	lastBlock, err := state.GetLastElect(candidate)
+	if err != nil {
+		return err
+	}
+	if lastBlock != 0 {
+		return state_processor.CandidateNotActiveErr
+	}
+	return state.CheckDelegation(sender, candidate, tx.Amount())
+}
+
+func validUnDelegateTx(tx model.AbstractTransaction, chain ChainInterface, blockHeight uint64) error {
+	sender, err := tx.Sender(tx.GetSigner())
+	if err != nil {
+		return err
+	}
+	amount, err := state_processor.DecodeUnDelegateAmount(tx.ExtraData())
+	if err != nil {
+		return err
+	}
+	state, err := getPreStateForHeight(blockHeight, chain)
+	if err != nil {
+		return err
+	}
+	delegation, err := state.GetDelegation(sender, cs_crypto.GetNormalAddressFromDelegation(*tx.To()))
+	if err != nil {
+		return err
+	}
+	return state_processor.CheckUnDelegation(delegation, amount)
+}
+
+// the unbonding delegation is locked for the same slots as the unStake tx
+func validWithdrawDelegationTx(tx model.AbstractTransaction, chain ChainInterface, blockHeight uint64) error {
+	sender, err := tx.Sender(tx.GetSigner())
+	if err != nil {
+		return err
+	}
+	state, err := getPreStateForHeight(blockHeight, chain)
+	if err != nil {
+		return err
+	}
+	delegation, err := state.GetDelegation(sender, cs_crypto.GetNormalAddressFromDelegation(*tx.To()))
+	if err != nil {
+		return err
+	}
+	if delegation.Unbonding.Sign() == 0 {
+		return state_processor.NoUnbondingDelegationErr
+	}
+
+	config := getConfigForHeight(blockHeight, chain)
+	current := chain.CurrentBlock().Number()
+	slotSpace := (current+1)/config.SlotSize - delegation.UnbondHeight/config.SlotSize
+	if slotSpace < config.StakeLockSlot {
+		return errors.New("invalid withdraw delegation time")
+	}
+	return nil
+}
+
+// whether the sender has stake and hasn't sent the cancel tx
+func isRegisteredVerifier(tx model.AbstractTransaction, chain ChainInterface, blockHeight uint64) (bool, error) {
+	sender, err := tx.Sender(tx.GetSigner())
+	if err != nil {
+		return false, err
+	}
+	state, err := getPreStateForHeight(blockHeight, chain)
+	if err != nil {
+		return false, err
+	}
+	stake, err := state.GetStake(sender)
+	if err != nil {
+		// not exist account
+		return false, nil
+	}
+	if stake.Sign() == 0 {
+		return false, nil
+	}
+	lastBlock, err := state.GetLastElect(sender)
+	if err != nil {
+		return false, err
+	}
+	return lastBlock == 0, nil
+}
+
+// the registered verifier can withdraw part of its stake, the rest can't be lower than MiniPledgeValue
+func validPartialUnStake(tx model.AbstractTransaction, chain ChainInterface, blockHeight uint64) error {
+	sender, err := tx.Sender(tx.GetSigner())
+	if err != nil {
+		return err
+	}
+	amount, err := state_processor.DecodePartialUnStakeAmount(tx.ExtraData())
+	if err != nil {
+		return err
+	}
+	registered, err := isRegisteredVerifier(tx, chain, blockHeight)
+	if err != nil {
+		return err
+	}
+	if !registered {
+		return state_processor.SendRegisterTxFirst
+	}
+	state, err := getPreStateForHeight(blockHeight, chain)
+	if err != nil {
+		return err
+	}
+	stake, err := state.GetStake(sender)
+	if err != nil {
+		return err
+	}
+	if big.NewInt(0).Sub(stake, amount).Cmp(economy_model.MiniPledgeValue) < 0 {
+		return errors.New("the rest stake is lower than MiniPledgeValue")
+	}
+	return nil
+}
+
+// the partially unStaked money is locked for the same slots as the unStake tx
+func validWithdrawStakeTx(tx model.AbstractTransaction, chain ChainInterface, blockHeight uint64) error {
+	sender, err := tx.Sender(tx.GetSigner())
+	if err != nil {
+		return err
+	}
+	state, err := getPreStateForHeight(blockHeight, chain)
+	if err != nil {
+		return err
+	}
+	unStaking, err := state.GetUnStaking(sender)
+	if err != nil {
+		return err
+	}
+	if unStaking.Amount.Sign() == 0 {
+		return state_processor.NoUnStakingErr
+	}
+
+	config := getConfigForHeight(blockHeight, chain)
+	current := chain.CurrentBlock().Number()
+	slotSpace := (current+1)/config.SlotSize - unStaking.Height/config.SlotSize
+	if slotSpace < config.StakeLockSlot {
+		return errors.New("invalid withdraw stake time")
+	}
+	return nil
+}
+
+// the slot of the block of the height, the next block is used if height == 0
+func getSlotForHeight(height uint64, chain ChainInterface) (uint64, error) {
+	if height == 0 {
+		height = chain.CurrentBlock().Number() + 1
+	}
+	slot := chain.GetSlotByNum(height)
+	if slot == nil {
+		return 0, errors.New("can't get the slot of the block")
+	}
+	return *slot, nil
+}
+
+// only the verifiers of the current slot can propose and vote, and the votes must be finished before
+// the verifiers of the activation slot are calculated
+func validGovernanceSender(tx model.AbstractTransaction, chain ChainInterface, blockHeight uint64, activationSlot uint64) error {
+	sender, err := tx.Sender(tx.GetSigner())
+	if err != nil {
+		return err
+	}
+	slot, err := getSlotForHeight(blockHeight, chain)
+	if err != nil {
+		return err
+	}
+	if !sender.InSlice(chain.GetVerifiers(slot)) {
+		return errors.New("the governance tx sender is not a current verifier")
+	}
+	if activationSlot <= slot+chain.GetChainConfig().SlotMargin {
+		return errors.New("the governance activation slot is too early")
+	}
+	return nil
+}
+
+func validGovernanceProposalTx(tx model.AbstractTransaction, chain ChainInterface, blockHeight uint64) error {
+	proposal, err := state_processor.DecodeGovernanceProposal(tx.ExtraData())
+	if err != nil {
+		return err
+	}
+	if proposal.Param == state_processor.GovernanceParamVerifierNumber && proposal.Value > maxVerifierNumber() {
+		return state_processor.InvalidGovernanceValueErr
+	}
+	return validGovernanceSender(tx, chain, blockHeight, proposal.ActivationSlot)
+}
+
+// the default verifiers take the empty places, so there can't be more verifiers than them
+func maxVerifierNumber() uint64 {
+	return uint64(len(chain.VerifierAddress))
+}
+
+func validGovernanceVoteTx(tx model.AbstractTransaction, chain ChainInterface, blockHeight uint64) error {
+	id, err := state_processor.DecodeGovernanceVote(tx.ExtraData())
+	if err != nil {
+		return err
+	}
+	state, err := getPreStateForHeight(blockHeight, chain)
+	if err != nil {
+		return err
+	}
+	proposal, err := state.GetGovernanceProposal(id)
+	if err != nil {
+		return err
+	}
+	if proposal.Passed {
+		return state_processor.GovernanceProposalPassedErr
+	}
+	return validGovernanceSender(tx, chain, blockHeight, proposal.ActivationSlot)
+}
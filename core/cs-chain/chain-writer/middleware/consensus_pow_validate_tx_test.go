@@ -63,9 +63,42 @@ func TestNewTxValidatorForRpcService(t *testing.T) {
 	}, Chain: &fakeChainInterface{}})())
 }
 
+// configCountChain counts the governed config reads
+type configCountChain struct {
+	*fakeChainInterface
+	configReads int
+}
+
+func (ci *configCountChain) GetChainConfigAtHeight(height uint64) *chain_config.ChainConfig {
+	ci.configReads++
+	return ci.fakeChainInterface.GetChainConfigAtHeight(height)
+}
+
+func TestValidateBlockTxs_ChainConfigOnce(t *testing.T) {
+	_, _, passTx, passChain := getTxTestEnv(t)
+	chain := &configCountChain{fakeChainInterface: passChain}
+	txs := []model.AbstractTransaction{passTx, passTx, passTx}
+	c := &BlockContext{Block: &fakeBlock{
+		num:    2,
+		txRoot: model.DeriveSha(model.AbsTransactions(txs)),
+		txs:    txs,
+	}, Chain: chain}
+
+	// the config of the block is resolved once for all the txs and the other middlewares
+	assert.NoError(t, ValidateBlockTxs(c)())
+	assert.Equal(t, 1, chain.configReads)
+	assert.Equal(t, chain.GetChainConfig(), c.ChainConfig())
+	assert.Equal(t, 1, chain.configReads)
+
+	// the other heights are still read from the chain
+	blockChain := &blockConfigChain{ChainInterface: chain, height: 2, config: c.ChainConfig()}
+	blockChain.GetChainConfigAtHeight(3)
+	assert.Equal(t, 2, chain.configReads)
+}
+
 func TestTxValidatorForRpcService_Valid(t *testing.T) {
-	assert.Error(t, ValidTxSize(&fakeTx{size: chain_config.MaxTxSize + 1}))
-	assert.NoError(t, ValidTxSize(&fakeTx{}))
+	assert.Error(t, ValidTxSize(&fakeTx{size: chain_config.DefaultMaxTxSize + 1}, chain_config.DefaultMaxTxSize))
+	assert.NoError(t, ValidTxSize(&fakeTx{}, chain_config.DefaultMaxTxSize))
 
 	assert.Error(t, ValidTxSender(&fakeTx{
 		sender: common.Address{0x11},
@@ -125,7 +158,7 @@ func TestValidTxByType(t *testing.T) {
 func Test_validTx(t *testing.T) {
 	_, _, passTx, passChain := getTxTestEnv(t)
 	assert.NoError(t, validTx(passTx, passChain, 0))
-	passTx.size = chain_config.MaxTxSize + 1
+	passTx.size = chain_config.DefaultMaxTxSize + 1
 	assert.Error(t, validTx(passTx, passChain, 0))
 	passTx.size = 1
 	passTx.txType = 0x9999
@@ -144,6 +177,26 @@ func Test_validUnStakeTx(t *testing.T) {
 	assert.Error(t, validUnStakeTx(passTx, passChain, 0))
 }
 
+func Test_validUnStakeTx_PartialUnStakeHeight(t *testing.T) {
+	config := chain_config.GetChainConfig()
+	defer func(height uint64) { config.PartialUnStakeHeight = height }(config.PartialUnStakeHeight)
+	config.PartialUnStakeHeight = 5
+
+	s, adb, passTx, passChain := getTxTestEnv(t)
+	assert.NoError(t, adb.AddStake(s, big.NewInt(0).Add(economy_model.MiniPledgeValue, big.NewInt(100))))
+	passTx.extraData, _ = rlp.EncodeToBytes(big.NewInt(50))
+
+	// the extra data isn't decoded before the partial unStake height, the verifier must cancel first
+	assert.Equal(t, state_processor.SendCancelTxFirst, validUnStakeTx(passTx, passChain, 4))
+	assert.NoError(t, validUnStakeTx(passTx, passChain, 5))
+
+	// the tx in the pool is checked with the next block
+	passChain.block = &fakeBlock{num: 3}
+	assert.Equal(t, state_processor.SendCancelTxFirst, validUnStakeTx(passTx, passChain, 0))
+	passChain.block = &fakeBlock{num: 4}
+	assert.NoError(t, validUnStakeTx(passTx, passChain, 0))
+}
+
 func Test_validCancelTx(t *testing.T) {
 	s, adb, passTx, passChain := getTxTestEnv(t)
 	assert.Error(t, validCancelTx(passTx, passChain, 0))
@@ -481,6 +534,10 @@ func (ci *fakeChainInterface) GetChainConfig() *chain_config.ChainConfig {
 	return ci.cf
 }
 
+func (ci *fakeChainInterface) GetChainConfigAtHeight(height uint64) *chain_config.ChainConfig {
+	return ci.GetChainConfig()
+}
+
 func (ci *fakeChainInterface) GetEconomyModel() economy_model.EconomyModel {
 	return ci.em
 }
@@ -769,7 +826,7 @@ func (fb *fakeBlock) Body() model.AbstractBody {
 }
 
 func (fb *fakeBlock) TxCount() int {
-	return 0
+	return len(fb.txs)
 }
 
 func (fb *fakeBlock) GetEiBloomBlockData(reqEstimator *iblt.HybridEstimator) *model.BloomBlockData {
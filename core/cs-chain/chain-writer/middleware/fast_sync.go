@@ -0,0 +1,118 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/log"
+)
+
+// The blocks before the pivot of the fast sync are saved without their states, so only the
+// validations which don't read any state are used for them.
+
+func ValidateBlockTxRoot(c *BlockContext) Middleware {
+	return func() error {
+		txs := c.Block.GetAbsTransactions()
+		targetRoot := model.DeriveSha(model.AbsTransactions(txs))
+
+		if !targetRoot.IsEqual(c.Block.TxRoot()) {
+			return errors.New(fmt.Sprintf("tx root not match, target: %v, root in block: %v", targetRoot.Hex(), c.Block.TxRoot().Hex()))
+		}
+
+		if c.Block.IsSpecial() && txs != nil {
+			return errors.New("special block should not have transactions")
+		}
+		return c.Next()
+	}
+}
+
+func ValidateVerificationRoot(c *BlockContext) Middleware {
+	return func() error {
+		if c.Block.Number() == 1 {
+			if !c.Block.VerificationRoot().IsEqual(model.EmptyVerfRoot) || len(c.Block.GetVerifications()) != 0 {
+				return g_error.ErrFirstBlockShouldNotHaveVerifications
+			}
+			return c.Next()
+		}
+
+		if err := validVerificationRoot(c.Block.GetVerifications(), c.Block.VerificationRoot()); err != nil {
+			return err
+		}
+		return c.Next()
+	}
+}
+
+// valid block hash for the difficulty in the block, the difficulty itself needs the state to be checked
+func ValidateBlockPow(c *BlockContext) Middleware {
+	return func() error {
+		if c.Block.IsSpecial() || model.IsIgnoreDifficultyValidation() {
+			return c.Next()
+		}
+
+		if !c.Block.RefreshHashCache().ValidHashForDifficulty(c.Block.Difficulty()) {
+			return g_error.ErrWrongHashDiff
+		}
+		return c.Next()
+	}
+}
+
+// ValidatePreBlockWithoutState checks the block is linked to the saved block before it by the pre hash, the seals
+// and votes of the blocks without state aren't verified, so they are only trusted when the trusted checkpoint is
+// linked to them
+func ValidatePreBlockWithoutState(c *BlockContext) Middleware {
+	return func() error {
+		if c.Block.Number() == 0 {
+			return c.Next()
+		}
+		preBlock := c.Chain.GetBlockByNumber(c.Block.Number() - 1)
+		if preBlock == nil {
+			return g_error.ErrPreBlockIsNil
+		}
+		if !preBlock.Hash().IsEqual(c.Block.PreHash()) {
+			return g_error.ErrPreBlockHashNotMatch
+		}
+		return c.Next()
+	}
+}
+
+// InsertBlockWithoutState saves the block as the canonical block of its height, the current block isn't changed
+func InsertBlockWithoutState(c *BlockContext) Middleware {
+	return func() error {
+		if c.Block.Number() <= c.Chain.CurrentBlock().Number() {
+			return g_error.ErrBlockHeightTooLow
+		}
+
+		chainDB := c.Chain.GetChainDB()
+		chainDB.SaveBlock(c.Block)
+		chainDB.SaveTxLookupEntries(c.Block)
+		chainDB.SaveBlockHash(c.Block.Hash(), c.Block.Number())
+		log.Info("insert block without state successful", "num", c.Block.Number())
+		return c.Next()
+	}
+}
+
+// ValidateSeenCommits checks the seen commits of the block are signed by enough verifiers
+func ValidateSeenCommits(block model.AbstractBlock, votes []model.AbstractVerification, verifiers []common.Address) error {
+	if err := validVotesForBlock(votes, block, verifiers); err != nil {
+		return err
+	}
+	return validBlockHash(votes, block)
+}
@@ -0,0 +1,33 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package middleware
+
+import (
+	"testing"
+
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidatePreBlockWithoutState(t *testing.T) {
+	preBlock := &fakeBlock{num: 9, hash: common.Hash{0x9}}
+	chain := &fakeChainInterface{block: preBlock}
+
+	assert.NoError(t, ValidatePreBlockWithoutState(&BlockContext{Block: &fakeBlock{num: 10, preHash: common.Hash{0x9}}, Chain: chain})())
+	assert.Equal(t, g_error.ErrPreBlockHashNotMatch, ValidatePreBlockWithoutState(&BlockContext{Block: &fakeBlock{num: 10, preHash: common.Hash{0x8}}, Chain: chain})())
+}
@@ -18,6 +18,7 @@
 package middleware
 
 import (
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/model"
 )
 
@@ -39,6 +40,17 @@ type BlockContext struct {
 	Block model.AbstractBlock
 	// chain
 	Chain ChainInterface
+
+	// the governed chain config of the block, resolved once for all the middlewares
+	chainConfig *chain_config.ChainConfig
+}
+
+// ChainConfig returns the governed chain config used by the block
+func (bc *BlockContext) ChainConfig() *chain_config.ChainConfig {
+	if bc.chainConfig == nil {
+		bc.chainConfig = bc.Chain.GetChainConfigAtHeight(bc.Block.Number())
+	}
+	return bc.chainConfig
 }
 
 // basic middleware, can be comprised by other middleware
@@ -43,6 +43,10 @@ func (f *ChainWriterFactory) NewWriter(context interface{}) ChainWriter {
 		return NewBftChainWriter(c, f.chain)
 	case *middleware.BftBlockContextWithoutVotes:
 		return NewBftChainWriterWithoutVotes(c, f.chain)
+	case *middleware.BftBlockContextWithoutState:
+		return NewBftChainWriterWithoutState(c, f.chain)
+	case *middleware.BftBlockContextBeforeCheckpoint:
+		return NewBftChainWriterBeforeCheckpoint(c, f.chain)
 	}
 
 	panic(fmt.Sprintf("block context type error, got: %v", reflect.TypeOf(context)))
@@ -31,6 +31,7 @@ func TestNewChainWriterFactory(t *testing.T) {
 	f.NewWriter(&middleware.BlockContext{})
 	f.NewWriter(&middleware.BftBlockContext{})
 	f.NewWriter(&middleware.BftBlockContextWithoutVotes{})
+	f.NewWriter(&middleware.BftBlockContextBeforeCheckpoint{})
 	assert.Panics(t, func() {
 		f.NewWriter(&model.Block{})
 	})
@@ -93,6 +93,7 @@ type StateHelper interface {
 
 type ChainHelper interface {
 	GetChainConfig() *chain_config.ChainConfig
+	GetChainConfigAtHeight(height uint64) *chain_config.ChainConfig
 	GetEconomyModel() economy_model.EconomyModel
 	GetChainDB() chaindb.Database
 }
@@ -0,0 +1,132 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package cs_chain
+
+import (
+	"fmt"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/g-error"
+	"github.com/dipperin/dipperin-core/common/g-metrics"
+	"github.com/dipperin/dipperin-core/core/cs-chain/chain-writer/middleware"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/log/pbft_log"
+)
+
+// InsertBlockWithoutState saves the block downloaded by the fast sync and its seen commits,
+// the txs are not processed and the current block isn't changed
+func (cs *CsChainService) InsertBlockWithoutState(block model.AbstractBlock, seenCommits []model.AbstractVerification) error {
+	cs.wg.Add(1)
+	defer cs.wg.Done()
+
+	cs.saveBlockLock.Lock()
+	defer cs.saveBlockLock.Unlock()
+
+	if err := cs.SaveBftBlockWithoutState(block, seenCommits); err != nil {
+		return err
+	}
+
+	if len(seenCommits) > 0 {
+		if err := cs.CacheDB.SaveSeenCommits(block.Number(), common.Hash{}, seenCommits); err != nil {
+			pbft_log.Error("save seenCommits failed", "err", err)
+			return err
+		}
+	}
+	return nil
+}
+
+// CommitFastSyncPivot sets the pivot of the fast sync as the current block after its state is downloaded.
+// The seals and votes of the blocks before the pivot aren't verified, so the pivot must be a trusted checkpoint
+// which is linked to them by the pre hashes. The verifiers of the pivot are calculated with the downloaded
+// states of the blocks before it, the current block isn't changed if the seen commits of the pivot are not
+// signed by them.
+func (cs *CsChainService) CommitFastSyncPivot(block model.AbstractBlock, seenCommits []model.AbstractVerification) error {
+	cs.wg.Add(1)
+	defer cs.wg.Done()
+
+	cs.saveBlockLock.Lock()
+	defer cs.saveBlockLock.Unlock()
+
+	if cp := cs.GetChainConfig().GetCheckpoint(block.Number()); cp == nil || !cp.Hash.IsEqual(block.Hash()) {
+		return g_error.ErrPivotNotCheckpoint
+	}
+	if !cs.ChainDB.GetBlockHashByNumber(block.Number()).IsEqual(block.Hash()) {
+		return g_error.ErrBlockNotFound
+	}
+	if _, err := cs.StateAtByStateRoot(block.StateRoot()); err != nil {
+		return g_error.ErrPivotStateNotFound
+	}
+	if _, err := cs.BuildRegisterProcessor(block.GetRegisterRoot()); err != nil {
+		return g_error.ErrPivotStateNotFound
+	}
+
+	oldCurrentBlock := cs.CurrentBlock()
+	cs.setCurrentBlock(block)
+
+	verifiers, err := cs.pivotVerifiers(block)
+	if err == nil {
+		err = middleware.ValidateSeenCommits(block, seenCommits, verifiers)
+	}
+	if err != nil {
+		pbft_log.Warn("the seen commits of the fast sync pivot are invalid", "num", block.Number(), "err", err)
+		cs.setCurrentBlock(oldCurrentBlock)
+		// the verifiers may be calculated with the wrong current block
+		cs.cachedVerifiers.Purge()
+		cs.slotCache.Purge()
+		return err
+	}
+
+	if err := cs.CacheDB.SaveSeenCommits(block.Number(), common.Hash{}, seenCommits); err != nil {
+		pbft_log.Error("save seenCommits failed", "err", err)
+		return err
+	}
+	cs.TxPool.Reset(oldCurrentBlock.Header().(*model.Header), block.Header().(*model.Header))
+
+	g_metrics.Set(g_metrics.CurChainHeight, "", float64(block.Number()))
+	pbft_log.Info("fast sync pivot committed", "num", block.Number(), "hash", block.Hash().Hex())
+	return nil
+}
+
+func (cs *CsChainService) setCurrentBlock(block model.AbstractBlock) {
+	cs.ChainDB.SaveHeadBlockHash(block.Hash())
+	cs.ChainDB.SaveHeadHeaderHash(block.Header().Hash())
+	cs.currentBlock.Store(block)
+	cs.currentHeader.Store(block.Header())
+}
+
+// the calculation of verifiers panics if the blocks or states are missing
+func (cs *CsChainService) pivotVerifiers(block model.AbstractBlock) (verifiers []common.Address, err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("%v: %v", g_error.ErrPivotVerifiersNotFound, r)
+		}
+	}()
+
+	slot := cs.GetSlot(block)
+	if slot == nil {
+		return nil, g_error.ErrPivotVerifiersNotFound
+	}
+	return cs.GetVerifiers(*slot), nil
+}
+
+// GetStateEntry returns the trie node or the preimage of the hash, they are requested by the fast sync of the other nodes
+func (cs *CsChainService) GetStateEntry(hash common.Hash) ([]byte, error) {
+	trieDB := cs.StateStorage.TrieDB()
+	if blob, err := trieDB.Node(hash); err == nil && len(blob) > 0 {
+		return blob, nil
+	}
+	return trieDB.Preimage(hash)
+}
@@ -108,9 +108,18 @@ func NewCsChainService(config *CsChainServiceConfig, cs *chain_state.ChainState)
 	return service
 }
 
+// Start does nothing, the chain service is started when it's created. It's a node service so the states
+// kept in memory by the pruned node are flushed when the node stops.
+func (cs *CsChainService) Start() error {
+	return nil
+}
+
 func (cs *CsChainService) Stop() {
 	close(cs.Quit)
 	cs.wg.Wait()
+	if err := cs.TrieGC.Flush(); err != nil {
+		log.Error("flush recent states failed", "err", err)
+	}
 	log.Info("Blockchain manager stopped")
 }
 
@@ -201,7 +210,7 @@ func (cs *CsChainService) SaveBlock(block model.AbstractBlock, seenCommits []mod
 		return err
 	}
 
-	if err := cs.saveBftBlock(block, seenCommits); err != nil {
+	if err := cs.saveBftBlock(block, seenCommits, cs.SaveBftBlock); err != nil {
 		g_metrics.Add(g_metrics.FailedInsertBlockCount, "", 1)
 		return err
 	}
@@ -212,6 +221,28 @@ func (cs *CsChainService) SaveBlock(block model.AbstractBlock, seenCommits []mod
 	return nil
 }
 
+// SaveBlockBeforeCheckpoint saves the block downloaded until the trusted checkpoint, its votes and seal are not
+// verified because the checkpoint is linked to it by the pre hashes
+func (cs *CsChainService) SaveBlockBeforeCheckpoint(block model.AbstractBlock, seenCommits []model.AbstractVerification) error {
+	cs.wg.Add(1)
+	defer cs.wg.Done()
+
+	cs.saveBlockLock.Lock()
+	defer cs.saveBlockLock.Unlock()
+
+	if err := cs.checkBftBlock(block, seenCommits); err != nil {
+		return err
+	}
+
+	if err := cs.saveBftBlock(block, seenCommits, cs.SaveBftBlockBeforeCheckpoint); err != nil {
+		g_metrics.Add(g_metrics.FailedInsertBlockCount, "", 1)
+		return err
+	}
+
+	g_metrics.Set(g_metrics.CurChainHeight, "", float64(cs.CurrentBlock().Number()))
+	return nil
+}
+
 func (cs *CsChainService) checkBftBlock(block model.AbstractBlock, seenCommits []model.AbstractVerification) error {
 	// todo this can be optimized in middleware
 	if block.Number() <= cs.CurrentBlock().Number() {
@@ -226,9 +257,9 @@ func (cs *CsChainService) checkBftBlock(block model.AbstractBlock, seenCommits [
 	return nil
 }
 
-func (cs *CsChainService) saveBftBlock(block model.AbstractBlock, seenCommits []model.AbstractVerification) error {
+func (cs *CsChainService) saveBftBlock(block model.AbstractBlock, seenCommits []model.AbstractVerification, save func(model.AbstractBlock, []model.AbstractVerification) error) error {
 	oldCurrentHead := cs.CurrentHeader().(*model.Header)
-	err := cs.SaveBftBlock(block, seenCommits)
+	err := save(block, seenCommits)
 	switch err {
 	case nil:
 
@@ -245,6 +276,11 @@ func (cs *CsChainService) saveBftBlock(block model.AbstractBlock, seenCommits []
 		// check future block
 		cs.FutureBlocks.Remove(block.Hash())
 
+		// the block is saved even if the old states can't be released
+		if err := cs.TrieGC.Commit(block.Number(), block.StateRoot(), block.GetRegisterRoot()); err != nil {
+			log.Error("trie gc failed", "num", block.Number(), "err", err)
+		}
+
 		// insert success then calculate verifiers
 		if cs.IsChangePoint(block, false) {
 			cs.CalVerifiers(block)
@@ -312,12 +348,17 @@ func (cs *CsChainService) initService() error {
 	}
 
 	// check genesis block
-	if genesisBlock := cs.CacheChainState.Genesis(); genesisBlock == nil {
+	genesisBlock := cs.CacheChainState.Genesis()
+	if genesisBlock == nil {
 		return g_error.ErrNoGenesis
 	}
+	// the state of the genesis is always flushed to the disk
+	if err := cs.TrieGC.Commit(0, genesisBlock.StateRoot(), genesisBlock.GetRegisterRoot()); err != nil {
+		return err
+	}
 
 	headBlockHash := cs.CacheChainState.ChainState.ChainDB.GetHeadBlockHash()
-	currentBlock := cs.CacheChainState.GetBlockByHash(headBlockHash)
+	currentBlock := cs.repairHead(cs.CacheChainState.GetBlockByHash(headBlockHash))
 
 	cs.CacheChainState.currentBlock.Store(currentBlock)
 	currentHeader := currentBlock.Header()
@@ -347,6 +388,30 @@ func (cs *CsChainService) initService() error {
 	return nil
 }
 
+// the pruned node loses the states kept in memory if it isn't stopped normally, the latest block with
+// the state is set as the current block and the blocks after it are synced again
+func (cs *CsChainService) repairHead(head model.AbstractBlock) model.AbstractBlock {
+	if !state_processor.IsPrunedStorage(cs.StateStorage) {
+		return head
+	}
+
+	db := cs.ChainDB.DB()
+	for block := head; block != nil; block = cs.GetBlockByNumber(block.Number() - 1) {
+		if state_processor.HasTrie(db, block.StateRoot()) && state_processor.HasTrie(db, block.GetRegisterRoot()) {
+			if block.Number() != head.Number() {
+				log.Warn("the state of the current block is missing, rewind the chain", "from", head.Number(), "to", block.Number())
+				cs.ChainDB.SaveHeadBlockHash(block.Hash())
+				cs.ChainDB.SaveHeadHeaderHash(block.Header().Hash())
+			}
+			return block
+		}
+		if block.Number() == 0 {
+			break
+		}
+	}
+	return head
+}
+
 func (cs *CsChainService) handleFutureBlockTask() {
 	// 5s update chain future block
 	tickHandler := func() { cs.handleFutureBlock() }
@@ -35,10 +35,15 @@ import (
 
 // new bft node
 func NewCsBft(config *state_machine.BftConfig) *CsBft {
+    return NewCsBftWithTimeoutConfig(config, state_machine.DefaultConfig)
+}
+
+// new bft node with the timeout config of the state handler
+func NewCsBftWithTimeoutConfig(config *state_machine.BftConfig, timeoutConfig state_machine.Config) *CsBft {
     bft := &CsBft{BftConfig: config}
     bp := components.NewBlockPool(0, nil)
     bp.SetNodeConfig(config.ChainReader)
-    stateHandler :=state_machine.NewStateHandler(config,state_machine.DefaultConfig,bp)
+    stateHandler :=state_machine.NewStateHandler(config,timeoutConfig,bp)
     bp.SetPoolEventNotifier(stateHandler)
     bft.blockPool = bp
     bft.stateHandler = stateHandler
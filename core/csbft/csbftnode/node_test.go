@@ -170,6 +170,10 @@ func (fc *FakeFullChain) GetCurrVerifiers() []common.Address {
 	return v
 }
 
+func (fc *FakeFullChain) GetVerifierWeights(height uint64, verifiers []common.Address) []uint64 {
+	return nil
+}
+
 func (fc *FakeFullChain) SetNewHeightNotifier(nc func(height uint64)){
 	fc.notify = nc
 }
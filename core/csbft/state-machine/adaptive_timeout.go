@@ -0,0 +1,118 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+
+package state_machine
+
+import (
+	"github.com/dipperin/dipperin-core/common/g-metrics"
+	model2 "github.com/dipperin/dipperin-core/core/csbft/model"
+	"time"
+)
+
+// stepTimeouts decides the timeout of the propose, prevote and precommit steps.
+// In adaptive mode, it records how long each step took to complete in recent rounds,
+// uses twice the slowest one as the timeout, and raises the timeout by half when a step times out.
+// The timeout always stays in [MinStepTimeout, MaxStepTimeout].
+type stepTimeouts struct {
+	config Config
+
+	current map[model2.RoundStepType]time.Duration
+	latency map[model2.RoundStepType][]time.Duration
+	enterAt map[model2.RoundStepType]time.Time
+}
+
+func newStepTimeouts(config Config) *stepTimeouts {
+	st := &stepTimeouts{
+		config: config,
+		current: map[model2.RoundStepType]time.Duration{
+			model2.RoundStepPropose:   config.ProposalTimeout,
+			model2.RoundStepPreVote:   config.PreVoteTimeout,
+			model2.RoundStepPreCommit: config.PreCommitTimeout,
+		},
+		latency: map[model2.RoundStepType][]time.Duration{},
+		enterAt: map[model2.RoundStepType]time.Time{},
+	}
+	for step, d := range st.current {
+		st.setTimeout(step, d)
+	}
+	return st
+}
+
+func (st *stepTimeouts) timeout(step model2.RoundStepType) time.Duration {
+	return st.current[step]
+}
+
+// called when the state handler enters the step
+func (st *stepTimeouts) onEnter(step model2.RoundStepType) {
+	st.enterAt[step] = time.Now()
+}
+
+// called when the step completed before its timeout
+func (st *stepTimeouts) onFinish(step model2.RoundStepType) {
+	enterAt, ok := st.enterAt[step]
+	if !ok {
+		return
+	}
+	delete(st.enterAt, step)
+	st.addLatency(step, time.Now().Sub(enterAt))
+}
+
+// called when the step timed out
+func (st *stepTimeouts) onTimeout(step model2.RoundStepType) {
+	delete(st.enterAt, step)
+	if !st.config.AdaptiveTimeout {
+		return
+	}
+	st.setTimeout(step, st.current[step]*3/2)
+}
+
+func (st *stepTimeouts) addLatency(step model2.RoundStepType, d time.Duration) {
+	if !st.config.AdaptiveTimeout {
+		return
+	}
+
+	window := st.config.LatencyWindow
+	if window <= 0 {
+		window = 1
+	}
+	latency := append(st.latency[step], d)
+	if len(latency) > window {
+		latency = latency[len(latency)-window:]
+	}
+	st.latency[step] = latency
+
+	slowest := time.Duration(0)
+	for _, l := range latency {
+		if l > slowest {
+			slowest = l
+		}
+	}
+	st.setTimeout(step, 2*slowest)
+}
+
+func (st *stepTimeouts) setTimeout(step model2.RoundStepType, d time.Duration) {
+	if st.config.AdaptiveTimeout {
+		if d < st.config.MinStepTimeout {
+			d = st.config.MinStepTimeout
+		}
+		if st.config.MaxStepTimeout > 0 && d > st.config.MaxStepTimeout {
+			d = st.config.MaxStepTimeout
+		}
+	}
+	st.current[step] = d
+	g_metrics.Set(g_metrics.BftStepTimeoutGauge, step.String(), d.Seconds())
+}
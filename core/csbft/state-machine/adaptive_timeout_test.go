@@ -0,0 +1,71 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+
+package state_machine
+
+import (
+	model2 "github.com/dipperin/dipperin-core/core/csbft/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestStepTimeouts_Fixed(t *testing.T) {
+	st := newStepTimeouts(TestConfig)
+	assert.Equal(t, TestConfig.ProposalTimeout, st.timeout(model2.RoundStepPropose))
+
+	st.onEnter(model2.RoundStepPropose)
+	st.onFinish(model2.RoundStepPropose)
+	st.onTimeout(model2.RoundStepPreVote)
+	assert.Equal(t, TestConfig.ProposalTimeout, st.timeout(model2.RoundStepPropose))
+	assert.Equal(t, TestConfig.PreVoteTimeout, st.timeout(model2.RoundStepPreVote))
+}
+
+func TestStepTimeouts_Adaptive(t *testing.T) {
+	config := TestConfig
+	config.AdaptiveTimeout = true
+	config.MinStepTimeout = 100 * time.Millisecond
+	config.MaxStepTimeout = time.Second
+	config.LatencyWindow = 3
+	st := newStepTimeouts(config)
+
+	// raise on timeout, but not over the max
+	st.onTimeout(model2.RoundStepPreVote)
+	assert.Equal(t, 300*time.Millisecond, st.timeout(model2.RoundStepPreVote))
+	for i := 0; i < 10; i++ {
+		st.onTimeout(model2.RoundStepPreVote)
+	}
+	assert.Equal(t, time.Second, st.timeout(model2.RoundStepPreVote))
+
+	// a slow round sets the timeout, fast rounds push it out of the window
+	st.addLatency(model2.RoundStepPreVote, 400*time.Millisecond)
+	assert.Equal(t, 800*time.Millisecond, st.timeout(model2.RoundStepPreVote))
+	for i := 0; i < 3; i++ {
+		st.addLatency(model2.RoundStepPreVote, 10*time.Millisecond)
+	}
+	assert.Equal(t, 100*time.Millisecond, st.timeout(model2.RoundStepPreVote))
+
+	// other steps are not affected
+	assert.Equal(t, config.ProposalTimeout, st.timeout(model2.RoundStepPropose))
+
+	// finish without enter is ignored
+	st.onFinish(model2.RoundStepPreCommit)
+	assert.Equal(t, config.PreCommitTimeout, st.timeout(model2.RoundStepPreCommit))
+	st.onEnter(model2.RoundStepPreCommit)
+	st.onFinish(model2.RoundStepPreCommit)
+	assert.Equal(t, config.MinStepTimeout, st.timeout(model2.RoundStepPreCommit))
+}
@@ -25,6 +25,11 @@ var DefaultConfig = Config{
 	ProposalTimeout:    8 * time.Second,
 	PreVoteTimeout:     8 * time.Second,
 	PreCommitTimeout:   8 * time.Second,
+
+	AdaptiveTimeout: false,
+	MinStepTimeout:  2 * time.Second,
+	MaxStepTimeout:  30 * time.Second,
+	LatencyWindow:   10,
 	//WaitNewRound:       2 * time.Second,
 	//WaitProposeTimeout: 2 * time.Second,
 	//ProposalTimeout:    3 * time.Second,
@@ -38,4 +43,12 @@ type Config struct {
 	ProposalTimeout     time.Duration
 	PreVoteTimeout      time.Duration
 	PreCommitTimeout    time.Duration
+
+	// adjust the propose, prevote and precommit timeout by the latency of recent rounds
+	AdaptiveTimeout bool
+	// bounds of the adaptive timeout
+	MinStepTimeout time.Duration
+	MaxStepTimeout time.Duration
+	// how many recent rounds are used to calculate the adaptive timeout
+	LatencyWindow int
 }
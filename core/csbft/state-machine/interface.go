@@ -29,6 +29,8 @@ type ChainReader interface {
 	IsChangePoint(block model.AbstractBlock,isProcessPackageBlock bool) bool
 	GetNextVerifiers() []common.Address
 	GetCurrVerifiers() []common.Address
+	// weights of the verifiers used to select the proposer at this height, nil if not activated
+	GetVerifierWeights(height uint64, verifiers []common.Address) []uint64
 }
 
 type MsgSigner interface {
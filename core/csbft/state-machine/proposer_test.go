@@ -0,0 +1,65 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+
+package state_machine
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/csbft/components"
+	model2 "github.com/dipperin/dipperin-core/core/csbft/model"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBftState_proposerAtRound(t *testing.T) {
+	_, v := CreateKey()
+	bs := NewBftState(1, 0, model2.RoundStepNewHeight)
+
+	// plain rotation without weights
+	for round := uint64(0); round < 8; round++ {
+		assert.Equal(t, v[round%4], bs.proposerAtRound(round))
+	}
+
+	// equal weights keep the plain rotation
+	bs.SetProposerWeights([]uint64{10, 10, 10, 10})
+	for round := uint64(0); round < 8; round++ {
+		assert.Equal(t, v[round%4], bs.proposerAtRound(round))
+	}
+
+	bs.SetProposerWeights([]uint64{9000, 1000, 1000, 1000})
+	count := map[common.Address]int{}
+	for round := uint64(0); round < uint64(len(bs.ProposerSequence))*3; round++ {
+		count[bs.proposerAtRound(round)]++
+	}
+	assert.Equal(t, 300, count[v[0]])
+	assert.Equal(t, 33, count[v[1]])
+
+	// weights are reset on new height
+	bs.enterNewHeight(2, 0, v)
+	assert.Nil(t, bs.ProposerSequence)
+}
+
+func TestStateHandler_OnNewHeightWithWeights(t *testing.T) {
+	fc := NewFakeFullChain()
+	fc.Weights = []uint64{9000, 1000, 1000, 1000}
+	sks, _ := CreateKey()
+	config := &BftConfig{fc, &FakeFetcher{}, newFackSigner(sks[0]), &FackMsgSender{}, &FakeValidtor{}}
+	sh := NewStateHandler(config, TestConfig, components.NewBlockPool(fc.Height+1, nil))
+
+	assert.NotNil(t, sh.bs.ProposerSequence)
+	assert.Equal(t, sh.bs.proposerAtRound(sh.bs.Round), sh.curProposer())
+}
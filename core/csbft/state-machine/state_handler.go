@@ -36,6 +36,7 @@ type StateHandler struct {
 	util.BaseService
 	*BftConfig
 	timeoutConfig Config
+	stepTimeouts  *stepTimeouts
 
 	bs        *BftState
 	blockPool *components.BlockPool
@@ -73,6 +74,7 @@ func NewStateHandler(bftConfig *BftConfig, timeConfig Config, blockPool *compone
 	h := &StateHandler{
 		bs:               &BftState{Height: 0, BlockPoolNotEmpty: false},
 		timeoutConfig:    timeConfig,
+		stepTimeouts:     newStepTimeouts(timeConfig),
 		blockPool:        blockPool,
 		newHeightChan:    make(chan uint64, 5),
 		newRoundChan:     make(chan *model2.NewRoundMsg, 5),
@@ -164,10 +166,13 @@ func (h *StateHandler) OnNewHeight(height uint64) {
 	if h.ChainReader.IsChangePoint(Block, false) {
 		verifiers := h.ChainReader.GetNextVerifiers()
 		h.bs.OnNewHeight(height, 0, verifiers)
+		h.bs.SetProposerWeights(h.ChainReader.GetVerifierWeights(height, verifiers))
 		return
 	}
 
-	h.bs.OnNewHeight(height, round+1, h.ChainReader.GetCurrVerifiers())
+	verifiers := h.ChainReader.GetCurrVerifiers()
+	h.bs.OnNewHeight(height, round+1, verifiers)
+	h.bs.SetProposerWeights(h.ChainReader.GetVerifierWeights(height, verifiers))
 	pbft_log.Debug(fmt.Sprintf("EnterNewHeight (H: %v, R: %v, S: %v)",h.bs.Height,h.bs.Round,h.bs.Step))
 }
 
@@ -268,6 +273,7 @@ func (h *StateHandler) OnVote(v *model.VoteMsg) {
 		}
 
 		if block != nil {
+			h.stepTimeouts.onFinish(model2.RoundStepPreCommit)
 			pbft_log.Info("[StateHandler-OnVote]:finalBlock","blockNumber",block.Number())
 			h.finalBlock(block, commits)
 			pbft_log.Info("=========================================================================")
@@ -285,14 +291,17 @@ func (h *StateHandler) OnTimeout(toutInfo components.TimeoutInfo) {
 	switch toutInfo.Step {
 	case model2.RoundStepPropose:
 		h.addTimeoutCount("RoundStepPropose")
+		h.stepTimeouts.onTimeout(model2.RoundStepPropose)
 		h.onProposeTimeout()
 
 	case model2.RoundStepPreVote:
 		h.addTimeoutCount("RoundStepPreVote")
+		h.stepTimeouts.onTimeout(model2.RoundStepPreVote)
 		h.onPreVoteTimeout()
 
 	case model2.RoundStepPreCommit:
 		h.addTimeoutCount("RoundStepPreCommit")
+		h.stepTimeouts.onTimeout(model2.RoundStepPreCommit)
 		h.onPreCommitTimeout()
 
 	case model2.RoundStepNewRound:
@@ -326,14 +335,11 @@ func (h *StateHandler) broadcastNewRoundMsg() {
 	h.OnNewRound(msg)
 }
 
-// todo mv to BftState
 func (h *StateHandler) curProposer() (result common.Address) {
-	vLen := len(h.bs.CurVerifiers)
-	if vLen == 0 {
+	if len(h.bs.CurVerifiers) == 0 {
 		return
 	}
-	index := int(h.bs.Round) % vLen
-	return h.bs.CurVerifiers[index]
+	return h.bs.curProposer()
 }
 
 
@@ -366,7 +372,8 @@ func (h *StateHandler) onEnterNewRound() {
 }
 
 func (h *StateHandler) onEnterPropose() {
-	h.ticker.ScheduleTimeout(components.TimeoutInfo{Duration: h.timeoutConfig.ProposalTimeout, Height: h.bs.Height, Round: h.bs.Round, Step: model2.RoundStepPropose})
+	h.stepTimeouts.onEnter(model2.RoundStepPropose)
+	h.ticker.ScheduleTimeout(components.TimeoutInfo{Duration: h.stepTimeouts.timeout(model2.RoundStepPropose), Height: h.bs.Height, Round: h.bs.Round, Step: model2.RoundStepPropose})
 
 	pbft_log.Debug(fmt.Sprintf("EnterPropose (H: %v, R: %v, S: %v)",h.bs.Height,h.bs.Round,h.bs.Step))
 
@@ -450,7 +457,9 @@ func (h *StateHandler) finalBlock(block model.AbstractBlock, commits []model.Abs
 }
 
 func (h *StateHandler) onEnterPrevote() {
-	h.ticker.ScheduleTimeout(components.TimeoutInfo{Duration: h.timeoutConfig.WaitNewRound, Height: h.bs.Height, Round: h.bs.Round, Step: model2.RoundStepPreVote})
+	h.stepTimeouts.onFinish(model2.RoundStepPropose)
+	h.stepTimeouts.onEnter(model2.RoundStepPreVote)
+	h.ticker.ScheduleTimeout(components.TimeoutInfo{Duration: h.stepTimeouts.timeout(model2.RoundStepPreVote), Height: h.bs.Height, Round: h.bs.Round, Step: model2.RoundStepPreVote})
 	voteMsg := h.bs.makePrevote()
 
 	pbft_log.Debug(fmt.Sprintf("EnterPrevote (H: %v, R: %v, S: %v)",h.bs.Height,h.bs.Round,h.bs.Step))
@@ -478,7 +487,9 @@ func (h *StateHandler) isCurProposer() bool {
 }
 
 func (h *StateHandler) onEnterPrecommit() {
-	h.ticker.ScheduleTimeout(components.TimeoutInfo{Duration: h.timeoutConfig.WaitNewRound, Height: h.bs.Height, Round: h.bs.Round, Step: model2.RoundStepPreCommit})
+	h.stepTimeouts.onFinish(model2.RoundStepPreVote)
+	h.stepTimeouts.onEnter(model2.RoundStepPreCommit)
+	h.ticker.ScheduleTimeout(components.TimeoutInfo{Duration: h.stepTimeouts.timeout(model2.RoundStepPreCommit), Height: h.bs.Height, Round: h.bs.Round, Step: model2.RoundStepPreCommit})
 	voteMsg := h.bs.makeVote()
 
 	pbft_log.Debug(fmt.Sprintf("EnterPrecommit (H: %v, R: %v, S: %v)",h.bs.Height,h.bs.Round,h.bs.Step))
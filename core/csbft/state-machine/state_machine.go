@@ -37,6 +37,8 @@ type BftState struct {
 
 	BlockPoolNotEmpty bool
 	CurVerifiers      []common.Address
+	// proposer order of a weighted round robin cycle, nil means plain rotation
+	ProposerSequence  []int
 	LockedBlock       model.AbstractBlock
 	LockedRound	uint64
 
@@ -54,6 +56,11 @@ func (bs *BftState) OnNewHeight(height uint64, round uint64, verifiers []common.
 	bs.enterNewHeight(height, round, verifiers)
 }
 
+// set the verifier weights of this height, the proposer is selected by weighted round robin if they are valid
+func (bs *BftState) SetProposerWeights(weights []uint64) {
+	bs.ProposerSequence = model.MakeProposerSequence(bs.CurVerifiers, weights)
+}
+
 //When receive a block on this height
 func (bs *BftState) OnBlockPoolNotEmpty() {
 	bs.BlockPoolNotEmpty = true
@@ -186,6 +193,7 @@ func (bs *BftState) enterNewHeight(newHeight uint64, round uint64, verifiers []c
 	bs.Step = model2.RoundStepNewHeight
 	bs.BlockPoolNotEmpty = false
 	bs.CurVerifiers = verifiers
+	bs.ProposerSequence = nil
 	bs.NewRound = NewNRoundSet(newHeight, verifiers)
 	bs.Proposal = NewProposalSet()
 	bs.ProposalBlock = NewBlockSet()
@@ -399,6 +407,10 @@ func (bs *BftState) proposerAtRound(round uint64) common.Address {
 		panic("No verifiers")
 		return common.Address{}
 	}
+
+	if sLen := len(bs.ProposerSequence); sLen > 0 {
+		return bs.CurVerifiers[bs.ProposerSequence[round%uint64(sLen)]]
+	}
 	index := int(round) % vLen
 	return bs.CurVerifiers[index]
 }
@@ -94,6 +94,7 @@ type FakeFullChain struct {
 	commits    []model.AbstractVerification
 	notify  func(height uint64)
 	CannotSave bool
+	Weights    []uint64
 }
 
 func (fc *FakeFullChain) GetSeenCommit(height uint64) []model.AbstractVerification {
@@ -136,6 +137,10 @@ func (fc *FakeFullChain) GetCurrVerifiers() []common.Address {
 	return v
 }
 
+func (fc *FakeFullChain) GetVerifierWeights(height uint64, verifiers []common.Address) []uint64 {
+	return fc.Weights
+}
+
 func (fc *FakeFullChain) SetNewHeightNotifier(nc func(height uint64)){
 	fc.notify = nc
 }
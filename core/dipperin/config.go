@@ -24,6 +24,7 @@ import (
 	"os"
 	"runtime"
 	"strconv"
+	"time"
 	"github.com/dipperin/dipperin-core/core/dipperin/service"
 	"github.com/dipperin/dipperin-core/third-party/rpc"
 )
@@ -95,6 +96,54 @@ type NodeConfig struct {
 
 	PMetricsPort int
 
+	// adjust the pbft step timeouts by the observed round latency
+	BftAdaptiveTimeout bool
+	// the bounds of the adaptive step timeout, the defaults are used if =0
+	BftMinStepTimeout time.Duration
+	BftMaxStepTimeout time.Duration
+	// how many recent rounds the adaptive timeout is calculated by, the default is used if =0
+	BftLatencyWindow int
+
+	// the listen address of the stratum server of the mine master, no stratum server if it is empty
+	StratumAddr string
+
+	// how the mine master divides the block rewards by the worker shares, pplns or proportional
+	MineRewardMode string
+
+	// the hashing thread count of the local mine worker
+	MineThreads int
+
+	// how the txs are ordered when packing a block, fee or fee_per_byte
+	PackTxOrder string
+	// the txs of these senders are packed before the others
+	PackPrioritySenders []string
+	// pack the txs of the local accounts before the others
+	PackPriorityLocal bool
+	// the max tx count and the max total tx bytes of a packed block, no limit if =0
+	PackMaxTxCount int
+	PackMaxTxSize  int
+	// the percent of the block space only for the verifier txs
+	PackReservedPercent int
+
+	// how the chain is synced, full or fast
+	SyncMode string
+	// the trusted checkpoint as number:hash[:verifier set hash]
+	Checkpoint string
+	// how the states are stored, archive or pruned
+	GCMode string
+	// the count of the recent block states kept by the pruned node
+	StateKeepRecent uint64
+	// the states of the blocks at the multiple of it are flushed to the disk by the pruned node
+	StateCheckpointInterval uint64
+	// the blocks older than it are moved to the freezer, 0 disables the moving
+	AncientThreshold uint64
+	// the dir of the freezer, it's in the chain data dir if it's empty
+	AncientDir string
+	// the key-value store of the chain data and its cache size in MB and max open files
+	DatabaseBackend string
+	DatabaseCache   int
+	DatabaseHandles int
+
 	ExtraServiceFunc ExtraServiceFunc
 }
 
@@ -28,6 +28,7 @@ import (
 	"github.com/dipperin/dipperin-core/core/chain-communication"
 	"github.com/dipperin/dipperin-core/core/chain-config"
 	"github.com/dipperin/dipperin-core/core/chain/cachedb"
+	"github.com/dipperin/dipperin-core/core/chain/chaindb"
 	"github.com/dipperin/dipperin-core/core/dipperin/service"
 	"github.com/dipperin/dipperin-core/core/cs-chain"
 	"github.com/dipperin/dipperin-core/core/cs-chain/chain-state"
@@ -54,6 +55,7 @@ import (
 	"github.com/dipperin/dipperin-core/third-party/p2p/nat"
 	"github.com/dipperin/dipperin-core/third-party/p2p/netutil"
 	"github.com/dipperin/dipperin-core/third-party/rpc"
+	"github.com/ethereum/go-ethereum/ethdb"
 	"os"
 	"path/filepath"
 	"strings"
@@ -78,6 +80,7 @@ type BaseComponent struct {
 	prometheusServer *g_metrics.PrometheusMetricsServer
 	cacheDB                     *cachedb.CacheDB
 	fullChain                   *cs_chain.CsChainService
+	freezerMigrator             *chaindb.FreezerMigrator
 	txPool                      *tx_pool.TxPool
 	rpcService                  *rpc_interface.Service
 	txSigner                    model.Signer
@@ -97,6 +100,7 @@ type BaseComponent struct {
 	minePm                      *chain_communication.MineProtocolManager
 	mineMaster                  minemaster.Master
 	mineMasterServer            minemaster.MasterServer
+	stratumServer               *minemaster.StratumServer
 	defaultAccountAddress       common.Address
 	verHaltCheck                *verifiers_halt_check.SystemHaltedCheck
 }
@@ -165,6 +169,17 @@ func newBaseComponent(nodeConfig NodeConfig) *BaseComponent {
 		coinbaseAddr:              &atomic.Value{},
 		nodeConfig:                nodeConfig,
 	}
+	// the chain id and the verifiers of the forked chain are in the genesis file
+	if err := chain.SetupGenesisConfig(b.chainConfig); err != nil {
+		panic("setup genesis config failed: " + err.Error())
+	}
+	if b.nodeConfig.Checkpoint != "" {
+		cp, err := chain_config.ParseCheckpoint(b.nodeConfig.Checkpoint)
+		if err != nil {
+			panic("parse checkpoint failed: " + err.Error())
+		}
+		b.chainConfig.AddCheckpoint(cp)
+	}
 	b.txSigner = model.NewMercurySigner(b.chainConfig.ChainId)
 
 	// init block decoder
@@ -213,6 +228,7 @@ func (b *BaseComponent) buildDipperinConfig() {
 	b.DipperinConfig.WalletManager = b.walletManager
 	b.DipperinConfig.MineMaster = b.mineMaster
 	b.DipperinConfig.MineMasterServer = b.mineMasterServer
+	b.DipperinConfig.MineThreads = b.nodeConfig.MineThreads
 	b.DipperinConfig.DefaultAccount = b.defaultAccountAddress
 	b.DipperinConfig.MsgSigner = b.msgSigner
 }
@@ -248,6 +264,7 @@ func (b *BaseComponent) buildCommunicationConfig() {
 		VerifiersReader: b.verifiersReader,
 		PbftNode:        b.bftNode,
 		MsgSigner:       b.msgSigner,
+		SyncMode:        b.nodeConfig.SyncMode,
 	}
 	b.txBConf = &chain_communication.NewTxBroadcasterConfig{
 		P2PMsgDecoder: b.defaultMsgDecoder,
@@ -271,9 +288,31 @@ func (b *BaseComponent) builderModelConfig() builder.ModelConfig {
 		PriorityCalculator:          b.defaultPriorityCalculator,
 		MsgSigner: b.msgSigner,
 		ChainConfig:                 *b.chainConfig,
+		TxSelection:                 b.txSelectionPolicy(),
 	}
 }
 
+func (b *BaseComponent) txSelectionPolicy() builder.TxSelectionPolicy {
+	selectionConfig := builder.TxSelectionConfig{
+		Order:           builder.TxOrderFromString(b.nodeConfig.PackTxOrder),
+		MaxTxCount:      b.nodeConfig.PackMaxTxCount,
+		MaxTxSize:       common.StorageSize(b.nodeConfig.PackMaxTxSize),
+		ReservedPercent: b.nodeConfig.PackReservedPercent,
+	}
+	for _, sender := range b.nodeConfig.PackPrioritySenders {
+		address := common.HexToAddress(sender)
+		if address.IsEmpty() {
+			log.Warn("ignore the invalid priority sender", "sender", sender)
+			continue
+		}
+		selectionConfig.PrioritySenders = append(selectionConfig.PrioritySenders, address)
+	}
+	if b.nodeConfig.PackPriorityLocal {
+		selectionConfig.IsLocal = b.txPool.IsLocal
+	}
+	return builder.NewTxSelectionPolicy(selectionConfig)
+}
+
 
 func (b *BaseComponent) initFullChain() {
 	// init full chain
@@ -281,8 +320,21 @@ func (b *BaseComponent) initFullChain() {
 		ChainConfig: b.chainConfig,
 		DataDir:     b.nodeConfig.DataDir,
 		WriterFactory: chain_writer.NewChainWriterFactory(),
+		GCMode:                  b.nodeConfig.GCMode,
+		StateKeepRecent:         b.nodeConfig.StateKeepRecent,
+		StateCheckpointInterval: b.nodeConfig.StateCheckpointInterval,
+		AncientDir:              b.nodeConfig.AncientDir,
+		DatabaseBackend:         b.nodeConfig.DatabaseBackend,
+		DatabaseCache:           b.nodeConfig.DatabaseCache,
+		DatabaseHandles:         b.nodeConfig.DatabaseHandles,
 	}))
-	b.csChainServiceConfig.CacheDB = cachedb.NewCacheDB(b.fullChain.GetDB())
+	if freezer := b.fullChain.Freezer; freezer != nil {
+		cacheDB := cachedb.NewCacheDBWithAncients(b.fullChain.GetDB(), freezer)
+		b.csChainServiceConfig.CacheDB = cacheDB
+		b.freezerMigrator = chaindb.NewFreezerMigrator(b.fullChain.ChainDB, freezer, cacheDB, b.nodeConfig.AncientThreshold)
+	} else {
+		b.csChainServiceConfig.CacheDB = cachedb.NewCacheDB(b.fullChain.GetDB())
+	}
 	cachedb.SetCacheDataDecoder(&cachedb.BFTCacheDataDecoder{})
 
 	b.verifiersReader = chain.MakeVerifiersReader(b.fullChain)
@@ -419,6 +471,8 @@ func (b *BaseComponent) initRpc() {
 	rpcApi := rpc_interface.MakeDipperinMercuryApi(b.chainService)
 	debugApi := rpc_interface.MakeDipperinDebugApi(b.chainService)
 	p2pApi := rpc_interface.MakeDipperinP2PApi(b.chainService)
+	mineAdminApi := rpc_interface.MakeDipperinMineAdminApi(b.chainService)
+	minerApi := rpc_interface.MakeDipperinMinerApi(b.chainService)
 
 	b.rpcService = rpc_interface.MakeRpcService(b.nodeConfig, []rpc.API{
 		{
@@ -445,6 +499,18 @@ func (b *BaseComponent) initRpc() {
 			Service:   p2pApi,
 			Public:    true,
 		},
+		{
+			Namespace: "mineadmin",
+			Version:   "1.0",
+			Service:   mineAdminApi,
+			Public:    true,
+		},
+		{
+			Namespace: "miner",
+			Version:   "1.0",
+			Service:   minerApi,
+			Public:    true,
+		},
 	}, b.nodeConfig.GetAllowHosts())
 
 	if chain_config.GetCurBootsEnv() != "mercury" {
@@ -460,6 +526,12 @@ func (b *BaseComponent) initMineMaster() {
 	}
 
 	mineConfig := b.buildMineConfig(b.builderModelConfig())
+	mineDB := b.openMineMasterDB()
+	mineConfig.ShareStore = minemaster.NewShareStore(mineDB)
+	mineConfig.StateStore = minemaster.NewStateStore(mineDB)
+	mineConfig.RewardMode = minemaster.RewardModeFromString(b.nodeConfig.MineRewardMode)
+	mineConfig.RewardReader = b.fullChain.GetEconomyModel()
+	mineConfig.RewardSender = b.chainService
 	// chain service not init here
 	mineMaster, mineMasterServer := minemaster.MakeMineMaster(mineConfig)
 	minePm := chain_communication.NewMineProtocolManager(mineMasterServer)
@@ -468,6 +540,24 @@ func (b *BaseComponent) initMineMaster() {
 	b.minePm = minePm
 	b.mineMaster = mineMaster
 	b.mineMasterServer = mineMasterServer
+
+	if b.nodeConfig.StratumAddr != "" {
+		b.stratumServer = minemaster.NewStratumServer(b.nodeConfig.StratumAddr, mineMasterServer)
+	}
+}
+
+// the shares and the state of the workers are kept after restarts
+func (b *BaseComponent) openMineMasterDB() ethdb.Database {
+	switch b.nodeConfig.DataDir {
+	case "mem", "test", "":
+		return ethdb.NewMemDatabase()
+	}
+
+	db, err := ethdb.NewLDBDatabase(filepath.Join(b.nodeConfig.DataDir, "minemaster_data"), 0, 0)
+	if err != nil {
+		panic(err)
+	}
+	return db
 }
 
 // must have init wallet manager
@@ -485,7 +575,27 @@ func (b *BaseComponent) initBft() {
 		return
 	}
 	b.buildBftConfig()
-	b.bftNode = csbftnode.NewCsBft(b.bftConfig)
+	b.bftNode = csbftnode.NewCsBftWithTimeoutConfig(b.bftConfig, b.bftTimeoutConfig())
+}
+
+// the default timeouts are kept for the options which aren't set
+func (b *BaseComponent) bftTimeoutConfig() state_machine.Config {
+	timeoutConfig := state_machine.DefaultConfig
+	timeoutConfig.AdaptiveTimeout = b.nodeConfig.BftAdaptiveTimeout
+	if b.nodeConfig.BftMinStepTimeout > 0 {
+		timeoutConfig.MinStepTimeout = b.nodeConfig.BftMinStepTimeout
+	}
+	if b.nodeConfig.BftMaxStepTimeout > 0 {
+		timeoutConfig.MaxStepTimeout = b.nodeConfig.BftMaxStepTimeout
+	}
+	if timeoutConfig.MaxStepTimeout < timeoutConfig.MinStepTimeout {
+		log.Warn("the max bft step timeout is less than the min one, use the min one", "min", timeoutConfig.MinStepTimeout, "max", timeoutConfig.MaxStepTimeout)
+		timeoutConfig.MaxStepTimeout = timeoutConfig.MinStepTimeout
+	}
+	if b.nodeConfig.BftLatencyWindow > 0 {
+		timeoutConfig.LatencyWindow = b.nodeConfig.BftLatencyWindow
+	}
+	return timeoutConfig
 }
 
 func (b *BaseComponent) setBftAfterP2PInit() {
@@ -511,7 +621,9 @@ func (b *BaseComponent) getNodeServices() []NodeService {
 	// these services may have nil
 	return filterNilService([]NodeService{
 		b.chainService, b.bftNode, b.walletManager, b.csPm,
-		b.p2pServer, b.rpcService,b.txPool, b.prometheusServer,
+		b.p2pServer, b.rpcService,b.txPool, b.prometheusServer, b.stratumServer, b.freezerMigrator,
+		// stop the chain after the others, no block is inserted when the states are flushed
+		b.fullChain,
 	})
 }
 
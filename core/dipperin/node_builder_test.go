@@ -26,6 +26,8 @@ import (
 
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/third-party/rpc"
+	"github.com/dipperin/dipperin-core/core/csbft/state-machine"
+	"time"
 )
 
 var (
@@ -74,6 +76,29 @@ func TestNewBftNode(t *testing.T) {
 	os.RemoveAll(nodeConfig.DataDir)
 }
 
+func TestBaseComponent_bftTimeoutConfig(t *testing.T) {
+	// the defaults are kept if the options aren't set
+	b := &BaseComponent{nodeConfig: NodeConfig{BftAdaptiveTimeout: true}}
+	expected := state_machine.DefaultConfig
+	expected.AdaptiveTimeout = true
+	assert.Equal(t, expected, b.bftTimeoutConfig())
+
+	b.nodeConfig.BftMinStepTimeout = time.Second
+	b.nodeConfig.BftMaxStepTimeout = 5 * time.Second
+	b.nodeConfig.BftLatencyWindow = 3
+	config := b.bftTimeoutConfig()
+	assert.Equal(t, time.Second, config.MinStepTimeout)
+	assert.Equal(t, 5*time.Second, config.MaxStepTimeout)
+	assert.Equal(t, 3, config.LatencyWindow)
+	assert.Equal(t, state_machine.DefaultConfig.PreVoteTimeout, config.PreVoteTimeout)
+
+	// the max timeout can't be less than the min one
+	b.nodeConfig.BftMinStepTimeout = 40 * time.Second
+	config = b.bftTimeoutConfig()
+	assert.Equal(t, 40*time.Second, config.MinStepTimeout)
+	assert.Equal(t, 40*time.Second, config.MaxStepTimeout)
+}
+
 func TestMsgSender(t *testing.T) {
 	cs_chain.GenesisSetUp = true
 	os.Setenv("boots_env", "test")
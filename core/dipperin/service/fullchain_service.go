@@ -128,6 +128,8 @@ type DipperinConfig struct {
 	ChainConfig        chain_config.ChainConfig
 	PriorityCalculator model.PriofityCalculator
 	MineMasterServer   minemaster.MasterServer
+	// the hashing thread count of the local mine worker
+	MineThreads        int
 	P2PServer          *p2p.Server
 	NormalPm           chain_communication.PeerManager
 
@@ -150,6 +152,40 @@ func (service *MercuryFullChainService) GetSyncStatus() bool {
 	return service.NormalPm.IsSync()
 }
 
+const (
+	SyncProgressEvent = "progress"
+	SyncedEvent       = "synced"
+)
+
+// the interval of the sync progress notifications
+var syncProgressInterval = 5 * time.Second
+
+type SyncProgressResp struct {
+	chain_communication.SyncProgress
+	// whether the local chain has caught up with the best peer
+	Synced bool `json:"synced"`
+}
+
+type SyncEventResp struct {
+	Event    string            `json:"event"`
+	Progress *SyncProgressResp `json:"progress"`
+}
+
+type syncProgressReader interface {
+	SyncProgress() chain_communication.SyncProgress
+}
+
+func (service *MercuryFullChainService) GetSyncProgress() (*SyncProgressResp, error) {
+	reader, ok := service.NormalPm.(syncProgressReader)
+	if !ok {
+		return nil, errors.New("the peer manager doesn't support the sync progress")
+	}
+	return &SyncProgressResp{
+		SyncProgress: reader.SyncProgress(),
+		Synced:       service.NormalPm.BestPeer() != nil && !service.NormalPm.IsSync(),
+	}, nil
+}
+
 func (service *MercuryFullChainService) CurrentBlock() model.AbstractBlock {
 	return service.ChainReader.CurrentBlock()
 }
@@ -208,7 +244,7 @@ func (service *MercuryFullChainService) Start() error {
 	if service.MineMaster != nil && !service.MineMaster.CurrentCoinbaseAddress().IsEmpty() {
 		if service.localWorker == nil {
 			time.Sleep(500 * time.Millisecond)
-			service.localWorker = mineworker.MakeLocalWorker(service.MineMaster.CurrentCoinbaseAddress(), 1, service.MineMasterServer)
+			service.localWorker = mineworker.MakeLocalThreadWorker(service.MineMaster.CurrentCoinbaseAddress(), service.MineThreads, service.MineMasterServer)
 			log.Info("start local worker")
 			service.localWorker.Start()
 		}
@@ -706,7 +742,7 @@ func (service *MercuryFullChainService) CurrentElectPriority(addr common.Address
 		return 0, err
 	}
 
-	stake, err := state.GetStake(addr)
+	stake, err := state.GetVotingStake(addr)
 	if err != nil {
 		return 0, err
 	}
@@ -728,8 +764,14 @@ func (service *MercuryFullChainService) CurrentReputation(addr common.Address) (
 	if err != nil {
 		return 0, err
 	}
-	stake, err := state.GetStake(addr)
+	stake, err := state.GetVotingStake(addr)
+	if err != nil {
+		return 0, err
+	}
 	performance, err := state.GetPerformance(addr)
+	if err != nil {
+		return 0, err
+	}
 
 	reputation, err := service.PriorityCalculator.GetReputation(0, stake, performance)
 	if err != nil {
@@ -812,6 +854,291 @@ func (service *MercuryFullChainService) SendCancelTransaction(from common.Addres
 	return txHash, nil
 }
 
+//send a register transaction to top up the stake of a registered verifier
+func (service *MercuryFullChainService) SendAddStakeTransaction(from common.Address, amount, fee *big.Int, nonce *uint64) (common.Hash, error) {
+	if service.NodeConf.GetNodeType() != chain_config.NodeTypeOfVerifier {
+		return common.Hash{}, errors.New("the node isn't verifier")
+	}
+	state, err := service.ChainReader.CurrentState()
+	if err != nil {
+		return common.Hash{}, err
+	}
+	stake, err := state.GetStake(from)
+	if err != nil || stake.Sign() == 0 {
+		return common.Hash{}, state_processor.SendRegisterTxFirst
+	}
+
+	tmpWallet, usedNonce, err := service.getSendTxInfo(from, nonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx := model.NewRegisterTransaction(usedNonce, amount, fee)
+	signTx, err := service.signTxAndSend(tmpWallet, from, tx, usedNonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	txHash := signTx.CalTxId()
+	log.Info("the SendAddStakeTransaction txId is: ", "txId", txHash.Hex())
+	return txHash, nil
+}
+
+//send a unStake transaction which only withdraws amount from the stake
+func (service *MercuryFullChainService) SendPartialUnStakeTransaction(from common.Address, amount, fee *big.Int, nonce *uint64) (common.Hash, error) {
+	if service.NodeConf.GetNodeType() != chain_config.NodeTypeOfVerifier {
+		return common.Hash{}, errors.New("the node isn't verifier")
+	}
+
+	tmpWallet, usedNonce, err := service.getSendTxInfo(from, nonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx := model.NewPartialUnStakeTransaction(usedNonce, amount, fee)
+	signTx, err := service.signTxAndSend(tmpWallet, from, tx, usedNonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	txHash := signTx.CalTxId()
+	log.Info("the SendPartialUnStakeTransaction txId is: ", "txId", txHash.Hex())
+	return txHash, nil
+}
+
+//send a withdraw stake transaction to return the partially unStaked money after the lock period
+func (service *MercuryFullChainService) SendWithdrawStakeTransaction(from common.Address, fee *big.Int, nonce *uint64) (common.Hash, error) {
+	if service.NodeConf.GetNodeType() != chain_config.NodeTypeOfVerifier {
+		return common.Hash{}, errors.New("the node isn't verifier")
+	}
+
+	tmpWallet, usedNonce, err := service.getSendTxInfo(from, nonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx := model.NewWithdrawStakeTransaction(usedNonce, fee)
+	signTx, err := service.signTxAndSend(tmpWallet, from, tx, usedNonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	txHash := signTx.CalTxId()
+	log.Info("the SendWithdrawStakeTransaction txId is: ", "txId", txHash.Hex())
+	return txHash, nil
+}
+
+//get the partially unStaked money of the verifier
+func (service *MercuryFullChainService) GetUnStaking(addr common.Address) (*state_processor.UnStaking, error) {
+	state, err := service.ChainReader.CurrentState()
+	if err != nil {
+		return nil, err
+	}
+	return state.GetUnStaking(addr)
+}
+
+//send a register transaction which declares the commission taken from the rewards of the delegators
+func (service *MercuryFullChainService) SendRegisterTransactionWithCommission(from common.Address, stake, fee *big.Int, commission uint64, nonce *uint64) (common.Hash, error) {
+	if service.NodeConf.GetNodeType() != chain_config.NodeTypeOfVerifier {
+		return common.Hash{}, errors.New("the node isn't verifier")
+	}
+	if commission > model.MaxCommission {
+		return common.Hash{}, state_processor.InvalidCommissionErr
+	}
+
+	tmpWallet, usedNonce, err := service.getSendTxInfo(from, nonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx := model.NewRegisterTransactionWithCommission(usedNonce, stake, fee, commission)
+	signTx, err := service.signTxAndSend(tmpWallet, from, tx, usedNonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	txHash := signTx.CalTxId()
+	log.Info("the SendRegisterTransactionWithCommission txId is: ", "txId", txHash.Hex())
+	return txHash, nil
+}
+
+//send a delegate transaction, any node can delegate its money to a verifier candidate
+func (service *MercuryFullChainService) SendDelegateTransaction(from, candidate common.Address, amount, fee *big.Int, nonce *uint64) (common.Hash, error) {
+	tmpWallet, usedNonce, err := service.getSendTxInfo(from, nonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx := model.NewDelegateTransaction(usedNonce, candidate, amount, fee)
+	signTx, err := service.signTxAndSend(tmpWallet, from, tx, usedNonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	txHash := signTx.CalTxId()
+	log.Info("the SendDelegateTransaction txId is: ", "txId", txHash.Hex())
+	return txHash, nil
+}
+
+//send a undelegate transaction
+func (service *MercuryFullChainService) SendUnDelegateTransaction(from, candidate common.Address, amount, fee *big.Int, nonce *uint64) (common.Hash, error) {
+	tmpWallet, usedNonce, err := service.getSendTxInfo(from, nonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx := model.NewUnDelegateTransaction(usedNonce, candidate, amount, fee)
+	signTx, err := service.signTxAndSend(tmpWallet, from, tx, usedNonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	txHash := signTx.CalTxId()
+	log.Info("the SendUnDelegateTransaction txId is: ", "txId", txHash.Hex())
+	return txHash, nil
+}
+
+//send a withdraw delegation transaction
+func (service *MercuryFullChainService) SendWithdrawDelegationTransaction(from, candidate common.Address, fee *big.Int, nonce *uint64) (common.Hash, error) {
+	tmpWallet, usedNonce, err := service.getSendTxInfo(from, nonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx := model.NewWithdrawDelegationTransaction(usedNonce, candidate, fee)
+	signTx, err := service.signTxAndSend(tmpWallet, from, tx, usedNonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	txHash := signTx.CalTxId()
+	log.Info("the SendWithdrawDelegationTransaction txId is: ", "txId", txHash.Hex())
+	return txHash, nil
+}
+
+//get the delegation of the delegator to the candidate
+func (service *MercuryFullChainService) GetDelegation(delegator, candidate common.Address) (*state_processor.Delegation, error) {
+	state, err := service.ChainReader.CurrentState()
+	if err != nil {
+		return nil, err
+	}
+	return state.GetDelegation(delegator, candidate)
+}
+
+//get the delegated stake, the commission and the delegators of the candidate
+func (service *MercuryFullChainService) GetCandidateDelegation(candidate common.Address) (delegated *big.Int, commission uint64, delegators []common.Address, err error) {
+	state, err := service.ChainReader.CurrentState()
+	if err != nil {
+		return
+	}
+	if delegated, err = state.GetDelegatedStake(candidate); err != nil {
+		return
+	}
+	if commission, err = state.GetCommission(candidate); err != nil {
+		return
+	}
+	delegators, err = state.GetDelegators(candidate)
+	return
+}
+
+//send a governance proposal transaction, the proposal id is the returned tx hash
+func (service *MercuryFullChainService) SendGovernanceProposalTransaction(from common.Address, param string, value uint64, activationSlot uint64, fee *big.Int, nonce *uint64) (common.Hash, error) {
+	if service.NodeConf.GetNodeType() != chain_config.NodeTypeOfVerifier {
+		return common.Hash{}, errors.New("the node isn't verifier")
+	}
+	if err := state_processor.CheckGovernanceParam(param, value); err != nil {
+		return common.Hash{}, err
+	}
+
+	tmpWallet, usedNonce, err := service.getSendTxInfo(from, nonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx := model.NewGovernanceProposalTransaction(usedNonce, param, value, activationSlot, fee)
+	signTx, err := service.signTxAndSend(tmpWallet, from, tx, usedNonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	txHash := signTx.CalTxId()
+	log.Info("the SendGovernanceProposalTransaction txId is: ", "txId", txHash.Hex())
+	return txHash, nil
+}
+
+//send a governance vote transaction for the proposal
+func (service *MercuryFullChainService) SendGovernanceVoteTransaction(from common.Address, proposalId common.Hash, fee *big.Int, nonce *uint64) (common.Hash, error) {
+	if service.NodeConf.GetNodeType() != chain_config.NodeTypeOfVerifier {
+		return common.Hash{}, errors.New("the node isn't verifier")
+	}
+
+	tmpWallet, usedNonce, err := service.getSendTxInfo(from, nonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	tx := model.NewGovernanceVoteTransaction(usedNonce, proposalId, fee)
+	signTx, err := service.signTxAndSend(tmpWallet, from, tx, usedNonce)
+	if err != nil {
+		return common.Hash{}, err
+	}
+
+	txHash := signTx.CalTxId()
+	log.Info("the SendGovernanceVoteTransaction txId is: ", "txId", txHash.Hex())
+	return txHash, nil
+}
+
+//get the governance proposal and its votes
+func (service *MercuryFullChainService) GetGovernanceProposal(proposalId common.Hash) (*state_processor.GovernanceProposalState, error) {
+	state, err := service.ChainReader.CurrentState()
+	if err != nil {
+		return nil, err
+	}
+	return state.GetGovernanceProposal(proposalId)
+}
+
+//get the chain config with the governed parameters used by the block of the height
+func (service *MercuryFullChainService) GetChainConfigAtHeight(height uint64) chain_config.ChainConfig {
+	return *service.ChainReader.GetChainConfigAtHeight(height)
+}
+
+// the max accounts returned by a page of the state dump or diff
+const maxStateDumpAccounts = 1000
+
+// the changed accounts are collected from the whole range for each page of the state diff, so the range is limited
+const maxStateDiffBlocks = 1000
+
+//get a page of the accounts in the state of the block, the page is started from the hashed key start
+func (service *MercuryFullChainService) DumpState(number uint64, start []byte, max int) (*state_processor.StateDump, error) {
+	if max <= 0 || max > maxStateDumpAccounts {
+		max = maxStateDumpAccounts
+	}
+	state, err := service.ChainReader.StateAtByBlockNumber(number)
+	if err != nil {
+		return nil, err
+	}
+	return state.DumpState(start, max)
+}
+
+//get a page of the accounts changed from the state of the block from to the state of the block to, the page is started from the address start
+func (service *MercuryFullChainService) DiffState(from, to uint64, start common.Address, max int) (*state_processor.StateDiff, error) {
+	if max <= 0 || max > maxStateDumpAccounts {
+		max = maxStateDumpAccounts
+	}
+	if (from > to && from-to > maxStateDiffBlocks) || (to > from && to-from > maxStateDiffBlocks) {
+		return nil, fmt.Errorf("the blocks of the state diff are more than %v blocks apart", maxStateDiffBlocks)
+	}
+	fromState, err := service.ChainReader.StateAtByBlockNumber(from)
+	if err != nil {
+		return nil, err
+	}
+	toState, err := service.ChainReader.StateAtByBlockNumber(to)
+	if err != nil {
+		return nil, err
+	}
+	return state_processor.DiffStates(fromState, toState, start, max)
+}
+
 //get address nonce from chain
 func (service *MercuryFullChainService) GetTransactionNonce(addr common.Address) (nonce uint64, err error) {
 	state, err := service.ChainReader.CurrentState()
@@ -1002,6 +1329,86 @@ func (service *MercuryFullChainService) Mining() bool {
 	return false
 }
 
+// list the workers kept by the mine master
+func (service *MercuryFullChainService) GetMineWorkers() ([]minemaster.WorkerRecord, error) {
+	if service.MineMaster == nil {
+		return nil, errors.New("current node is not mine master")
+	}
+	return service.MineMaster.WorkerRecords(), nil
+}
+
+// set the performance and the reward of the worker address
+func (service *MercuryFullChainService) AdjustMineWorker(address common.Address, performance uint64, reward *big.Int) error {
+	if service.MineMaster == nil {
+		return errors.New("current node is not mine master")
+	}
+	return service.MineMaster.AdjustWorker(address, performance, reward)
+}
+
+// list the payouts of the worker address, all the payouts if the address is empty
+func (service *MercuryFullChainService) GetMinePayouts(address common.Address) ([]minemaster.PayoutRecord, error) {
+	if service.MineMaster == nil {
+		return nil, errors.New("current node is not mine master")
+	}
+	return service.MineMaster.PayoutRecords(address), nil
+}
+
+// export all the state of the mine master
+func (service *MercuryFullChainService) ExportMineState() (*minemaster.MasterState, error) {
+	if service.MineMaster == nil {
+		return nil, errors.New("current node is not mine master")
+	}
+	state := service.MineMaster.ExportState()
+	return &state, nil
+}
+
+// list the connected workers with their shares, blocks, rewards and estimated hashrate
+func (service *MercuryFullChainService) GetMinerWorkers() ([]minemaster.WorkerStats, error) {
+	if service.MineMaster == nil {
+		return nil, errors.New("current node is not mine master")
+	}
+	return service.MineMaster.WorkerStats(), nil
+}
+
+// the height and the tx count of the block in mining
+func (service *MercuryFullChainService) GetMinerWork() (height uint64, txCount int, err error) {
+	if service.MineMaster == nil {
+		return 0, 0, errors.New("current node is not mine master")
+	}
+	return service.MineMaster.CurrentWorkHeight(), service.MineMaster.MineTxCount(), nil
+}
+
+func (service *MercuryFullChainService) KickMinerWorker(workerId string) error {
+	if service.MineMaster == nil {
+		return errors.New("current node is not mine master")
+	}
+	return service.MineMaster.KickWorker(minemaster.WorkerId(workerId))
+}
+
+// pay the workers whose rewards reach the threshold, returns the tx hashes
+func (service *MercuryFullChainService) MinerPayout() (map[common.Address]common.Hash, error) {
+	if service.MineMaster == nil {
+		return nil, errors.New("current node is not mine master")
+	}
+	return service.MineMaster.Payout()
+}
+
+// build a block template for the external miners
+func (service *MercuryFullChainService) GetBlockTemplate() (*minemaster.BlockTemplate, error) {
+	if service.MineMaster == nil {
+		return nil, errors.New("current node is not mine master")
+	}
+	return service.MineMaster.GetBlockTemplate()
+}
+
+// seal the block of the template with the nonce, and broadcast it
+func (service *MercuryFullChainService) SubmitBlock(templateId common.Hash, nonce common.BlockNonce, workerAddress common.Address) error {
+	if service.MineMaster == nil {
+		return errors.New("current node is not mine master")
+	}
+	return service.MineMaster.SubmitBlock(templateId, nonce, workerAddress)
+}
+
 // debug
 func (service *MercuryFullChainService) Metrics(raw bool) (map[string]interface{}, error) {
 	/*// Create a rate formatter
@@ -1456,6 +1863,47 @@ func (service *MercuryFullChainService) SubscribeBlock(ctx context.Context) (*rp
 	return rpcSub, nil
 }
 
+// notify the sync progress periodically, and the synced event when the local chain catches up with the best peer
+func (service *MercuryFullChainService) SubscribeSyncProgress(ctx context.Context) (*rpc.Subscription, error) {
+	notifier, supported := rpc.NotifierFromContext(ctx)
+	if !supported {
+		return &rpc.Subscription{}, rpc.ErrNotificationsUnsupported
+	}
+	if _, err := service.GetSyncProgress(); err != nil {
+		return &rpc.Subscription{}, err
+	}
+
+	rpcSub := notifier.CreateSubscription()
+
+	go func() {
+		ticker := time.NewTicker(syncProgressInterval)
+		defer ticker.Stop()
+
+		synced := false
+		for {
+			progress, _ := service.GetSyncProgress()
+			if err := notifier.Notify(rpcSub.ID, &SyncEventResp{Event: SyncProgressEvent, Progress: progress}); err != nil {
+				log.Error("can't notify sync progress", "err", err)
+			}
+			if progress.Synced && !synced {
+				if err := notifier.Notify(rpcSub.ID, &SyncEventResp{Event: SyncedEvent, Progress: progress}); err != nil {
+					log.Error("can't notify synced", "err", err)
+				}
+			}
+			synced = progress.Synced
+
+			select {
+			case <-ticker.C:
+			case <-rpcSub.Err():
+				return
+			case <-notifier.Closed():
+				return
+			}
+		}
+	}()
+	return rpcSub, nil
+}
+
 // stop this node service
 func (service *MercuryFullChainService) StopDipperin() {
 	service.Node.Stop()
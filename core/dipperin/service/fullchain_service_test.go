@@ -52,6 +52,31 @@ func TestMercuryFullChainService_NormalPM(t *testing.T) {
 	assert.True(t, result)
 }
 
+type fakeSyncPeerManager struct {
+	fakePeerManager
+	progress chain_communication.SyncProgress
+}
+
+func (pm fakeSyncPeerManager) SyncProgress() chain_communication.SyncProgress {
+	return pm.progress
+}
+
+func TestMercuryFullChainService_GetSyncProgress(t *testing.T) {
+	service := MakeFullChainService(&DipperinConfig{NormalPm: fakePeerManager{}})
+	_, err := service.GetSyncProgress()
+	assert.Error(t, err)
+
+	progress := chain_communication.SyncProgress{Syncing: true, StartingBlock: 1, CurrentBlock: 5, HighestBlock: 10}
+	service = MakeFullChainService(&DipperinConfig{NormalPm: fakeSyncPeerManager{progress: progress}})
+	resp, err := service.GetSyncProgress()
+	assert.NoError(t, err)
+	assert.Equal(t, progress, resp.SyncProgress)
+	assert.False(t, resp.Synced)
+
+	_, err = service.SubscribeSyncProgress(context.Background())
+	assert.Error(t, err)
+}
+
 func TestMercuryFullChainService_AddAccount(t *testing.T) {
 	manager := createWalletManager(t)
 	defer os.RemoveAll(util.HomeDir() + testPath)
@@ -748,6 +773,74 @@ func TestMercuryFullChainService_MineTxCount(t *testing.T) {
 	assert.Equal(t, 1, service.MineTxCount())
 }
 
+func TestMercuryFullChainService_MineAdmin(t *testing.T) {
+	config := DipperinConfig{}
+	service := MakeFullChainService(&config)
+	_, err := service.GetMineWorkers()
+	assert.Error(t, err)
+	assert.Error(t, service.AdjustMineWorker(aliceAddr, 1, big.NewInt(1)))
+	_, err = service.GetMinePayouts(aliceAddr)
+	assert.Error(t, err)
+	_, err = service.ExportMineState()
+	assert.Error(t, err)
+
+	config = DipperinConfig{MineMaster: fakeMaster{}}
+	service = MakeFullChainService(&config)
+	workers, err := service.GetMineWorkers()
+	assert.NoError(t, err)
+	assert.Len(t, workers, 1)
+	assert.NoError(t, service.AdjustMineWorker(aliceAddr, 1, big.NewInt(1)))
+	payouts, err := service.GetMinePayouts(aliceAddr)
+	assert.NoError(t, err)
+	assert.Len(t, payouts, 1)
+	state, err := service.ExportMineState()
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1), state.TotalReward)
+}
+
+func TestMercuryFullChainService_Miner(t *testing.T) {
+	config := DipperinConfig{}
+	service := MakeFullChainService(&config)
+	_, err := service.GetMinerWorkers()
+	assert.Error(t, err)
+	_, _, err = service.GetMinerWork()
+	assert.Error(t, err)
+	assert.Error(t, service.KickMinerWorker("worker1"))
+	_, err = service.MinerPayout()
+	assert.Error(t, err)
+
+	config = DipperinConfig{MineMaster: fakeMaster{}}
+	service = MakeFullChainService(&config)
+	workers, err := service.GetMinerWorkers()
+	assert.NoError(t, err)
+	assert.Len(t, workers, 1)
+	height, txCount, err := service.GetMinerWork()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), height)
+	assert.Equal(t, 1, txCount)
+	assert.NoError(t, service.KickMinerWorker("worker1"))
+	assert.Error(t, service.KickMinerWorker("worker2"))
+	txs, err := service.MinerPayout()
+	assert.NoError(t, err)
+	assert.Len(t, txs, 1)
+}
+
+func TestMercuryFullChainService_BlockTemplate(t *testing.T) {
+	config := DipperinConfig{}
+	service := MakeFullChainService(&config)
+	_, err := service.GetBlockTemplate()
+	assert.Error(t, err)
+	assert.Error(t, service.SubmitBlock(common.HexToHash("0x01"), common.BlockNonce{}, aliceAddr))
+
+	config = DipperinConfig{MineMaster: fakeMaster{}}
+	service = MakeFullChainService(&config)
+	template, err := service.GetBlockTemplate()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), template.Header.Number)
+	assert.NoError(t, service.SubmitBlock(template.Id, common.BlockNonce{}, aliceAddr))
+	assert.Error(t, service.SubmitBlock(common.HexToHash("0x02"), common.BlockNonce{}, aliceAddr))
+}
+
 func TestMercuryFullChainService_StartMine(t *testing.T) {
 	config := DipperinConfig{}
 	service := MakeFullChainService(&config)
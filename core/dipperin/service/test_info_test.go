@@ -427,6 +427,52 @@ func (m fakeMaster) RetrieveReward(address common.Address) {
 	panic("implement me")
 }
 
+func (m fakeMaster) WorkerRecords() []minemaster.WorkerRecord {
+	return []minemaster.WorkerRecord{{Address: aliceAddr, Performance: 1, Reward: big.NewInt(1)}}
+}
+
+func (m fakeMaster) AdjustWorker(address common.Address, performance uint64, reward *big.Int) error {
+	return nil
+}
+
+func (m fakeMaster) PayoutRecords(address common.Address) []minemaster.PayoutRecord {
+	return []minemaster.PayoutRecord{{Address: aliceAddr, Value: big.NewInt(1)}}
+}
+
+func (m fakeMaster) ExportState() minemaster.MasterState {
+	return minemaster.MasterState{TotalReward: big.NewInt(1), Workers: m.WorkerRecords(), Payouts: m.PayoutRecords(common.Address{})}
+}
+
+func (m fakeMaster) WorkerStats() []minemaster.WorkerStats {
+	return []minemaster.WorkerStats{{Id: "worker1", Address: aliceAddr, Shares: 2, Blocks: 1, Hashrate: big.NewInt(100), Reward: big.NewInt(1)}}
+}
+
+func (m fakeMaster) CurrentWorkHeight() uint64 {
+	return 2
+}
+
+func (m fakeMaster) KickWorker(workerId minemaster.WorkerId) error {
+	if workerId != "worker1" {
+		return errors.New("the worker is not connected")
+	}
+	return nil
+}
+
+func (m fakeMaster) Payout() (map[common.Address]common.Hash, error) {
+	return map[common.Address]common.Hash{aliceAddr: {}}, nil
+}
+
+func (m fakeMaster) GetBlockTemplate() (*minemaster.BlockTemplate, error) {
+	return &minemaster.BlockTemplate{Id: common.HexToHash("0x01"), Header: model.Header{Number: 2}, Target: big.NewInt(1)}, nil
+}
+
+func (m fakeMaster) SubmitBlock(templateId common.Hash, nonce common.BlockNonce, workerAddress common.Address) error {
+	if templateId != common.HexToHash("0x01") {
+		return errors.New("the block template is not found or already submitted")
+	}
+	return nil
+}
+
 type fakeMasterServer struct{}
 
 func (s fakeMasterServer) RegisterWorker(worker minemaster.WorkerForMaster) {
@@ -96,6 +96,9 @@ var (
 	// set the minimal deposit to register as a verifier
 	MiniPledgeValue = big.NewInt(1000*consts.DIP)
 
+	// set the minimal bonded delegation of a delegator to a verifier
+	MiniDelegationValue = big.NewInt(100 * consts.DIP)
+
 	// the total supply of pre-mining
 	PreMineDIP = big.NewInt(525600000 * consts.DIP)
 
@@ -229,6 +232,8 @@ const (
 type EconomyNeedService interface {
 	GetVerifiers(slotNum uint64) (addresses []common.Address)
 	GetSlot(block model.AbstractBlock) *uint64
+	GetChainConfigAtHeight(height uint64) *chain_config.ChainConfig
+	GetVerifierWeights(height uint64, verifiers []common.Address) []uint64
 }
 
 type DipperinEconomyModel struct {
@@ -305,6 +310,22 @@ func CalcDIPTotalCirculation(year uint64) (value *big.Int) {
 	}
 }
 
+// calculate the total supply until the block, pre-mining included
+func CalcDIPTotalIssuance(blockNumber uint64) *big.Int {
+	total := big.NewInt(0).Set(PreMineDIP)
+	economyModel := &DipperinEconomyModel{}
+	// the reward of one block is the same during a year
+	for start := uint64(1); start <= blockNumber; start += HeightAfterOneYear {
+		end := start + HeightAfterOneYear - 1
+		if end > blockNumber {
+			end = blockNumber
+		}
+		reward, _ := economyModel.GetOneBlockTotalDIPReward(start)
+		total.Add(total, big.NewInt(0).Mul(reward, big.NewInt(0).SetUint64(end-start+1)))
+	}
+	return total
+}
+
 func (economyModel *DipperinEconomyModel) GetOneBlockTotalDIPReward(blockNumber uint64) (*big.Int, error) {
 	rewardOneBlock := big.NewInt(0)
 	if blockNumber == 0 {
@@ -321,10 +342,9 @@ func (economyModel *DipperinEconomyModel) GetOneBlockTotalDIPReward(blockNumber
 }
 
 //calculate different verifier reward
-func (economyModel *DipperinEconomyModel) calcDifferentVerifierReward(totalReward *big.Int) map[VerifierType]*big.Int {
-	conf := chain_config.GetChainConfig()
-	commitNumber := conf.VerifierNumber*2/3 + 1
-	notCommitNumber := conf.VerifierNumber - commitNumber
+func (economyModel *DipperinEconomyModel) calcDifferentVerifierReward(totalReward *big.Int, verifierNumber int) map[VerifierType]*big.Int {
+	commitNumber := verifierNumber*2/3 + 1
+	notCommitNumber := verifierNumber - commitNumber
 	mineVerifierNumber := 1
 
 	totalWeight := mineVerifierNumber*MainVerifierRewardWeight + commitNumber*CommitVerifierRewardWeight + notCommitNumber*NoCommitVerifierRewardWeight
@@ -406,7 +426,15 @@ func (economyModel *DipperinEconomyModel) GetVerifierDIPReward(block model.Abstr
 	}
 
 	verifierReward := big.NewInt(0).Div(big.NewInt(0).Mul(totalReward, big.NewInt(VerifierRewardProportion)), big.NewInt(100))
-	return economyModel.calcDifferentVerifierReward(verifierReward), nil
+	return economyModel.calcDifferentVerifierReward(verifierReward, economyModel.getVerifierNumber(block)), nil
+}
+
+// the verifier number of the slot of the block, it can be changed by governance
+func (economyModel *DipperinEconomyModel) getVerifierNumber(block model.AbstractBlock) int {
+	if economyModel.Service == nil {
+		return chain_config.GetChainConfig().VerifierNumber
+	}
+	return economyModel.Service.GetChainConfigAtHeight(block.Number()).VerifierNumber
 }
 
 // get the address of different verifier type for each block
@@ -417,7 +445,6 @@ func (economyModel *DipperinEconomyModel) GetDiffVerifierAddress(preBlock, block
 		return map[VerifierType][]common.Address{}, ErrBlockNumberIs0Ore1
 	}
 
-	config := chain_config.GetChainConfig()
 	//log.Info("get address", "economyModel", economyModel)
 	slot := economyModel.Service.GetSlot(preBlock)
 	log.Debug("the slot is:","slot",*slot)
@@ -441,7 +468,18 @@ func (economyModel *DipperinEconomyModel) GetDiffVerifierAddress(preBlock, block
 		}
 	}
 
-	masterVerifierIndex := int(verifications[0].GetRound()) % config.VerifierNumber
+	// the master verifier is the proposer of the commit round, which is selected by the weighted round robin
+	// of the pre block height once the weighted proposer is activated
+	round := verifications[0].GetRound()
+	var masterVerifierIndex int
+	if sequence := model.MakeProposerSequence(verifiers, economyModel.Service.GetVerifierWeights(preBlock.Number(), verifiers)); len(sequence) > 0 {
+		masterVerifierIndex = sequence[round%uint64(len(sequence))]
+	} else {
+		masterVerifierIndex = int(round) % economyModel.getVerifierNumber(preBlock)
+	}
+	if masterVerifierIndex >= len(verifiers) {
+		return map[VerifierType][]common.Address{}, ErrVerifierNumber
+	}
 	log.Info("the masterVerifierIndex is:", "index", masterVerifierIndex)
 	log.Info("the verifierAddress is:", "number", len(verifiers))
 	verifierAddress[MasterVerifier] = []common.Address{verifiers[masterVerifierIndex]}
@@ -45,6 +45,14 @@ func (*testService) GetSlot(block model.AbstractBlock) *uint64 {
 	return &slot
 }
 
+func (*testService) GetChainConfigAtHeight(height uint64) *chain_config.ChainConfig {
+	return chain_config.GetChainConfig()
+}
+
+func (*testService) GetVerifierWeights(height uint64, verifiers []common.Address) []uint64 {
+	return nil
+}
+
 var testEconomyService = &testService{}
 
 
@@ -81,6 +89,20 @@ func TestDipperinEconomyModel_GetOneBlockTotalDIPReward(t *testing.T) {
 	assert.NotEqual(t, v, economy_model.TotalReWardDIPOneBlock)
 }
 
+// test the total supply until the block
+func TestCalcDIPTotalIssuance(t *testing.T) {
+	assert.Equal(t, economy_model.PreMineDIP, economy_model.CalcDIPTotalIssuance(0))
+
+	expect := big.NewInt(0).Mul(economy_model.TotalReWardDIPOneBlock, big.NewInt(100))
+	expect.Add(expect, economy_model.PreMineDIP)
+	assert.Equal(t, expect, economy_model.CalcDIPTotalIssuance(100))
+
+	// 10 years after, the issuance is the pre-mining and the circulation of the years
+	expect = big.NewInt(0).Add(economy_model.CalcDIPTotalCirculation(economy_model.ChangeIssuingYear), economy_model.PreMineDIP)
+	assert.Equal(t, expect, economy_model.CalcDIPTotalIssuance(economy_model.HeightAfterTenYear))
+	assert.True(t, economy_model.CalcDIPTotalIssuance(economy_model.HeightAfterTenYear+1).Cmp(expect) > 0)
+}
+
 // test the foundation
 func TestDipperinEconomyModel_GetFoundation(t *testing.T) {
 	economyModel := economy_model.MakeDipperinEconomyModel(testEconomyService, economy_model.DIPProportion)
@@ -301,7 +323,7 @@ func TestDipperinEconomyModel_GetDiffVerifierAddress(t *testing.T) {
 	_,err :=economyModel.GetDiffVerifierAddress(mockPreBlock,mockBlock)
 	assert.Equal(t,economy_model.ErrBlockNumberIs0Ore1,err)
 
-	mockPreBlock.EXPECT().Number().Return(uint64(2))
+	mockPreBlock.EXPECT().Number().Return(uint64(2)).Times(3)
 	mockBlock.EXPECT().Number().Return(uint64(3))
 
 	mockVerifier := economy_model.NewMockAbstractVerification(controller)
@@ -315,3 +337,43 @@ func TestDipperinEconomyModel_GetDiffVerifierAddress(t *testing.T) {
 	log.Info("the verAddr is:","verAddr",verAddr)
 	//assert.Equal(t,map[economy_model.VerifierType][]common.Address{},verAddr)
 }
+
+// the verifier of the index has the largest weight, the others have no weight
+type weightedTestService struct {
+	testService
+	index int
+}
+
+func (s *weightedTestService) GetVerifierWeights(height uint64, verifiers []common.Address) []uint64 {
+	weights := make([]uint64, len(verifiers))
+	weights[s.index] = 9000
+	return weights
+}
+
+func TestDipperinEconomyModel_GetDiffVerifierAddress_WeightedProposer(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	service := &weightedTestService{index: 5}
+	verifiers := service.GetVerifiers(0)
+	economyModel := economy_model.MakeDipperinEconomyModel(service, economy_model.DIPProportion)
+
+	mockPreBlock := economy_model.NewMockAbstractBlock(controller)
+	mockBlock := economy_model.NewMockAbstractBlock(controller)
+	mockPreBlock.EXPECT().Number().Return(uint64(2)).AnyTimes()
+	mockBlock.EXPECT().Number().Return(uint64(3)).AnyTimes()
+
+	mockVerifier := economy_model.NewMockAbstractVerification(controller)
+	mockVerifier.EXPECT().GetRound().Return(uint64(1))
+	mockVerifier.EXPECT().GetAddress().Return(verifiers[1]).AnyTimes()
+	mockBlock.EXPECT().GetVerifications().Return(model.Verifications{mockVerifier})
+
+	// the weighted proposer of round 1 is the verifier with the largest weight, not verifiers[1 % N]
+	sequence := model.MakeProposerSequence(verifiers, service.GetVerifierWeights(2, verifiers))
+	assert.Equal(t, service.index, sequence[1])
+
+	verAddr, err := economyModel.GetDiffVerifierAddress(mockPreBlock, mockBlock)
+	assert.NoError(t, err)
+	assert.Equal(t, []common.Address{verifiers[service.index]}, verAddr[economy_model.MasterVerifier])
+	assert.Equal(t, []common.Address{verifiers[1]}, verAddr[economy_model.CommitVerifier])
+}
@@ -29,4 +29,6 @@ var (
     ErrAddress = errors.New("the address isn't investor or developer")
 
     ErrAddressExist = errors.New("address exist")
+
+    ErrVerifierNumber = errors.New("the verifiers are less than the verifier number")
 )
\ No newline at end of file
@@ -0,0 +1,128 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemaster
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/model"
+	"math/big"
+	"sync"
+)
+
+// keep the latest templates, the miners could submit a template which isn't the newest one
+const maxBlockTemplates = 16
+
+var (
+	noCoinbaseErr        = errors.New("the coinbase address of the mine master is empty")
+	buildTemplateErr     = errors.New("build the block template failed")
+	templateNotFoundErr  = errors.New("the block template is not found or already submitted")
+	invalidSealErr       = errors.New("the block hash doesn't reach the difficulty")
+	unsupportedHeaderErr = errors.New("the block header is not supported")
+)
+
+// BlockTemplate is a block to mine for the external miners which can't speak devp2p.
+// The miner hashes keccak256(HeaderRlpWithoutNonce + nonce) with the 32 bytes nonce,
+// and submits the nonce once the hash is no bigger than the Target.
+type BlockTemplate struct {
+	// the hash of the header without the nonce
+	Id                    common.Hash
+	Header                model.Header
+	HeaderRlpWithoutNonce []byte
+	Target                *big.Int
+	TxCount               int
+}
+
+// blockTemplates keeps the blocks of the templates until they are submitted or expired
+type blockTemplates struct {
+	lock   sync.Mutex
+	ids    []common.Hash
+	blocks map[common.Hash]model.AbstractBlock
+}
+
+func newBlockTemplates() *blockTemplates {
+	return &blockTemplates{blocks: make(map[common.Hash]model.AbstractBlock)}
+}
+
+func (templates *blockTemplates) add(block model.AbstractBlock) (*BlockTemplate, error) {
+	header, ok := block.Header().(*model.Header)
+	if !ok {
+		return nil, unsupportedHeaderErr
+	}
+	template := &BlockTemplate{
+		Id:                    header.HashWithoutNonce(),
+		Header:                *header,
+		HeaderRlpWithoutNonce: header.RlpBlockWithoutNonce(),
+		Target:                header.Diff.Big(),
+		TxCount:               block.TxCount(),
+	}
+
+	templates.lock.Lock()
+	defer templates.lock.Unlock()
+
+	// the templates of the lower heights can't be mined anymore
+	ids := make([]common.Hash, 0, len(templates.ids)+1)
+	for _, id := range templates.ids {
+		if templates.blocks[id].Number() < block.Number() {
+			delete(templates.blocks, id)
+			continue
+		}
+		ids = append(ids, id)
+	}
+	if templates.blocks[template.Id] == nil {
+		ids = append(ids, template.Id)
+	}
+	templates.blocks[template.Id] = block
+	if len(ids) > maxBlockTemplates {
+		for _, id := range ids[:len(ids)-maxBlockTemplates] {
+			delete(templates.blocks, id)
+		}
+		ids = ids[len(ids)-maxBlockTemplates:]
+	}
+	templates.ids = ids
+	return template, nil
+}
+
+// take returns the block of the template sealed with the nonce, the template is removed.
+// The template is kept if the nonce is invalid.
+func (templates *blockTemplates) take(id common.Hash, nonce common.BlockNonce) (model.AbstractBlock, error) {
+	templates.lock.Lock()
+	defer templates.lock.Unlock()
+
+	block := templates.blocks[id]
+	if block == nil {
+		return nil, templateNotFoundErr
+	}
+	header, ok := block.Header().(*model.Header)
+	if !ok {
+		return nil, unsupportedHeaderErr
+	}
+	header.Nonce = nonce
+	if !header.Hash().ValidHashForDifficulty(header.Diff) {
+		return nil, invalidSealErr
+	}
+
+	delete(templates.blocks, id)
+	for i := range templates.ids {
+		if templates.ids[i] == id {
+			templates.ids = append(templates.ids[:i], templates.ids[i+1:]...)
+			break
+		}
+	}
+	block.SetNonce(nonce)
+	return block, nil
+}
@@ -0,0 +1,110 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemaster
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/tests/factory"
+	"github.com/stretchr/testify/assert"
+	"sync/atomic"
+	"testing"
+)
+
+type recordBlockBroadcaster struct {
+	blocks []model.AbstractBlock
+}
+
+func (b *recordBlockBroadcaster) BroadcastMinedBlock(block model.AbstractBlock) {
+	b.blocks = append(b.blocks, block)
+}
+
+type templateBlockBuilder struct {
+	number uint64
+}
+
+func (b *templateBlockBuilder) BuildWaitPackBlock(coinbaseAddr common.Address) model.AbstractBlock {
+	return factory.CreateBlock2(common.HexToDiff("0x1effffff"), b.number)
+}
+
+// find a nonce which reaches the difficulty of the template or not
+func findTemplateNonce(template *BlockTemplate, valid bool) common.BlockNonce {
+	header := template.Header
+	for i := uint32(0); ; i++ {
+		header.Nonce = common.BlockNonceFromInt(i)
+		if header.Hash().ValidHashForDifficulty(header.Diff) == valid {
+			return header.Nonce
+		}
+	}
+}
+
+func TestBlockTemplates(t *testing.T) {
+	templates := newBlockTemplates()
+	block := factory.CreateBlock2(common.HexToDiff("0x1effffff"), 2)
+	template, err := templates.add(block)
+	assert.NoError(t, err)
+	header := block.Header().(*model.Header)
+	assert.Equal(t, header.HashWithoutNonce(), template.Id)
+	assert.Equal(t, header.RlpBlockWithoutNonce(), template.HeaderRlpWithoutNonce)
+	assert.Equal(t, header.Diff.Big(), template.Target)
+	assert.Equal(t, block.TxCount(), template.TxCount)
+
+	// the template is kept if the nonce is invalid
+	_, err = templates.take(template.Id, findTemplateNonce(template, false))
+	assert.Equal(t, invalidSealErr, err)
+
+	nonce := findTemplateNonce(template, true)
+	sealed, err := templates.take(template.Id, nonce)
+	assert.NoError(t, err)
+	assert.Equal(t, nonce, sealed.Nonce())
+	_, err = templates.take(template.Id, nonce)
+	assert.Equal(t, templateNotFoundErr, err)
+
+	// the templates of the lower heights are dropped
+	old, _ := templates.add(factory.CreateBlock2(common.HexToDiff("0x1effffff"), 2))
+	_, err = templates.add(factory.CreateBlock2(common.HexToDiff("0x1effffff"), 3))
+	assert.NoError(t, err)
+	_, err = templates.take(old.Id, findTemplateNonce(old, true))
+	assert.Equal(t, templateNotFoundErr, err)
+	assert.Len(t, templates.ids, 1)
+}
+
+func Test_master_BlockTemplate(t *testing.T) {
+	broadcaster := &recordBlockBroadcaster{}
+	config := MineConfig{
+		CoinbaseAddress:  &atomic.Value{},
+		BlockBuilder:     &templateBlockBuilder{number: 2},
+		BlockBroadcaster: broadcaster,
+	}
+	nM := testMasterBuilder(config)
+	_, err := nM.GetBlockTemplate()
+	assert.Equal(t, noCoinbaseErr, err)
+
+	nM.SetCoinbaseAddress(common.HexToAddress("0x1234"))
+	template, err := nM.GetBlockTemplate()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(2), template.Header.Number)
+
+	assert.Equal(t, invalidSealErr, nM.SubmitBlock(template.Id, findTemplateNonce(template, false), common.Address{}))
+	assert.Len(t, broadcaster.blocks, 0)
+
+	worker := common.HexToAddress("0x5678")
+	assert.NoError(t, nM.SubmitBlock(template.Id, findTemplateNonce(template, true), worker))
+	assert.Len(t, broadcaster.blocks, 1)
+	assert.True(t, broadcaster.blocks[0].Hash().ValidHashForDifficulty(broadcaster.blocks[0].Difficulty()))
+	assert.Equal(t, uint64(1), nM.GetPerformance(worker))
+}
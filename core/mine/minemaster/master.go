@@ -18,6 +18,7 @@
 package minemaster
 
 import (
+	"errors"
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/common/util"
 	"github.com/dipperin/dipperin-core/core/model"
@@ -28,13 +29,20 @@ import (
 	"github.com/dipperin/dipperin-core/common/g-event"
 )
 
-var waitTimeout = 20 * time.Second
+var (
+	waitTimeout = 20 * time.Second
+
+	masterStoppedErr  = errors.New("the mine master is not mining")
+	workerNotFoundErr = errors.New("the worker is not connected")
+)
 
 func newMaster(config MineConfig) *master {
 	m := &master{
 		MineConfig: config,
 
 		workers: map[WorkerId]WorkerForMaster{},
+		stats: newWorkerStatsTracker(),
+		templates: newBlockTemplates(),
 		registerWorkerChan: make(chan WorkerForMaster),
 		unRegisterWorkerChan: make(chan WorkerId),
 		onNewBlockChan: make(chan model.AbstractBlock),
@@ -48,9 +56,15 @@ type master struct {
 	MineConfig
 
 	workers         map[WorkerId]WorkerForMaster
+	// the statistics of the workers in the map above, it could be read out of the loop
+	stats *workerStatsTracker
+	// the blocks in mining by the external miners
+	templates *blockTemplates
 
 	workDispatcher dispatcher
 	workManager workManager
+	// nil if there is no RewardSender in MineConfig
+	payoutEngine *payoutEngine
 
 	// control the reception of OnNewBlock to prevent the repeated launch of reset task
 	curNewBlockHeight uint64
@@ -66,17 +80,21 @@ type master struct {
 }
 
 func (ms *master) RetrieveReward(address common.Address) {
-	ms.sendReward(address)
+	if err := ms.sendReward(address); err != nil {
+		log.Warn("retrieve reward failed", "address", address.Hex(), "err", err)
+		return
+	}
 
-	ms.workManager.clearReward(address)
 	ms.workManager.clearPerformance(address)
-
 }
 
-func (ms *master) sendReward(address common.Address) {
-	//reward := ms.workManager.getReward(address)
-	// ms.send(reward)
-	// TODO, initiate transaction
+// send all the reward to the address, the reward is kept if the tx isn't sent
+func (ms *master) sendReward(address common.Address) error {
+	if ms.payoutEngine == nil {
+		return noRewardSenderErr
+	}
+	_, err := ms.payoutEngine.payAll(address)
+	return err
 }
 
 func (ms *master) GetReward(address common.Address) *big.Int {
@@ -87,6 +105,93 @@ func (ms *master) GetPerformance(address common.Address) uint64 {
 	return ms.workManager.getPerformance(address)
 }
 
+func (ms *master) WorkerRecords() []WorkerRecord {
+	return ms.workManager.workerRecords()
+}
+
+func (ms *master) AdjustWorker(address common.Address, performance uint64, reward *big.Int) error {
+	return ms.workManager.adjustWorker(address, performance, reward)
+}
+
+func (ms *master) PayoutRecords(address common.Address) []PayoutRecord {
+	return ms.workManager.payoutRecords(address)
+}
+
+func (ms *master) ExportState() MasterState {
+	return ms.workManager.exportState()
+}
+
+func (ms *master) WorkerStats() []WorkerStats {
+	stats := ms.stats.list(time.Now())
+	for i := range stats {
+		stats[i].Reward = ms.workManager.getReward(stats[i].Address)
+	}
+	return stats
+}
+
+func (ms *master) CurrentWorkHeight() uint64 {
+	if ms.workDispatcher.curWorkBlock() == nil {
+		return 0
+	}
+	return ms.workDispatcher.curWorkBlock().Number()
+}
+
+// the worker is unregistered in the loop, so the master must be mining
+func (ms *master) KickWorker(workerId WorkerId) error {
+	if ms.stopped() {
+		return masterStoppedErr
+	}
+	if !ms.stats.has(workerId) {
+		return workerNotFoundErr
+	}
+	log.Info("kick the worker", "worker id", workerId)
+	ms.unRegisterWorker(workerId)
+	return nil
+}
+
+func (ms *master) Payout() (map[common.Address]common.Hash, error) {
+	if ms.payoutEngine == nil {
+		return nil, noRewardSenderErr
+	}
+	return ms.payoutEngine.payout(), nil
+}
+
+// GetBlockTemplate builds a new block to mine for the external miners
+func (ms *master) GetBlockTemplate() (*BlockTemplate, error) {
+	coinbase := ms.CurrentCoinbaseAddress()
+	if coinbase.IsEmpty() {
+		return nil, noCoinbaseErr
+	}
+	block := ms.BlockBuilder.BuildWaitPackBlock(coinbase)
+	if block == nil {
+		return nil, buildTemplateErr
+	}
+	return ms.templates.add(block)
+}
+
+// SubmitBlock seals the block of the template with the nonce, and broadcasts it as the blocks of the workers
+func (ms *master) SubmitBlock(templateId common.Hash, nonce common.BlockNonce, workerAddress common.Address) error {
+	block, err := ms.templates.take(templateId, nonce)
+	if err != nil {
+		return err
+	}
+	if workerAddress.IsEmpty() {
+		workerAddress = ms.CurrentCoinbaseAddress()
+	}
+
+	log.Info("mine master receive template block", "number", block.Number(), "worker", workerAddress.Hex())
+	ms.startWaitTimer()
+	return submitSealedBlock(ms.workManager, workerAddress, block)
+}
+
+func (ms *master) onWorkerShare(workerId WorkerId, work *big.Int) {
+	ms.stats.addShare(workerId, work, time.Now())
+}
+
+func (ms *master) onWorkerBlock(workerId WorkerId) {
+	ms.stats.addBlock(workerId)
+}
+
 /*
 
 to handle:
@@ -111,6 +216,8 @@ func (ms *master) loop() {
 			//	//continue
 			//}
 			ms.workers[worker.GetId()] = worker
+			ms.stats.add(worker)
+			ms.workManager.registerWorker(worker.GetId(), worker.CurrentCoinbaseAddress())
 
 		case wId := <- ms.unRegisterWorkerChan:
 			log.Info("un register worker", "w id", wId)
@@ -118,6 +225,7 @@ func (ms *master) loop() {
 				ms.workers[wId].Stop()
 			}
 			delete(ms.workers, wId)
+			ms.stats.remove(wId)
 
 		case block := <- ms.onNewBlockChan:
 			ms.doOnNewBlock(block)
@@ -235,6 +343,9 @@ func (ms *master) doOnNewBlock(block model.AbstractBlock) {
 	if block.CoinBaseAddress().IsEqual(ms.CurrentCoinbaseAddress()) {
 		// if the received block is mined by ourselves
 		ms.workManager.onNewBlock(block)
+		if ms.payoutEngine != nil {
+			go ms.payoutEngine.payout()
+		}
 	}
 	if err := ms.workDispatcher.onNewBlock(block); err == nil {
 		ms.curNewBlockHeight = block.Number()
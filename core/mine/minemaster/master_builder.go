@@ -31,6 +31,9 @@ func MakeMineMaster(config MineConfig) (Master, MasterServer) {
 	// communicator for master with workers
 	server := newServer(master, manager, dispatcher.curWorkBlock)
 	master.workManager = manager
+	if config.RewardSender != nil {
+		master.payoutEngine = newPayoutEngine(config, manager)
+	}
 	// set depends
 	master.setWorkDispatcher(dispatcher)
 	return master, server
@@ -45,6 +45,9 @@ type Master interface {
 	MineTxCount() int
 
 	SpendableMaster
+	AdminMaster
+	PoolMaster
+	TemplateMaster
 	// Done: 1. add get worker's work,
 	// Done: 2. worker's coin count method,
 	// Done: 3. add withdrawal coins method
@@ -56,12 +59,41 @@ type mineMaster interface {
 	unRegisterWorker(workerId WorkerId)
 	startWaitTimer()
 	getWorker(id WorkerId) WorkerForMaster
+	onWorkerShare(workerId WorkerId, work *big.Int)
+	onWorkerBlock(workerId WorkerId)
 }
 
 type SpendableMaster interface {
 	RetrieveReward(address common.Address)
 }
 
+// AdminMaster lists, adjusts and exports the state of the workers kept by the master
+type AdminMaster interface {
+	WorkerRecords() []WorkerRecord
+	AdjustWorker(address common.Address, performance uint64, reward *big.Int) error
+	// all the payouts if the address is empty
+	PayoutRecords(address common.Address) []PayoutRecord
+	ExportState() MasterState
+}
+
+// PoolMaster serves the statistics and the control of the connected workers
+type PoolMaster interface {
+	WorkerStats() []WorkerStats
+	// the height of the block in mining, 0 if there is no work
+	CurrentWorkHeight() uint64
+	// stop and unregister the worker
+	KickWorker(workerId WorkerId) error
+	// pay the rewards reach the threshold now, instead of waiting for the next block
+	Payout() (map[common.Address]common.Hash, error)
+}
+
+// TemplateMaster serves the external miners by the block templates
+type TemplateMaster interface {
+	GetBlockTemplate() (*BlockTemplate, error)
+	// the block is counted for the workerAddress, or the coinbase of the master if it's empty
+	SubmitBlock(templateId common.Hash, nonce common.BlockNonce, workerAddress common.Address) error
+}
+
 type WorkerForMaster interface {
 	Start()
 	Stop()
@@ -90,8 +122,11 @@ type workManager interface {
 	getPerformance(address common.Address) uint64
 	getReward(address common.Address) *big.Int
 	onNewBlock(block model.AbstractBlock)
+	submitShare(share Share)
 	spendableWorkManager
 	partialSpendableWorkManager
+	payableWorkManager
+	adminWorkManager
 }
 
 type dispatcher interface {
@@ -110,6 +145,25 @@ type partialSpendableWorkManager interface {
 	subtractReward(address common.Address, reward *big.Int)
 }
 
+// payableWorkManager hands the rewards to the payout engine
+type payableWorkManager interface {
+	rewards() map[common.Address]*big.Int
+	// take all the reward of the address out, the reward is cleared
+	takeReward(address common.Address) *big.Int
+	// give back the reward which is failed to pay
+	restoreReward(address common.Address, reward *big.Int)
+	recordPayout(record PayoutRecord)
+}
+
+// adminWorkManager keeps the identities of the workers, and serves the AdminMaster
+type adminWorkManager interface {
+	registerWorker(workerId WorkerId, address common.Address)
+	workerRecords() []WorkerRecord
+	adjustWorker(address common.Address, performance uint64, reward *big.Int) error
+	payoutRecords(address common.Address) []PayoutRecord
+	exportState() MasterState
+}
+
 // workerPerformance keeps records of all worker's work
 // different worker could have different types of workerPerformance.
 type workerPerformance interface {
@@ -147,10 +201,35 @@ type BlockBroadcaster interface {
 	BroadcastMinedBlock(block model.AbstractBlock)
 }
 
+// CoinbaseRewardReader returns the reward of the mine master for the block, the tx fees are not included
+type CoinbaseRewardReader interface {
+	GetMineMasterDIPReward(block model.AbstractBlock) (*big.Int, error)
+}
+
+// RewardSender sends the transfer txs of the payouts
+type RewardSender interface {
+	SendTransaction(from, to common.Address, value, transactionFee *big.Int, data []byte, nonce *uint64) (common.Hash, error)
+}
+
 type MineConfig struct {
 	CoinbaseAddress  *atomic.Value
 	BlockBuilder     BlockBuilder
 	BlockBroadcaster BlockBroadcaster
+
+	// the rewards are divided by the shares if ShareStore is set,
+	// otherwise they are divided by the count of the blocks mined by the workers
+	ShareStore   ShareStore
+	RewardMode   RewardMode
+	PPLNSWindow  uint64
+	RewardReader CoinbaseRewardReader
+
+	// the performance, rewards, worker identities and payouts are kept after restarts if StateStore is set
+	StateStore StateStore
+
+	// the rewards reach the threshold are paid automatically if RewardSender is set
+	RewardSender    RewardSender
+	PayoutThreshold *big.Int
+	PayoutTxFee     *big.Int
 }
 
 func (conf *MineConfig) GetCoinbaseAddr() (result common.Address) {
@@ -18,6 +18,7 @@ package minemaster
 
 import (
 	"errors"
+	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/core/chain-communication"
 	"github.com/dipperin/dipperin-core/core/mine/minemsg"
 	"github.com/dipperin/dipperin-core/core/model"
@@ -25,6 +26,8 @@ import (
 	"github.com/dipperin/dipperin-core/third-party/log/pbft_log"
 	"github.com/dipperin/dipperin-core/third-party/p2p"
 	"reflect"
+	"sync"
+	"time"
 )
 
 func newServer(mineMaster mineMaster, wManager workManager, getCurWorkBlockFunc getCurWorkBlockFunc) *server {
@@ -41,6 +44,11 @@ type server struct {
 	master              mineMaster
 	getCurWorkBlockFunc getCurWorkBlockFunc
 	workManager         workManager
+
+	// the nonces of the accepted shares of the current work block, to reject the duplicate shares
+	shareLock   sync.Mutex
+	shareHeight uint64
+	shareNonces map[common.BlockNonce]struct{}
 }
 
 func (s *server) RegisterWorker(worker WorkerForMaster) {
@@ -64,6 +72,13 @@ func (s *server) ReceiveMsg(workerID WorkerId, code uint64, msg interface{}) {
 		s.master.startWaitTimer()
 		// TODO: verify different block difficulty
 		s.onSubmitBlock(workerID, w)
+	case minemsg.SubmitShareMsg:
+		share, ok := msg.(*minemsg.ShareWork)
+		if !ok {
+			log.Warn("receive wrong share submit msg", "share", reflect.TypeOf(msg))
+			return
+		}
+		s.onSubmitShare(workerID, share)
 	default:
 		log.Debug("receive wrong msg", "code", code)
 	}
@@ -79,16 +94,76 @@ func (s *server) onSubmitBlock(workerID WorkerId, work minemsg.Work) {
 	}
 
 	log.Info("mine master before submit block", "hash", block.RefreshHashCache())
-	// check block valid
-	if !block.RefreshHashCache().ValidHashForDifficulty(block.Difficulty()) {
+	//fmt.Println("mine master prepare broadcast block", util.StringifyJson(block), block.Hash())
+	//log.Info("mine master receive new work", "block hash", block.Hash().Hex(), "block number", block.Number())
+	if err := submitSealedBlock(s.workManager, work.GetWorkerCoinbaseAddress(), block); err != nil {
 		log.Warn("master receive invalid mined block", "do unregister worker", workerID)
 		//s.UnRegisterWorker(workerID)
 		return
 	}
+	s.master.onWorkerBlock(workerID)
+}
 
-	//fmt.Println("mine master prepare broadcast block", util.StringifyJson(block), block.Hash())
-	//log.Info("mine master receive new work", "block hash", block.Hash().Hex(), "block number", block.Number())
-	s.workManager.submitBlock(work.GetWorkerCoinbaseAddress(), block)
+// submitSealedBlock checks the block is sealed, and broadcasts it by the workManager.
+// The blocks of the workers and the external miners are submitted here.
+func submitSealedBlock(wManager workManager, workerAddress common.Address, block model.AbstractBlock) error {
+	// check block valid
+	if !block.RefreshHashCache().ValidHashForDifficulty(block.Difficulty()) {
+		return invalidSealErr
+	}
+	wManager.submitBlock(workerAddress, block)
+	return nil
+}
+
+// onSubmitShare verifies the share by the current work block, and passes it to workManager.
+func (s *server) onSubmitShare(workerID WorkerId, share *minemsg.ShareWork) {
+	block := s.getCurWorkBlockFunc()
+	if block == nil || share.Work.BlockHeader.Number != block.Number() {
+		log.Debug("master receive stale share", "worker id", workerID, "share height", share.Work.BlockHeader.Number)
+		return
+	}
+	address := share.Work.GetWorkerCoinbaseAddress()
+	if address.IsEmpty() {
+		log.Warn("master receive share without coinbase address", "worker id", workerID)
+		return
+	}
+
+	// the share difficulty can't be easier than the minimum one
+	if !minemsg.ValidDifficulty(share.ShareDiff) || share.ShareDiff.Big().Cmp(minemsg.ShareDifficulty(block.Difficulty()).Big()) > 0 {
+		log.Warn("master receive share with invalid difficulty", "worker id", workerID, "diff", share.ShareDiff.Hex())
+		return
+	}
+	header, ok := block.Header().(*model.Header)
+	if !ok {
+		return
+	}
+	header.Nonce = share.Work.ResultNonce
+	if !header.Hash().ValidHashForDifficulty(share.ShareDiff) {
+		log.Warn("master receive invalid share", "worker id", workerID)
+		return
+	}
+
+	s.shareLock.Lock()
+	if s.shareHeight != block.Number() {
+		s.shareHeight = block.Number()
+		s.shareNonces = make(map[common.BlockNonce]struct{})
+	}
+	_, exist := s.shareNonces[header.Nonce]
+	s.shareNonces[header.Nonce] = struct{}{}
+	s.shareLock.Unlock()
+	if exist {
+		log.Debug("master receive duplicate share", "worker id", workerID)
+		return
+	}
+
+	accepted := Share{
+		Address:     address,
+		Difficulty:  share.ShareDiff,
+		BlockNumber: block.Number(),
+		Timestamp:   uint64(time.Now().Unix()),
+	}
+	s.master.onWorkerShare(workerID, accepted.Work())
+	s.workManager.submitShare(accepted)
 }
 
 // only for worker, do nothing
@@ -135,6 +210,13 @@ func (s *server) OnNewMsg(msg p2p.Msg, p chain_communication.PmAbstractPeer) err
 		}
 		s.ReceiveMsg(workerId, msg.Code, &defaultWork)
 
+	case minemsg.SubmitShareMsg:
+		var share minemsg.ShareWork
+		if err := msg.Decode(&share); err != nil {
+			return err
+		}
+		s.ReceiveMsg(workerId, msg.Code, &share)
+
 	default:
 		log.Warn("receive unknown msg", "code", msg.Code)
 		return errors.New("unknown msg")
@@ -31,6 +31,7 @@ import (
 	"github.com/dipperin/dipperin-core/core/mine/minemsg"
 	"github.com/dipperin/dipperin-core/core/model"
 	"github.com/dipperin/dipperin-core/tests/factory"
+	"github.com/ethereum/go-ethereum/ethdb"
 )
 
 var fakeBlock *model.Block
@@ -192,3 +193,47 @@ func Test_server_OnNewMsg(t *testing.T) {
 
 	assert.Error(t, err)
 }
+
+func Test_server_ReceiveShare(t *testing.T) {
+	config := fakeMineConfig()
+	config.ShareStore = NewShareStore(ethdb.NewMemDatabase())
+	m := testMasterBuilder(config)
+	wm := newDefaultWorkManager(config)
+	s := newServer(m, wm, fakeGetCurWorkBlockFunc)
+
+	diff := common.HexToDiff("0x1effffff")
+	fakeBlock = factory.CreateBlock2(diff, 1)
+	shareDiff := minemsg.ShareDifficulty(diff)
+
+	// find a nonce which reaches the share difficulty
+	header := fakeBlock.Header().(*model.Header)
+	for i := uint32(0); !header.Hash().ValidHashForDifficulty(shareDiff); i++ {
+		header.Nonce = common.BlockNonceFromInt(i)
+	}
+	share := &minemsg.ShareWork{
+		Work:      minemsg.DefaultWork{WorkerCoinbaseAddress: common.HexToAddress("0x1234"), BlockHeader: *header, ResultNonce: header.Nonce},
+		ShareDiff: shareDiff,
+	}
+
+	s.ReceiveMsg("123", minemsg.SubmitShareMsg, share)
+	assert.EqualValues(t, 1, config.ShareStore.Head())
+
+	// duplicate share
+	s.ReceiveMsg("123", minemsg.SubmitShareMsg, share)
+	assert.EqualValues(t, 1, config.ShareStore.Head())
+
+	// the share difficulty is easier than the minimum one
+	easyShare := *share
+	easyShare.ShareDiff = common.HexToDiff("0x20ffffff")
+	s.ReceiveMsg("123", minemsg.SubmitShareMsg, &easyShare)
+	assert.EqualValues(t, 1, config.ShareStore.Head())
+
+	// stale share
+	staleShare := *share
+	staleShare.Work.BlockHeader.Number = 0
+	s.ReceiveMsg("123", minemsg.SubmitShareMsg, &staleShare)
+	assert.EqualValues(t, 1, config.ShareStore.Head())
+
+	s.ReceiveMsg("123", minemsg.SubmitShareMsg, share.Work)
+	assert.EqualValues(t, 1, config.ShareStore.Head())
+}
@@ -24,6 +24,7 @@ import (
 	"time"
 
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/consts"
 	"github.com/dipperin/dipperin-core/common/g-event"
 	"github.com/dipperin/dipperin-core/core/mine/minemsg"
 	"github.com/dipperin/dipperin-core/core/model"
@@ -231,6 +232,60 @@ func Test_master_MineTxCount(t *testing.T) {
 	assert.Equal(t, nM.MineTxCount(), 2)
 }
 
+func Test_master_CurrentWorkHeight(t *testing.T) {
+	nM := testMasterBuilder(testMineConfig)
+	nM.setWorkDispatcher(&mockDispatch{curBlock: nil})
+	assert.Equal(t, uint64(0), nM.CurrentWorkHeight())
+
+	block := factory.CreateBlock2(common.HexToDiff("0x1effffff"), 3)
+	nM.setWorkDispatcher(&mockDispatch{curBlock: block})
+	assert.Equal(t, uint64(3), nM.CurrentWorkHeight())
+}
+
+func Test_master_KickWorker(t *testing.T) {
+	block := factory.CreateBlock2(common.HexToDiff("0x1effffff"), 1)
+	nM := testMasterBuilder(testMineConfig)
+	nM.setWorkDispatcher(&mockDispatch{curBlock: block})
+	assert.Equal(t, masterStoppedErr, nM.KickWorker("1"))
+
+	nM.Start()
+	defer nM.Stop()
+	worker := mockWorker{workerId: "1", coinbase: common.HexToAddress("0x1234")}
+	nM.registerWorker(&worker)
+	time.Sleep(100 * time.Millisecond)
+
+	nM.onWorkerShare("1", big.NewInt(600))
+	nM.onWorkerBlock("1")
+	stats := nM.WorkerStats()
+	assert.Len(t, stats, 1)
+	assert.Equal(t, WorkerId("1"), stats[0].Id)
+	assert.Equal(t, worker.coinbase, stats[0].Address)
+	assert.Equal(t, uint64(1), stats[0].Shares)
+	assert.Equal(t, uint64(1), stats[0].Blocks)
+	assert.Equal(t, big.NewInt(0), stats[0].Reward)
+
+	assert.Equal(t, workerNotFoundErr, nM.KickWorker("2"))
+	assert.NoError(t, nM.KickWorker("1"))
+	time.Sleep(100 * time.Millisecond)
+	assert.Len(t, nM.Workers(), 0)
+	assert.Len(t, nM.WorkerStats(), 0)
+}
+
+func Test_master_Payout(t *testing.T) {
+	nM := testMasterBuilder(testMineConfig)
+	_, err := nM.Payout()
+	assert.Equal(t, noRewardSenderErr, err)
+
+	sender := &fakeRewardSender{txs: map[common.Address]*big.Int{}}
+	nM.payoutEngine = newPayoutEngine(MineConfig{CoinbaseAddress: &atomic.Value{}, RewardSender: sender}, nM.workManager)
+	worker := common.HexToAddress("0x1234")
+	nM.workManager.restoreReward(worker, big.NewInt(2*consts.DIP))
+	paid, err := nM.Payout()
+	assert.NoError(t, err)
+	assert.Len(t, paid, 1)
+	assert.Equal(t, new(big.Int).Sub(big.NewInt(2*consts.DIP), defaultPayoutTxFee), sender.txs[worker])
+}
+
 func Test_master_SetCoinbaseAddress(t *testing.T) {
 	dispatch := mockDispatch{curBlock: nil}
 
@@ -324,6 +379,8 @@ func testMasterBuilder(config MineConfig) *master {
 
 		workManager:          manager,
 		workers:              map[WorkerId]WorkerForMaster{},
+		stats:                newWorkerStatsTracker(),
+		templates:            newBlockTemplates(),
 		registerWorkerChan:   make(chan WorkerForMaster),
 		unRegisterWorkerChan: make(chan WorkerId),
 		onNewBlockChan:       make(chan model.AbstractBlock),
@@ -0,0 +1,121 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemaster
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/consts"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"math/big"
+	"sync"
+	"time"
+)
+
+var (
+	defaultPayoutThreshold = big.NewInt(consts.DIP)
+	defaultPayoutTxFee     = big.NewInt(100000)
+
+	noRewardSenderErr = errors.New("no reward sender for the mine master")
+	rewardTooLowErr   = errors.New("the reward is not enough to pay the tx fee")
+)
+
+// payoutEngine transfers the rewards to the workers, the tx fee is paid by the worker's reward
+type payoutEngine struct {
+	sender      RewardSender
+	getFromFunc func() common.Address
+	manager     payableWorkManager
+
+	threshold *big.Int
+	txFee     *big.Int
+
+	// send the txs of a batch in order, so the nonces are continuous
+	lock sync.Mutex
+}
+
+func newPayoutEngine(config MineConfig, manager payableWorkManager) *payoutEngine {
+	engine := &payoutEngine{
+		sender:      config.RewardSender,
+		getFromFunc: config.GetCoinbaseAddr,
+		manager:     manager,
+		threshold:   config.PayoutThreshold,
+		txFee:       config.PayoutTxFee,
+	}
+	if engine.threshold == nil {
+		engine.threshold = defaultPayoutThreshold
+	}
+	if engine.txFee == nil {
+		engine.txFee = defaultPayoutTxFee
+	}
+	return engine
+}
+
+// payout sends a batch of transfer txs to all the workers whose rewards reach the threshold
+func (engine *payoutEngine) payout() map[common.Address]common.Hash {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+
+	res := make(map[common.Address]common.Hash)
+	for address, reward := range engine.manager.rewards() {
+		if reward.Cmp(engine.threshold) < 0 {
+			continue
+		}
+		txHash, err := engine.pay(address)
+		if err != nil {
+			log.Warn("pay the worker reward failed", "address", address.Hex(), "err", err)
+			continue
+		}
+		res[address] = txHash
+	}
+	if len(res) > 0 {
+		log.Info("mine master payout", "tx count", len(res))
+	}
+	return res
+}
+
+// payAll sends all the reward of the address regardless of the threshold
+func (engine *payoutEngine) payAll(address common.Address) (common.Hash, error) {
+	engine.lock.Lock()
+	defer engine.lock.Unlock()
+	return engine.pay(address)
+}
+
+// must hold the lock
+func (engine *payoutEngine) pay(address common.Address) (common.Hash, error) {
+	reward := engine.manager.takeReward(address)
+	if reward.Cmp(engine.txFee) <= 0 {
+		engine.manager.restoreReward(address, reward)
+		return common.Hash{}, rewardTooLowErr
+	}
+
+	value := new(big.Int).Sub(reward, engine.txFee)
+	txHash, err := engine.sender.SendTransaction(engine.getFromFunc(), address, value, engine.txFee, nil, nil)
+	if err != nil {
+		// the reward is kept for the next payout
+		engine.manager.restoreReward(address, reward)
+		return common.Hash{}, err
+	}
+	log.Info("pay the worker reward", "address", address.Hex(), "value", value, "tx", txHash.Hex())
+	engine.manager.recordPayout(PayoutRecord{
+		Address:   address,
+		Value:     value,
+		TxFee:     engine.txFee,
+		TxHash:    txHash,
+		Timestamp: uint64(time.Now().Unix()),
+	})
+	return txHash, nil
+}
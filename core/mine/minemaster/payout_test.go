@@ -0,0 +1,103 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemaster
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/tests/factory"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"sync"
+	"testing"
+)
+
+type fakeRewardSender struct {
+	lock sync.Mutex
+	txs  map[common.Address]*big.Int
+	err  error
+}
+
+func (sender *fakeRewardSender) SendTransaction(from, to common.Address, value, transactionFee *big.Int, data []byte, nonce *uint64) (common.Hash, error) {
+	sender.lock.Lock()
+	defer sender.lock.Unlock()
+	if sender.err != nil {
+		return common.Hash{}, sender.err
+	}
+	sender.txs[to] = value
+	return common.BytesToHash(to[:]), nil
+}
+
+type fakeRewardReader struct{}
+
+func (fakeRewardReader) GetMineMasterDIPReward(block model.AbstractBlock) (*big.Int, error) {
+	return big.NewInt(6e9), nil
+}
+
+func TestPayoutEngine_Payout(t *testing.T) {
+	sender := &fakeRewardSender{txs: map[common.Address]*big.Int{}}
+	config := fakeMineConfig()
+	config.ShareStore = NewShareStore(ethdb.NewMemDatabase())
+	config.RewardMode = RewardModeProportional
+	config.RewardReader = fakeRewardReader{}
+	config.RewardSender = sender
+	config.PayoutThreshold = big.NewInt(3e9)
+	manager := newDefaultWorkManager(config)
+	engine := newPayoutEngine(config, manager)
+
+	worker1 := common.HexToAddress("0x123")
+	worker2 := common.HexToAddress("0x123223")
+	diff := common.HexToDiff("0x1effffff")
+	manager.submitShare(Share{Address: worker1, Difficulty: diff})
+	manager.submitShare(Share{Address: worker2, Difficulty: diff})
+	manager.submitShare(Share{Address: worker2, Difficulty: diff})
+
+	block := factory.CreateBlock2(diff, 1)
+	manager.onNewBlock(block)
+	total := new(big.Int).Add(big.NewInt(6e9), block.GetTransactionFees())
+	reward1 := new(big.Int).Div(total, big.NewInt(3))
+	reward2 := new(big.Int).Div(new(big.Int).Mul(total, big.NewInt(2)), big.NewInt(3))
+	assert.Equal(t, reward1, manager.getReward(worker1))
+	assert.Equal(t, reward2, manager.getReward(worker2))
+
+	// the reward of the same block is divided only once
+	manager.onNewBlock(block)
+	assert.Equal(t, reward1, manager.getReward(worker1))
+
+	// only the reward of worker2 reaches the threshold
+	paid := engine.payout()
+	assert.Len(t, paid, 1)
+	assert.Equal(t, new(big.Int).Sub(reward2, defaultPayoutTxFee), sender.txs[worker2])
+	assert.Equal(t, big.NewInt(0), manager.getReward(worker2))
+	assert.Equal(t, reward1, manager.getReward(worker1))
+
+	// the reward is kept if the tx isn't sent
+	sender.err = errors.New("send tx failed")
+	_, err := engine.payAll(worker1)
+	assert.Error(t, err)
+	assert.Equal(t, reward1, manager.getReward(worker1))
+
+	sender.err = nil
+	_, err = engine.payAll(worker1)
+	assert.NoError(t, err)
+	assert.Equal(t, new(big.Int).Sub(reward1, defaultPayoutTxFee), sender.txs[worker1])
+
+	_, err = engine.payAll(worker1)
+	assert.Equal(t, rewardTooLowErr, err)
+}
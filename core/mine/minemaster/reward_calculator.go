@@ -0,0 +1,140 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemaster
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"math/big"
+)
+
+// RewardMode decides how the reward of a mined block is divided by the shares
+type RewardMode int
+
+const (
+	// PPLNS pays per last N shares, N is counted by the work of PPLNSWindow blocks
+	RewardModePPLNS RewardMode = iota
+	// Proportional divides the reward by the shares submitted since the last block
+	RewardModeProportional
+)
+
+const defaultPPLNSWindow = 2
+
+// rewardCalculator divides the reward of a block found by the pool to the workers by their shares
+type rewardCalculator interface {
+	calculate(store ShareStore, blockDiff common.Difficulty, reward *big.Int) (map[common.Address]*big.Int, error)
+}
+
+func newRewardCalculator(config MineConfig) rewardCalculator {
+	switch config.RewardMode {
+	case RewardModeProportional:
+		return &proportionalCalculator{}
+	default:
+		window := config.PPLNSWindow
+		if window == 0 {
+			window = defaultPPLNSWindow
+		}
+		return &pplnsCalculator{window: window}
+	}
+}
+
+// divide the reward by the work of the addresses, the remainder is kept by the pool
+func divideByWork(works map[common.Address]*big.Int, totalWork *big.Int, reward *big.Int) map[common.Address]*big.Int {
+	res := make(map[common.Address]*big.Int)
+	if totalWork.Sign() == 0 {
+		return res
+	}
+	for addr, work := range works {
+		value := new(big.Int).Mul(work, reward)
+		res[addr] = value.Quo(value, totalWork)
+	}
+	return res
+}
+
+type pplnsCalculator struct {
+	window uint64
+}
+
+// the shares within the work of window blocks are counted, the oldest share is counted partially
+// if it crosses the boundary. The shares out of the window can never be counted again, so they are pruned.
+func (calculator *pplnsCalculator) calculate(store ShareStore, blockDiff common.Difficulty, reward *big.Int) (map[common.Address]*big.Int, error) {
+	windowWork := new(big.Int).Mul(workForDifficulty(blockDiff), new(big.Int).SetUint64(calculator.window))
+	works := make(map[common.Address]*big.Int)
+	totalWork := big.NewInt(0)
+	oldest := store.Head()
+
+	err := store.ReverseIterate(func(seq uint64, share Share) bool {
+		work := share.Work()
+		if left := new(big.Int).Sub(windowWork, totalWork); work.Cmp(left) > 0 {
+			work = left
+		}
+		if works[share.Address] == nil {
+			works[share.Address] = big.NewInt(0)
+		}
+		works[share.Address].Add(works[share.Address], work)
+		totalWork.Add(totalWork, work)
+		oldest = seq
+		return totalWork.Cmp(windowWork) < 0
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = store.Prune(oldest); err != nil {
+		return nil, err
+	}
+	return divideByWork(works, totalWork, reward), nil
+}
+
+type proportionalCalculator struct{}
+
+// all the shares of the round are counted, then a new round begins
+func (calculator *proportionalCalculator) calculate(store ShareStore, blockDiff common.Difficulty, reward *big.Int) (map[common.Address]*big.Int, error) {
+	works := make(map[common.Address]*big.Int)
+	totalWork := big.NewInt(0)
+	head := store.Head()
+
+	err := store.ReverseIterate(func(seq uint64, share Share) bool {
+		if seq >= head {
+			return true
+		}
+		work := share.Work()
+		if works[share.Address] == nil {
+			works[share.Address] = big.NewInt(0)
+		}
+		works[share.Address].Add(works[share.Address], work)
+		totalWork.Add(totalWork, work)
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if err = store.Prune(head); err != nil {
+		return nil, err
+	}
+	return divideByWork(works, totalWork, reward), nil
+}
+
+// RewardModeFromString returns PPLNS for the unknown mode
+func RewardModeFromString(mode string) RewardMode {
+	switch mode {
+	case "proportional":
+		return RewardModeProportional
+	default:
+		return RewardModePPLNS
+	}
+}
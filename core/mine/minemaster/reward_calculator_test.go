@@ -0,0 +1,81 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemaster
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestPPLNSCalculator(t *testing.T) {
+	store := NewShareStore(ethdb.NewMemDatabase())
+	diff := common.HexToDiff("0x1effffff")
+	shareWork := workForDifficulty(diff)
+	worker1 := common.HexToAddress("0x123")
+	worker2 := common.HexToAddress("0x123223")
+
+	// the block work equals the work of 3 shares, so the window is 6 shares
+	blockDiff := common.BigToDiff(new(big.Int).Div(diff.Big(), big.NewInt(3)))
+	for i := 0; i < 4; i++ {
+		assert.NoError(t, store.AddShare(Share{Address: worker1, Difficulty: diff}))
+	}
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, store.AddShare(Share{Address: worker2, Difficulty: diff}))
+	}
+
+	calculator := newRewardCalculator(MineConfig{})
+	res, err := calculator.calculate(store, blockDiff, big.NewInt(6e9))
+	assert.NoError(t, err)
+
+	// one and a little less of worker1's shares are in the window
+	windowWork := new(big.Int).Mul(workForDifficulty(blockDiff), big.NewInt(defaultPPLNSWindow))
+	work1 := new(big.Int).Sub(windowWork, new(big.Int).Mul(shareWork, big.NewInt(5)))
+	assert.Equal(t, new(big.Int).Div(new(big.Int).Mul(work1, big.NewInt(6e9)), windowWork), res[worker1])
+	assert.Equal(t, new(big.Int).Div(new(big.Int).Mul(new(big.Int).Mul(shareWork, big.NewInt(5)), big.NewInt(6e9)), windowWork), res[worker2])
+
+	// the shares out of the window are pruned
+	count := 0
+	assert.NoError(t, store.ReverseIterate(func(seq uint64, share Share) bool {
+		count++
+		return true
+	}))
+	assert.Equal(t, 6, count)
+}
+
+func TestProportionalCalculator(t *testing.T) {
+	store := NewShareStore(ethdb.NewMemDatabase())
+	worker1 := common.HexToAddress("0x123")
+	worker2 := common.HexToAddress("0x123223")
+
+	assert.NoError(t, store.AddShare(Share{Address: worker1, Difficulty: common.HexToDiff("0x1effffff")}))
+	assert.NoError(t, store.AddShare(Share{Address: worker2, Difficulty: common.HexToDiff("0x1effffff")}))
+	assert.NoError(t, store.AddShare(Share{Address: worker2, Difficulty: common.HexToDiff("0x1effffff")}))
+
+	calculator := newRewardCalculator(MineConfig{RewardMode: RewardModeProportional})
+	res, err := calculator.calculate(store, common.HexToDiff("0x1effffff"), big.NewInt(3e9))
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(1e9), res[worker1])
+	assert.Equal(t, big.NewInt(2e9), res[worker2])
+
+	// a new round begins
+	res, err = calculator.calculate(store, common.HexToDiff("0x1effffff"), big.NewInt(3e9))
+	assert.NoError(t, err)
+	assert.Len(t, res, 0)
+}
@@ -0,0 +1,180 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemaster
+
+import (
+	"encoding/binary"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"math/big"
+	"sync"
+)
+
+var (
+	shareKeyPrefix = []byte("minemaster_share_")
+	shareHeadKey   = []byte("minemaster_share_head")
+	shareTailKey   = []byte("minemaster_share_tail")
+
+	two256 = new(big.Int).Lsh(big.NewInt(1), 256)
+)
+
+// Share records a work of the worker which reaches the share difficulty.
+// The rewards are divided by the shares, so the workers never find a block are also rewarded.
+type Share struct {
+	Address     common.Address
+	Difficulty  common.Difficulty
+	BlockNumber uint64
+	Timestamp   uint64
+}
+
+// Work returns the expected hash count to find the share
+func (share Share) Work() *big.Int {
+	return workForDifficulty(share.Difficulty)
+}
+
+func workForDifficulty(diff common.Difficulty) *big.Int {
+	target := diff.Big()
+	return target.Div(two256, target.Add(target, big.NewInt(1)))
+}
+
+// ShareStore keeps the shares in the order of submission
+type ShareStore interface {
+	AddShare(share Share) error
+	// iterate the shares from the newest to the oldest, stop if cb returns false
+	ReverseIterate(cb func(seq uint64, share Share) bool) error
+	// remove all the shares before seq
+	Prune(seq uint64) error
+	// the seq of the next share
+	Head() uint64
+}
+
+// NewShareStore create a ShareStore on the db, the shares in the db are kept after restarts
+func NewShareStore(db ethdb.Database) ShareStore {
+	store := &dbShareStore{db: db}
+	store.head = store.readSeq(shareHeadKey)
+	store.tail = store.readSeq(shareTailKey)
+	return store
+}
+
+type dbShareStore struct {
+	db   ethdb.Database
+	lock sync.RWMutex
+
+	// shares in [tail, head) are stored
+	head uint64
+	tail uint64
+}
+
+func shareKey(seq uint64) []byte {
+	key := make([]byte, len(shareKeyPrefix)+8)
+	copy(key, shareKeyPrefix)
+	binary.BigEndian.PutUint64(key[len(shareKeyPrefix):], seq)
+	return key
+}
+
+func (store *dbShareStore) readSeq(key []byte) uint64 {
+	data, err := store.db.Get(key)
+	if err != nil || len(data) != 8 {
+		return 0
+	}
+	return binary.BigEndian.Uint64(data)
+}
+
+func (store *dbShareStore) putSeq(putter ethdb.Putter, key []byte, seq uint64) error {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, seq)
+	return putter.Put(key, data)
+}
+
+func (store *dbShareStore) AddShare(share Share) error {
+	data, err := rlp.EncodeToBytes(share)
+	if err != nil {
+		return err
+	}
+
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	batch := store.db.NewBatch()
+	if err = batch.Put(shareKey(store.head), data); err != nil {
+		return err
+	}
+	if err = store.putSeq(batch, shareHeadKey, store.head+1); err != nil {
+		return err
+	}
+	if err = batch.Write(); err != nil {
+		return err
+	}
+	store.head++
+	return nil
+}
+
+func (store *dbShareStore) ReverseIterate(cb func(seq uint64, share Share) bool) error {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+
+	for seq := store.head; seq > store.tail; seq-- {
+		data, err := store.db.Get(shareKey(seq - 1))
+		if err != nil {
+			return err
+		}
+		var share Share
+		if err = rlp.DecodeBytes(data, &share); err != nil {
+			return err
+		}
+		if !cb(seq-1, share) {
+			return nil
+		}
+	}
+	return nil
+}
+
+func (store *dbShareStore) Prune(seq uint64) error {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	if seq > store.head {
+		seq = store.head
+	}
+	if seq <= store.tail {
+		return nil
+	}
+
+	batch := store.db.NewBatch()
+	for i := store.tail; i < seq; i++ {
+		if err := batch.Delete(shareKey(i)); err != nil {
+			return err
+		}
+	}
+	if err := store.putSeq(batch, shareTailKey, seq); err != nil {
+		return err
+	}
+	if err := batch.Write(); err != nil {
+		return err
+	}
+	log.Debug("prune shares", "from", store.tail, "to", seq)
+	store.tail = seq
+	return nil
+}
+
+func (store *dbShareStore) Head() uint64 {
+	store.lock.RLock()
+	defer store.lock.RUnlock()
+	return store.head
+}
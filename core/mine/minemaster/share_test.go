@@ -0,0 +1,64 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemaster
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestShareStore(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	store := NewShareStore(db)
+	assert.EqualValues(t, 0, store.Head())
+
+	diff := common.HexToDiff("0x1effffff")
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, store.AddShare(Share{Address: common.Address{byte(i)}, Difficulty: diff, BlockNumber: uint64(i)}))
+	}
+	assert.EqualValues(t, 5, store.Head())
+
+	var seqs []uint64
+	assert.NoError(t, store.ReverseIterate(func(seq uint64, share Share) bool {
+		assert.Equal(t, common.Address{byte(seq)}, share.Address)
+		seqs = append(seqs, seq)
+		return seq > 2
+	}))
+	assert.Equal(t, []uint64{4, 3, 2}, seqs)
+
+	assert.NoError(t, store.Prune(3))
+	_, err := db.Get(shareKey(2))
+	assert.Error(t, err)
+
+	// the shares are restored from the db
+	store = NewShareStore(db)
+	assert.EqualValues(t, 5, store.Head())
+	count := 0
+	assert.NoError(t, store.ReverseIterate(func(seq uint64, share Share) bool {
+		count++
+		return true
+	}))
+	assert.Equal(t, 2, count)
+}
+
+func TestShare_Work(t *testing.T) {
+	easy := Share{Difficulty: common.HexToDiff("0x1fffffff")}
+	hard := Share{Difficulty: common.HexToDiff("0x1effffff")}
+	assert.Equal(t, 1, hard.Work().Cmp(easy.Work()))
+}
@@ -0,0 +1,233 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemaster
+
+import (
+	"encoding/binary"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
+	"math/big"
+	"sync"
+)
+
+var (
+	workerIndexKey  = []byte("minemaster_worker_index")
+	workerKeyPrefix = []byte("minemaster_worker_")
+	payoutHeadKey   = []byte("minemaster_payout_head")
+	payoutKeyPrefix = []byte("minemaster_payout_")
+	masterMetaKey   = []byte("minemaster_meta")
+)
+
+// WorkerRecord is the state of a worker coinbase address kept by the mine master
+type WorkerRecord struct {
+	Address common.Address
+	// the ids of the workers which mine for this address
+	WorkerIds   []string
+	Performance uint64
+	Reward      *big.Int
+	// unix time of the last registration
+	LastSeen uint64
+}
+
+// PayoutRecord is a transfer tx sent to the worker
+type PayoutRecord struct {
+	Address   common.Address
+	Value     *big.Int
+	TxFee     *big.Int
+	TxHash    common.Hash
+	Timestamp uint64
+}
+
+// MasterState is all the state of the mine master, for the export
+type MasterState struct {
+	TotalReward    *big.Int
+	RewardedHeight uint64
+	Workers        []WorkerRecord
+	Payouts        []PayoutRecord
+}
+
+type masterMeta struct {
+	TotalReward    *big.Int
+	RewardedHeight uint64
+}
+
+// StateStore keeps the state of the mine master across restarts
+type StateStore interface {
+	SaveWorker(record WorkerRecord) error
+	LoadWorkers() ([]WorkerRecord, error)
+	AddPayout(record PayoutRecord) error
+	LoadPayouts() ([]PayoutRecord, error)
+	SaveMeta(totalReward *big.Int, rewardedHeight uint64) error
+	LoadMeta() (totalReward *big.Int, rewardedHeight uint64, err error)
+}
+
+// NewStateStore create a StateStore on the db, it could share the db with the ShareStore
+func NewStateStore(db ethdb.Database) StateStore {
+	store := &dbStateStore{db: db, workers: map[common.Address]struct{}{}}
+	if data, err := db.Get(workerIndexKey); err == nil {
+		var addresses []common.Address
+		if err = rlp.DecodeBytes(data, &addresses); err == nil {
+			store.index = addresses
+			for _, address := range addresses {
+				store.workers[address] = struct{}{}
+			}
+		}
+	}
+	if data, err := db.Get(payoutHeadKey); err == nil && len(data) == 8 {
+		store.payoutHead = binary.BigEndian.Uint64(data)
+	}
+	return store
+}
+
+type dbStateStore struct {
+	db   ethdb.Database
+	lock sync.Mutex
+
+	// the addresses of all the saved workers, in the order of the first save
+	index      []common.Address
+	workers    map[common.Address]struct{}
+	payoutHead uint64
+}
+
+func workerKey(address common.Address) []byte {
+	return append(append([]byte{}, workerKeyPrefix...), address[:]...)
+}
+
+func payoutKey(seq uint64) []byte {
+	key := make([]byte, len(payoutKeyPrefix)+8)
+	copy(key, payoutKeyPrefix)
+	binary.BigEndian.PutUint64(key[len(payoutKeyPrefix):], seq)
+	return key
+}
+
+func (store *dbStateStore) SaveWorker(record WorkerRecord) error {
+	data, err := rlp.EncodeToBytes(record)
+	if err != nil {
+		return err
+	}
+
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	batch := store.db.NewBatch()
+	if err = batch.Put(workerKey(record.Address), data); err != nil {
+		return err
+	}
+	_, exist := store.workers[record.Address]
+	if !exist {
+		index, err := rlp.EncodeToBytes(append(store.index, record.Address))
+		if err != nil {
+			return err
+		}
+		if err = batch.Put(workerIndexKey, index); err != nil {
+			return err
+		}
+	}
+	if err = batch.Write(); err != nil {
+		return err
+	}
+	if !exist {
+		store.index = append(store.index, record.Address)
+		store.workers[record.Address] = struct{}{}
+	}
+	return nil
+}
+
+func (store *dbStateStore) LoadWorkers() ([]WorkerRecord, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	records := make([]WorkerRecord, 0, len(store.index))
+	for _, address := range store.index {
+		data, err := store.db.Get(workerKey(address))
+		if err != nil {
+			return nil, err
+		}
+		var record WorkerRecord
+		if err = rlp.DecodeBytes(data, &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (store *dbStateStore) AddPayout(record PayoutRecord) error {
+	data, err := rlp.EncodeToBytes(record)
+	if err != nil {
+		return err
+	}
+
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	head := make([]byte, 8)
+	binary.BigEndian.PutUint64(head, store.payoutHead+1)
+	batch := store.db.NewBatch()
+	if err = batch.Put(payoutKey(store.payoutHead), data); err != nil {
+		return err
+	}
+	if err = batch.Put(payoutHeadKey, head); err != nil {
+		return err
+	}
+	if err = batch.Write(); err != nil {
+		return err
+	}
+	store.payoutHead++
+	return nil
+}
+
+func (store *dbStateStore) LoadPayouts() ([]PayoutRecord, error) {
+	store.lock.Lock()
+	defer store.lock.Unlock()
+
+	records := make([]PayoutRecord, 0, store.payoutHead)
+	for seq := uint64(0); seq < store.payoutHead; seq++ {
+		data, err := store.db.Get(payoutKey(seq))
+		if err != nil {
+			return nil, err
+		}
+		var record PayoutRecord
+		if err = rlp.DecodeBytes(data, &record); err != nil {
+			return nil, err
+		}
+		records = append(records, record)
+	}
+	return records, nil
+}
+
+func (store *dbStateStore) SaveMeta(totalReward *big.Int, rewardedHeight uint64) error {
+	data, err := rlp.EncodeToBytes(masterMeta{TotalReward: totalReward, RewardedHeight: rewardedHeight})
+	if err != nil {
+		return err
+	}
+	return store.db.Put(masterMetaKey, data)
+}
+
+func (store *dbStateStore) LoadMeta() (*big.Int, uint64, error) {
+	data, err := store.db.Get(masterMetaKey)
+	if err != nil {
+		// nothing saved yet
+		return big.NewInt(0), 0, nil
+	}
+	var meta masterMeta
+	if err = rlp.DecodeBytes(data, &meta); err != nil {
+		return nil, 0, err
+	}
+	return meta.TotalReward, meta.RewardedHeight, nil
+}
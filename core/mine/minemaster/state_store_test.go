@@ -0,0 +1,62 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemaster
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestStateStore(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	store := NewStateStore(db)
+
+	records, err := store.LoadWorkers()
+	assert.NoError(t, err)
+	assert.Len(t, records, 0)
+	totalReward, height, err := store.LoadMeta()
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(0), totalReward)
+	assert.EqualValues(t, 0, height)
+
+	worker1 := WorkerRecord{Address: common.HexToAddress("0x123"), WorkerIds: []string{"1"}, Performance: 2, Reward: big.NewInt(3), LastSeen: 4}
+	worker2 := WorkerRecord{Address: common.HexToAddress("0x123223"), WorkerIds: []string{}, Reward: big.NewInt(0)}
+	assert.NoError(t, store.SaveWorker(worker1))
+	assert.NoError(t, store.SaveWorker(worker2))
+	worker1.Reward = big.NewInt(5)
+	assert.NoError(t, store.SaveWorker(worker1))
+
+	payout := PayoutRecord{Address: worker1.Address, Value: big.NewInt(1), TxFee: big.NewInt(2), TxHash: common.HexToHash("0x1")}
+	assert.NoError(t, store.AddPayout(payout))
+	assert.NoError(t, store.SaveMeta(big.NewInt(10), 7))
+
+	// restore from the db
+	store = NewStateStore(db)
+	records, err = store.LoadWorkers()
+	assert.NoError(t, err)
+	assert.Equal(t, []WorkerRecord{worker1, worker2}, records)
+	payouts, err := store.LoadPayouts()
+	assert.NoError(t, err)
+	assert.Equal(t, []PayoutRecord{payout}, payouts)
+	totalReward, height, err = store.LoadMeta()
+	assert.NoError(t, err)
+	assert.Equal(t, big.NewInt(10), totalReward)
+	assert.EqualValues(t, 7, height)
+}
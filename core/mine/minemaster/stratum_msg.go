@@ -0,0 +1,105 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemaster
+
+import (
+	"encoding/json"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"strings"
+)
+
+// the stratum methods, the messages are line delimited json-rpc objects
+const (
+	stratumSubscribe     = "mining.subscribe"
+	stratumAuthorize     = "mining.authorize"
+	stratumSubmit        = "mining.submit"
+	stratumNotify        = "mining.notify"
+	stratumSetDifficulty = "mining.set_difficulty"
+)
+
+// the error codes of the stratum protocol
+const (
+	stratumErrOther          = 20
+	stratumErrJobNotFound    = 21
+	stratumErrDuplicateShare = 22
+	stratumErrLowDifficulty  = 23
+	stratumErrUnauthorized   = 24
+	stratumErrNotSubscribed  = 25
+)
+
+type stratumRequest struct {
+	Id     *json.RawMessage  `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type stratumResponse struct {
+	Id     *json.RawMessage `json:"id"`
+	Result interface{}      `json:"result"`
+	Error  interface{}      `json:"error"`
+}
+
+// the id of the notification is always null
+type stratumNotification struct {
+	Id     *json.RawMessage `json:"id"`
+	Method string           `json:"method"`
+	Params []interface{}    `json:"params"`
+}
+
+func newStratumError(code int, msg string) []interface{} {
+	return []interface{}{code, msg, nil}
+}
+
+// the param at the index must be a string
+func stratumStringParam(req *stratumRequest, index int) (string, bool) {
+	if len(req.Params) <= index {
+		return "", false
+	}
+	var res string
+	if err := json.Unmarshal(req.Params[index], &res); err != nil {
+		return "", false
+	}
+	return res, true
+}
+
+// the user name of the authorize request is the payout address, it may be followed by ".worker name"
+func parseStratumUser(user string) (common.Address, bool) {
+	if index := strings.Index(user, "."); index >= 0 {
+		user = user[:index]
+	}
+	addr, err := hexutil.Decode(user)
+	if err != nil || len(addr) != common.AddressLength {
+		return common.Address{}, false
+	}
+	res := common.BytesToAddress(addr)
+	if res.GetAddressType() != common.AddressTypeNormal || res.IsEmpty() {
+		return common.Address{}, false
+	}
+	return res, true
+}
+
+// the submitted nonce must be the whole nonce of the header
+func parseStratumNonce(nonce string) (common.BlockNonce, bool) {
+	b, err := hexutil.Decode(nonce)
+	if err != nil || len(b) != common.NonceLength {
+		return common.BlockNonce{}, false
+	}
+	var res common.BlockNonce
+	copy(res[:], b)
+	return res, true
+}
@@ -0,0 +1,458 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemaster
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/core/mine/minemsg"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"net"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// only the latest jobs of a session can be submitted
+	maxStratumJobs = 4
+	// the msgs waiting to be written to a session, the session is closed if its rig doesn't read them in time
+	stratumSendQueueSize = 16
+	// the session is closed if a request line is longer than it
+	maxStratumLineSize = 4096
+)
+
+// a stalled rig is disconnected after the write timeout
+var stratumWriteTimeout = 10 * time.Second
+
+// NewStratumServer make a stratum tcp server for the external mining rigs, the sessions are registered to the
+// master server as workers
+func NewStratumServer(listenAddr string, server MasterServer) *StratumServer {
+	return &StratumServer{
+		listenAddr: listenAddr,
+		server:     server,
+		sessions:   map[WorkerId]*stratumSession{},
+	}
+}
+
+type StratumServer struct {
+	listenAddr string
+	server     MasterServer
+
+	// the share difficulty is lower than the block difficulty, so the workers submit shares frequently.
+	// the minimum share difficulty of minemsg is used if it isn't set.
+	shareDifficulty atomic.Value
+
+	listener net.Listener
+	sessions map[WorkerId]*stratumSession
+	lock     sync.Mutex
+}
+
+func (s *StratumServer) Start() error {
+	listener, err := net.Listen("tcp", s.listenAddr)
+	if err != nil {
+		return err
+	}
+	s.lock.Lock()
+	s.listener = listener
+	s.lock.Unlock()
+
+	log.Info("start stratum server", "addr", listener.Addr().String())
+	go s.acceptLoop(listener)
+	return nil
+}
+
+func (s *StratumServer) Stop() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.listener == nil {
+		return
+	}
+	s.listener.Close()
+	s.listener = nil
+	for _, session := range s.sessions {
+		session.conn.Close()
+	}
+}
+
+// Addr return the listening address, it is nil if the server isn't started
+func (s *StratumServer) Addr() net.Addr {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.listener == nil {
+		return nil
+	}
+	return s.listener.Addr()
+}
+
+func (s *StratumServer) ShareDifficulty() common.Difficulty {
+	if v := s.shareDifficulty.Load(); v != nil {
+		return v.(common.Difficulty)
+	}
+	return common.Difficulty{}
+}
+
+// the share difficulty of the job, it is between the minimum share difficulty and the block difficulty
+func (s *StratumServer) shareDifficultyFor(blockDiff common.Difficulty) common.Difficulty {
+	minDiff := minemsg.ShareDifficulty(blockDiff)
+	shareDiff := s.ShareDifficulty()
+	if shareDiff.Equal(common.Difficulty{}) || shareDiff.Big().Cmp(minDiff.Big()) > 0 {
+		return minDiff
+	}
+	if shareDiff.Big().Cmp(blockDiff.Big()) < 0 {
+		return blockDiff
+	}
+	return shareDiff
+}
+
+// SetShareDifficulty set the share difficulty and notify all the authorized sessions
+func (s *StratumServer) SetShareDifficulty(diff common.Difficulty) {
+	s.shareDifficulty.Store(diff)
+
+	s.lock.Lock()
+	sessions := make([]*stratumSession, 0, len(s.sessions))
+	for _, session := range s.sessions {
+		sessions = append(sessions, session)
+	}
+	s.lock.Unlock()
+
+	for _, session := range sessions {
+		if session.isAuthorized() {
+			session.notify(stratumSetDifficulty, diff.Hex())
+		}
+	}
+}
+
+func (s *StratumServer) acceptLoop(listener net.Listener) {
+	for {
+		conn, err := listener.Accept()
+		if err != nil {
+			log.Info("stratum server stop accepting", "err", err)
+			return
+		}
+		session := newStratumSession(s, conn)
+		s.lock.Lock()
+		s.sessions[session.workerId] = session
+		s.lock.Unlock()
+		go session.loop()
+	}
+}
+
+func (s *StratumServer) removeSession(session *stratumSession) {
+	s.lock.Lock()
+	delete(s.sessions, session.workerId)
+	s.lock.Unlock()
+
+	if session.isAuthorized() {
+		s.server.UnRegisterWorker(session.workerId)
+	}
+}
+
+type stratumJob struct {
+	id     string
+	work   minemsg.DefaultWork
+	shares map[common.BlockNonce]struct{}
+}
+
+func newStratumSession(server *StratumServer, conn net.Conn) *stratumSession {
+	return &stratumSession{
+		server:   server,
+		conn:     conn,
+		workerId: WorkerId("stratum-" + conn.RemoteAddr().String()),
+		sendCh:   make(chan interface{}, stratumSendQueueSize),
+		quit:     make(chan struct{}),
+	}
+}
+
+// stratumSession is a connection of a mining rig, it is a worker of the master after it is authorized
+type stratumSession struct {
+	server   *StratumServer
+	conn     net.Conn
+	workerId WorkerId
+
+	// the msgs are written by the write loop, so a stalled rig doesn't block the master
+	sendCh    chan interface{}
+	quit      chan struct{}
+	closeOnce sync.Once
+
+	curCoinbaseAddr atomic.Value
+
+	subscribed bool
+	authorized bool
+	jobSeq     uint64
+	jobs       []*stratumJob
+	lock       sync.Mutex
+}
+
+func (session *stratumSession) loop() {
+	go session.writeLoop()
+	defer func() {
+		session.close()
+		session.server.removeSession(session)
+	}()
+
+	// the requests are json lines, a line longer than the buffer fails with bufio.ErrBufferFull
+	reader := bufio.NewReaderSize(session.conn, maxStratumLineSize)
+	for {
+		line, err := reader.ReadSlice('\n')
+		if err != nil {
+			log.Info("stratum session closed", "worker id", session.workerId, "err", err)
+			return
+		}
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		var req stratumRequest
+		if err := json.Unmarshal(line, &req); err != nil {
+			log.Info("stratum session closed", "worker id", session.workerId, "err", err)
+			return
+		}
+		session.handleRequest(&req)
+	}
+}
+
+func (session *stratumSession) writeLoop() {
+	encoder := json.NewEncoder(session.conn)
+	for {
+		select {
+		case msg := <-session.sendCh:
+			session.conn.SetWriteDeadline(time.Now().Add(stratumWriteTimeout))
+			if err := encoder.Encode(msg); err != nil {
+				log.Warn("stratum session write failed", "worker id", session.workerId, "err", err)
+				session.close()
+				return
+			}
+		case <-session.quit:
+			return
+		}
+	}
+}
+
+func (session *stratumSession) close() {
+	session.closeOnce.Do(func() {
+		close(session.quit)
+		session.conn.Close()
+	})
+}
+
+func (session *stratumSession) handleRequest(req *stratumRequest) {
+	switch req.Method {
+	case stratumSubscribe:
+		session.lock.Lock()
+		session.subscribed = true
+		session.lock.Unlock()
+		// the nonce prefix is assigned by the jobs, so there is no extra nonce
+		session.reply(req, []interface{}{[]interface{}{[]interface{}{stratumNotify, string(session.workerId)}}, "", 0}, nil)
+
+	case stratumAuthorize:
+		session.onAuthorize(req)
+
+	case stratumSubmit:
+		if accepted, err := session.onSubmit(req); err != nil {
+			session.reply(req, false, err)
+		} else {
+			session.reply(req, accepted, nil)
+		}
+
+	default:
+		session.reply(req, nil, newStratumError(stratumErrOther, "unknown method"))
+	}
+}
+
+func (session *stratumSession) onAuthorize(req *stratumRequest) {
+	session.lock.Lock()
+	subscribed, authorized := session.subscribed, session.authorized
+	session.lock.Unlock()
+	if !subscribed {
+		session.reply(req, false, newStratumError(stratumErrNotSubscribed, "not subscribed"))
+		return
+	}
+
+	user, _ := stratumStringParam(req, 0)
+	coinbase, ok := parseStratumUser(user)
+	if !ok {
+		session.reply(req, false, newStratumError(stratumErrUnauthorized, "the user must be a payout address"))
+		return
+	}
+	session.SetCoinbase(coinbase)
+	session.reply(req, true, nil)
+	if authorized {
+		return
+	}
+
+	session.lock.Lock()
+	session.authorized = true
+	session.lock.Unlock()
+	if diff := session.server.ShareDifficulty(); !diff.Equal(common.Difficulty{}) {
+		session.notify(stratumSetDifficulty, diff.Hex())
+	}
+	log.Info("stratum worker authorized", "worker id", session.workerId, "coinbase", coinbase.Hex())
+	session.server.server.RegisterWorker(session)
+}
+
+// return true if the share is accepted
+func (session *stratumSession) onSubmit(req *stratumRequest) (bool, []interface{}) {
+	if !session.isAuthorized() {
+		return false, newStratumError(stratumErrUnauthorized, "unauthorized worker")
+	}
+	jobId, ok := stratumStringParam(req, 1)
+	if !ok {
+		return false, newStratumError(stratumErrOther, "invalid job id")
+	}
+	nonceStr, ok := stratumStringParam(req, 2)
+	if !ok {
+		return false, newStratumError(stratumErrOther, "invalid nonce")
+	}
+	nonce, ok := parseStratumNonce(nonceStr)
+	if !ok {
+		return false, newStratumError(stratumErrOther, "invalid nonce")
+	}
+
+	session.lock.Lock()
+	job := session.getJob(jobId)
+	if job == nil {
+		session.lock.Unlock()
+		return false, newStratumError(stratumErrJobNotFound, "job not found")
+	}
+	// the first 4 bytes of the nonce are assigned by the master to split the nonce space of the workers
+	if binary.BigEndian.Uint32(nonce[:4]) != binary.BigEndian.Uint32(job.work.BlockHeader.Nonce[:4]) {
+		session.lock.Unlock()
+		return false, newStratumError(stratumErrOther, "the nonce prefix is not assigned to the worker")
+	}
+	if _, exist := job.shares[nonce]; exist {
+		session.lock.Unlock()
+		return false, newStratumError(stratumErrDuplicateShare, "duplicate share")
+	}
+	job.shares[nonce] = struct{}{}
+	work := job.work
+	session.lock.Unlock()
+
+	work.BlockHeader.Nonce = nonce
+	hash, err := work.CalHash()
+	if err != nil {
+		return false, newStratumError(stratumErrOther, err.Error())
+	}
+
+	blockDiff := work.BlockHeader.Diff
+	shareDiff := session.server.shareDifficultyFor(blockDiff)
+	if !hash.ValidHashForDifficulty(shareDiff) {
+		return false, newStratumError(stratumErrLowDifficulty, "low difficulty share")
+	}
+
+	work.ResultNonce = nonce
+	work.WorkerCoinbaseAddress = session.CurrentCoinbaseAddress()
+	session.server.server.ReceiveMsg(session.workerId, minemsg.SubmitShareMsg, &minemsg.ShareWork{Work: work, ShareDiff: shareDiff})
+	if hash.ValidHashForDifficulty(blockDiff) {
+		log.Info("stratum worker found a block", "worker id", session.workerId, "block number", work.BlockHeader.Number, "hash", hash.Hex())
+		session.server.server.ReceiveMsg(session.workerId, minemsg.SubmitDefaultWorkMsg, &work)
+	}
+	return true, nil
+}
+
+// must hold the lock
+func (session *stratumSession) getJob(jobId string) *stratumJob {
+	for _, job := range session.jobs {
+		if job.id == jobId {
+			return job
+		}
+	}
+	return nil
+}
+
+func (session *stratumSession) isAuthorized() bool {
+	session.lock.Lock()
+	defer session.lock.Unlock()
+	return session.authorized
+}
+
+func (session *stratumSession) reply(req *stratumRequest, result interface{}, err interface{}) {
+	session.write(&stratumResponse{Id: req.Id, Result: result, Error: err})
+}
+
+func (session *stratumSession) notify(method string, params ...interface{}) {
+	session.write(&stratumNotification{Method: method, Params: params})
+}
+
+// the msg is queued without blocking, the session is closed if its queue is full
+func (session *stratumSession) write(msg interface{}) {
+	select {
+	case <-session.quit:
+	case session.sendCh <- msg:
+	default:
+		log.Warn("stratum session send queue is full", "worker id", session.workerId)
+		session.close()
+	}
+}
+
+// the rigs keep mining the job until a new job is notified
+func (session *stratumSession) Start() {}
+
+// the jobs are dropped, so the shares of them are rejected
+func (session *stratumSession) Stop() {
+	session.lock.Lock()
+	session.jobs = nil
+	session.lock.Unlock()
+}
+
+func (session *stratumSession) GetId() WorkerId {
+	return session.workerId
+}
+
+// SendNewWork notify the job with the header rlp without nonce, the assigned nonce prefix and the block difficulty
+func (session *stratumSession) SendNewWork(msgCode int, work minemsg.Work) {
+	defaultWork, ok := work.(*minemsg.DefaultWork)
+	if msgCode != minemsg.NewDefaultWorkMsg || !ok {
+		log.Warn("stratum worker receive unsupported work", "msg code", msgCode)
+		return
+	}
+
+	job := &stratumJob{work: *defaultWork, shares: map[common.BlockNonce]struct{}{}}
+	job.work.CalBlockRlpWithoutNonce()
+
+	session.lock.Lock()
+	session.jobSeq++
+	job.id = fmt.Sprintf("%x", session.jobSeq)
+	session.jobs = append(session.jobs, job)
+	if len(session.jobs) > maxStratumJobs {
+		session.jobs = session.jobs[len(session.jobs)-maxStratumJobs:]
+	}
+	session.lock.Unlock()
+
+	header := job.work.BlockHeader
+	// the rigs follow the minimum share difficulty if it isn't set
+	if diff := session.server.ShareDifficulty(); diff.Equal(common.Difficulty{}) {
+		session.notify(stratumSetDifficulty, session.server.shareDifficultyFor(header.Diff).Hex())
+	}
+	session.notify(stratumNotify, job.id, hexutil.Encode(job.work.RlpPreCal), hexutil.Encode(header.Nonce[:4]),
+		header.Diff.Hex(), header.Number, true)
+}
+
+func (session *stratumSession) SetCoinbase(coinbase common.Address) {
+	session.curCoinbaseAddr.Store(coinbase)
+}
+
+func (session *stratumSession) CurrentCoinbaseAddress() common.Address {
+	if addr := session.curCoinbaseAddr.Load(); addr != nil {
+		return addr.(common.Address)
+	}
+	return common.Address{}
+}
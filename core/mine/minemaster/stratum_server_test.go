@@ -0,0 +1,224 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemaster
+
+import (
+	"bufio"
+	"encoding/json"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/core/bloom"
+	"github.com/dipperin/dipperin-core/core/chain-communication"
+	"github.com/dipperin/dipperin-core/core/mine/minemsg"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/third-party/p2p"
+	"github.com/stretchr/testify/assert"
+	"net"
+	"testing"
+	"time"
+)
+
+type fakeStratumMasterServer struct {
+	registered chan WorkerForMaster
+	submitted  chan minemsg.Work
+	shares     chan *minemsg.ShareWork
+}
+
+func (s *fakeStratumMasterServer) RegisterWorker(worker WorkerForMaster) {
+	s.registered <- worker
+}
+
+func (s *fakeStratumMasterServer) UnRegisterWorker(workerId WorkerId) {}
+
+func (s *fakeStratumMasterServer) ReceiveMsg(workerID WorkerId, code uint64, msg interface{}) {
+	if code == minemsg.SubmitShareMsg {
+		s.shares <- msg.(*minemsg.ShareWork)
+		return
+	}
+	s.submitted <- msg.(minemsg.Work)
+}
+
+func (s *fakeStratumMasterServer) OnNewMsg(msg p2p.Msg, p chain_communication.PmAbstractPeer) error {
+	return nil
+}
+
+func (s *fakeStratumMasterServer) SetMineMasterPeer(peer chain_communication.PmAbstractPeer) {}
+
+type stratumTestClient struct {
+	conn   net.Conn
+	reader *bufio.Reader
+}
+
+func (c *stratumTestClient) call(t *testing.T, id int, method string, params ...interface{}) map[string]interface{} {
+	assert.NoError(t, json.NewEncoder(c.conn).Encode(map[string]interface{}{"id": id, "method": method, "params": params}))
+	return c.read(t)
+}
+
+func (c *stratumTestClient) read(t *testing.T) map[string]interface{} {
+	c.conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	line, err := c.reader.ReadBytes('\n')
+	assert.NoError(t, err)
+	var res map[string]interface{}
+	assert.NoError(t, json.Unmarshal(line, &res))
+	return res
+}
+
+func TestStratumServer(t *testing.T) {
+	master := &fakeStratumMasterServer{registered: make(chan WorkerForMaster, 1), submitted: make(chan minemsg.Work, 1), shares: make(chan *minemsg.ShareWork, 1)}
+	server := NewStratumServer("127.0.0.1:0", master)
+	assert.Nil(t, server.Addr())
+	assert.NoError(t, server.Start())
+	defer server.Stop()
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+	client := &stratumTestClient{conn: conn, reader: bufio.NewReader(conn)}
+
+	// authorize before subscribe
+	coinbase := common.HexToAddress("0x000062be10f46b5d01Ecd9b502c4bA3d6131f6fc2e41")
+	res := client.call(t, 1, stratumAuthorize, coinbase.Hex(), "")
+	assert.Equal(t, false, res["result"])
+	assert.NotNil(t, res["error"])
+
+	res = client.call(t, 2, stratumSubscribe)
+	assert.Nil(t, res["error"])
+	res = client.call(t, 3, stratumAuthorize, "0x1234.rig", "")
+	assert.Equal(t, false, res["result"])
+
+	server.SetShareDifficulty(common.HexToDiff("0x1fffffff"))
+	res = client.call(t, 4, stratumAuthorize, coinbase.Hex()+".rig", "")
+	assert.Equal(t, true, res["result"])
+	res = client.read(t)
+	assert.Equal(t, stratumSetDifficulty, res["method"])
+
+	var worker WorkerForMaster
+	select {
+	case worker = <-master.registered:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stratum worker isn't registered")
+	}
+	assert.Equal(t, coinbase, worker.CurrentCoinbaseAddress())
+
+	// the block difficulty is lower than the share difficulty, so every share is a block
+	header := model.Header{Number: 1, Diff: common.HexToDiff("0x1fffffff"), Nonce: common.BlockNonce{0, 0, 0, 2}, Bloom: iblt.NewBloom(iblt.NewBloomConfig(1<<12, 4))}
+	worker.SendNewWork(minemsg.NewDefaultWorkMsg, &minemsg.DefaultWork{BlockHeader: header})
+	res = client.read(t)
+	assert.Equal(t, stratumNotify, res["method"])
+	params := res["params"].([]interface{})
+	jobId := params[0].(string)
+	rlpPreCal, err := hexutil.Decode(params[1].(string))
+	assert.NoError(t, err)
+	assert.Equal(t, "0x00000002", params[2])
+
+	res = client.call(t, 5, stratumSubmit, coinbase.Hex(), "ff", hexutil.Encode(make([]byte, common.NonceLength)))
+	assert.NotNil(t, res["error"])
+	res = client.call(t, 6, stratumSubmit, coinbase.Hex(), jobId, hexutil.Encode(make([]byte, common.NonceLength)))
+	assert.NotNil(t, res["error"])
+
+	// find a valid nonce like a rig
+	work := minemsg.DefaultWork{BlockHeader: header, RlpPreCal: rlpPreCal}
+	var lowNonce, validNonce *common.BlockNonce
+	for i := uint32(0); lowNonce == nil || validNonce == nil; i++ {
+		nonce := header.Nonce
+		nonce[4], nonce[5], nonce[6], nonce[7] = byte(i>>24), byte(i>>16), byte(i>>8), byte(i)
+		work.BlockHeader.Nonce = nonce
+		hash, _ := work.CalHash()
+		if hash.ValidHashForDifficulty(header.Diff) {
+			if validNonce == nil {
+				validNonce = &nonce
+			}
+		} else if lowNonce == nil {
+			lowNonce = &nonce
+		}
+	}
+
+	res = client.call(t, 7, stratumSubmit, coinbase.Hex(), jobId, hexutil.Encode(lowNonce[:]))
+	assert.Equal(t, false, res["result"])
+	res = client.call(t, 8, stratumSubmit, coinbase.Hex(), jobId, hexutil.Encode(validNonce[:]))
+	assert.Equal(t, true, res["result"])
+	select {
+	case share := <-master.shares:
+		assert.Equal(t, coinbase, share.Work.GetWorkerCoinbaseAddress())
+		assert.Equal(t, header.Diff, share.ShareDiff)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the share isn't submitted")
+	}
+	select {
+	case w := <-master.submitted:
+		assert.Equal(t, coinbase, w.GetWorkerCoinbaseAddress())
+		assert.Equal(t, *validNonce, w.(*minemsg.DefaultWork).ResultNonce)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the block isn't submitted")
+	}
+
+	res = client.call(t, 9, stratumSubmit, coinbase.Hex(), jobId, hexutil.Encode(validNonce[:]))
+	assert.Equal(t, false, res["result"])
+
+	// the jobs are dropped after stop
+	worker.Stop()
+	res = client.call(t, 10, stratumSubmit, coinbase.Hex(), jobId, hexutil.Encode(validNonce[:]))
+	assert.Equal(t, false, res["result"])
+}
+
+func TestStratumServer_LongLine(t *testing.T) {
+	server := NewStratumServer("127.0.0.1:0", &fakeStratumMasterServer{})
+	assert.NoError(t, server.Start())
+	defer server.Stop()
+
+	conn, err := net.Dial("tcp", server.Addr().String())
+	assert.NoError(t, err)
+	defer conn.Close()
+
+	// the session is closed before the line ends
+	_, err = conn.Write(make([]byte, maxStratumLineSize+1))
+	assert.NoError(t, err)
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	_, err = conn.Read(make([]byte, 1))
+	assert.Error(t, err)
+	assert.False(t, err.(net.Error).Timeout())
+}
+
+func TestStratumSession_Stalled(t *testing.T) {
+	conn, rig := net.Pipe()
+	defer rig.Close()
+	session := newStratumSession(NewStratumServer("127.0.0.1:0", &fakeStratumMasterServer{}), conn)
+
+	// the write doesn't block without the write loop, the session is closed when the queue is full
+	for i := 0; i < stratumSendQueueSize+1; i++ {
+		session.notify(stratumSetDifficulty, "0x1fffffff")
+	}
+	select {
+	case <-session.quit:
+	default:
+		t.Fatal("the stalled session isn't closed")
+	}
+
+	// the rig doesn't read the msg before the write timeout
+	defer func(timeout time.Duration) { stratumWriteTimeout = timeout }(stratumWriteTimeout)
+	stratumWriteTimeout = 10 * time.Millisecond
+	conn, rig = net.Pipe()
+	defer rig.Close()
+	session = newStratumSession(session.server, conn)
+	go session.writeLoop()
+	session.notify(stratumSetDifficulty, "0x1fffffff")
+	select {
+	case <-session.quit:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the stalled session isn't closed")
+	}
+}
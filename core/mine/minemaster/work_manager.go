@@ -23,16 +23,30 @@ import (
 	"github.com/dipperin/dipperin-core/third-party/log"
 	"sync"
 	"math/big"
+	"errors"
+	"sort"
+	"time"
 )
 
+// keep the latest worker ids of an address
+const maxWorkerIdsPerAddress = 16
+
+var negativeRewardErr = errors.New("the reward can't be negative")
+
 func newDefaultWorkManager(config MineConfig) *defaultWorkManager {
-	return &defaultWorkManager{
+	manager := &defaultWorkManager{
 		MineConfig: config,
 
 		performance: make(map[common.Address]workerPerformance),
 		reward:      make(map[common.Address]*big.Int),
+		identities:  make(map[common.Address]*workerIdentity),
 		totalReward: new(big.Int),
+		calculator:  newRewardCalculator(config),
 	}
+	if config.StateStore != nil {
+		manager.restore()
+	}
+	return manager
 }
 
 type defaultWorkManager struct {
@@ -42,16 +56,29 @@ type defaultWorkManager struct {
 
 	performance map[common.Address]workerPerformance
 	reward      map[common.Address]*big.Int
+	identities  map[common.Address]*workerIdentity
+	payouts     []PayoutRecord
+	// guards the performance, rewards, identities and payouts, which are saved to StateStore once changed
+	stateLock sync.Mutex
 
 	// wallet sums up all the rewards that this minemaster had received
 	totalReward *big.Int
+
+	// divide the rewards by the shares in ShareStore
+	calculator rewardCalculator
+	// the highest block whose reward has been divided by the shares
+	rewardedHeight uint64
 }
 
 func (manager *defaultWorkManager) subtractPerformance(address common.Address, performance uint64) {
+	manager.stateLock.Lock()
+	defer manager.stateLock.Unlock()
+
 	if p := manager.performance[address]; p != nil {
 		op := p.getPerformance()
 		if op > performance {
 			manager.performance[address].setPerformance(op - performance)
+			manager.saveWorker(address)
 		} else {
 			log.Debug("reward is less than current performance", "performance", performance, "current performance", op)
 		}
@@ -62,11 +89,15 @@ func (manager *defaultWorkManager) subtractPerformance(address common.Address, p
 }
 
 func (manager *defaultWorkManager) subtractReward(address common.Address, reward *big.Int) {
+	manager.stateLock.Lock()
+	defer manager.stateLock.Unlock()
+
 	if r := manager.reward[address]; r != nil {
 		if r.Cmp(reward) > 0 {
 			newPer := r.Sub(r, reward)
-			manager.clearReward(address)
+			delete(manager.reward, address)
 			manager.reward[address] = newPer
+			manager.saveWorker(address)
 		} else {
 			log.Debug("reward is less than current reward", "reward", reward, "current reward", r)
 		}
@@ -76,23 +107,80 @@ func (manager *defaultWorkManager) subtractReward(address common.Address, reward
 }
 
 func (manager *defaultWorkManager) clearPerformance(address common.Address) {
+	manager.stateLock.Lock()
+	defer manager.stateLock.Unlock()
+
 	delete(manager.performance, address)
+	manager.saveWorker(address)
 }
 
 func (manager *defaultWorkManager) clearReward(address common.Address) {
+	manager.stateLock.Lock()
+	defer manager.stateLock.Unlock()
+
 	delete(manager.reward, address)
+	manager.saveWorker(address)
 }
 
 func (manager *defaultWorkManager) getReward(address common.Address) *big.Int {
+	manager.stateLock.Lock()
+	defer manager.stateLock.Unlock()
+
 	if manager.reward[address] == nil {
 		return big.NewInt(0)
 	}
-	return manager.reward[address]
+	return new(big.Int).Set(manager.reward[address])
+}
+
+func (manager *defaultWorkManager) rewards() map[common.Address]*big.Int {
+	manager.stateLock.Lock()
+	defer manager.stateLock.Unlock()
+
+	res := make(map[common.Address]*big.Int, len(manager.reward))
+	for address, reward := range manager.reward {
+		res[address] = new(big.Int).Set(reward)
+	}
+	return res
+}
+
+func (manager *defaultWorkManager) takeReward(address common.Address) *big.Int {
+	manager.stateLock.Lock()
+	defer manager.stateLock.Unlock()
+
+	reward := manager.reward[address]
+	delete(manager.reward, address)
+	if reward == nil {
+		return big.NewInt(0)
+	}
+	manager.saveWorker(address)
+	return reward
+}
+
+func (manager *defaultWorkManager) restoreReward(address common.Address, reward *big.Int) {
+	manager.stateLock.Lock()
+	defer manager.stateLock.Unlock()
+
+	manager.addReward(address, reward)
+	manager.saveWorker(address)
+}
+
+// must hold the state lock
+func (manager *defaultWorkManager) addReward(address common.Address, reward *big.Int) {
+	if reward.Sign() == 0 {
+		return
+	}
+	if manager.reward[address] == nil {
+		manager.reward[address] = big.NewInt(0)
+	}
+	manager.reward[address].Add(manager.reward[address], reward)
 }
 
 // divideReward updates the reward distribution every time it is called.
 // it refreshes the reward map according to the performance and totalReward
 func (manager *defaultWorkManager) divideReward(coinbase *big.Int) map[common.Address]*big.Int {
+	manager.stateLock.Lock()
+	defer manager.stateLock.Unlock()
+
 	res := make(map[common.Address]*big.Int)
 
 	manager.totalReward.Add(manager.totalReward, coinbase)
@@ -113,18 +201,84 @@ func (manager *defaultWorkManager) divideReward(coinbase *big.Int) map[common.Ad
 
 	// refresh the reward map
 	manager.reward = res
+	for address := range manager.performance {
+		manager.saveWorker(address)
+	}
+	manager.saveMeta()
 
 	return manager.reward
 }
 
 func (manager *defaultWorkManager) onNewBlock(block model.AbstractBlock) {
+	if manager.ShareStore != nil {
+		manager.divideRewardByShares(block)
+		return
+	}
+
 	coinbase := block.CoinBase()
 	txFees := block.GetTransactionFees()
 
 	manager.divideReward(coinbase.Add(coinbase, txFees))
 }
 
+// divideRewardByShares is called once the block mined by the master is inserted into the chain,
+// the coinbase reward of the block is final then. The reward is added to the workers' rewards.
+func (manager *defaultWorkManager) divideRewardByShares(block model.AbstractBlock) {
+	manager.stateLock.Lock()
+	if block.Number() <= manager.rewardedHeight {
+		manager.stateLock.Unlock()
+		log.Debug("the block reward has been divided", "block number", block.Number())
+		return
+	}
+	manager.rewardedHeight = block.Number()
+	manager.stateLock.Unlock()
+
+	reward := manager.blockReward(block)
+	res, err := manager.calculator.calculate(manager.ShareStore, block.Difficulty(), reward)
+	if err != nil {
+		log.Error("divide the block reward by shares failed", "block number", block.Number(), "err", err)
+		return
+	}
+
+	manager.stateLock.Lock()
+	defer manager.stateLock.Unlock()
+
+	manager.totalReward.Add(manager.totalReward, reward)
+	for address, value := range res {
+		manager.addReward(address, value)
+		manager.saveWorker(address)
+	}
+	manager.saveMeta()
+	log.Info("divide the block reward by shares", "block number", block.Number(), "reward", reward, "workers", len(res))
+}
+
+// the coinbase reward and the tx fees of the block
+func (manager *defaultWorkManager) blockReward(block model.AbstractBlock) *big.Int {
+	coinbase := block.CoinBase()
+	if manager.RewardReader != nil {
+		if reward, err := manager.RewardReader.GetMineMasterDIPReward(block); err != nil {
+			log.Warn("get the mine master reward failed", "block number", block.Number(), "err", err)
+		} else {
+			coinbase = reward
+		}
+	}
+	return new(big.Int).Add(coinbase, block.GetTransactionFees())
+}
+
+func (manager *defaultWorkManager) submitShare(share Share) {
+	if manager.ShareStore == nil {
+		log.Debug("no share store, drop the share", "address", share.Address.Hex())
+		return
+	}
+	if err := manager.ShareStore.AddShare(share); err != nil {
+		log.Warn("store the share failed", "address", share.Address.Hex(), "err", err)
+	}
+}
+
 func (manager *defaultWorkManager) getPerformance(address common.Address) uint64 {
+	manager.stateLock.Lock()
+	defer manager.stateLock.Unlock()
+
 	// now performance equals reward
 	if manager.performance[address] == nil {
 		return 0
@@ -161,12 +315,211 @@ func (manager *defaultWorkManager) submitBlock(workerAddress common.Address, blo
 	defer manager.submitBlockLock.Unlock()
 	//pbft_log.Debug("submitBlock","block id",block.Number(),"block txs",block.TxCount())
 
+	manager.stateLock.Lock()
 	if manager.performance[workerAddress] == nil {
 		manager.performance[workerAddress] = newDefaultPerformance()
 	}
 	manager.performance[workerAddress].updatePerformance()
+	manager.saveWorker(workerAddress)
+	manager.stateLock.Unlock()
 
 	// broadcast block
 	//pbft_log.Debug("submitBlock broad cast block","block id",block.Number(),"block txs",block.TxCount())
 	manager.BlockBroadcaster.BroadcastMinedBlock(block)
 }
+
+// workerIdentity records the workers which mine for an address
+type workerIdentity struct {
+	workerIds []string
+	lastSeen  uint64
+}
+
+func (manager *defaultWorkManager) registerWorker(workerId WorkerId, address common.Address) {
+	if address.IsEmpty() {
+		return
+	}
+
+	manager.stateLock.Lock()
+	defer manager.stateLock.Unlock()
+
+	identity := manager.identities[address]
+	if identity == nil {
+		identity = &workerIdentity{}
+		manager.identities[address] = identity
+	}
+	for i, id := range identity.workerIds {
+		if id == string(workerId) {
+			identity.workerIds = append(identity.workerIds[:i], identity.workerIds[i+1:]...)
+			break
+		}
+	}
+	identity.workerIds = append(identity.workerIds, string(workerId))
+	if len(identity.workerIds) > maxWorkerIdsPerAddress {
+		identity.workerIds = identity.workerIds[len(identity.workerIds)-maxWorkerIdsPerAddress:]
+	}
+	identity.lastSeen = uint64(time.Now().Unix())
+	manager.saveWorker(address)
+}
+
+func (manager *defaultWorkManager) recordPayout(record PayoutRecord) {
+	manager.stateLock.Lock()
+	defer manager.stateLock.Unlock()
+
+	manager.payouts = append(manager.payouts, record)
+	if manager.StateStore == nil {
+		return
+	}
+	if err := manager.StateStore.AddPayout(record); err != nil {
+		log.Warn("save the payout failed", "address", record.Address.Hex(), "tx", record.TxHash.Hex(), "err", err)
+	}
+}
+
+// workerRecords returns the records of all the known addresses, sorted by the address
+func (manager *defaultWorkManager) workerRecords() []WorkerRecord {
+	manager.stateLock.Lock()
+	defer manager.stateLock.Unlock()
+
+	addresses := make(map[common.Address]struct{})
+	for address := range manager.performance {
+		addresses[address] = struct{}{}
+	}
+	for address := range manager.reward {
+		addresses[address] = struct{}{}
+	}
+	for address := range manager.identities {
+		addresses[address] = struct{}{}
+	}
+
+	records := make([]WorkerRecord, 0, len(addresses))
+	for address := range addresses {
+		records = append(records, manager.workerRecord(address))
+	}
+	sort.Slice(records, func(i, j int) bool {
+		return records[i].Address.Hex() < records[j].Address.Hex()
+	})
+	return records
+}
+
+// adjustWorker sets the performance and the reward of the address, for the corrections of the operator
+func (manager *defaultWorkManager) adjustWorker(address common.Address, performance uint64, reward *big.Int) error {
+	if reward == nil || reward.Sign() < 0 {
+		return negativeRewardErr
+	}
+
+	manager.stateLock.Lock()
+	defer manager.stateLock.Unlock()
+
+	if performance == 0 {
+		delete(manager.performance, address)
+	} else {
+		p := newDefaultPerformance()
+		p.setPerformance(performance)
+		manager.performance[address] = p
+	}
+	if reward.Sign() == 0 {
+		delete(manager.reward, address)
+	} else {
+		manager.reward[address] = new(big.Int).Set(reward)
+	}
+	manager.saveWorker(address)
+	log.Info("adjust the worker", "address", address.Hex(), "performance", performance, "reward", reward)
+	return nil
+}
+
+// payoutRecords returns the payouts of the address, all the payouts if the address is empty
+func (manager *defaultWorkManager) payoutRecords(address common.Address) []PayoutRecord {
+	manager.stateLock.Lock()
+	defer manager.stateLock.Unlock()
+
+	records := make([]PayoutRecord, 0)
+	for _, record := range manager.payouts {
+		if address.IsEmpty() || record.Address.IsEqual(address) {
+			records = append(records, record)
+		}
+	}
+	return records
+}
+
+func (manager *defaultWorkManager) exportState() MasterState {
+	workers := manager.workerRecords()
+	payouts := manager.payoutRecords(common.Address{})
+
+	manager.stateLock.Lock()
+	defer manager.stateLock.Unlock()
+	return MasterState{
+		TotalReward:    new(big.Int).Set(manager.totalReward),
+		RewardedHeight: manager.rewardedHeight,
+		Workers:        workers,
+		Payouts:        payouts,
+	}
+}
+
+// must hold the state lock
+func (manager *defaultWorkManager) workerRecord(address common.Address) WorkerRecord {
+	record := WorkerRecord{Address: address, Reward: big.NewInt(0)}
+	if p := manager.performance[address]; p != nil {
+		record.Performance = p.getPerformance()
+	}
+	if r := manager.reward[address]; r != nil {
+		record.Reward.Set(r)
+	}
+	if identity := manager.identities[address]; identity != nil {
+		record.WorkerIds = append([]string{}, identity.workerIds...)
+		record.LastSeen = identity.lastSeen
+	}
+	return record
+}
+
+// must hold the state lock
+func (manager *defaultWorkManager) saveWorker(address common.Address) {
+	if manager.StateStore == nil {
+		return
+	}
+	if err := manager.StateStore.SaveWorker(manager.workerRecord(address)); err != nil {
+		log.Warn("save the worker failed", "address", address.Hex(), "err", err)
+	}
+}
+
+// must hold the state lock
+func (manager *defaultWorkManager) saveMeta() {
+	if manager.StateStore == nil {
+		return
+	}
+	if err := manager.StateStore.SaveMeta(manager.totalReward, manager.rewardedHeight); err != nil {
+		log.Warn("save the mine master meta failed", "err", err)
+	}
+}
+
+// restore the state saved before the restart
+func (manager *defaultWorkManager) restore() {
+	records, err := manager.StateStore.LoadWorkers()
+	if err != nil {
+		log.Error("load the workers failed", "err", err)
+		return
+	}
+	for _, record := range records {
+		if record.Performance > 0 {
+			p := newDefaultPerformance()
+			p.setPerformance(record.Performance)
+			manager.performance[record.Address] = p
+		}
+		if record.Reward != nil && record.Reward.Sign() > 0 {
+			manager.reward[record.Address] = record.Reward
+		}
+		if len(record.WorkerIds) > 0 {
+			manager.identities[record.Address] = &workerIdentity{workerIds: record.WorkerIds, lastSeen: record.LastSeen}
+		}
+	}
+
+	if manager.payouts, err = manager.StateStore.LoadPayouts(); err != nil {
+		log.Error("load the payouts failed", "err", err)
+	}
+	totalReward, rewardedHeight, err := manager.StateStore.LoadMeta()
+	if err != nil {
+		log.Error("load the mine master meta failed", "err", err)
+		return
+	}
+	manager.totalReward = totalReward
+	manager.rewardedHeight = rewardedHeight
+	log.Info("restore the mine master state", "workers", len(records), "payouts", len(manager.payouts), "rewarded height", rewardedHeight)
+}
@@ -25,6 +25,7 @@ import (
 	"math/big"
 	"github.com/stretchr/testify/assert"
 	"sync/atomic"
+	"github.com/ethereum/go-ethereum/ethdb"
 )
 
 func TestDefaultPerformance_GetPerformance(t *testing.T) {
@@ -313,3 +314,38 @@ func TestChangeCoinbase(t *testing.T) {
 	fc.coinbase.Store(common.HexToAddress("0x123"))
 	assert.Equal(t, common.HexToAddress("0x123"), m.CurrentCoinbaseAddress())
 }
+
+func TestWorkerManager_Restore(t *testing.T) {
+	db := ethdb.NewMemDatabase()
+	config := fakeMineConfig()
+	config.StateStore = NewStateStore(db)
+	manager := newDefaultWorkManager(config)
+
+	worker1 := common.HexToAddress("0x123")
+	worker2 := common.HexToAddress("0x123223")
+	manager.registerWorker("w1", worker1)
+	manager.registerWorker("w2", worker1)
+	manager.registerWorker("w1", worker1)
+	manager.submitBlock(worker1, &model.Block{})
+	manager.submitBlock(worker2, &model.Block{})
+	manager.onNewBlock(fakeCalculableBlock{})
+	manager.recordPayout(PayoutRecord{Address: worker2, Value: big.NewInt(1), TxFee: big.NewInt(1)})
+	assert.Error(t, manager.adjustWorker(worker2, 3, big.NewInt(-1)))
+	assert.NoError(t, manager.adjustWorker(worker2, 3, big.NewInt(5)))
+
+	// the state is restored after restart
+	config.StateStore = NewStateStore(db)
+	restored := newDefaultWorkManager(config)
+	assert.Equal(t, manager.exportState(), restored.exportState())
+	assert.EqualValues(t, 1, restored.getPerformance(worker1))
+	assert.EqualValues(t, 3, restored.getPerformance(worker2))
+	assert.Equal(t, big.NewInt(10500000000), restored.getReward(worker1))
+	assert.Equal(t, big.NewInt(5), restored.getReward(worker2))
+	assert.Len(t, restored.payoutRecords(worker2), 1)
+	assert.Len(t, restored.payoutRecords(worker1), 0)
+
+	records := restored.workerRecords()
+	assert.Len(t, records, 2)
+	assert.Equal(t, worker1, records[0].Address)
+	assert.Equal(t, []string{"w2", "w1"}, records[0].WorkerIds)
+}
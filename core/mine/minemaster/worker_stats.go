@@ -0,0 +1,174 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemaster
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"math/big"
+	"sort"
+	"sync"
+	"time"
+)
+
+// the hashrate of a worker is estimated by the work of the shares submitted in the window
+var hashrateWindow = 10 * time.Minute
+
+// WorkerStats is the statistics of a connected worker since it's registered
+type WorkerStats struct {
+	Id      WorkerId
+	Address common.Address
+	// count of the accepted shares
+	Shares uint64
+	// count of the valid blocks found
+	Blocks uint64
+	// estimated hashes per second
+	Hashrate *big.Int
+	// the unpaid reward of the address, the workers mine for the same address share it
+	Reward *big.Int
+	// unix time of the registration and the last accepted share
+	ConnectedAt uint64
+	LastShare   uint64
+}
+
+type shareSample struct {
+	time time.Time
+	work *big.Int
+}
+
+type workerStat struct {
+	worker      WorkerForMaster
+	shares      uint64
+	blocks      uint64
+	connectedAt time.Time
+	lastShare   time.Time
+	// the shares in the hashrate window, the oldest first
+	samples []shareSample
+}
+
+// workerStatsTracker keeps the statistics of the connected workers,
+// the statistics are dropped once the worker is unregistered
+type workerStatsTracker struct {
+	lock  sync.Mutex
+	stats map[WorkerId]*workerStat
+}
+
+func newWorkerStatsTracker() *workerStatsTracker {
+	return &workerStatsTracker{stats: make(map[WorkerId]*workerStat)}
+}
+
+func (tracker *workerStatsTracker) add(worker WorkerForMaster) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	// keep the statistics if the worker registers again
+	if stat := tracker.stats[worker.GetId()]; stat != nil {
+		stat.worker = worker
+		return
+	}
+	tracker.stats[worker.GetId()] = &workerStat{worker: worker, connectedAt: time.Now()}
+}
+
+func (tracker *workerStatsTracker) remove(workerId WorkerId) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	delete(tracker.stats, workerId)
+}
+
+func (tracker *workerStatsTracker) has(workerId WorkerId) bool {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+	return tracker.stats[workerId] != nil
+}
+
+func (tracker *workerStatsTracker) addShare(workerId WorkerId, work *big.Int, now time.Time) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	stat := tracker.stats[workerId]
+	if stat == nil {
+		return
+	}
+	stat.shares++
+	stat.lastShare = now
+	stat.samples = append(stat.samples, shareSample{time: now, work: work})
+	stat.expire(now)
+}
+
+func (tracker *workerStatsTracker) addBlock(workerId WorkerId) {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	if stat := tracker.stats[workerId]; stat != nil {
+		stat.blocks++
+	}
+}
+
+// list returns the statistics of all the connected workers, sorted by the worker id
+func (tracker *workerStatsTracker) list(now time.Time) []WorkerStats {
+	tracker.lock.Lock()
+	defer tracker.lock.Unlock()
+
+	result := make([]WorkerStats, 0, len(tracker.stats))
+	for id, stat := range tracker.stats {
+		stat.expire(now)
+		stats := WorkerStats{
+			Id:          id,
+			Address:     stat.worker.CurrentCoinbaseAddress(),
+			Shares:      stat.shares,
+			Blocks:      stat.blocks,
+			Hashrate:    stat.hashrate(now),
+			ConnectedAt: uint64(stat.connectedAt.Unix()),
+		}
+		if !stat.lastShare.IsZero() {
+			stats.LastShare = uint64(stat.lastShare.Unix())
+		}
+		result = append(result, stats)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Id < result[j].Id
+	})
+	return result
+}
+
+// drop the samples out of the hashrate window
+func (stat *workerStat) expire(now time.Time) {
+	i := 0
+	for ; i < len(stat.samples); i++ {
+		if now.Sub(stat.samples[i].time) <= hashrateWindow {
+			break
+		}
+	}
+	stat.samples = stat.samples[i:]
+}
+
+// the work in the window divided by the window, a worker connected recently is divided by the connected duration
+func (stat *workerStat) hashrate(now time.Time) *big.Int {
+	total := big.NewInt(0)
+	for _, sample := range stat.samples {
+		total.Add(total, sample.work)
+	}
+
+	duration := now.Sub(stat.connectedAt)
+	if duration > hashrateWindow {
+		duration = hashrateWindow
+	}
+	seconds := int64(duration / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	return total.Div(total, big.NewInt(seconds))
+}
@@ -0,0 +1,64 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemaster
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+	"time"
+)
+
+func TestWorkerStatsTracker(t *testing.T) {
+	tracker := newWorkerStatsTracker()
+	worker := &mockWorker{workerId: "1", coinbase: common.HexToAddress("0x1234")}
+	tracker.add(worker)
+	assert.True(t, tracker.has("1"))
+	assert.False(t, tracker.has("2"))
+
+	start := tracker.stats["1"].connectedAt
+	// the shares of unknown workers are ignored
+	tracker.addShare("2", big.NewInt(100), start)
+	tracker.addBlock("2")
+
+	// connected for 10 seconds
+	tracker.addShare("1", big.NewInt(600000), start.Add(5*time.Second))
+	tracker.addShare("1", big.NewInt(400000), start.Add(10*time.Second))
+	tracker.addBlock("1")
+	stats := tracker.list(start.Add(10 * time.Second))
+	assert.Len(t, stats, 1)
+	assert.Equal(t, worker.coinbase, stats[0].Address)
+	assert.Equal(t, uint64(2), stats[0].Shares)
+	assert.Equal(t, uint64(1), stats[0].Blocks)
+	assert.Equal(t, big.NewInt(100000), stats[0].Hashrate)
+	assert.Equal(t, uint64(start.Add(10*time.Second).Unix()), stats[0].LastShare)
+
+	// the first share is out of the window, the work is divided by the whole window
+	now := start.Add(hashrateWindow + 6*time.Second)
+	stats = tracker.list(now)
+	assert.Equal(t, uint64(2), stats[0].Shares)
+	assert.Equal(t, big.NewInt(400000/int64(hashrateWindow/time.Second)), stats[0].Hashrate)
+	assert.Len(t, tracker.stats["1"].samples, 1)
+
+	// registering again keeps the statistics
+	tracker.add(worker)
+	assert.Equal(t, uint64(2), tracker.list(now)[0].Shares)
+
+	tracker.remove("1")
+	assert.Len(t, tracker.list(now), 0)
+}
@@ -28,6 +28,7 @@ const (
 	// work msg(one dispatch, one submit)
 	NewDefaultWorkMsg = 0x10
 	SubmitDefaultWorkMsg = 0x11
+	SubmitShareMsg = 0x12
 
 	// msg sent to master by worker
 	RegisterMsg = 0x50
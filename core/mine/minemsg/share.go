@@ -0,0 +1,52 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemsg
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"math/big"
+)
+
+// ShareDifficultyFactor means the minimum share difficulty is 256 times easier than the block difficulty,
+// so the workers which can't find a block in a long time could still prove their work by the shares.
+const ShareDifficultyFactor = 256
+
+// the easiest share difficulty, the target of it can be converted back to the difficulty
+var maxShareDifficulty = common.HexToDiff("0x207fffff")
+
+// ShareWork is submitted by the worker once the hash of the work reaches the share difficulty
+type ShareWork struct {
+	Work      DefaultWork
+	ShareDiff common.Difficulty
+}
+
+// ShareDifficulty returns the minimum share difficulty of the block difficulty
+func ShareDifficulty(blockDiff common.Difficulty) common.Difficulty {
+	if !ValidDifficulty(blockDiff) {
+		return blockDiff
+	}
+	target := new(big.Int).Mul(blockDiff.Big(), big.NewInt(ShareDifficultyFactor))
+	if target.Cmp(maxShareDifficulty.Big()) >= 0 {
+		return maxShareDifficulty
+	}
+	return common.BigToDiff(target)
+}
+
+// ValidDifficulty check whether the difficulty received from the others can be converted to the target
+func ValidDifficulty(diff common.Difficulty) bool {
+	return diff[0] >= 3 && diff[0] <= common.HashLength
+}
@@ -0,0 +1,38 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package minemsg
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestShareDifficulty(t *testing.T) {
+	blockDiff := common.HexToDiff("0x1d00ffff")
+	shareDiff := ShareDifficulty(blockDiff)
+	assert.True(t, ValidDifficulty(shareDiff))
+	assert.Equal(t, new(big.Int).Mul(blockDiff.Big(), big.NewInt(ShareDifficultyFactor)), shareDiff.Big())
+
+	// the share difficulty can't be easier than the max share difficulty
+	assert.Equal(t, maxShareDifficulty, ShareDifficulty(common.HexToDiff("0x1fffffff")))
+	assert.Equal(t, maxShareDifficulty, ShareDifficulty(common.HexToDiff("0x20ffffff")))
+
+	assert.False(t, ValidDifficulty(common.Difficulty{}))
+	assert.False(t, ValidDifficulty(common.HexToDiff("0x21ffffff")))
+}
@@ -45,6 +45,8 @@ type defaultMiner struct {
 	lock    sync.Mutex
 
 	mineStartAt time.Time
+	// count the hashes if it isn't nil
+	meter *hashrateMeter
 }
 
 func (miner *defaultMiner) receiveWork(work workExecutor) {
@@ -110,8 +112,15 @@ func (miner *defaultMiner) doMine() {
 	if miner.curWork == nil {
 		miner.waitNewWork()
 	}
+	if miner.curWork == nil {
+		return
+	}
+	found := miner.curWork.ChangeNonce()
+	if miner.meter != nil {
+		miner.meter.mark()
+	}
 	// Submit if it is discovered, and wait for a new task
-	if miner.curWork != nil && miner.curWork.ChangeNonce() {
+	if found {
 		log.Info("miner found nonce", "use time", time.Now().Sub(miner.mineStartAt))
 
 		miner.curWork.Submit()
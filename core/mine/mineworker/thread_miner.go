@@ -0,0 +1,161 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mineworker
+
+import (
+	"github.com/dipperin/dipperin-core/common/g-metrics"
+	"github.com/dipperin/dipperin-core/common/util"
+	"github.com/dipperin/dipperin-core/third-party/log"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const hashrateReportInterval = 5 * time.Second
+
+func newHashrateMeter() *hashrateMeter {
+	return &hashrateMeter{lastReport: time.Now()}
+}
+
+// hashrateMeter counts the hashes calculated by the miner threads
+type hashrateMeter struct {
+	hashes uint64
+
+	lock       sync.Mutex
+	lastReport time.Time
+	hashrate   float64
+}
+
+func (meter *hashrateMeter) mark() {
+	atomic.AddUint64(&meter.hashes, 1)
+}
+
+// report calculates the hashrate since the last report and sets it to g-metrics
+func (meter *hashrateMeter) report(now time.Time) float64 {
+	meter.lock.Lock()
+	defer meter.lock.Unlock()
+
+	hashes := atomic.SwapUint64(&meter.hashes, 0)
+	if elapsed := now.Sub(meter.lastReport); elapsed > 0 {
+		meter.hashrate = float64(hashes) / elapsed.Seconds()
+	}
+	meter.lastReport = now
+	g_metrics.Set(g_metrics.LocalMinerHashrateGauge, "", meter.hashrate)
+	return meter.hashrate
+}
+
+func (meter *hashrateMeter) reset() {
+	meter.lock.Lock()
+	defer meter.lock.Unlock()
+
+	atomic.StoreUint64(&meter.hashes, 0)
+	meter.hashrate = 0
+	meter.lastReport = time.Now()
+	g_metrics.Set(g_metrics.LocalMinerHashrateGauge, "", 0)
+}
+
+func (meter *hashrateMeter) Hashrate() float64 {
+	meter.lock.Lock()
+	defer meter.lock.Unlock()
+
+	return meter.hashrate
+}
+
+// the miner runs several hashing threads for one work, every thread searches a disjoint nonce range
+func newThreadMiner(threadCount int) *threadMiner {
+	m := &threadMiner{meter: newHashrateMeter()}
+	for i := 0; i < threadCount; i++ {
+		thread := NewMiner()
+		thread.meter = m.meter
+		m.threads = append(m.threads, thread)
+	}
+	return m
+}
+
+type threadMiner struct {
+	threads []*defaultMiner
+	meter   *hashrateMeter
+
+	stopChan chan struct{}
+	lock     sync.Mutex
+}
+
+func (miner *threadMiner) startMine() {
+	miner.lock.Lock()
+	defer miner.lock.Unlock()
+
+	for _, thread := range miner.threads {
+		thread.startMine()
+	}
+
+	if !util.StopChanClosed(miner.stopChan) {
+		log.Info("call start thread miner, but miner already started")
+		return
+	}
+	miner.stopChan = make(chan struct{})
+	miner.meter.reset()
+	go miner.reportLoop(miner.stopChan)
+}
+
+func (miner *threadMiner) stopMine() {
+	miner.lock.Lock()
+	defer miner.lock.Unlock()
+
+	if !util.StopChanClosed(miner.stopChan) {
+		close(miner.stopChan)
+	}
+	for _, thread := range miner.threads {
+		thread.stopMine()
+	}
+	miner.meter.reset()
+}
+
+// receiveWork splits the work to the threads, only the first thread mines the work if it can't be split
+func (miner *threadMiner) receiveWork(work workExecutor) {
+	works := []workExecutor{work}
+	if splittable, ok := work.(splittableExecutor); ok && len(miner.threads) > 1 {
+		works = splittable.SplitNonce(len(miner.threads))
+	}
+
+	var wg sync.WaitGroup
+	for i, w := range works {
+		wg.Add(1)
+		go func(thread *defaultMiner, w workExecutor) {
+			defer wg.Done()
+			thread.receiveWork(w)
+		}(miner.threads[i], w)
+	}
+	wg.Wait()
+}
+
+func (miner *threadMiner) Hashrate() float64 {
+	return miner.meter.Hashrate()
+}
+
+func (miner *threadMiner) reportLoop(stopChan chan struct{}) {
+	ticker := time.NewTicker(hashrateReportInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case now := <-ticker.C:
+			log.Debug("local miner hashrate", "threads", len(miner.threads), "hashrate", miner.meter.report(now))
+		case <-stopChan:
+			return
+		}
+	}
+}
@@ -0,0 +1,114 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package mineworker
+
+import (
+	"encoding/binary"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/mine/minemsg"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/dipperin/dipperin-core/tests/factory"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+type fakeSplitWork struct {
+	fakeWork
+	works []*fakeWork
+}
+
+func (fw *fakeSplitWork) SplitNonce(count int) (result []workExecutor) {
+	for i := 0; i < count; i++ {
+		w := &fakeWork{id: i, submitWorkChan: make(chan int, 1)}
+		fw.works = append(fw.works, w)
+		result = append(result, w)
+	}
+	return
+}
+
+func TestDefaultWorkExecutor_SplitNonce(t *testing.T) {
+	block := factory.CreateBlock2(common.HexToDiff("0x1effffff"), 1)
+	work := &minemsg.DefaultWork{BlockHeader: *(block.Header().(*model.Header))}
+	work.CalBlockRlpWithoutNonce()
+	executor := NewDefaultWorkExecutor(work, &fakeWorkSubmitter{})
+
+	executors := executor.SplitNonce(3)
+	assert.Len(t, executors, 3)
+	for i, ex := range executors {
+		threadEx := ex.(*defaultWorkExecutor)
+		assert.Equal(t, work.RlpPreCal, threadEx.curWork.RlpPreCal)
+		assert.True(t, &work.RlpPreCal[0] != &threadEx.curWork.RlpPreCal[0])
+
+		// the nonce can't be changed into the range of the other threads
+		for j := range threadEx.nonceSuffix[2:] {
+			threadEx.nonceSuffix[2+j] = 255
+		}
+		threadEx.ChangeNonce()
+		assert.Equal(t, uint16(i), binary.BigEndian.Uint16(threadEx.curWork.BlockHeader.Nonce[8:10]))
+		assert.Equal(t, make([]byte, common.NonceLength-10), threadEx.curWork.BlockHeader.Nonce[10:])
+	}
+	// the origin work is not changed
+	assert.Equal(t, common.BlockNonce{}, work.BlockHeader.Nonce)
+}
+
+func TestHashrateMeter(t *testing.T) {
+	meter := newHashrateMeter()
+	for i := 0; i < 100; i++ {
+		meter.mark()
+	}
+	assert.Equal(t, float64(50), meter.report(meter.lastReport.Add(2*time.Second)))
+	assert.Equal(t, float64(50), meter.Hashrate())
+
+	assert.Equal(t, float64(0), meter.report(meter.lastReport.Add(time.Second)))
+	meter.mark()
+	meter.reset()
+	assert.Equal(t, float64(0), meter.Hashrate())
+	assert.Equal(t, uint64(0), meter.hashes)
+}
+
+func TestThreadMiner(t *testing.T) {
+	m := newThreadMiner(3)
+	m.startMine()
+	time.Sleep(100 * time.Millisecond)
+
+	// every thread mines a split work
+	work := &fakeSplitWork{}
+	m.receiveWork(work)
+	assert.Len(t, work.works, 3)
+	for _, w := range work.works {
+		w.waitForSubmit(t)
+	}
+	assert.Equal(t, uint64(33), m.meter.hashes)
+
+	// only the first thread mines the work which can't be split
+	fw := &fakeWork{id: 10, submitWorkChan: make(chan int, 1)}
+	m.receiveWork(fw)
+	fw.waitForSubmit(t)
+
+	m.stopMine()
+	assert.Equal(t, float64(0), m.Hashrate())
+	time.Sleep(100 * time.Millisecond)
+
+	// restart after stop
+	m.startMine()
+	time.Sleep(100 * time.Millisecond)
+	fw = &fakeWork{id: 11, submitWorkChan: make(chan int, 1)}
+	m.receiveWork(fw)
+	fw.waitForSubmit(t)
+	m.stopMine()
+}
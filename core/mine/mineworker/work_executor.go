@@ -18,6 +18,7 @@
 package mineworker
 
 import (
+	"encoding/binary"
 	"github.com/dipperin/dipperin-core/common"
 	"github.com/dipperin/dipperin-core/core/mine/minemsg"
 	"github.com/dipperin/dipperin-core/third-party/log"
@@ -28,6 +29,7 @@ func NewDefaultWorkExecutor(work *minemsg.DefaultWork, submitter workSubmitter)
 	ex := &defaultWorkExecutor{
 		curWork:   work,
 		submitter: submitter,
+		shareDiff: minemsg.ShareDifficulty(work.BlockHeader.Diff),
 		//nonceSuffix:big.NewInt(0),
 	}
 	return ex
@@ -36,24 +38,51 @@ func NewDefaultWorkExecutor(work *minemsg.DefaultWork, submitter workSubmitter)
 
 type workSubmitter interface {
 	SubmitWork(work minemsg.Work)
+	SubmitShare(share *minemsg.ShareWork)
 }
 
 type defaultWorkExecutor struct {
 	curWork   *minemsg.DefaultWork
 	submitter workSubmitter
+	// the hashes reach this difficulty are submitted as shares
+	shareDiff common.Difficulty
 
 	// The first 8 bytes are allocated by the fragment server and the miner server, and cannot be changed.
 	// The latter field is freely played by the miners.
 
 	nonceSuffix [common.NonceLength - 8]byte
 	//nonceSuffix *big.Int
+
+	// the first bytes of the nonce suffix are assigned by the thread miner and can't be changed by ChangeNonce
+	fixedSuffixLen int
+}
+
+// the executor which can be split into the executors searching the disjoint nonce ranges
+type splittableExecutor interface {
+	workExecutor
+	SplitNonce(count int) []workExecutor
+}
+
+// SplitNonce splits the work into count executors, the first 2 bytes of the nonce suffix are the index of the executor
+func (executor *defaultWorkExecutor) SplitNonce(count int) (result []workExecutor) {
+	for i := 0; i < count; i++ {
+		work := *executor.curWork
+		// every executor appends the nonce to its own rlp when calculating the hash
+		work.RlpPreCal = append([]byte(nil), executor.curWork.RlpPreCal...)
+
+		ex := NewDefaultWorkExecutor(&work, executor.submitter)
+		binary.BigEndian.PutUint16(ex.nonceSuffix[:2], uint16(i))
+		ex.fixedSuffixLen = 2
+		result = append(result, ex)
+	}
+	return
 }
 
 //
 func (executor *defaultWorkExecutor) ChangeNonce() bool {
 	//todo use byte operation to optimal this nonce change step
 	// Nonce increments and verify the validity
-	for index := len(executor.nonceSuffix) - 1; index >= 0; {
+	for index := len(executor.nonceSuffix) - 1; index >= executor.fixedSuffixLen; {
 		if executor.nonceSuffix[index] < 255 {
 			executor.nonceSuffix[index]++
 			break
@@ -76,6 +105,13 @@ func (executor *defaultWorkExecutor) ChangeNonce() bool {
 	//new hash calculation replacing upstairs
 	bHash, err := executor.curWork.CalHash()
 	if err == nil {
+		if bHash.ValidHashForDifficulty(executor.shareDiff) {
+			share := &minemsg.ShareWork{Work: *executor.curWork, ShareDiff: executor.shareDiff}
+			share.Work.ResultNonce = executor.curWork.BlockHeader.Nonce
+			// the master verifies the share by its own work block
+			share.Work.RlpPreCal = nil
+			executor.submitter.SubmitShare(share)
+		}
 		if bHash.ValidHashForDifficulty(executor.curWork.BlockHeader.Diff) {
 			// some thing interesting here
 			executor.curWork.ResultNonce = executor.curWork.BlockHeader.Nonce
@@ -35,6 +35,9 @@ type fakeWorkSubmitter struct {
 func (submitter *fakeWorkSubmitter) SubmitWork(work minemsg.Work) {
 }
 
+func (submitter *fakeWorkSubmitter) SubmitShare(share *minemsg.ShareWork) {
+}
+
 func TestDefaultWorkExecutor_ChangeNonce(t *testing.T) {
 	diff := common.HexToDiff("0x1effffff")
 	block := factory.CreateBlock2(diff,1)
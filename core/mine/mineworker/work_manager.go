@@ -80,3 +80,10 @@ func (workManager *workManager) SubmitWork(work minemsg.Work) {
 		log.Warn("submit work failed", "err", err)
 	}
 }
+
+func (workManager *workManager) SubmitShare(share *minemsg.ShareWork) {
+	share.Work.SetWorkerCoinbaseAddress(workManager.getCoinbaseAddressFunc())
+	if err := workManager.msgSender.SendMsg(minemsg.SubmitShareMsg, share); err != nil {
+		log.Warn("submit share failed", "err", err)
+	}
+}
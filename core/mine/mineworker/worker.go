@@ -24,11 +24,16 @@ import (
 )
 
 func newWorker(coinbaseAddr common.Address, workerCount int, connector connector) *worker {
-	worker := &worker{connector: connector}
-	worker.SetCoinbaseAddress(coinbaseAddr)
+	var miners []miner
 	for i := 0; i < workerCount; i++ {
-		worker.miners = append(worker.miners, NewMiner())
+		miners = append(miners, NewMiner())
 	}
+	return newWorkerWithMiners(coinbaseAddr, miners, connector)
+}
+
+func newWorkerWithMiners(coinbaseAddr common.Address, miners []miner, connector connector) *worker {
+	worker := &worker{connector: connector, miners: miners}
+	worker.SetCoinbaseAddress(coinbaseAddr)
 	return worker
 }
 
@@ -41,6 +41,29 @@ func MakeLocalWorker(coinbaseAddr common.Address, workerCount int, master minema
 	return w
 }
 
+// make a local worker which runs threadCount hashing threads for every work
+func MakeLocalThreadWorker(coinbaseAddr common.Address, threadCount int, master minemaster.MasterServer) Worker {
+	if threadCount < 1 {
+		threadCount = 1
+	}
+
+	// init conn
+	conn := newLocalConnector("local", master)
+
+	// init worker with one thread miner
+	w := newWorkerWithMiners(coinbaseAddr, []miner{newThreadMiner(threadCount)}, conn)
+
+	// init work manager
+	manager := newWorkManager(conn, w.Miners, w.CurrentCoinbaseAddress)
+
+	// set conn msg send to work manager
+	conn.receiver = manager
+	// set worker for receive stop msg
+	conn.worker = w
+
+	return w
+}
+
 // make a remote worker
 func MakeRemoteWorker(coinbaseAddr common.Address, workerCount int) (Worker, *RemoteConnector) {
 	// init conn
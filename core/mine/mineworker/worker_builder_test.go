@@ -30,6 +30,17 @@ func TestMakeLocalWorker(t *testing.T) {
 	assert.NotNil(t, w)
 }
 
+func TestMakeLocalThreadWorker(t *testing.T) {
+	ms := mine_spec.MasterServerBuilder()
+	w := MakeLocalThreadWorker(common.HexToAddress("0x123"), 4, ms)
+	assert.NotNil(t, w)
+	assert.Len(t, w.(*worker).Miners(), 1)
+	assert.Len(t, w.(*worker).Miners()[0].(*threadMiner).threads, 4)
+
+	w = MakeLocalThreadWorker(common.HexToAddress("0x123"), 0, ms)
+	assert.Len(t, w.(*worker).Miners()[0].(*threadMiner).threads, 1)
+}
+
 func TestMakeRemoteWorker(t *testing.T) {
 	w,c := MakeRemoteWorker(common.HexToAddress("0x123"), 1)
 	assert.NotNil(t, w)
@@ -29,8 +29,13 @@ import (
 	"github.com/dipperin/dipperin-core/third-party/crypto"
 	"github.com/dipperin/dipperin-core/core/chain"
 	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
+	"github.com/dipperin/dipperin-core/core/chain-config"
+	"github.com/ethereum/go-ethereum/rlp"
 )
 
+// the margin for the growth of the tx list prefix in the encoded block
+const blockSizeMargin = 16
+
 // context must have chainReader state_processor.ChainReader, stateProcessorBuilder stateProcessorBuilder, accountStorage state_processor.StateStorage, txPool txPool
 func MakeBftBlockBuilder(config ModelConfig) *BftBlockBuilder {
 	return &BftBlockBuilder{
@@ -53,7 +58,7 @@ func (builder *BftBlockBuilder) commitTransaction(tx model.AbstractTransaction,
 	return nil
 }
 
-func (builder *BftBlockBuilder) commitTransactions(txs *model.TransactionsByFeeAndNonce, state *chain.BlockProcessor, header *model.Header, vers []model.AbstractVerification) (txBuf []model.AbstractTransaction) {
+func (builder *BftBlockBuilder) commitTransactions(txs TxIterator, state *chain.BlockProcessor, header *model.Header, vers []model.AbstractVerification, limits chain_config.BlockLimits, blockSize int) (txBuf []model.AbstractTransaction) {
 	var invalidList []*model.Transaction
 	for {
 		// Retrieve the next transaction and abort if all done
@@ -61,6 +66,16 @@ func (builder *BftBlockBuilder) commitTransactions(txs *model.TransactionsByFeeA
 		if tx == nil {
 			break
 		}
+		if limits.MaxTxCount > 0 && len(txBuf) >= limits.MaxTxCount {
+			log.Info("the block reaches the max tx count", "count", len(txBuf))
+			break
+		}
+		// the txs of the sender after an oversize one can't be packed either
+		txSize := int(tx.Size())
+		if txSize > limits.MaxTxSize || blockSize+txSize > limits.MaxBlockSize {
+			txs.Pop()
+			continue
+		}
 		//from, _ := tx.Sender(builder.nodeContext.TxSigner())
 		err := builder.commitTransaction(tx, state, header.Number)
 		if err != nil {
@@ -69,6 +84,7 @@ func (builder *BftBlockBuilder) commitTransactions(txs *model.TransactionsByFeeA
 			invalidList = append(invalidList, tx.(*model.Transaction))
 		} else {
 			txBuf = append(txBuf, tx)
+			blockSize += txSize
 			txs.Shift()
 		}
 	}
@@ -84,6 +100,25 @@ func (builder *BftBlockBuilder) commitTransactions(txs *model.TransactionsByFeeA
 	return
 }
 
+// blockSizeWithoutTxs returns the encoded size of the block without any tx,
+// the interlinks only depend on the header so they are the same after packing the txs
+func blockSizeWithoutTxs(header *model.Header, vers []model.AbstractVerification, preLinks model.InterLink) (int, error) {
+	block := model.NewBlockWithLink(header, nil, vers, preLinks)
+	bb, err := rlp.EncodeToBytes(block)
+	if err != nil {
+		return 0, err
+	}
+	return len(bb) + blockSizeMargin, nil
+}
+
+// selectTransactions orders the pending txs by the selection policy of the node
+func (builder *BftBlockBuilder) selectTransactions(pending map[common.Address][]model.AbstractTransaction) TxIterator {
+	if builder.TxSelection == nil {
+		return model.NewTransactionsByFeeAndNonce(builder.TxSigner, pending)
+	}
+	return builder.TxSelection.Select(builder.TxSigner, pending)
+}
+
 //build the wait-pack block
 func (builder *BftBlockBuilder) BuildWaitPackBlock(coinbaseAddr common.Address) model.AbstractBlock {
 	if coinbaseAddr.IsEmpty() {
@@ -120,6 +155,14 @@ func (builder *BftBlockBuilder) BuildWaitPackBlock(coinbaseAddr common.Address)
 		Bloom: iblt.NewBloom(model.DefaultBlockBloomConfig),
 	}
 
+	// the timestamp must be after the median time past
+	if builder.ChainConfig.IsMedianTime(header.Number) {
+		recentBlocks := model.GetRecentNormalBlocks(builder.ChainReader, builder.ChainReader.GetLatestNormalBlock(), builder.ChainConfig.MedianTimeBlocks)
+		if medianTime := model.CalMedianTimePast(recentBlocks); header.TimeStamp.Cmp(medianTime) <= 0 {
+			header.TimeStamp = medianTime.Add(medianTime, big.NewInt(1))
+		}
+	}
+
 	// set pre block verifications
 	vers := builder.ChainReader.GetSeenCommit(curHeight)
 	for index, v := range vers {
@@ -138,9 +181,15 @@ func (builder *BftBlockBuilder) BuildWaitPackBlock(coinbaseAddr common.Address)
 	processor, err := builder.ChainReader.BlockProcessor(curBlock.StateRoot())
 	//processor, err := builder.BuildStateProcessor.BuildStateProcessor(curBlock.StateRoot(), builder.ChainReader, builder.StateStorage)
 
+	blockSize, err := blockSizeWithoutTxs(header, vers, curBlock.GetInterlinks())
+	if err != nil {
+		log.Error("can't get the block size without txs", "err", err)
+		return nil
+	}
+
 	log.Info("~~~~~~~~~~~~~~~the pending len is:", "number", len(pending))
-	txs := model.NewTransactionsByFeeAndNonce(builder.TxSigner, pending)
-	txBuf := builder.commitTransactions(txs, processor, header, vers)
+	limits := builder.ChainConfig.GetBlockLimits(header.Number)
+	txBuf := builder.commitTransactions(builder.selectTransactions(pending), processor, header, vers, limits, blockSize)
 
 	//log.Info("~~~~~~~~~~~~~~ the txBuf len is: ", "txBuf Len", len(txBuf))
 
@@ -255,6 +304,13 @@ func (builder *BftBlockBuilder) GetDifficulty() common.Difficulty {
 	chainReader := builder.ChainReader
 
 	curBlock := chainReader.CurrentBlock()
+	if builder.ChainConfig.IsPerBlockDiff(curBlock.Number() + 1) {
+		recentBlocks := model.GetRecentNormalBlocks(chainReader, chainReader.GetLatestNormalBlock(), builder.ChainConfig.PerBlockDiffWindow+1)
+		diff := model.CalWeightedWorkDiff(recentBlocks)
+		log.Debug("mine master per block difficulty", "diff", diff.Hex())
+		return diff
+	}
+
 	lastPNum := model.LastPeriodBlockNum(curBlock.Number())
 	if lastPNum == 0 {
 		lastPNum = 1
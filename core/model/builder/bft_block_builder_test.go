@@ -32,6 +32,7 @@ import (
 	"github.com/dipperin/dipperin-core/tests"
 	"github.com/dipperin/dipperin-core/third-party/crypto"
 	"github.com/ethereum/go-ethereum/ethdb"
+	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"math/big"
@@ -222,3 +223,21 @@ func (f *fakeChainReader) BuildRegisterProcessor(preRoot common.Hash) (*register
 func (f *fakeChainReader) GetEconomyModel() economy_model.EconomyModel {
 	panic("implement me")
 }
+
+func Test_blockSizeWithoutTxs(t *testing.T) {
+	header := model.NewHeader(1, 2, common.HexToHash("0x1"), common.HexToHash("0x2"), common.HexToDiff("0x1fffffff"), big.NewInt(1), common.HexToAddress("0x3"), common.BlockNonce{})
+	preLinks := model.InterLink{common.HexToHash("0x4"), common.HexToHash("0x5")}
+	size, err := blockSizeWithoutTxs(header, nil, preLinks)
+	assert.NoError(t, err)
+
+	var txs []*model.Transaction
+	txsSize := 0
+	for i := 0; i < 3; i++ {
+		tx := model.NewTransaction(uint64(i), common.HexToAddress("0x6"), big.NewInt(1), testFee, make([]byte, 100))
+		txs = append(txs, tx)
+		txsSize += int(tx.Size())
+	}
+	bb, err := rlp.EncodeToBytes(model.NewBlockWithLink(header, txs, nil, preLinks))
+	assert.NoError(t, err)
+	assert.True(t, len(bb) <= size+txsSize)
+}
@@ -64,6 +64,8 @@ type ModelConfig struct {
 	TxSigner           model.Signer
 	MsgSigner          chain_communication.PbftSigner
 	ChainConfig        chain_config.ChainConfig
+	// the pending txs are packed by fee and nonce if it is nil
+	TxSelection TxSelectionPolicy
 }
 
 //go:generate mockgen -destination=./signer_mock_test.go -package=builder github.com/caiqingfeng/dipperin-core/core/model Signer
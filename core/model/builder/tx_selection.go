@@ -0,0 +1,244 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package builder
+
+import (
+	"container/heap"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/model"
+	"math/big"
+)
+
+// TxOrder is the order in which the pending txs are tried to be packed
+type TxOrder string
+
+const (
+	TxOrderByFee        TxOrder = "fee"
+	TxOrderByFeePerByte TxOrder = "fee_per_byte"
+)
+
+func TxOrderFromString(order string) TxOrder {
+	switch TxOrder(order) {
+	case TxOrderByFeePerByte:
+		return TxOrderByFeePerByte
+	default:
+		return TxOrderByFee
+	}
+}
+
+// the txs of the verifiers, a share of the block space could be reserved for them,
+// so they are never starved by the txs paying higher fees. They are packed first only if some space is reserved
+var reservedTxTypes = map[common.TxType]bool{
+	common.AddressTypeStake:    true,
+	common.AddressTypeCancel:   true,
+	common.AddressTypeUnStake:  true,
+	common.AddressTypeEvidence: true,
+}
+
+// TxIterator iterates the pending txs in a nonce-honouring order, *model.TransactionsByFeeAndNonce is one of them
+type TxIterator interface {
+	// the next tx to pack, nil if there is no tx left or the block is full
+	Peek() model.AbstractTransaction
+	// the peeked tx is packed, move to the next tx of the same sender
+	Shift()
+	// the peeked tx can't be packed, the rest txs of the same sender are skipped
+	Pop()
+}
+
+// TxSelectionPolicy decides which pending txs are packed into a new block and in what order
+type TxSelectionPolicy interface {
+	Select(signer model.Signer, pending map[common.Address][]model.AbstractTransaction) TxIterator
+}
+
+// TxSelectionConfig configures the built-in selection policy, the zero value packs the txs like
+// model.TransactionsByFeeAndNonce
+type TxSelectionConfig struct {
+	Order TxOrder
+
+	// the txs of these senders are packed before the others
+	PrioritySenders []common.Address
+	// the txs of the local senders are packed before the others if it is set
+	IsLocal func(address common.Address) bool
+
+	// the max tx count and the max total tx size of a block, 0 is unlimited
+	MaxTxCount int
+	MaxTxSize  common.StorageSize
+	// the percentage of MaxTxCount and MaxTxSize which only the stake, cancel, unstake and evidence txs could use
+	ReservedPercent int
+}
+
+// NewTxSelectionPolicy makes the built-in selection policy by the config
+func NewTxSelectionPolicy(config TxSelectionConfig) TxSelectionPolicy {
+	policy := &defaultTxSelectionPolicy{
+		TxSelectionConfig: config,
+		prioritySenders:   make(map[common.Address]bool, len(config.PrioritySenders)),
+	}
+	for _, address := range config.PrioritySenders {
+		policy.prioritySenders[address] = true
+	}
+	if policy.ReservedPercent < 0 {
+		policy.ReservedPercent = 0
+	}
+	if policy.ReservedPercent > 100 {
+		policy.ReservedPercent = 100
+	}
+	return policy
+}
+
+type defaultTxSelectionPolicy struct {
+	TxSelectionConfig
+	prioritySenders map[common.Address]bool
+}
+
+// the txs of a smaller class are packed first
+const (
+	reservedTxClass = iota
+	priorityTxClass
+	normalTxClass
+)
+
+func (policy *defaultTxSelectionPolicy) Select(signer model.Signer, pending map[common.Address][]model.AbstractTransaction) TxIterator {
+	selection := &txSelection{
+		policy: policy,
+		txs:    make(map[common.Address][]model.AbstractTransaction, len(pending)),
+	}
+	for from, accTxs := range pending {
+		if len(accTxs) == 0 {
+			continue
+		}
+		selection.heads = append(selection.heads, policy.newHead(from, accTxs[0]))
+		selection.txs[from] = accTxs[1:]
+	}
+	heap.Init(&selection.heads)
+	return selection
+}
+
+func (policy *defaultTxSelectionPolicy) newHead(from common.Address, tx model.AbstractTransaction) *txHead {
+	class := normalTxClass
+	if policy.ReservedPercent > 0 && reservedTxTypes[tx.GetType()] {
+		class = reservedTxClass
+	} else if policy.prioritySenders[from] || (policy.IsLocal != nil && policy.IsLocal(from)) {
+		class = priorityTxClass
+	}
+	return &txHead{from: from, tx: tx, class: class, byFeePerByte: policy.Order == TxOrderByFeePerByte}
+}
+
+// fits checks whether a tx could be added after the packed txs, the reserved space is excluded if withReserved is false
+func (policy *defaultTxSelectionPolicy) fits(count, size, txSize int64, withReserved bool) bool {
+	limit := func(l int64) int64 {
+		if withReserved {
+			return l
+		}
+		return l * int64(100-policy.ReservedPercent) / 100
+	}
+
+	if policy.MaxTxCount > 0 && count+1 > limit(int64(policy.MaxTxCount)) {
+		return false
+	}
+	if policy.MaxTxSize > 0 && size+txSize > limit(int64(policy.MaxTxSize)) {
+		return false
+	}
+	return true
+}
+
+type txHead struct {
+	from         common.Address
+	tx           model.AbstractTransaction
+	class        int
+	byFeePerByte bool
+}
+
+// higher returns true if the head should be packed before the other
+func (head *txHead) higher(other *txHead) bool {
+	if head.class != other.class {
+		return head.class < other.class
+	}
+	if !head.byFeePerByte {
+		return head.tx.Fee().Cmp(other.tx.Fee()) > 0
+	}
+	// fee1 / size1 > fee2 / size2
+	fee1 := new(big.Int).Mul(head.tx.Fee(), big.NewInt(int64(other.tx.Size())))
+	fee2 := new(big.Int).Mul(other.tx.Fee(), big.NewInt(int64(head.tx.Size())))
+	return fee1.Cmp(fee2) > 0
+}
+
+type txHeads []*txHead
+
+func (s txHeads) Len() int            { return len(s) }
+func (s txHeads) Less(i, j int) bool  { return s[i].higher(s[j]) }
+func (s txHeads) Swap(i, j int)       { s[i], s[j] = s[j], s[i] }
+func (s *txHeads) Push(x interface{}) { *s = append(*s, x.(*txHead)) }
+func (s *txHeads) Pop() interface{} {
+	old := *s
+	n := len(old)
+	x := old[n-1]
+	*s = old[0 : n-1]
+	return x
+}
+
+// txSelection packs the txs by the order and the limits of the policy
+type txSelection struct {
+	policy *defaultTxSelectionPolicy
+	txs    map[common.Address][]model.AbstractTransaction
+	heads  txHeads
+
+	count, normalCount int64
+	size, normalSize   int64
+}
+
+func (selection *txSelection) Peek() model.AbstractTransaction {
+	for len(selection.heads) > 0 {
+		tx := selection.heads[0].tx
+		if selection.fits(tx) {
+			return tx
+		}
+		// the following txs of the sender can't be packed without this one
+		heap.Pop(&selection.heads)
+	}
+	return nil
+}
+
+func (selection *txSelection) Shift() {
+	head := selection.heads[0]
+	selection.count++
+	selection.size += int64(head.tx.Size())
+	if !reservedTxTypes[head.tx.GetType()] {
+		selection.normalCount++
+		selection.normalSize += int64(head.tx.Size())
+	}
+
+	if txs := selection.txs[head.from]; len(txs) > 0 {
+		selection.heads[0], selection.txs[head.from] = selection.policy.newHead(head.from, txs[0]), txs[1:]
+		heap.Fix(&selection.heads, 0)
+	} else {
+		heap.Pop(&selection.heads)
+	}
+}
+
+func (selection *txSelection) Pop() {
+	heap.Pop(&selection.heads)
+}
+
+func (selection *txSelection) fits(tx model.AbstractTransaction) bool {
+	policy := selection.policy
+	txSize := int64(tx.Size())
+	if !policy.fits(selection.count, selection.size, txSize, true) {
+		return false
+	}
+	// the normal txs can't use the reserved space
+	return reservedTxTypes[tx.GetType()] || policy.fits(selection.normalCount, selection.normalSize, txSize, false)
+}
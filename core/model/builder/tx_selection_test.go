@@ -0,0 +1,165 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package builder
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+var (
+	selectionNormalTo = common.HexToAddress("0x00001234567890123456789012345678901234567890")
+	selectionStakeTo  = common.HexToAddress("0x00020000000000000000000000000000000000000000")
+)
+
+func selectionTx(nonce uint64, to common.Address, fee int64, dataLen int) model.AbstractTransaction {
+	return model.NewTransaction(nonce, to, big.NewInt(1), big.NewInt(fee), make([]byte, dataLen))
+}
+
+// drain packs all the txs the iterator returns
+func drain(txs TxIterator) (result []model.AbstractTransaction) {
+	for tx := txs.Peek(); tx != nil; tx = txs.Peek() {
+		result = append(result, tx)
+		txs.Shift()
+	}
+	return
+}
+
+func TestTxOrderFromString(t *testing.T) {
+	assert.Equal(t, TxOrderByFee, TxOrderFromString(""))
+	assert.Equal(t, TxOrderByFee, TxOrderFromString("fee"))
+	assert.Equal(t, TxOrderByFeePerByte, TxOrderFromString("fee_per_byte"))
+}
+
+func TestTxSelectionPolicy_Order(t *testing.T) {
+	alice, bob := common.HexToAddress("0x1"), common.HexToAddress("0x2")
+	// alice pays a higher fee with a much bigger tx
+	aliceTx := selectionTx(0, selectionNormalTo, 300, 1000)
+	bobTx := selectionTx(0, selectionNormalTo, 200, 0)
+	pending := func() map[common.Address][]model.AbstractTransaction {
+		return map[common.Address][]model.AbstractTransaction{alice: {aliceTx}, bob: {bobTx}}
+	}
+
+	assert.Equal(t, []model.AbstractTransaction{aliceTx, bobTx}, drain(NewTxSelectionPolicy(TxSelectionConfig{}).Select(nil, pending())))
+	assert.Equal(t, []model.AbstractTransaction{bobTx, aliceTx}, drain(NewTxSelectionPolicy(TxSelectionConfig{Order: TxOrderByFeePerByte}).Select(nil, pending())))
+
+	// the priority and local senders go first
+	policy := NewTxSelectionPolicy(TxSelectionConfig{PrioritySenders: []common.Address{bob}})
+	assert.Equal(t, []model.AbstractTransaction{bobTx, aliceTx}, drain(policy.Select(nil, pending())))
+	policy = NewTxSelectionPolicy(TxSelectionConfig{IsLocal: func(address common.Address) bool { return address == bob }})
+	assert.Equal(t, []model.AbstractTransaction{bobTx, aliceTx}, drain(policy.Select(nil, pending())))
+
+	// the verifier txs go before the priority senders if some space is reserved for them
+	stakeTx := selectionTx(0, selectionStakeTo, 1, 0)
+	withStake := func() map[common.Address][]model.AbstractTransaction {
+		txs := pending()
+		txs[common.HexToAddress("0x3")] = []model.AbstractTransaction{stakeTx}
+		return txs
+	}
+	assert.Equal(t, []model.AbstractTransaction{bobTx, aliceTx, stakeTx}, drain(policy.Select(nil, withStake())))
+	policy = NewTxSelectionPolicy(TxSelectionConfig{PrioritySenders: []common.Address{bob}, ReservedPercent: 10})
+	assert.Equal(t, []model.AbstractTransaction{stakeTx, bobTx, aliceTx}, drain(policy.Select(nil, withStake())))
+}
+
+func TestTxSelectionPolicy_ZeroValue(t *testing.T) {
+	alice, bob, carol := common.HexToAddress("0x1"), common.HexToAddress("0x2"), common.HexToAddress("0x3")
+	alice0 := selectionTx(0, selectionNormalTo, 100, 0)
+	alice1 := selectionTx(1, selectionNormalTo, 300, 0)
+	bob0 := selectionTx(0, selectionStakeTo, 1, 0)
+	carol0 := selectionTx(0, selectionNormalTo, 200, 0)
+	pending := func() map[common.Address][]model.AbstractTransaction {
+		return map[common.Address][]model.AbstractTransaction{alice: {alice0, alice1}, bob: {bob0}, carol: {carol0}}
+	}
+
+	// the zero value packs the verifier txs by the fee and the nonce like model.TransactionsByFeeAndNonce
+	expected := []model.AbstractTransaction{carol0, alice0, alice1, bob0}
+	assert.Equal(t, expected, drain(NewTxSelectionPolicy(TxSelectionConfig{}).Select(nil, pending())))
+	assert.Equal(t, expected, drain(NewTxSelectionPolicy(TxSelectionConfig{ReservedPercent: -1}).Select(nil, pending())))
+}
+
+func TestTxSelectionPolicy_Nonce(t *testing.T) {
+	alice, bob := common.HexToAddress("0x1"), common.HexToAddress("0x2")
+	alice0 := selectionTx(0, selectionNormalTo, 100, 0)
+	alice1 := selectionTx(1, selectionNormalTo, 300, 0)
+	bob0 := selectionTx(0, selectionNormalTo, 200, 0)
+	pending := map[common.Address][]model.AbstractTransaction{alice: {alice0, alice1}, bob: {bob0}}
+	assert.Equal(t, []model.AbstractTransaction{bob0, alice0, alice1}, drain(NewTxSelectionPolicy(TxSelectionConfig{}).Select(nil, pending)))
+
+	// the rest txs of the sender are skipped by Pop
+	pending = map[common.Address][]model.AbstractTransaction{alice: {alice0, alice1}, bob: {bob0}}
+	txs := NewTxSelectionPolicy(TxSelectionConfig{}).Select(nil, pending)
+	assert.Equal(t, bob0, txs.Peek())
+	txs.Shift()
+	assert.Equal(t, alice0, txs.Peek())
+	txs.Pop()
+	assert.Nil(t, txs.Peek())
+}
+
+func TestTxSelectionPolicy_Limits(t *testing.T) {
+	spam := make(map[common.Address][]model.AbstractTransaction)
+	for i := 0; i < 10; i++ {
+		spam[common.BigToAddress(big.NewInt(int64(i+10)))] = []model.AbstractTransaction{selectionTx(0, selectionNormalTo, 1000, 0)}
+	}
+	stakeTx := selectionTx(0, selectionStakeTo, 1, 0)
+	withStake := func() map[common.Address][]model.AbstractTransaction {
+		txs := make(map[common.Address][]model.AbstractTransaction)
+		for from, accTxs := range spam {
+			txs[from] = accTxs
+		}
+		txs[common.HexToAddress("0x3")] = []model.AbstractTransaction{stakeTx}
+		return txs
+	}
+
+	// the max tx count
+	assert.Len(t, drain(NewTxSelectionPolicy(TxSelectionConfig{MaxTxCount: 4}).Select(nil, withStake())), 4)
+
+	// the normal txs can't use the reserved 25%
+	packed := drain(NewTxSelectionPolicy(TxSelectionConfig{MaxTxCount: 4, ReservedPercent: 25, Order: TxOrderByFee}).Select(nil, withStake()))
+	assert.Len(t, packed, 4)
+	assert.Contains(t, packed, stakeTx)
+	normal := 0
+	for _, tx := range packed {
+		if tx != stakeTx {
+			normal++
+		}
+	}
+	assert.Equal(t, 3, normal)
+
+	// all the space is reserved, only the verifier txs are packed
+	packed = drain(NewTxSelectionPolicy(TxSelectionConfig{MaxTxCount: 4, ReservedPercent: 200}).Select(nil, withStake()))
+	assert.Equal(t, []model.AbstractTransaction{stakeTx}, packed)
+
+	// the max total size
+	size := spam[common.BigToAddress(big.NewInt(10))][0].Size()
+	packed = drain(NewTxSelectionPolicy(TxSelectionConfig{MaxTxSize: size*3 + size/2}).Select(nil, withStake()))
+	assert.Len(t, packed, 3)
+}
+
+func TestBftBlockBuilder_selectTransactions(t *testing.T) {
+	alice := common.HexToAddress("0x1")
+	tx := selectionTx(0, selectionNormalTo, 1, 0)
+
+	builder := MakeBftBlockBuilder(ModelConfig{})
+	_, ok := builder.selectTransactions(map[common.Address][]model.AbstractTransaction{}).(*model.TransactionsByFeeAndNonce)
+	assert.True(t, ok)
+
+	builder = MakeBftBlockBuilder(ModelConfig{TxSelection: NewTxSelectionPolicy(TxSelectionConfig{})})
+	assert.Equal(t, []model.AbstractTransaction{tx}, drain(builder.selectTransactions(map[common.Address][]model.AbstractTransaction{alice: {tx}})))
+}
@@ -84,6 +84,78 @@ func NewCalNewWorkDiff(preSpanBlock, lastNormalBlock AbstractBlock,currentBlockN
 	return calNewWorkDiffByTime(preSpanBlock.Timestamp(), lastNormalBlock.Timestamp(), lastNormalBlock.Difficulty())
 }
 
+// DiffBlockReader reads the recent blocks for the per block difficulty adjustment
+type DiffBlockReader interface {
+	GetBlockByNumber(number uint64) AbstractBlock
+}
+
+// Get at most count normal blocks ending with the last normal block, they are in ascending order of number.
+// The special blocks are skipped because they have no difficulty.
+func GetRecentNormalBlocks(reader DiffBlockReader, lastNormalBlock AbstractBlock, count uint64) []AbstractBlock {
+	var blocks []AbstractBlock
+	for block := lastNormalBlock; !util.InterfaceIsNil(block) && uint64(len(blocks)) < count; {
+		if !block.IsSpecial() {
+			blocks = append(blocks, block)
+		}
+		if block.Number() == 0 {
+			break
+		}
+		block = reader.GetBlockByNumber(block.Number() - 1)
+	}
+
+	for i, j := 0, len(blocks)-1; i < j; i, j = i+1, j-1 {
+		blocks[i], blocks[j] = blocks[j], blocks[i]
+	}
+	return blocks
+}
+
+// Calculate the difficulty of the next block every block by the linearly weighted moving average of the recent normal blocks,
+// the recent solve times get the higher weights so the difficulty follows the hashrate changes quickly.
+// The recent blocks are in ascending order of number, the difficulty of the last one is used if there aren't enough blocks.
+// formula： target = avgTarget * sum(i * solveTime(i)) / (sum(i) * expectTime)
+func CalWeightedWorkDiff(recentBlocks []AbstractBlock) common.Difficulty {
+	if IsIgnoreDifficultyValidation() {
+		return common.HexToDiff("0x1fffffff")
+	}
+
+	lastBlock := recentBlocks[len(recentBlocks)-1]
+	if len(recentBlocks) < 2 {
+		return lastBlock.Difficulty()
+	}
+
+	// here is nanosecond in block
+	expectTime := new(big.Int).Mul(new(big.Int).SetUint64(blockgenerate), big.NewInt(1e9))
+	// a solve time is limited to [1, 6 * expectTime] for fear that a wrong timestamp changes the difficulty too much
+	minSolveTime := big.NewInt(1)
+	maxSolveTime := new(big.Int).Mul(expectTime, big.NewInt(6))
+
+	weightedSolveTime := big.NewInt(0)
+	sumTarget := big.NewInt(0)
+	sumWeight := int64(0)
+	for i := 1; i < len(recentBlocks); i++ {
+		solveTime := new(big.Int).Sub(recentBlocks[i].Timestamp(), recentBlocks[i-1].Timestamp())
+		if solveTime.Cmp(minSolveTime) < 0 {
+			solveTime.Set(minSolveTime)
+		} else if solveTime.Cmp(maxSolveTime) > 0 {
+			solveTime.Set(maxSolveTime)
+		}
+
+		weightedSolveTime.Add(weightedSolveTime, solveTime.Mul(solveTime, big.NewInt(int64(i))))
+		sumTarget.Add(sumTarget, recentBlocks[i].Difficulty().DiffToTarget().Big())
+		sumWeight += int64(i)
+	}
+
+	count := big.NewInt(int64(len(recentBlocks) - 1))
+	newTarget := new(big.Int).Mul(sumTarget, weightedSolveTime)
+	newTarget.Div(newTarget, new(big.Int).Mul(count, new(big.Int).Mul(big.NewInt(sumWeight), expectTime)))
+
+	if newTarget.Cmp(mainPowLimit) > 0 {
+		newTarget.Set(mainPowLimit)
+	}
+
+	return common.BigToDiff(newTarget)
+}
+
 //The header block of the previous cycle, currently set to 4320 blocks before,
 // and the previous block, returns the difficulty value.
 // the Genesis Block's timestamp is Changeless
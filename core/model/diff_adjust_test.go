@@ -18,6 +18,7 @@ package model
 
 import (
 	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/chain-config"
 	"testing"
 	"github.com/stretchr/testify/assert"
 	"time"
@@ -90,3 +91,135 @@ func TestNewCalNewWorkDiff(t *testing.T) {
 	result = NewCalNewWorkDiff(block1, block2, 12)
 	assert.Equal(t, common.HexToDiff("0x1fffffff"), result)
 }
+
+type fakeDiffBlockReader map[uint64]AbstractBlock
+
+func (reader fakeDiffBlockReader) GetBlockByNumber(number uint64) AbstractBlock {
+	return reader[number]
+}
+
+func newDiffTestBlock(num uint64, diff common.Difficulty, timestamp *big.Int) *Block {
+	return &Block{header: &Header{Number: num, Diff: diff, TimeStamp: timestamp}, body: &Body{}}
+}
+
+func TestGetRecentNormalBlocks(t *testing.T) {
+	reader := fakeDiffBlockReader{}
+	for i := uint64(0); i < 10; i++ {
+		diff := common.HexToDiff("0x1fffffff")
+		// the special blocks
+		if i == 5 || i == 6 {
+			diff = common.Difficulty{}
+		}
+		reader[i] = newDiffTestBlock(i, diff, big.NewInt(int64(i)))
+	}
+
+	blocks := GetRecentNormalBlocks(reader, reader[9], 5)
+	var nums []uint64
+	for _, b := range blocks {
+		nums = append(nums, b.Number())
+	}
+	assert.Equal(t, []uint64{3, 4, 7, 8, 9}, nums)
+
+	// stop at the genesis block
+	assert.Len(t, GetRecentNormalBlocks(reader, reader[9], 100), 8)
+	// stop at the missing block
+	delete(reader, 2)
+	assert.Len(t, GetRecentNormalBlocks(reader, reader[9], 100), 5)
+}
+
+// simulate mining the blocks by the hashrates, there are 100 units of hashrate which mine a block with
+// the reference target in blockgenerate seconds. The solve time of a block is in inverse proportion to
+// the hashrate and the target, the solve times are returned.
+func simulateWeightedDiff(window int, refTarget *big.Int, hashrates []int64) (blocks []AbstractBlock, solveTimes []*big.Int) {
+	expectTime := new(big.Int).Mul(new(big.Int).SetUint64(blockgenerate), big.NewInt(1e9))
+	timestamp := big.NewInt(0)
+	for i := 0; i <= window; i++ {
+		blocks = append(blocks, newDiffTestBlock(uint64(i), common.BigToDiff(refTarget), new(big.Int).Set(timestamp)))
+		timestamp.Add(timestamp, expectTime)
+	}
+
+	for _, hashrate := range hashrates {
+		last := blocks[len(blocks)-1]
+		diff := CalWeightedWorkDiff(blocks[len(blocks)-window-1:])
+
+		// solveTime = expectTime * 100 * refTarget / (target * hashrate)
+		solveTime := new(big.Int).Mul(expectTime, big.NewInt(100))
+		solveTime.Mul(solveTime, refTarget)
+		solveTime.Div(solveTime, new(big.Int).Mul(diff.DiffToTarget().Big(), big.NewInt(hashrate)))
+
+		solveTimes = append(solveTimes, solveTime)
+		blocks = append(blocks, newDiffTestBlock(last.Number()+1, diff, new(big.Int).Add(last.Timestamp(), solveTime)))
+	}
+	return
+}
+
+// the average solve time of the blocks in seconds
+func averageSolveTime(solveTimes []*big.Int) float64 {
+	sum := big.NewInt(0)
+	for _, st := range solveTimes {
+		sum.Add(sum, st)
+	}
+	return float64(sum.Div(sum, big.NewInt(int64(len(solveTimes)))).Int64()) / 1e9
+}
+
+func repeatHashrate(hashrate int64, count int) (hashrates []int64) {
+	for i := 0; i < count; i++ {
+		hashrates = append(hashrates, hashrate)
+	}
+	return
+}
+
+func TestCalWeightedWorkDiff(t *testing.T) {
+	IgnoreDifficultyValidation = false
+	refTarget := chain_config.GenesisDifficulty.DiffToTarget().Big()
+
+	// keep the difficulty if there isn't any solve time
+	block := newDiffTestBlock(1, chain_config.GenesisDifficulty, big.NewInt(1))
+	assert.Equal(t, chain_config.GenesisDifficulty, CalWeightedWorkDiff([]AbstractBlock{block}))
+
+	// the faster blocks increase the difficulty and the slower blocks decrease it
+	expectTime := int64(blockgenerate) * 1e9
+	fast := []AbstractBlock{block, newDiffTestBlock(2, chain_config.GenesisDifficulty, big.NewInt(1+expectTime/2))}
+	slow := []AbstractBlock{block, newDiffTestBlock(2, chain_config.GenesisDifficulty, big.NewInt(1+expectTime*2))}
+	assert.True(t, CalWeightedWorkDiff(fast).DiffToTarget().Big().Cmp(refTarget) < 0)
+	assert.True(t, CalWeightedWorkDiff(slow).DiffToTarget().Big().Cmp(refTarget) > 0)
+
+	// the solve time is limited for the wrong timestamps
+	wrong := []AbstractBlock{block, newDiffTestBlock(2, chain_config.GenesisDifficulty, big.NewInt(1+expectTime*1000))}
+	limitTarget := new(big.Int).Mul(refTarget, big.NewInt(6))
+	assert.True(t, CalWeightedWorkDiff(wrong).DiffToTarget().Big().Cmp(new(big.Int).Add(limitTarget, new(big.Int).Div(limitTarget, big.NewInt(100)))) < 0)
+
+	IgnoreDifficultyValidation = true
+	assert.Equal(t, common.HexToDiff("0x1fffffff"), CalWeightedWorkDiff(slow))
+	IgnoreDifficultyValidation = false
+}
+
+func TestCalWeightedWorkDiff_HashrateShock(t *testing.T) {
+	IgnoreDifficultyValidation = false
+	window := 60
+	refTarget := chain_config.GenesisDifficulty.DiffToTarget().Big()
+	expectTime := float64(blockgenerate)
+
+	// the stable hashrate keeps the block time
+	_, solveTimes := simulateWeightedDiff(window, refTarget, repeatHashrate(100, 200))
+	assert.InDelta(t, expectTime, averageSolveTime(solveTimes[100:]), expectTime*0.05)
+
+	// 90% of the hashrate leaves, the block time recovers in 3 windows instead of the rest of a 4096 blocks period
+	_, solveTimes = simulateWeightedDiff(window, refTarget, repeatHashrate(10, 400))
+	assert.True(t, averageSolveTime(solveTimes[:5]) > expectTime*5)
+	assert.InDelta(t, expectTime, averageSolveTime(solveTimes[3*window:3*window+60]), expectTime*0.1)
+	assert.InDelta(t, expectTime, averageSolveTime(solveTimes[300:]), expectTime*0.1)
+
+	// 10 times hashrate joins
+	_, solveTimes = simulateWeightedDiff(window, refTarget, repeatHashrate(1000, 400))
+	assert.True(t, averageSolveTime(solveTimes[:5]) < expectTime/5)
+	assert.InDelta(t, expectTime, averageSolveTime(solveTimes[3*window:3*window+60]), expectTime*0.1)
+
+	// the hashrate joins and then leaves, the difficulty follows it back
+	hashrates := append(repeatHashrate(1000, 200), repeatHashrate(100, 300)...)
+	blocks, solveTimes := simulateWeightedDiff(window, refTarget, hashrates)
+	assert.InDelta(t, expectTime, averageSolveTime(solveTimes[400:]), expectTime*0.1)
+	lastTarget := blocks[len(blocks)-1].Difficulty().DiffToTarget().Big()
+	ratio, _ := new(big.Float).Quo(new(big.Float).SetInt(lastTarget), new(big.Float).SetInt(refTarget)).Float64()
+	assert.InDelta(t, 1, ratio, 0.1)
+}
@@ -0,0 +1,39 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package model
+
+import (
+	"math/big"
+	"sort"
+)
+
+// Calculate the median time past of the recent normal blocks, a new normal block must have a later timestamp than it.
+// A miner can't move the time back by one wrong timestamp, so the difficulty can't be skewed by the timestamps.
+func CalMedianTimePast(recentBlocks []AbstractBlock) *big.Int {
+	if len(recentBlocks) == 0 {
+		return big.NewInt(0)
+	}
+
+	timestamps := make([]*big.Int, 0, len(recentBlocks))
+	for _, block := range recentBlocks {
+		timestamps = append(timestamps, block.Timestamp())
+	}
+	sort.Slice(timestamps, func(i, j int) bool {
+		return timestamps[i].Cmp(timestamps[j]) < 0
+	})
+	return new(big.Int).Set(timestamps[len(timestamps)/2])
+}
@@ -0,0 +1,40 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package model
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestCalMedianTimePast(t *testing.T) {
+	assert.Equal(t, big.NewInt(0), CalMedianTimePast(nil))
+
+	var blocks []AbstractBlock
+	for i, ts := range []int64{10, 30, 20, 1000, 25} {
+		blocks = append(blocks, newDiffTestBlock(uint64(i), common.HexToDiff("0x1fffffff"), big.NewInt(ts)))
+	}
+	// a wrong timestamp doesn't move the median time
+	assert.Equal(t, big.NewInt(25), CalMedianTimePast(blocks))
+	assert.Equal(t, big.NewInt(30), CalMedianTimePast(blocks[1:]))
+	assert.Equal(t, big.NewInt(10), CalMedianTimePast(blocks[:1]))
+
+	// the timestamps of the blocks are not changed
+	assert.Equal(t, big.NewInt(30), blocks[1].Timestamp())
+}
@@ -110,6 +110,40 @@ func NewUnStakeTransaction(nonce uint64, fee *big.Int) *Transaction {
 	return &Transaction{data: txData, wit: wit}
 }
 
+// NewPartialUnStakeTransaction create a unStake tx which only withdraws amount from the stake of a registered verifier,
+// the amount is rlp encoded in the extra data and it is locked until the withdraw stake tx
+func NewPartialUnStakeTransaction(nonce uint64, amount *big.Int, fee *big.Int) *Transaction {
+	tx := NewUnStakeTransaction(nonce, fee)
+	if amount == nil {
+		amount = big.NewInt(0)
+	}
+	tx.data.ExtraData, _ = rlp.EncodeToBytes(amount)
+	return tx
+}
+
+// NewWithdrawStakeTransaction create a tx which returns the partially unStaked amount after the lock period
+func NewWithdrawStakeTransaction(nonce uint64, fee *big.Int) *Transaction {
+	target := common.HexToAddress(common.AddressWithdrawStake)
+	txData := txData{
+		AccountNonce: nonce,
+		Recipient:    &target,
+		TimeLock:     new(big.Int),
+		Amount:       new(big.Int),
+		Fee:          new(big.Int),
+		ExtraData:    []byte{},
+	}
+	wit := witness{
+		R:       new(big.Int),
+		S:       new(big.Int),
+		V:       new(big.Int),
+		HashKey: nil,
+	}
+	if fee != nil {
+		txData.Fee.Set(fee)
+	}
+	return &Transaction{data: txData, wit: wit}
+}
+
 func NewCancelTransaction(nonce uint64, fee *big.Int) *Transaction {
 	target := common.HexToAddress(common.AddressCancel)
 	txData := txData{
@@ -132,6 +166,103 @@ func NewCancelTransaction(nonce uint64, fee *big.Int) *Transaction {
 	return &Transaction{data: txData, wit: wit}
 }
 
+// the commission of the verifiers is in per mille
+const MaxCommission = uint64(1000)
+
+// NewRegisterTransactionWithCommission create a register tx which declares the commission the verifier
+// takes from the rewards of its delegators, the commission is rlp encoded in the extra data
+func NewRegisterTransactionWithCommission(nonce uint64, amount *big.Int, fee *big.Int, commission uint64) *Transaction {
+	tx := NewRegisterTransaction(nonce, amount, fee)
+	tx.data.ExtraData, _ = rlp.EncodeToBytes(commission)
+	return tx
+}
+
+// NewDelegateTransaction create a tx which bonds amount to the candidate
+func NewDelegateTransaction(nonce uint64, candidate common.Address, amount *big.Int, fee *big.Int) *Transaction {
+	return newDelegationTransaction(nonce, common.AddressTypeDelegate, candidate, amount, fee, []byte{})
+}
+
+// NewUnDelegateTransaction create a tx which starts unbonding amount from the candidate,
+// the amount is rlp encoded in the extra data because the tx amount is paid by the sender
+func NewUnDelegateTransaction(nonce uint64, candidate common.Address, amount *big.Int, fee *big.Int) *Transaction {
+	if amount == nil {
+		amount = big.NewInt(0)
+	}
+	data, _ := rlp.EncodeToBytes(amount)
+	return newDelegationTransaction(nonce, common.AddressTypeUnDelegate, candidate, nil, fee, data)
+}
+
+// NewWithdrawDelegationTransaction create a tx which returns the unbonded amount to the sender
+func NewWithdrawDelegationTransaction(nonce uint64, candidate common.Address, fee *big.Int) *Transaction {
+	return newDelegationTransaction(nonce, common.AddressTypeWithdrawDelegation, candidate, nil, fee, []byte{})
+}
+
+func newDelegationTransaction(nonce uint64, txType common.TxType, candidate common.Address, amount *big.Int, fee *big.Int, extraData []byte) *Transaction {
+	target := cs_crypto.GetDelegationAddress(txType, candidate)
+	txData := txData{
+		AccountNonce: nonce,
+		Recipient:    &target,
+		TimeLock:     new(big.Int),
+		Amount:       new(big.Int),
+		Fee:          new(big.Int),
+		ExtraData:    extraData,
+	}
+	wit := witness{
+		R:       new(big.Int),
+		S:       new(big.Int),
+		V:       new(big.Int),
+		HashKey: nil,
+	}
+	if amount != nil {
+		txData.Amount.Set(amount)
+	}
+	if fee != nil {
+		txData.Fee.Set(fee)
+	}
+	return &Transaction{data: txData, wit: wit}
+}
+
+// GovernanceProposal is the extra data of the governance proposal tx, it proposes to set the consensus parameter
+// to the value from the activation slot
+type GovernanceProposal struct {
+	Param          string
+	Value          uint64
+	ActivationSlot uint64
+}
+
+// NewGovernanceProposalTransaction create a tx with which a current verifier proposes a new value of a consensus parameter
+func NewGovernanceProposalTransaction(nonce uint64, param string, value uint64, activationSlot uint64, fee *big.Int) *Transaction {
+	data, _ := rlp.EncodeToBytes(GovernanceProposal{Param: param, Value: value, ActivationSlot: activationSlot})
+	return newGovernanceTransaction(nonce, common.HexToAddress(common.AddressGovernanceProposal), fee, data)
+}
+
+// NewGovernanceVoteTransaction create a tx with which a current verifier votes for the proposal,
+// the proposal id is the hash of the proposal tx
+func NewGovernanceVoteTransaction(nonce uint64, proposalId common.Hash, fee *big.Int) *Transaction {
+	return newGovernanceTransaction(nonce, common.HexToAddress(common.AddressGovernanceVote), fee, proposalId.Bytes())
+}
+
+func newGovernanceTransaction(nonce uint64, target common.Address, fee *big.Int, extraData []byte) *Transaction {
+	txData := txData{
+		AccountNonce: nonce,
+		Recipient:    &target,
+		TimeLock:     new(big.Int),
+		Amount:       new(big.Int),
+		Fee:          new(big.Int),
+		ExtraData:    extraData,
+	}
+	wit := witness{
+		R:       new(big.Int),
+		S:       new(big.Int),
+		V:       new(big.Int),
+		HashKey: nil,
+	}
+	if fee != nil {
+		txData.Fee.Set(fee)
+	}
+	return &Transaction{data: txData, wit: wit}
+}
+
 func NewUnNormalTransaction(nonce uint64, amount *big.Int, fee *big.Int) *Transaction {
 	target := common.HexToAddress("0x00090000000000000000000000000000000000000000")
 	txData := txData{
@@ -0,0 +1,74 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+
+package model
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"math/big"
+)
+
+// the largest weight a single verifier gets after normalization, it bounds the length of a proposer cycle
+const maxProposerWeight = uint64(100)
+
+// MakeProposerSequence build the proposer sequence of a whole weighted round robin cycle.
+// weights are scaled to [1, maxProposerWeight] so that every verifier proposes at least once per cycle,
+// and each verifier appears in the cycle as many times as its scaled weight.
+// return nil if the weights can't be used, then the plain rotation is used.
+func MakeProposerSequence(verifiers []common.Address, weights []uint64) []int {
+	if len(verifiers) == 0 || len(weights) != len(verifiers) {
+		return nil
+	}
+
+	maxWeight := uint64(0)
+	for _, w := range weights {
+		if w > maxWeight {
+			maxWeight = w
+		}
+	}
+	if maxWeight == 0 {
+		return nil
+	}
+
+	scaled := make([]int64, len(weights))
+	total := int64(0)
+	for i, w := range weights {
+		// use big int, w * maxProposerWeight may overflow uint64
+		s := new(big.Int).Div(new(big.Int).Mul(new(big.Int).SetUint64(w), new(big.Int).SetUint64(maxProposerWeight)), new(big.Int).SetUint64(maxWeight)).Uint64()
+		if s == 0 {
+			s = 1
+		}
+		scaled[i] = int64(s)
+		total += int64(s)
+	}
+
+	// smooth weighted round robin, ties are broken by the verifier index so that all nodes get the same result
+	sequence := make([]int, 0, total)
+	current := make([]int64, len(scaled))
+	for len(sequence) < int(total) {
+		selected := 0
+		for i := range scaled {
+			current[i] += scaled[i]
+			if current[i] > current[selected] {
+				selected = i
+			}
+		}
+		current[selected] -= total
+		sequence = append(sequence, selected)
+	}
+	return sequence
+}
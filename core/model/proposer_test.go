@@ -0,0 +1,57 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+
+package model
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestMakeProposerSequence(t *testing.T) {
+	v := []common.Address{common.HexToAddress("0x01"), common.HexToAddress("0x02"), common.HexToAddress("0x03"), common.HexToAddress("0x04")}
+
+	assert.Nil(t, MakeProposerSequence(nil, nil))
+	assert.Nil(t, MakeProposerSequence(v, []uint64{1, 2}))
+	assert.Nil(t, MakeProposerSequence(v, []uint64{0, 0, 0, 0}))
+
+	// proportional to the weights, the verifier without weight still gets one turn
+	seq := MakeProposerSequence(v, []uint64{6000, 3000, 1500, 0})
+	count := make([]int, len(v))
+	for _, index := range seq {
+		count[index]++
+	}
+	assert.Equal(t, []int{100, 50, 25, 1}, count)
+
+	// deterministic
+	assert.Equal(t, seq, MakeProposerSequence(v, []uint64{6000, 3000, 1500, 0}))
+
+	// the largest verifier should not propose many rounds in a row
+	maxRun, run := 0, 0
+	for i := range seq {
+		if i > 0 && seq[i] == seq[i-1] {
+			run++
+		} else {
+			run = 1
+		}
+		if run > maxRun {
+			maxRun = run
+		}
+	}
+	assert.True(t, maxRun <= 2)
+}
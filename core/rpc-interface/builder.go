@@ -34,6 +34,14 @@ func MakeDipperinP2PApi(service P2PAPI) *DipperinP2PApi {
 	return &DipperinP2PApi{service: service}
 }
 
+func MakeDipperinMineAdminApi(service MineAdminAPI) *DipperinMineAdminApi {
+	return &DipperinMineAdminApi{service: service}
+}
+
+func MakeDipperinMinerApi(service MinerAPI) *DipperinMinerApi {
+	return &DipperinMinerApi{service: service}
+}
+
 type nodeConf interface {
 	IpcEndpoint() string
 	HttpEndpoint() string
@@ -22,6 +22,7 @@ import (
     "github.com/dipperin/dipperin-core/common/hexutil"
     "github.com/dipperin/dipperin-core/core/accounts"
     "github.com/dipperin/dipperin-core/core/chain-config"
+    "github.com/dipperin/dipperin-core/core/chain/state-processor"
     "github.com/dipperin/dipperin-core/core/contract"
     "github.com/dipperin/dipperin-core/core/economy-model"
     "github.com/dipperin/dipperin-core/core/model"
@@ -46,6 +47,11 @@ func (api *DipperinMercuryApi) GetSyncStatus() bool {
     return api.service.GetSyncStatus()
 }
 
+// get the progress of the block sync and the peers the blocks are fetched from
+func (api *DipperinMercuryApi) GetSyncProgress() (*service.SyncProgressResp, error) {
+    return api.service.GetSyncProgress()
+}
+
 // swagger:operation GET /url/CurrentBlock block information block
 // ---
 // summary: get the current block
@@ -864,6 +870,366 @@ func (api *DipperinMercuryApi) SendUnStakeTransaction(from common.Address, fee *
     return api.service.SendUnStakeTransaction(from, fee, nonce)
 }
 
+// send add stake transaction
+// swagger:operation POST /url/SendAddStakeTransaction transactionOperation transaction
+// ---
+// summary: send add stake transaction
+// description: top up the stake of a registered verifier
+// parameters:
+// - name: from
+//   in: body
+//   description: the registered verifier address
+//   type: common.Address
+//   required: true
+// - name: amount
+//   in: body
+//   description: the added stake
+//   type: *big.Int
+//   required: true
+// - name: fee
+//   in: body
+//   description: the transaction fee
+//   type: *big.Int
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        description: return operation result
+func (api *DipperinMercuryApi) SendAddStakeTransaction(from common.Address, amount, fee *big.Int, nonce *uint64) (common.Hash, error) {
+    return api.service.SendAddStakeTransaction(from, amount, fee, nonce)
+}
+
+// send partial unstake transaction
+// swagger:operation POST /url/SendPartialUnStakeTransaction transactionOperation transaction
+// ---
+// summary: send partial unstake transaction
+// description: withdraw part of the stake of a registered verifier, the amount is locked like the unstaked stake
+// parameters:
+// - name: from
+//   in: body
+//   description: the registered verifier address
+//   type: common.Address
+//   required: true
+// - name: amount
+//   in: body
+//   description: the withdrawn stake
+//   type: *big.Int
+//   required: true
+// - name: fee
+//   in: body
+//   description: the transaction fee
+//   type: *big.Int
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        description: return operation result
+func (api *DipperinMercuryApi) SendPartialUnStakeTransaction(from common.Address, amount, fee *big.Int, nonce *uint64) (common.Hash, error) {
+    return api.service.SendPartialUnStakeTransaction(from, amount, fee, nonce)
+}
+
+// send withdraw stake transaction
+// swagger:operation POST /url/SendWithdrawStakeTransaction transactionOperation transaction
+// ---
+// summary: send withdraw stake transaction
+// description: return the partially unstaked money after the lock period
+// parameters:
+// - name: from
+//   in: body
+//   description: the verifier address
+//   type: common.Address
+//   required: true
+// - name: fee
+//   in: body
+//   description: the transaction fee
+//   type: *big.Int
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        description: return operation result
+func (api *DipperinMercuryApi) SendWithdrawStakeTransaction(from common.Address, fee *big.Int, nonce *uint64) (common.Hash, error) {
+    return api.service.SendWithdrawStakeTransaction(from, fee, nonce)
+}
+
+// get the partially unstaked money of the verifier
+func (api *DipperinMercuryApi) GetUnStaking(address common.Address) (*UnStakingResp, error) {
+    unStaking, err := api.service.GetUnStaking(address)
+    if err != nil {
+        return nil, err
+    }
+    return &UnStakingResp{
+        Amount: (*hexutil.Big)(unStaking.Amount),
+        Height: unStaking.Height,
+    }, nil
+}
+
+// send register transaction with commission
+// swagger:operation POST /url/SendRegisterTransactionWithCommission transactionOperation transaction
+// ---
+// summary: send register transaction with commission
+// description: send register transaction which declares the per mille commission taken from the rewards of the delegators
+// parameters:
+// - name: from
+//   in: body
+//   description: the address that send register transaction
+//   type: common.Address
+//   required: true
+// - name: stake
+//   in: body
+//   description: the register pledge
+//   type: *big.Int
+//   required: true
+// - name: fee
+//   in: body
+//   description: the transaction fee
+//   type: *big.Int
+//   required: true
+// - name: commission
+//   in: body
+//   description: the commission in per mille
+//   type: uint64
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        description: return operation result
+func (api *DipperinMercuryApi) SendRegisterTransactionWithCommission(from common.Address, stake, fee *big.Int, commission uint64, nonce *uint64) (common.Hash, error) {
+    return api.service.SendRegisterTransactionWithCommission(from, stake, fee, commission, nonce)
+}
+
+// send delegate transaction
+// swagger:operation POST /url/SendDelegateTransaction transactionOperation transaction
+// ---
+// summary: send delegate transaction
+// description: bond money to a verifier candidate
+// parameters:
+// - name: from
+//   in: body
+//   description: the delegator address
+//   type: common.Address
+//   required: true
+// - name: candidate
+//   in: body
+//   description: the verifier candidate address
+//   type: common.Address
+//   required: true
+// - name: amount
+//   in: body
+//   description: the delegated amount
+//   type: *big.Int
+//   required: true
+// - name: fee
+//   in: body
+//   description: the transaction fee
+//   type: *big.Int
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        description: return operation result
+func (api *DipperinMercuryApi) SendDelegateTransaction(from, candidate common.Address, amount, fee *big.Int, nonce *uint64) (common.Hash, error) {
+    return api.service.SendDelegateTransaction(from, candidate, amount, fee, nonce)
+}
+
+// send undelegate transaction
+// swagger:operation POST /url/SendUnDelegateTransaction transactionOperation transaction
+// ---
+// summary: send undelegate transaction
+// description: start unbonding money from a verifier candidate
+// parameters:
+// - name: from
+//   in: body
+//   description: the delegator address
+//   type: common.Address
+//   required: true
+// - name: candidate
+//   in: body
+//   description: the verifier candidate address
+//   type: common.Address
+//   required: true
+// - name: amount
+//   in: body
+//   description: the unbonding amount
+//   type: *big.Int
+//   required: true
+// - name: fee
+//   in: body
+//   description: the transaction fee
+//   type: *big.Int
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        description: return operation result
+func (api *DipperinMercuryApi) SendUnDelegateTransaction(from, candidate common.Address, amount, fee *big.Int, nonce *uint64) (common.Hash, error) {
+    return api.service.SendUnDelegateTransaction(from, candidate, amount, fee, nonce)
+}
+
+// send withdraw delegation transaction
+// swagger:operation POST /url/SendWithdrawDelegationTransaction transactionOperation transaction
+// ---
+// summary: send withdraw delegation transaction
+// description: return the unbonded money after the lock period
+// parameters:
+// - name: from
+//   in: body
+//   description: the delegator address
+//   type: common.Address
+//   required: true
+// - name: candidate
+//   in: body
+//   description: the verifier candidate address
+//   type: common.Address
+//   required: true
+// - name: fee
+//   in: body
+//   description: the transaction fee
+//   type: *big.Int
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        description: return operation result
+func (api *DipperinMercuryApi) SendWithdrawDelegationTransaction(from, candidate common.Address, fee *big.Int, nonce *uint64) (common.Hash, error) {
+    return api.service.SendWithdrawDelegationTransaction(from, candidate, fee, nonce)
+}
+
+// get the delegation of the delegator to the candidate
+func (api *DipperinMercuryApi) GetDelegation(delegator, candidate common.Address) (*DelegationResp, error) {
+    delegation, err := api.service.GetDelegation(delegator, candidate)
+    if err != nil {
+        return nil, err
+    }
+    return &DelegationResp{
+        Bonded:       (*hexutil.Big)(delegation.Bonded),
+        Unbonding:    (*hexutil.Big)(delegation.Unbonding),
+        UnbondHeight: delegation.UnbondHeight,
+    }, nil
+}
+
+// get the delegated stake, the commission and the delegators of the candidate
+func (api *DipperinMercuryApi) GetCandidateDelegation(candidate common.Address) (*CandidateDelegationResp, error) {
+    delegated, commission, delegators, err := api.service.GetCandidateDelegation(candidate)
+    if err != nil {
+        return nil, err
+    }
+    return &CandidateDelegationResp{
+        DelegatedStake: (*hexutil.Big)(delegated),
+        Commission:     commission,
+        Delegators:     delegators,
+    }, nil
+}
+
+// send governance proposal transaction
+// swagger:operation POST /url/SendGovernanceProposalTransaction transactionOperation transaction
+// ---
+// summary: send governance proposal transaction
+// description: propose a new value of a consensus parameter, the proposal id is the returned tx hash
+// parameters:
+// - name: from
+//   in: body
+//   description: the current verifier address
+//   type: common.Address
+//   required: true
+// - name: param
+//   in: body
+//   description: VerifierNumber, SlotSize, StakeLockSlot or BlockTimeRestriction(seconds)
+//   type: string
+//   required: true
+// - name: value
+//   in: body
+//   description: the new value of the parameter
+//   type: uint64
+//   required: true
+// - name: activationSlot
+//   in: body
+//   description: the slot from which the new value is used
+//   type: uint64
+//   required: true
+// - name: fee
+//   in: body
+//   description: the transaction fee
+//   type: *big.Int
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        description: return operation result
+func (api *DipperinMercuryApi) SendGovernanceProposalTransaction(from common.Address, param string, value uint64, activationSlot uint64, fee *big.Int, nonce *uint64) (common.Hash, error) {
+    return api.service.SendGovernanceProposalTransaction(from, param, value, activationSlot, fee, nonce)
+}
+
+// send governance vote transaction
+// swagger:operation POST /url/SendGovernanceVoteTransaction transactionOperation transaction
+// ---
+// summary: send governance vote transaction
+// description: vote for the governance proposal
+// parameters:
+// - name: from
+//   in: body
+//   description: the current verifier address
+//   type: common.Address
+//   required: true
+// - name: proposalId
+//   in: body
+//   description: the hash of the proposal transaction
+//   type: common.Hash
+//   required: true
+// - name: fee
+//   in: body
+//   description: the transaction fee
+//   type: *big.Int
+//   required: true
+// produces:
+// - application/json
+// responses:
+//   "200":
+//        description: return operation result
+func (api *DipperinMercuryApi) SendGovernanceVoteTransaction(from common.Address, proposalId common.Hash, fee *big.Int, nonce *uint64) (common.Hash, error) {
+    return api.service.SendGovernanceVoteTransaction(from, proposalId, fee, nonce)
+}
+
+// get the governance proposal and its votes
+func (api *DipperinMercuryApi) GetGovernanceProposal(proposalId common.Hash) (*GovernanceProposalResp, error) {
+    proposal, err := api.service.GetGovernanceProposal(proposalId)
+    if err != nil {
+        return nil, err
+    }
+    return &GovernanceProposalResp{
+        Param:          proposal.Param,
+        Value:          proposal.Value,
+        ActivationSlot: proposal.ActivationSlot,
+        Proposer:       proposal.Proposer,
+        Votes:          proposal.Votes,
+        Passed:         proposal.Passed,
+    }, nil
+}
+
+// get the chain config with the governed parameters used by the block of the height
+func (api *DipperinMercuryApi) GetChainConfigAtHeight(height uint64) (conf chain_config.ChainConfig, err error) {
+    return api.service.GetChainConfigAtHeight(height), nil
+}
+
+// get a page of the accounts in the state of the block, start is the next of the last page and it's empty for the first page
+func (api *DipperinMercuryApi) DumpState(number uint64, start hexutil.Bytes, max int) (*state_processor.StateDump, error) {
+    return api.service.DumpState(number, start, max)
+}
+
+// get a page of the accounts changed between the states of two blocks, start is the next of the last page and it's
+// the zero address for the first page. The blocks can't be more than 1000 blocks apart.
+func (api *DipperinMercuryApi) DiffState(from, to uint64, start common.Address, max int) (*state_processor.StateDiff, error) {
+    return api.service.DiffState(from, to, start, max)
+}
+
 // send evidence transaction
 // swagger:operation POST /url/SendEvidenceTransaction transactionOperation transaction
 // ---
@@ -1196,6 +1562,10 @@ func (api *DipperinMercuryApi) SubscribeBlock(ctx context.Context) (*rpc.Subscri
     return api.service.SubscribeBlock(ctx)
 }
 
+func (api *DipperinMercuryApi) SubscribeSyncProgress(ctx context.Context) (*rpc.Subscription, error) {
+    return api.service.SubscribeSyncProgress(ctx)
+}
+
 func (api *DipperinMercuryApi) StopDipperin() {
     api.service.StopDipperin()
 }
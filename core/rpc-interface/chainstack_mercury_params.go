@@ -97,6 +97,98 @@ type VerifierStatus struct {
 	IsCurrentVerifier bool
 }
 
+type UnStakingResp struct {
+	Amount *hexutil.Big
+	Height uint64
+}
+
+type DelegationResp struct {
+	Bonded       *hexutil.Big
+	Unbonding    *hexutil.Big
+	UnbondHeight uint64
+}
+
+type CandidateDelegationResp struct {
+	DelegatedStake *hexutil.Big
+	// per mille
+	Commission uint64
+	Delegators []common.Address
+}
+
+type GovernanceProposalResp struct {
+	Param          string
+	Value          uint64
+	ActivationSlot uint64
+	Proposer       common.Address
+	Votes          []common.Address
+	Passed         bool
+}
+
+type MineWorkerResp struct {
+	Address     common.Address
+	WorkerIds   []string
+	Performance uint64
+	Reward      *hexutil.Big
+	LastSeen    uint64
+}
+
+type MinePayoutResp struct {
+	Address   common.Address
+	Value     *hexutil.Big
+	TxFee     *hexutil.Big
+	TxHash    common.Hash
+	Timestamp uint64
+}
+
+type MineStateResp struct {
+	TotalReward    *hexutil.Big
+	RewardedHeight uint64
+	Workers        []MineWorkerResp
+	Payouts        []MinePayoutResp
+}
+
+type MinerWorkerResp struct {
+	WorkerId string
+	Address  common.Address
+	Shares   uint64
+	Blocks   uint64
+	// hashes per second
+	Hashrate    *hexutil.Big
+	Reward      *hexutil.Big
+	ConnectedAt uint64
+	LastShare   uint64
+}
+
+type MinerWorkResp struct {
+	Height  uint64
+	TxCount int
+}
+
+type MinerPayoutResp struct {
+	Address common.Address
+	TxHash  common.Hash
+}
+
+type BlockTemplateResp struct {
+	TemplateId       common.Hash
+	Version          uint64
+	Number           uint64
+	Seed             common.Hash
+	PreHash          common.Hash
+	Diff             common.Difficulty
+	Target           *hexutil.Big
+	TimeStamp        *hexutil.Big
+	CoinBase         common.Address
+	TransactionRoot  common.Hash
+	StateRoot        common.Hash
+	VerificationRoot common.Hash
+	InterlinkRoot    common.Hash
+	RegisterRoot     common.Hash
+	TxCount          int
+	// the rlp of the header without the nonce
+	HeaderRlp hexutil.Bytes
+}
+
 
 
 
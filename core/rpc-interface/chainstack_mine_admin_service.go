@@ -0,0 +1,102 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rpc_interface
+
+import (
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/core/mine/minemaster"
+	"math/big"
+)
+
+//go:generate mockgen -destination=./mine_admin_api_mock_test.go -package=rpc_interface github.com/dipperin/dipperin-core/core/rpc-interface MineAdminAPI
+type MineAdminAPI interface {
+	GetMineWorkers() ([]minemaster.WorkerRecord, error)
+	AdjustMineWorker(address common.Address, performance uint64, reward *big.Int) error
+	GetMinePayouts(address common.Address) ([]minemaster.PayoutRecord, error)
+	ExportMineState() (*minemaster.MasterState, error)
+}
+
+// DipperinMineAdminApi is for the operators of the mine master to manage what the workers are owed
+type DipperinMineAdminApi struct {
+	service MineAdminAPI
+}
+
+func (api *DipperinMineAdminApi) Workers() ([]MineWorkerResp, error) {
+	records, err := api.service.GetMineWorkers()
+	if err != nil {
+		return nil, err
+	}
+	return makeMineWorkerResps(records), nil
+}
+
+func (api *DipperinMineAdminApi) AdjustWorker(address common.Address, performance uint64, reward *hexutil.Big) error {
+	if reward == nil {
+		return api.service.AdjustMineWorker(address, performance, big.NewInt(0))
+	}
+	return api.service.AdjustMineWorker(address, performance, reward.ToInt())
+}
+
+// the payouts of the address, all the payouts if the address is empty
+func (api *DipperinMineAdminApi) Payouts(address common.Address) ([]MinePayoutResp, error) {
+	records, err := api.service.GetMinePayouts(address)
+	if err != nil {
+		return nil, err
+	}
+	return makeMinePayoutResps(records), nil
+}
+
+func (api *DipperinMineAdminApi) Export() (*MineStateResp, error) {
+	state, err := api.service.ExportMineState()
+	if err != nil {
+		return nil, err
+	}
+	return &MineStateResp{
+		TotalReward:    (*hexutil.Big)(state.TotalReward),
+		RewardedHeight: state.RewardedHeight,
+		Workers:        makeMineWorkerResps(state.Workers),
+		Payouts:        makeMinePayoutResps(state.Payouts),
+	}, nil
+}
+
+func makeMineWorkerResps(records []minemaster.WorkerRecord) []MineWorkerResp {
+	resps := make([]MineWorkerResp, 0, len(records))
+	for _, record := range records {
+		resps = append(resps, MineWorkerResp{
+			Address:     record.Address,
+			WorkerIds:   record.WorkerIds,
+			Performance: record.Performance,
+			Reward:      (*hexutil.Big)(record.Reward),
+			LastSeen:    record.LastSeen,
+		})
+	}
+	return resps
+}
+
+func makeMinePayoutResps(records []minemaster.PayoutRecord) []MinePayoutResp {
+	resps := make([]MinePayoutResp, 0, len(records))
+	for _, record := range records {
+		resps = append(resps, MinePayoutResp{
+			Address:   record.Address,
+			Value:     (*hexutil.Big)(record.Value),
+			TxFee:     (*hexutil.Big)(record.TxFee),
+			TxHash:    record.TxHash,
+			Timestamp: record.Timestamp,
+		})
+	}
+	return resps
+}
@@ -0,0 +1,77 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rpc_interface
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/core/mine/minemaster"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestDipperinMineAdminApi(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	ms := NewMockMineAdminAPI(controller)
+	api := &DipperinMineAdminApi{service: ms}
+
+	addr := common.HexToAddress("0x0000121d6e4e5A2a6B4b1e0B4b9bF5e1CcC1D9a6D7fb")
+	worker := minemaster.WorkerRecord{Address: addr, WorkerIds: []string{"rig1"}, Performance: 10, Reward: big.NewInt(100), LastSeen: 1}
+	payout := minemaster.PayoutRecord{Address: addr, Value: big.NewInt(90), TxFee: big.NewInt(10), Timestamp: 2}
+
+	ms.EXPECT().GetMineWorkers().Return([]minemaster.WorkerRecord{worker}, nil)
+	workers, err := api.Workers()
+	assert.NoError(t, err)
+	assert.Len(t, workers, 1)
+	assert.Equal(t, addr, workers[0].Address)
+	assert.Equal(t, big.NewInt(100), workers[0].Reward.ToInt())
+
+	ms.EXPECT().GetMineWorkers().Return(nil, errors.New("not mine master"))
+	_, err = api.Workers()
+	assert.Error(t, err)
+
+	ms.EXPECT().AdjustMineWorker(addr, uint64(5), big.NewInt(0)).Return(nil)
+	assert.NoError(t, api.AdjustWorker(addr, 5, nil))
+	ms.EXPECT().AdjustMineWorker(addr, uint64(5), big.NewInt(7)).Return(nil)
+	assert.NoError(t, api.AdjustWorker(addr, 5, (*hexutil.Big)(big.NewInt(7))))
+
+	ms.EXPECT().GetMinePayouts(addr).Return([]minemaster.PayoutRecord{payout}, nil)
+	payouts, err := api.Payouts(addr)
+	assert.NoError(t, err)
+	assert.Len(t, payouts, 1)
+	assert.Equal(t, big.NewInt(90), payouts[0].Value.ToInt())
+
+	ms.EXPECT().ExportMineState().Return(&minemaster.MasterState{
+		TotalReward:    big.NewInt(190),
+		RewardedHeight: 3,
+		Workers:        []minemaster.WorkerRecord{worker},
+		Payouts:        []minemaster.PayoutRecord{payout},
+	}, nil)
+	state, err := api.Export()
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), state.RewardedHeight)
+	assert.Len(t, state.Workers, 1)
+	assert.Len(t, state.Payouts, 1)
+
+	ms.EXPECT().ExportMineState().Return(nil, errors.New("not mine master"))
+	_, err = api.Export()
+	assert.Error(t, err)
+}
@@ -0,0 +1,148 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rpc_interface
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/common/hexutil"
+	"github.com/dipperin/dipperin-core/core/mine/minemaster"
+	"sort"
+)
+
+var workerNotConnectedErr = errors.New("the worker is not connected")
+
+//go:generate mockgen -destination=./miner_api_mock_test.go -package=rpc_interface github.com/dipperin/dipperin-core/core/rpc-interface MinerAPI
+type MinerAPI interface {
+	GetMinerWorkers() ([]minemaster.WorkerStats, error)
+	GetMinerWork() (height uint64, txCount int, err error)
+	KickMinerWorker(workerId string) error
+	MinerPayout() (map[common.Address]common.Hash, error)
+	GetBlockTemplate() (*minemaster.BlockTemplate, error)
+	SubmitBlock(templateId common.Hash, nonce common.BlockNonce, workerAddress common.Address) error
+}
+
+// DipperinMinerApi serves the statistics and the control of the mining pool
+type DipperinMinerApi struct {
+	service MinerAPI
+}
+
+// the connected workers with their shares, blocks, rewards and estimated hashrate
+func (api *DipperinMinerApi) Workers() ([]MinerWorkerResp, error) {
+	stats, err := api.service.GetMinerWorkers()
+	if err != nil {
+		return nil, err
+	}
+
+	resps := make([]MinerWorkerResp, 0, len(stats))
+	for _, s := range stats {
+		resps = append(resps, MinerWorkerResp{
+			WorkerId:    string(s.Id),
+			Address:     s.Address,
+			Shares:      s.Shares,
+			Blocks:      s.Blocks,
+			Hashrate:    (*hexutil.Big)(s.Hashrate),
+			Reward:      (*hexutil.Big)(s.Reward),
+			ConnectedAt: s.ConnectedAt,
+			LastShare:   s.LastShare,
+		})
+	}
+	return resps, nil
+}
+
+// the stats of a connected worker
+func (api *DipperinMinerApi) Worker(workerId string) (*MinerWorkerResp, error) {
+	workers, err := api.Workers()
+	if err != nil {
+		return nil, err
+	}
+	for i := range workers {
+		if workers[i].WorkerId == workerId {
+			return &workers[i], nil
+		}
+	}
+	return nil, workerNotConnectedErr
+}
+
+// the height and the tx count of the block in mining
+func (api *DipperinMinerApi) CurrentWork() (*MinerWorkResp, error) {
+	height, txCount, err := api.service.GetMinerWork()
+	if err != nil {
+		return nil, err
+	}
+	return &MinerWorkResp{Height: height, TxCount: txCount}, nil
+}
+
+func (api *DipperinMinerApi) KickWorker(workerId string) error {
+	return api.service.KickMinerWorker(workerId)
+}
+
+// pay the workers whose rewards reach the threshold now
+func (api *DipperinMinerApi) Payout() ([]MinerPayoutResp, error) {
+	txs, err := api.service.MinerPayout()
+	if err != nil {
+		return nil, err
+	}
+
+	resps := make([]MinerPayoutResp, 0, len(txs))
+	for address, txHash := range txs {
+		resps = append(resps, MinerPayoutResp{Address: address, TxHash: txHash})
+	}
+	sort.Slice(resps, func(i, j int) bool {
+		return resps[i].Address.Hex() < resps[j].Address.Hex()
+	})
+	return resps, nil
+}
+
+// GetBlockTemplate returns a new block to mine for the external miners which can't speak devp2p.
+// The miner hashes keccak256(HeaderRlp + nonce) with a 32 bytes nonce until the hash is no bigger than the Target.
+func (api *DipperinMinerApi) GetBlockTemplate() (*BlockTemplateResp, error) {
+	template, err := api.service.GetBlockTemplate()
+	if err != nil {
+		return nil, err
+	}
+
+	header := template.Header
+	return &BlockTemplateResp{
+		TemplateId:       template.Id,
+		Version:          header.Version,
+		Number:           header.Number,
+		Seed:             header.Seed,
+		PreHash:          header.PreHash,
+		Diff:             header.Diff,
+		Target:           (*hexutil.Big)(template.Target),
+		TimeStamp:        (*hexutil.Big)(header.TimeStamp),
+		CoinBase:         header.CoinBase,
+		TransactionRoot:  header.TransactionRoot,
+		StateRoot:        header.StateRoot,
+		VerificationRoot: header.VerificationRoot,
+		InterlinkRoot:    header.InterlinkRoot,
+		RegisterRoot:     header.RegisterRoot,
+		TxCount:          template.TxCount,
+		HeaderRlp:        template.HeaderRlpWithoutNonce,
+	}, nil
+}
+
+// SubmitBlock seals the block of the template with the nonce and broadcasts it.
+// The block is counted for the workerAddress, or the coinbase of the mine master if it's omitted.
+func (api *DipperinMinerApi) SubmitBlock(templateId common.Hash, nonce common.BlockNonce, workerAddress *common.Address) error {
+	var address common.Address
+	if workerAddress != nil {
+		address = *workerAddress
+	}
+	return api.service.SubmitBlock(templateId, nonce, address)
+}
@@ -0,0 +1,117 @@
+// Copyright 2019, Keychain Foundation Ltd.
+// This file is part of the dipperin-core library.
+//
+// The dipperin-core library is free software: you can redistribute
+// it and/or modify it under the terms of the GNU Lesser General Public License
+// as published by the Free Software Foundation, either version 3 of the
+// License, or (at your option) any later version.
+//
+// The dipperin-core library is distributed in the hope that it will be useful,
+// but WITHOUT ANY WARRANTY without even the implied warranty of
+// MERCHANTABILITY or FITNESS FOR A PARTICULAR PURPOSE.  See the
+// GNU Lesser General Public License for more details.
+//
+// You should have received a copy of the GNU Lesser General Public License
+// along with this program.  If not, see <https://www.gnu.org/licenses/>.
+
+package rpc_interface
+
+import (
+	"errors"
+	"github.com/dipperin/dipperin-core/common"
+	"github.com/dipperin/dipperin-core/core/mine/minemaster"
+	"github.com/dipperin/dipperin-core/core/model"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"math/big"
+	"testing"
+)
+
+func TestDipperinMinerApi(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	ms := NewMockMinerAPI(controller)
+	api := &DipperinMinerApi{service: ms}
+
+	addr1 := common.HexToAddress("0x0000121d6e4e5A2a6B4b1e0B4b9bF5e1CcC1D9a6D7fb")
+	addr2 := common.HexToAddress("0x00005586B883Ec6dd4f8c26063E18eb4Bd228e59c3E9")
+	stats := []minemaster.WorkerStats{
+		{Id: "rig1", Address: addr1, Shares: 10, Blocks: 1, Hashrate: big.NewInt(1000), Reward: big.NewInt(5)},
+		{Id: "rig2", Address: addr2, Shares: 3, Hashrate: big.NewInt(300), Reward: big.NewInt(0)},
+	}
+
+	ms.EXPECT().GetMinerWorkers().Return(stats, nil).Times(3)
+	workers, err := api.Workers()
+	assert.NoError(t, err)
+	assert.Len(t, workers, 2)
+	assert.Equal(t, "rig1", workers[0].WorkerId)
+	assert.Equal(t, big.NewInt(1000), workers[0].Hashrate.ToInt())
+
+	worker, err := api.Worker("rig2")
+	assert.NoError(t, err)
+	assert.Equal(t, uint64(3), worker.Shares)
+	_, err = api.Worker("rig3")
+	assert.Equal(t, workerNotConnectedErr, err)
+
+	ms.EXPECT().GetMinerWorkers().Return(nil, errors.New("not mine master"))
+	_, err = api.Worker("rig1")
+	assert.Error(t, err)
+
+	ms.EXPECT().GetMinerWork().Return(uint64(12), 3, nil)
+	work, err := api.CurrentWork()
+	assert.NoError(t, err)
+	assert.Equal(t, &MinerWorkResp{Height: 12, TxCount: 3}, work)
+
+	ms.EXPECT().KickMinerWorker("rig1").Return(nil)
+	assert.NoError(t, api.KickWorker("rig1"))
+
+	ms.EXPECT().MinerPayout().Return(map[common.Address]common.Hash{
+		addr2: common.HexToHash("0x02"),
+		addr1: common.HexToHash("0x01"),
+	}, nil)
+	payouts, err := api.Payout()
+	assert.NoError(t, err)
+	assert.Equal(t, []MinerPayoutResp{
+		{Address: addr1, TxHash: common.HexToHash("0x01")},
+		{Address: addr2, TxHash: common.HexToHash("0x02")},
+	}, payouts)
+
+	ms.EXPECT().MinerPayout().Return(nil, errors.New("no reward sender"))
+	_, err = api.Payout()
+	assert.Error(t, err)
+}
+
+func TestDipperinMinerApi_BlockTemplate(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+	ms := NewMockMinerAPI(controller)
+	api := &DipperinMinerApi{service: ms}
+
+	header := model.Header{Number: 3, Diff: common.HexToDiff("0x1effffff"), TimeStamp: big.NewInt(100), TransactionRoot: common.HexToHash("0x12")}
+	ms.EXPECT().GetBlockTemplate().Return(&minemaster.BlockTemplate{
+		Id:                    common.HexToHash("0x01"),
+		Header:                header,
+		HeaderRlpWithoutNonce: []byte{1, 2, 3},
+		Target:                header.Diff.Big(),
+		TxCount:               2,
+	}, nil)
+	template, err := api.GetBlockTemplate()
+	assert.NoError(t, err)
+	assert.Equal(t, common.HexToHash("0x01"), template.TemplateId)
+	assert.Equal(t, uint64(3), template.Number)
+	assert.Equal(t, header.Diff.Big(), template.Target.ToInt())
+	assert.Equal(t, header.TransactionRoot, template.TransactionRoot)
+	assert.Equal(t, 2, template.TxCount)
+	assert.Equal(t, []byte{1, 2, 3}, []byte(template.HeaderRlp))
+
+	ms.EXPECT().GetBlockTemplate().Return(nil, errors.New("not mine master"))
+	_, err = api.GetBlockTemplate()
+	assert.Error(t, err)
+
+	nonce := common.BlockNonceFromInt(7)
+	worker := common.HexToAddress("0x1234")
+	ms.EXPECT().SubmitBlock(common.HexToHash("0x01"), nonce, common.Address{}).Return(nil)
+	assert.NoError(t, api.SubmitBlock(common.HexToHash("0x01"), nonce, nil))
+	ms.EXPECT().SubmitBlock(common.HexToHash("0x01"), nonce, worker).Return(errors.New("invalid nonce"))
+	assert.Error(t, api.SubmitBlock(common.HexToHash("0x01"), nonce, &worker))
+}
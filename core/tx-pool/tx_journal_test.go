@@ -24,12 +24,14 @@ import (
 	"github.com/ethereum/go-ethereum/rlp"
 	"github.com/stretchr/testify/assert"
 	"os"
+	"path/filepath"
 	"testing"
 	"github.com/dipperin/dipperin-core/third-party/crypto/cs-crypto"
 	"errors"
 )
 
-var path = "./transaction.out"
+// the journal is written in the temp dir and removed by the tests
+var path = filepath.Join(os.TempDir(), "dipperin_tx_journal_test.out")
 
 // generate n transactions list file on the specified path
 func createTxListFile(n int, path string) {
@@ -81,7 +83,7 @@ func TestTxJournalLoad(t *testing.T) {
 	//err = jNoOpen.load(nil)
 	//assert.NotNil(t, err)
 
-	mj := newTxJournal("transaction.out")
+	mj := newTxJournal(path)
 	err = mj.load(func(txs []model.AbstractTransaction) []error {
 		return []error{errors.New("load test error")}
 	})
@@ -120,6 +122,8 @@ func readFromTxListFile(t *testing.T, path string) {
 //}
 
 func TestTxJournalRotate(t *testing.T) {
+	defer os.Remove(path)
+
 	j := newTxJournal(path)
 	defer j.close()
 
@@ -160,6 +164,8 @@ func (p *fakePool) Add(txs []model.AbstractTransaction) []error {
 }
 
 func TestTxJournal_InsertFail(t *testing.T) {
+	defer os.Remove(path)
+
 	createTxListFile(2, path)
 
 	p := newFakePool()
@@ -182,6 +188,8 @@ func TestTxJournal_InsertFail(t *testing.T) {
 }
 
 func TestTxJournal_Insert(t *testing.T) {
+	defer os.Remove(path)
+
 	createTxListFile(2, path)
 
 	j := newTxJournal(path)
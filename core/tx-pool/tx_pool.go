@@ -356,8 +356,9 @@ func (pool *TxPool) enqueueTx(hash common.Hash, tx model.AbstractTransaction) (b
 // rules and adheres to some heuristic limits of the local node (price and size).
 func (pool *TxPool) validateTx(tx model.AbstractTransaction, local bool) error {
 
-	// Heuristic limit, reject transactions over 32KB to prevent DOS attacks
-	if err := middleware.ValidTxSize(tx); err != nil {
+	// Heuristic limit, reject transactions over the max tx size of the next block to prevent DOS attacks
+	nextHeight := pool.chain.CurrentBlock().Number() + 1
+	if err := middleware.ValidTxSize(tx, pool.chainConfig.GetBlockLimits(nextHeight).MaxTxSize); err != nil {
 		return g_error.ErrTxOverSize
 	}
 	// Transactions can't be negative. This may never happen using RLP decoded
@@ -519,6 +520,14 @@ func (pool *TxPool) promoteTx(addr common.Address, hash common.Hash, tx model.Ab
 	return true
 }
 
+// IsLocal reports whether the txs of the address are treated as local
+func (pool *TxPool) IsLocal(addr common.Address) bool {
+	pool.mu.RLock()
+	defer pool.mu.RUnlock()
+
+	return pool.locals.contains(addr)
+}
+
 // local retrieves all currently known local transactions, groupped by origin
 // account and sorted by nonce. The returned transaction set is a copy and can be
 // freely modified by calling code.
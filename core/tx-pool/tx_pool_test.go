@@ -252,8 +252,8 @@ func TestTxPool_validateTx(t *testing.T) {
 	aliceAddr := cs_crypto.GetNormalAddress(key1.PublicKey)
 	bobAddr := cs_crypto.GetNormalAddress(key2.PublicKey)
 
-	overLoad := make([]byte, chain_config.MaxTxSize*2)
-	for i:=0; i<chain_config.MaxTxSize*2; i++ {
+	overLoad := make([]byte, chain_config.DefaultMaxTxSize*2)
+	for i:=0; i<chain_config.DefaultMaxTxSize*2; i++ {
 		overLoad[i] = byte(i)
 	}
 	unsignedTx1 := model.NewTransaction(1, bobAddr, big.NewInt(5000), testTxFee, overLoad)
@@ -537,6 +537,19 @@ func TestTxPool_AddLocal(t *testing.T) {
 	assert.NoError(t, err)
 }
 
+func TestTxPool_IsLocal(t *testing.T) {
+	pool := setupTxPool()
+	key1, key2, _ := createKey()
+	aliceAddr := cs_crypto.GetNormalAddress(key1.PublicKey)
+	bobAddr := cs_crypto.GetNormalAddress(key2.PublicKey)
+	assert.False(t, pool.IsLocal(bobAddr))
+
+	tx := transaction(uint64(30), aliceAddr, big.NewInt(1), testTxFee, key2)
+	assert.NoError(t, pool.AddLocal(tx))
+	assert.Equal(t, !pool.config.NoLocals, pool.IsLocal(bobAddr))
+	assert.False(t, pool.IsLocal(aliceAddr))
+}
+
 func TestTxPool_LocalAdd(t *testing.T) {
 	pool := setupTxPool()
 	key1, key2, _ := createKey()
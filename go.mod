@@ -9,10 +9,12 @@ require (
 	github.com/c-bata/go-prompt v0.2.3
 	github.com/davecgh/go-spew v1.1.1
 	github.com/deckarep/golang-set v1.7.1
+	github.com/dgraph-io/badger v1.6.2
 	github.com/ethereum/go-ethereum v1.8.21
 	github.com/fjl/memsize v0.0.0-20180929194037-2a09253e352a
 	github.com/go-stack/stack v1.8.0
 	github.com/golang/mock v1.2.0
+	github.com/golang/protobuf v1.3.1
 	github.com/golang/snappy v0.0.0-20180518054509-2e65f85255db
 	github.com/hashicorp/golang-lru v0.5.0
 	github.com/huin/goupnp v1.0.0
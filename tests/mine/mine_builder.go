@@ -78,6 +78,46 @@ func (m *fakeMaster) RetrieveReward(address common.Address) {
 	panic("implement me")
 }
 
+func (m *fakeMaster) WorkerRecords() []minemaster.WorkerRecord {
+	panic("implement me")
+}
+
+func (m *fakeMaster) AdjustWorker(address common.Address, performance uint64, reward *big.Int) error {
+	panic("implement me")
+}
+
+func (m *fakeMaster) PayoutRecords(address common.Address) []minemaster.PayoutRecord {
+	panic("implement me")
+}
+
+func (m *fakeMaster) ExportState() minemaster.MasterState {
+	panic("implement me")
+}
+
+func (m *fakeMaster) WorkerStats() []minemaster.WorkerStats {
+	panic("implement me")
+}
+
+func (m *fakeMaster) CurrentWorkHeight() uint64 {
+	panic("implement me")
+}
+
+func (m *fakeMaster) KickWorker(workerId minemaster.WorkerId) error {
+	panic("implement me")
+}
+
+func (m *fakeMaster) Payout() (map[common.Address]common.Hash, error) {
+	panic("implement me")
+}
+
+func (m *fakeMaster) GetBlockTemplate() (*minemaster.BlockTemplate, error) {
+	panic("implement me")
+}
+
+func (m *fakeMaster) SubmitBlock(templateId common.Hash, nonce common.BlockNonce, workerAddress common.Address) error {
+	panic("implement me")
+}
+
 func MasterServerBuilder() minemaster.MasterServer {
 	return &FakeMasterServer{
 		Workers: make(map[string] minemaster.WorkerForMaster),
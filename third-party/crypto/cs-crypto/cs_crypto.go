@@ -117,6 +117,23 @@ func GetNormalAddressFromEvidence(target common.Address) common.Address{
 	return common.BytesToAddress(evAdd)
 }
 
+// GetDelegationAddress replace the type of the candidate address with the delegation tx type,
+// txType should be AddressTypeDelegate, AddressTypeUnDelegate or AddressTypeWithdrawDelegation
+func GetDelegationAddress(txType common.TxType, candidate common.Address) common.Address {
+	var tmpType [2]byte
+	binary.BigEndian.PutUint16(tmpType[:], uint16(txType))
+	var trueAdd = candidate[2:]
+	var dAdd []byte
+	dAdd = append(dAdd, tmpType[0], tmpType[1])
+	dAdd = append(dAdd, trueAdd...)
+	return common.BytesToAddress(dAdd)
+}
+
+// GetNormalAddressFromDelegation get the candidate address from the to address of a delegation tx
+func GetNormalAddressFromDelegation(target common.Address) common.Address {
+	return GetNormalAddressFromEvidence(target)
+}
+
 func GetContractAddress(address common.Address) common.Address {
 	var tmpType [2]byte
 	binary.BigEndian.PutUint16(tmpType[:], uint16(common.AddressTypeERC20))
@@ -378,6 +378,18 @@ func (db *Database) preimage(hash common.Hash) ([]byte, error) {
 	return db.diskdb.Get(db.secureKey(hash[:]))
 }
 
+// Preimage retrieves a cached trie node pre-image from memory. If it cannot be
+// found cached, the method queries the persistent database for the content.
+func (db *Database) Preimage(hash common.Hash) ([]byte, error) {
+	return db.preimage(hash)
+}
+
+// PreimageKey returns the database key for the preimage of the hash. Unlike
+// secureKey, the returned slice is newly allocated and may be retained.
+func PreimageKey(hash common.Hash) []byte {
+	return append(append([]byte{}, secureKeyPrefix...), hash[:]...)
+}
+
 // secureKey returns the database key for the preimage of key, as an ephemeral
 // buffer. The caller must not hold onto the return value because it will become
 // invalid on the next call.